@@ -0,0 +1,98 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+)
+
+// ============================================================================
+// 进程级 atos 结果缓存（LRU）
+// ============================================================================
+
+// atosCacheSize 缓存的最大条目数（SYMBOL_CACHE_SIZE）
+var atosCacheSize = envInt("SYMBOL_CACHE_SIZE", 100000)
+
+// atosCacheKey 同一符号表（UUID）、同一加载地址、同一架构下的同一地址结果相同
+type atosCacheKey struct {
+	uuid     string
+	loadAddr uint64
+	addr     uint64
+	arch     string
+}
+
+// atosCacheEntry 缓存的结果；symbolArch 为实际解析出符号的架构，用其它 slice 重试成功时与 key.arch 不同
+type atosCacheEntry struct {
+	key        atosCacheKey
+	symbol     string
+	symbolArch string
+}
+
+// lruSymbolCache 按最近使用淘汰的符号缓存，并发安全
+type lruSymbolCache struct {
+	mu        sync.Mutex
+	capacity  int
+	ll        *list.List
+	items     map[atosCacheKey]*list.Element
+	hits      int64
+	misses    int64
+	evictions int64
+}
+
+func newLRUSymbolCache(capacity int) *lruSymbolCache {
+	return &lruSymbolCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[atosCacheKey]*list.Element),
+	}
+}
+
+// atosSymbolCache 所有请求共享的 atos 结果缓存
+var atosSymbolCache = newLRUSymbolCache(atosCacheSize)
+
+func (c *lruSymbolCache) get(key atosCacheKey) (symbol, symbolArch string, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.ll.MoveToFront(elem)
+		c.hits++
+		entry := elem.Value.(*atosCacheEntry)
+		return entry.symbol, entry.symbolArch, true
+	}
+	c.misses++
+	return "", "", false
+}
+
+func (c *lruSymbolCache) put(key atosCacheKey, symbol, symbolArch string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.ll.MoveToFront(elem)
+		entry := elem.Value.(*atosCacheEntry)
+		entry.symbol, entry.symbolArch = symbol, symbolArch
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&atosCacheEntry{key: key, symbol: symbol, symbolArch: symbolArch})
+	for c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*atosCacheEntry).key)
+		c.evictions++
+	}
+}
+
+// stats 返回命中/未命中/大小，用于 /api/cache/stats
+func (c *lruSymbolCache) stats() map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return map[string]interface{}{
+		"hits":      c.hits,
+		"misses":    c.misses,
+		"evictions": c.evictions,
+		"size":      c.ll.Len(),
+		"capacity":  c.capacity,
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestLRUSymbolCacheEviction(t *testing.T) {
+	cache := newLRUSymbolCache(2)
+	key := func(addr uint64) atosCacheKey {
+		return atosCacheKey{uuid: "UUID", loadAddr: 0x100000000, addr: addr, arch: "arm64"}
+	}
+
+	cache.put(key(1), "a", "arm64")
+	cache.put(key(2), "b", "arm64")
+	cache.get(key(1)) // 1 成为最近使用
+	cache.put(key(3), "c", "arm64")
+
+	if _, _, ok := cache.get(key(2)); ok {
+		t.Error("最久未使用的条目应被淘汰")
+	}
+	if symbol, symbolArch, ok := cache.get(key(1)); !ok || symbol != "a" || symbolArch != "arm64" {
+		t.Errorf("get(1) = %q, %q, %v", symbol, symbolArch, ok)
+	}
+
+	// 不同架构是不同的键
+	if _, _, ok := cache.get(atosCacheKey{uuid: "UUID", loadAddr: 0x100000000, addr: 1, arch: "arm64e"}); ok {
+		t.Error("不同架构不应命中")
+	}
+
+	stats := cache.stats()
+	if stats["size"] != 2 || stats["evictions"] != int64(1) || stats["hits"] != int64(2) || stats["misses"] != int64(2) {
+		t.Errorf("stats() = %v", stats)
+	}
+}
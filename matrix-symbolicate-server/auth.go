@@ -0,0 +1,70 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ============================================================================
+// API Key 鉴权：上传、符号化、删除等写操作需要 X-API-Key
+// ============================================================================
+
+// apiKeyHeader 携带 API Key 的请求头
+const apiKeyHeader = "X-API-Key"
+
+// apiKeys 允许写操作的 API Key（API_KEYS，逗号分隔），为空时不鉴权（兼容旧部署）
+var apiKeys = splitCommaList(os.Getenv("API_KEYS"))
+
+// validAPIKey 判断请求的 Key 是否在允许列表中，使用常量时间比较
+func validAPIKey(keys []string, key string) bool {
+	valid := false
+	for _, allowed := range keys {
+		if subtle.ConstantTimeCompare([]byte(allowed), []byte(key)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
+
+// requireAPIKey 校验写操作（非 GET/HEAD/OPTIONS）请求的 X-API-Key
+// 只读接口（包括 /api/health）始终开放；keys 为空时不做校验
+func requireAPIKey(keys []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		checkAPIKey(c, keys)
+	}
+}
+
+// requireAPIKeyForRead 只读接口中暴露服务器内部信息的（如 /api/health/details）同样需要 X-API-Key
+// keys 为空时不做校验
+func requireAPIKeyForRead(keys []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		checkAPIKey(c, keys)
+	}
+}
+
+// checkAPIKey 校验 X-API-Key，失败时返回 401 并中止请求
+func checkAPIKey(c *gin.Context, keys []string) {
+	if len(keys) == 0 {
+		c.Next()
+		return
+	}
+
+	key := c.GetHeader(apiKeyHeader)
+	if key == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "缺少 " + apiKeyHeader})
+		return
+	}
+	if !validAPIKey(keys, key) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "无效的 " + apiKeyHeader})
+		return
+	}
+	c.Next()
+}
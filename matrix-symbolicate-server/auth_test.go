@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(keys []string) *gin.Engine {
+		r := gin.New()
+		api := r.Group("/api")
+		api.Use(requireAPIKey(keys))
+		ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+		api.GET("/health", ok)
+		api.GET("/health/details", requireAPIKeyForRead(keys), ok)
+		api.POST("/report/upload", ok)
+		api.DELETE("/report/:id", ok)
+		return r
+	}
+	do := func(r *gin.Engine, method, path, key string) int {
+		req := httptest.NewRequest(method, path, nil)
+		if key != "" {
+			req.Header.Set(apiKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	r := newRouter(splitCommaList(" key-a, ,key-b "))
+	tests := []struct {
+		method, path, key string
+		want              int
+	}{
+		{http.MethodGet, "/api/health", "", http.StatusOK},
+		{http.MethodGet, "/api/health/details", "", http.StatusUnauthorized},
+		{http.MethodGet, "/api/health/details", "key-a", http.StatusOK},
+		{http.MethodPost, "/api/report/upload", "", http.StatusUnauthorized},
+		{http.MethodPost, "/api/report/upload", "wrong", http.StatusUnauthorized},
+		{http.MethodPost, "/api/report/upload", "key-a", http.StatusOK},
+		{http.MethodDelete, "/api/report/1", "key-b", http.StatusOK},
+		{http.MethodDelete, "/api/report/1", "key-", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		if got := do(r, tt.method, tt.path, tt.key); got != tt.want {
+			t.Errorf("%s %s (key=%q) = %d, want %d", tt.method, tt.path, tt.key, got, tt.want)
+		}
+	}
+
+	// 未配置 Key 时保持开放
+	if got := do(newRouter(splitCommaList("")), http.MethodPost, "/api/report/upload", ""); got != http.StatusOK {
+		t.Errorf("未配置 API_KEYS 时 POST = %d, want 200", got)
+	}
+}
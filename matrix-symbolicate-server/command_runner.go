@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CommandRunner 执行外部命令（atos、dwarfdump、unzip 等）并返回标准输出
+// 测试中替换 commandRunner 即可使用预置输出，不依赖主机上的 Xcode 工具
+type CommandRunner interface {
+	Run(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+// execRunner 默认实现：执行真实命令，配置了 DEVELOPER_DIR 时一并传给子进程
+type execRunner struct{}
+
+func (execRunner) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := toolCommandContext(ctx, name, args...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// 进程被终止后，残留的子进程可能仍占用输出管道，最多再等待 1 秒
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return stdout.Bytes(), fmt.Errorf("%w, stderr: %s", err, msg)
+		}
+		return stdout.Bytes(), err
+	}
+	return stdout.Bytes(), nil
+}
+
+// commandRunner 当前使用的命令执行器
+var commandRunner CommandRunner = execRunner{}
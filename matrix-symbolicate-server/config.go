@@ -0,0 +1,45 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// 环境变量配置读取
+// ============================================================================
+
+// envInt 读取整数环境变量，缺失或非法时返回默认值
+func envInt(name string, defaultValue int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Printf("警告: 环境变量 %s=%s 无效，使用默认值 %d", name, value, defaultValue)
+		return defaultValue
+	}
+	return n
+}
+
+// envString 读取字符串环境变量，未设置时返回默认值
+func envString(name, defaultValue string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// splitCommaList 拆分逗号分隔的配置项，去掉空白和空项
+func splitCommaList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
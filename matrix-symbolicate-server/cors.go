@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/gin-contrib/cors"
+)
+
+// corsAllowedOrigins 允许的跨域来源（CORS_ALLOWED_ORIGINS，逗号分隔；兼容旧的 CORS_ORIGINS）
+// 未配置或为 * 时允许任意来源
+var corsAllowedOrigins = splitCommaList(envString("CORS_ALLOWED_ORIGINS", os.Getenv("CORS_ORIGINS")))
+
+// corsConfig 根据允许的来源生成 CORS 配置
+// 通配来源不能与凭据同时使用（Fetch 规范禁止，浏览器会直接拒绝），
+// 因此只有配置了明确的来源列表时才允许携带凭据
+func corsConfig(origins []string) cors.Config {
+	config := cors.Config{
+		AllowMethods:  []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowHeaders:  []string{"Origin", "Content-Type", "Accept", apiKeyHeader, requestIDHeader},
+		ExposeHeaders: []string{"Content-Length", requestIDHeader},
+		MaxAge:        12 * time.Hour,
+	}
+
+	wildcard := len(origins) == 0
+	for _, origin := range origins {
+		if origin == "*" {
+			wildcard = true
+		}
+	}
+
+	if wildcard {
+		config.AllowAllOrigins = true
+		config.AllowCredentials = false
+	} else {
+		config.AllowOrigins = origins
+		config.AllowCredentials = true
+	}
+	return config
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORSConfig(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	preflight := func(origins []string, origin string) http.Header {
+		r := gin.New()
+		r.Use(cors.New(corsConfig(origins)))
+		r.POST("/api/report/upload", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		req := httptest.NewRequest(http.MethodOptions, "/api/report/upload", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", apiKeyHeader)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Header()
+	}
+
+	// 通配：任意来源，不允许凭据
+	for _, origins := range [][]string{nil, {"*"}, splitCommaList(" * ")} {
+		h := preflight(origins, "https://a.example.com")
+		if got := h.Get("Access-Control-Allow-Origin"); got != "*" {
+			t.Errorf("%v: Allow-Origin = %q, want *", origins, got)
+		}
+		if got := h.Get("Access-Control-Allow-Credentials"); got == "true" {
+			t.Errorf("%v: 通配来源不能同时允许凭据", origins)
+		}
+	}
+
+	// 明确的来源列表：回显来源并允许凭据，其它来源被拒绝
+	origins := splitCommaList("https://a.example.com, https://b.example.com")
+	h := preflight(origins, "https://b.example.com")
+	if got := h.Get("Access-Control-Allow-Origin"); got != "https://b.example.com" {
+		t.Errorf("Allow-Origin = %q, want https://b.example.com", got)
+	}
+	if got := h.Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Allow-Credentials = %q, want true", got)
+	}
+	if got := preflight(origins, "https://evil.example.com").Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("未授权来源 Allow-Origin = %q, want 空", got)
+	}
+}
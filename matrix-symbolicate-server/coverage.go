@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// ============================================================================
+// 符号表覆盖情况：报告中的每个镜像是否有匹配的 dSYM
+// ============================================================================
+
+// imageCoverage 单个镜像的符号表覆盖情况
+type imageCoverage struct {
+	Name      string `json:"name"`
+	UUID      string `json:"uuid"`
+	ImageAddr string `json:"image_addr"`
+	IsApp     bool   `json:"is_app"`
+	HasDsym   bool   `json:"has_dsym"`
+	DsymFile  string `json:"dsym_file,omitempty"`
+}
+
+// reportCoverage 按 UUID 将报告的 binary_images 与 dir 及 DSYM_SEARCH_PATHS 中的符号表对照
+// 匹配复用 findImageDsymsIn（及其 UUID 缓存），应用镜像也参与匹配
+func reportCoverage(dir string, reportMap map[string]interface{}) map[string]interface{} {
+	binaryImages, _ := reportMap["binary_images"].([]interface{})
+	found := findImageDsymsIn(dir, binaryImages, "")
+
+	appImage := findAppImage(reportMap)
+	appUUID := ""
+	if appImage != nil {
+		appUUID = getString(appImage, "uuid")
+	}
+
+	images := []imageCoverage{}
+	missing := []string{}
+	covered := 0
+	for _, img := range binaryImages {
+		imgMap, ok := img.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		imgAddr := uint64(getInt64(imgMap, "image_addr"))
+		uuid := normalizeUUID(getString(imgMap, "uuid"))
+		entry := imageCoverage{
+			Name:      filepath.Base(getString(imgMap, "name")),
+			UUID:      uuid,
+			ImageAddr: fmt.Sprintf("0x%x", imgAddr),
+			IsApp:     compareUUID(uuid, appUUID),
+		}
+		if dsymPath, ok := found[imgAddr]; ok && uuid != "" {
+			entry.HasDsym = true
+			entry.DsymFile = filepath.Base(dsymPath)
+			covered++
+		} else {
+			missing = append(missing, entry.Name)
+		}
+		images = append(images, entry)
+	}
+
+	return map[string]interface{}{
+		"images":  images,
+		"total":   len(images),
+		"covered": covered,
+		"missing": missing,
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReportCoverageHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	oldDsymDir, oldReportsDir := DsymDir, ReportsDir
+	DsymDir, ReportsDir = t.TempDir(), t.TempDir()
+	defer func() { DsymDir, ReportsDir = oldDsymDir, oldReportsDir }()
+
+	// 应用和 UIKitCore 有符号表，libsystem_kernel 没有
+	appPath := filepath.Join(DsymDir, "Demo")
+	uikitPath := filepath.Join(DsymDir, "UIKitCore")
+	writeFakeMachO(t, appPath, 0x0100000c, 0, [16]byte{0xc0, 0x01})
+	writeFakeMachO(t, uikitPath, 0x0100000c, 0, [16]byte{0xc0, 0x02})
+	defer evictDsymInfo(appPath)
+	defer evictDsymInfo(uikitPath)
+	appUUID, _, _ := readMachOUUID(appPath)
+	uikitUUID, _, _ := readMachOUUID(uikitPath)
+
+	data, _ := json.Marshal(map[string]interface{}{
+		"system": map[string]interface{}{"cpu_arch": "arm64", "CFBundleExecutable": "Demo"},
+		"binary_images": []interface{}{
+			map[string]interface{}{"name": "/private/var/containers/Bundle/Application/X/Demo.app/Demo", "uuid": appUUID, "image_addr": float64(0x100000000)},
+			map[string]interface{}{"name": "/System/Library/PrivateFrameworks/UIKitCore.framework/UIKitCore", "uuid": uikitUUID, "image_addr": float64(0x180000000)},
+			map[string]interface{}{"name": "/usr/lib/system/libsystem_kernel.dylib", "uuid": "00000000-0000-0000-0000-0000000000AA", "image_addr": float64(0x1a0000000)},
+		},
+	})
+	reportID, _, _, _, err := storeReport("report.json", data)
+	if err != nil {
+		t.Fatalf("storeReport() 失败: %v", err)
+	}
+
+	router := gin.New()
+	router.GET("/api/report/:id/coverage", getReportCoverageHandler)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/report/"+reportID+"/coverage", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Total   int             `json:"total"`
+		Covered int             `json:"covered"`
+		Missing []string        `json:"missing"`
+		Images  []imageCoverage `json:"images"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("响应解析失败: %v", err)
+	}
+	if resp.Total != 3 || resp.Covered != 2 {
+		t.Errorf("covered/total = %d/%d, want 2/3", resp.Covered, resp.Total)
+	}
+	if len(resp.Missing) != 1 || resp.Missing[0] != "libsystem_kernel.dylib" {
+		t.Errorf("missing = %v", resp.Missing)
+	}
+
+	want := []imageCoverage{
+		{Name: "Demo", UUID: appUUID, ImageAddr: "0x100000000", IsApp: true, HasDsym: true, DsymFile: "Demo"},
+		{Name: "UIKitCore", UUID: uikitUUID, ImageAddr: "0x180000000", HasDsym: true, DsymFile: "UIKitCore"},
+		{Name: "libsystem_kernel.dylib", UUID: "00000000-0000-0000-0000-0000000000AA", ImageAddr: "0x1a0000000"},
+	}
+	if len(resp.Images) != len(want) {
+		t.Fatalf("images = %+v", resp.Images)
+	}
+	for i := range want {
+		if resp.Images[i] != want[i] {
+			t.Errorf("images[%d] = %+v, want %+v", i, resp.Images[i], want[i])
+		}
+	}
+
+	// 不存在的报告
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/report/nope/coverage", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("不存在的报告 status = %d, want 404", w.Code)
+	}
+}
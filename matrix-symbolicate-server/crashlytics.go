@@ -0,0 +1,242 @@
+package main
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// Firebase Crashlytics 导出格式转换
+// ============================================================================
+//
+// Crashlytics 导出的崩溃 JSON 结构：
+//   threads[].frames[]  {binaryImage, address, symbol, offset}
+//   binaryImages[]      {name, path, uuid, baseAddress, size, arch}
+//   exceptions[]        {type, code, exception_message, address}
+// 上传时识别并转换为内部（KSCrash）结构，之后复用 symbolicateReport / formatReportToAppleStyle；
+// 上传的原始导出另存为 <id>.crashlytics.json，随报告一起删除
+
+// crashlyticsOriginalPath 返回报告对应的 Crashlytics 原始导出路径，与原始报告放在同一目录
+func crashlyticsOriginalPath(reportFile string) string {
+	reportID, _ := reportIDFromFilename(filepath.Base(reportFile))
+	return filepath.Join(filepath.Dir(reportFile), reportID+".crashlytics.json")
+}
+
+// isCrashlyticsReport 判断报告是否为 Crashlytics 导出格式
+func isCrashlyticsReport(report interface{}) bool {
+	reportMap, ok := report.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	// 内部格式一定有 crash 字段
+	if _, hasCrash := reportMap["crash"]; hasCrash {
+		return false
+	}
+
+	_, hasThreads := reportMap["threads"].([]interface{})
+	_, hasImages := reportMap["binaryImages"].([]interface{})
+	return hasThreads && hasImages
+}
+
+// convertCrashlyticsReport 将 Crashlytics 导出转换为内部报告结构
+func convertCrashlyticsReport(src map[string]interface{}) map[string]interface{} {
+	application, _ := src["application"].(map[string]interface{})
+	device, _ := src["device"].(map[string]interface{})
+	operatingSystem, _ := src["operating_system"].(map[string]interface{})
+
+	// binary_images，按镜像名建立索引供帧查找 object_addr
+	images := []interface{}{}
+	imagesByName := make(map[string]map[string]interface{})
+	exePath := ""
+	if srcImages, ok := src["binaryImages"].([]interface{}); ok {
+		for _, imgData := range srcImages {
+			img, ok := imgData.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			name := getString(img, "name")
+			path := getString(img, "path")
+			if path == "" {
+				path = name
+			}
+			if name == "" {
+				name = filepath.Base(path)
+			}
+
+			converted := map[string]interface{}{
+				"name":       path,
+				"uuid":       normalizeUUID(getString(img, "uuid")),
+				"image_addr": float64(crashlyticsAddress(img["baseAddress"])),
+				"image_size": float64(crashlyticsAddress(img["size"])),
+			}
+			images = append(images, converted)
+			imagesByName[name] = converted
+
+			if exePath == "" && strings.Contains(path, ".app/") && !strings.Contains(path, ".framework/") {
+				exePath = path
+			}
+		}
+	}
+
+	// 线程
+	threads := []interface{}{}
+	if srcThreads, ok := src["threads"].([]interface{}); ok {
+		for i, threadData := range srcThreads {
+			thread, ok := threadData.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			contents := []interface{}{}
+			if frames, ok := thread["frames"].([]interface{}); ok {
+				for _, frameData := range frames {
+					frame, ok := frameData.(map[string]interface{})
+					if !ok {
+						continue
+					}
+					contents = append(contents, convertCrashlyticsFrame(frame, imagesByName))
+				}
+			}
+
+			threads = append(threads, map[string]interface{}{
+				"index":   float64(i),
+				"crashed": getBool(thread, "crashed"),
+				"name":    getString(thread, "name"),
+				"backtrace": map[string]interface{}{
+					"contents": contents,
+				},
+			})
+		}
+	}
+
+	report := map[string]interface{}{
+		"report": map[string]interface{}{
+			"id":        getString(src, "event_id"),
+			"timestamp": float64(crashlyticsTimestamp(src["event_timestamp"])),
+			"type":      "crashlytics",
+		},
+		"system": map[string]interface{}{
+			"process_name":               strings.TrimSuffix(filepath.Base(filepath.Dir(exePath)), ".app"),
+			"CFBundleIdentifier":         getString(src, "bundle_identifier"),
+			"CFBundleShortVersionString": getString(application, "display_version"),
+			"CFBundleVersion":            getString(application, "build_version"),
+			"CFBundleExecutablePath":     exePath,
+			"cpu_arch":                   getString(device, "architecture"),
+			"machine":                    getString(device, "model"),
+			"system_name":                crashlyticsSystemName(src, operatingSystem, device),
+			"system_version":             getString(operatingSystem, "display_version"),
+		},
+		"binary_images": images,
+		"crash": map[string]interface{}{
+			"error":   convertCrashlyticsException(src),
+			"threads": threads,
+		},
+	}
+
+	return report
+}
+
+// convertCrashlyticsFrame 转换单帧：binaryImage 名称对应镜像的基地址作为 object_addr
+func convertCrashlyticsFrame(frame map[string]interface{}, imagesByName map[string]map[string]interface{}) map[string]interface{} {
+	imageName := getString(frame, "binaryImage")
+	converted := map[string]interface{}{
+		"instruction_addr": float64(crashlyticsAddress(frame["address"])),
+		"object_name":      imageName,
+	}
+
+	if img, ok := imagesByName[imageName]; ok {
+		converted["object_addr"] = img["image_addr"]
+	}
+
+	if symbol := getString(frame, "symbol"); symbol != "" {
+		converted["symbol_name"] = symbol
+		// offset 为相对符号起始的偏移，可以还原 symbol_addr
+		if offset := crashlyticsOffset(frame["offset"]); offset > 0 {
+			converted["symbol_addr"] = converted["instruction_addr"].(float64) - float64(offset)
+		}
+	}
+
+	return converted
+}
+
+// convertCrashlyticsException 将第一个 exception 转换为 crash.error
+func convertCrashlyticsException(src map[string]interface{}) map[string]interface{} {
+	exceptions, _ := src["exceptions"].([]interface{})
+	if len(exceptions) == 0 {
+		return map[string]interface{}{}
+	}
+
+	exc, ok := exceptions[0].(map[string]interface{})
+	if !ok {
+		return map[string]interface{}{}
+	}
+
+	return map[string]interface{}{
+		"type":    "mach",
+		"reason":  getString(exc, "exception_message"),
+		"address": float64(crashlyticsAddress(exc["address"])),
+		"mach": map[string]interface{}{
+			"exception_name": getString(exc, "type"),
+			"code_name":      getString(exc, "code"),
+		},
+	}
+}
+
+// crashlyticsAddress 地址/大小可能是数字，也可能是十六进制字符串
+func crashlyticsAddress(v interface{}) uint64 {
+	switch val := v.(type) {
+	case float64:
+		return uint64(val)
+	case string:
+		if addr, err := parseHexAddress(val); err == nil {
+			return addr
+		}
+	}
+	return 0
+}
+
+// crashlyticsOffset 符号偏移可能是数字、十进制字符串或 0x 开头的十六进制字符串
+func crashlyticsOffset(v interface{}) uint64 {
+	s, ok := v.(string)
+	if !ok {
+		return crashlyticsAddress(v)
+	}
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return crashlyticsAddress(s)
+	}
+	if offset, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return offset
+	}
+	return 0
+}
+
+// crashlyticsSystemName 从 operating_system.name 或顶层 platform（IOS、MACOS、TVOS 等）得到系统名
+// 都没有时按设备型号推断，统一为 reportPlatform 的写法
+func crashlyticsSystemName(src, operatingSystem, device map[string]interface{}) string {
+	name := getString(operatingSystem, "name")
+	if name == "" {
+		name = getString(src, "platform")
+	}
+	return reportPlatform(map[string]interface{}{
+		"system_name": name,
+		"machine":     getString(device, "model"),
+	})
+}
+
+// crashlyticsTimestamp 解析 RFC3339 时间或秒级时间戳
+func crashlyticsTimestamp(v interface{}) int64 {
+	switch val := v.(type) {
+	case float64:
+		return int64(val)
+	case string:
+		if t, err := time.Parse(time.RFC3339, val); err == nil {
+			return t.Unix()
+		}
+	}
+	return 0
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConvertCrashlyticsReport(t *testing.T) {
+	data, err := os.ReadFile("testdata/crashlytics_crash.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+
+	if !isCrashlyticsReport(raw) {
+		t.Fatal("未识别为 Crashlytics 报告")
+	}
+	if isCrashlyticsReport(map[string]interface{}{"crash": map[string]interface{}{}}) {
+		t.Error("内部格式被误判为 Crashlytics 报告")
+	}
+
+	report := convertCrashlyticsReport(raw.(map[string]interface{}))
+
+	// 应用镜像可以被 findMatchingDsym 使用的逻辑找到，UUID 已规范化
+	appImage := findAppImage(report)
+	if appImage == nil {
+		t.Fatal("未找到应用镜像")
+	}
+	if got := getString(appImage, "uuid"); got != "FD7CB3D0-06EF-3582-9C99-432ABD79F29C" {
+		t.Errorf("应用镜像 UUID = %s", got)
+	}
+
+	thread := findCrashedThread(report)
+	if thread == nil || getInt64(thread, "index") != 0 {
+		t.Fatalf("崩溃线程错误: %v", thread)
+	}
+
+	frames := thread["backtrace"].(map[string]interface{})["contents"].([]interface{})
+	first := frames[0].(map[string]interface{})
+	if getInt64(first, "instruction_addr") != 0x1000041a0 || getInt64(first, "object_addr") != 0x100000000 {
+		t.Errorf("第 0 帧地址错误: %v", first)
+	}
+	second := frames[1].(map[string]interface{})
+	if getInt64(second, "symbol_addr") != 0x180000200 {
+		t.Errorf("第 1 帧 symbol_addr 错误: %v", second)
+	}
+
+	// 顶层 platform 为 IOS，统一为 reportPlatform 的写法
+	if got := getString(report["system"].(map[string]interface{}), "system_name"); got != "iOS" {
+		t.Errorf("system_name = %q, want iOS", got)
+	}
+
+	formatted := formatReportToAppleStyle(report)
+	for _, want := range []string{
+		"Exception Type:  EXC_BAD_ACCESS",
+		"Thread 0 Crashed:",
+		"-[UIApplication _run] + 64",
+		"Process:                             MatrixTestApp",
+	} {
+		if !strings.Contains(formatted, want) {
+			t.Errorf("格式化结果缺少 %q:\n%s", want, formatted)
+		}
+	}
+}
+
+func TestCrashlyticsOffsetAndPlatform(t *testing.T) {
+	for _, tc := range []struct {
+		offset interface{}
+		want   uint64
+	}{
+		{float64(64), 64},
+		{"64", 64},
+		{"0x40", 64},
+		{"0X40", 64},
+		{"abc", 0},
+		{nil, 0},
+	} {
+		if got := crashlyticsOffset(tc.offset); got != tc.want {
+			t.Errorf("crashlyticsOffset(%v) = %d, want %d", tc.offset, got, tc.want)
+		}
+	}
+
+	for _, tc := range []struct {
+		src, os, device map[string]interface{}
+		want            string
+	}{
+		{map[string]interface{}{"platform": "MACOS"}, nil, nil, "macOS"},
+		{map[string]interface{}{"platform": "TVOS"}, nil, nil, "tvOS"},
+		{map[string]interface{}{"platform": "IOS"}, map[string]interface{}{"name": "iPadOS"}, nil, "iPadOS"},
+		{map[string]interface{}{}, nil, map[string]interface{}{"model": "Watch6,1"}, "watchOS"},
+	} {
+		if got := crashlyticsSystemName(tc.src, tc.os, tc.device); got != tc.want {
+			t.Errorf("crashlyticsSystemName(%v, %v, %v) = %q, want %q", tc.src, tc.os, tc.device, got, tc.want)
+		}
+	}
+}
+
+func TestStoreReportKeepsCrashlyticsOriginal(t *testing.T) {
+	oldReportsDir := ReportsDir
+	ReportsDir = t.TempDir()
+	defer func() { ReportsDir = oldReportsDir }()
+
+	data, err := os.ReadFile("testdata/crashlytics_crash.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, savePath, report, err := storeReport("crashlytics.json", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if findCrashedThread(normalizeReportFormat(report)) == nil {
+		t.Fatalf("报告未转换为内部结构: %v", report)
+	}
+
+	// 原始导出原样保留，且不会被当成报告列出
+	originalPath := crashlyticsOriginalPath(savePath)
+	original, err := os.ReadFile(originalPath)
+	if err != nil || !bytes.Equal(original, data) {
+		t.Fatalf("原始导出未保留: %v", err)
+	}
+	if _, ok := reportIDFromFilename(filepath.Base(originalPath)); ok {
+		t.Errorf("%s 被识别为原始报告", originalPath)
+	}
+
+	deleteReportFiles(ReportsDir, savePath)
+	if _, err := os.Stat(originalPath); !os.IsNotExist(err) {
+		t.Errorf("删除报告后原始导出仍然存在: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+)
+
+// ============================================================================
+// 可热更新的设备型号映射（devices.json）
+// ============================================================================
+
+// devicesFile 设备映射文件（DEVICES_FILE），格式为 {"iPhone18,1": "iPhone 17 Pro"}
+// 文件中的条目覆盖并补充内置的 deviceNames，新机型发布后无需重新编译
+var devicesFile = envString("DEVICES_FILE", "./devices.json")
+
+var (
+	loadedDeviceNamesMu sync.RWMutex
+	// loadedDeviceNames 从文件加载的映射，为 nil 时只使用内置映射
+	loadedDeviceNames map[string]string
+)
+
+// lookupDeviceName 查找设备标识符对应的型号名称，文件中的映射优先
+func lookupDeviceName(machine string) (string, bool) {
+	loadedDeviceNamesMu.RLock()
+	name, ok := loadedDeviceNames[machine]
+	loadedDeviceNamesMu.RUnlock()
+	if ok {
+		return name, true
+	}
+
+	name, ok = deviceNames[machine]
+	return name, ok
+}
+
+// loadDeviceNames 读取设备映射文件并替换当前映射，返回加载的条目数
+// 文件不存在或格式错误时返回错误，保留之前的映射
+func loadDeviceNames(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var names map[string]string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return 0, fmt.Errorf("解析 %s 失败: %v", path, err)
+	}
+
+	loadedDeviceNamesMu.Lock()
+	loadedDeviceNames = names
+	loadedDeviceNamesMu.Unlock()
+	return len(names), nil
+}
+
+// reloadDevices 启动时及 /api/devices/reload 调用，文件不存在（或已被删除）时清空之前加载的映射，只使用内置映射
+func reloadDevices() (int, error) {
+	n, err := loadDeviceNames(devicesFile)
+	if os.IsNotExist(err) {
+		loadedDeviceNamesMu.Lock()
+		loadedDeviceNames = nil
+		loadedDeviceNamesMu.Unlock()
+		return 0, nil
+	}
+	if err != nil {
+		log.Printf("⚠️ 加载设备映射失败: %v", err)
+		return 0, err
+	}
+	log.Printf("📱 已加载设备映射 %s: %d 个", devicesFile, n)
+	return n, nil
+}
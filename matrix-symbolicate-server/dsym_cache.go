@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// dSYM UUID 缓存（避免列表接口每次都解压 + dwarfdump）
+// ============================================================================
+
+// dsymInfoEntry 缓存的各架构 UUID，modTime/size 变化即视为失效
+type dsymInfoEntry struct {
+	modTime time.Time
+	size    int64
+	slices  []DsymSlice
+}
+
+var (
+	dsymInfoCache   = make(map[string]dsymInfoEntry)
+	dsymInfoCacheMu sync.RWMutex
+)
+
+// cachedDsymInfo 带缓存的 extractDsymInfo，按 路径 + 修改时间 + 大小 命中
+// 提取失败的结果不缓存，工具恢复后下次请求即可重新读取
+func cachedDsymInfo(dsymPath string) ([]DsymSlice, error) {
+	stat, err := os.Stat(dsymPath)
+	if err != nil {
+		return nil, err
+	}
+
+	dsymInfoCacheMu.RLock()
+	entry, ok := dsymInfoCache[dsymPath]
+	dsymInfoCacheMu.RUnlock()
+	if ok && entry.modTime.Equal(stat.ModTime()) && entry.size == stat.Size() {
+		return entry.slices, nil
+	}
+
+	slices, err := extractDsymInfo(dsymPath)
+	if err != nil {
+		return nil, err
+	}
+
+	dsymInfoCacheMu.Lock()
+	dsymInfoCache[dsymPath] = dsymInfoEntry{
+		modTime: stat.ModTime(),
+		size:    stat.Size(),
+		slices:  slices,
+	}
+	dsymInfoCacheMu.Unlock()
+
+	return slices, nil
+}
+
+// evictDsymInfo 删除符号表时移除对应的缓存
+func evictDsymInfo(dsymPath string) {
+	dsymInfoCacheMu.Lock()
+	delete(dsymInfoCache, dsymPath)
+	dsymInfoCacheMu.Unlock()
+}
+
+// moveDsymInfo 符号表改名后沿用已读取的 UUID，避免重新解压
+func moveDsymInfo(oldPath, newPath string) {
+	dsymInfoCacheMu.Lock()
+	if entry, ok := dsymInfoCache[oldPath]; ok {
+		dsymInfoCache[newPath] = entry
+		delete(dsymInfoCache, oldPath)
+	}
+	dsymInfoCacheMu.Unlock()
+}
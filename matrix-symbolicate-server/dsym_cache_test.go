@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCachedDsymInfoInvalidation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Demo")
+	writeFakeMachO(t, path, 0x0100000c, 0, [16]byte{0x01})
+	defer evictDsymInfo(path)
+
+	slices1, err := cachedDsymInfo(path)
+	if err != nil {
+		t.Fatalf("cachedDsymInfo() error = %v", err)
+	}
+
+	// 文件被替换且修改时间变化后，缓存失效
+	writeFakeMachO(t, path, 0x0100000c, 0, [16]byte{0x02})
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(path, later, later)
+
+	slices2, err := cachedDsymInfo(path)
+	if err != nil {
+		t.Fatalf("cachedDsymInfo() error = %v", err)
+	}
+	if slices1[0].UUID == slices2[0].UUID {
+		t.Errorf("修改时间变化后仍返回旧 UUID: %s", slices2[0].UUID)
+	}
+
+	evictDsymInfo(path)
+	dsymInfoCacheMu.RLock()
+	_, ok := dsymInfoCache[path]
+	dsymInfoCacheMu.RUnlock()
+	if ok {
+		t.Errorf("evictDsymInfo 后缓存仍存在")
+	}
+}
+
+// BenchmarkListDsyms 对比 20 个 .dSYM.zip 在有无缓存时的列表耗时
+func BenchmarkListDsyms(b *testing.B) {
+	if !toolAvailable("unzip") {
+		b.Skip("unzip 不可用")
+	}
+
+	dir := b.TempDir()
+	for i := 0; i < 20; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("Demo%02d.dSYM.zip", i))
+		writeFakeDsymZip(b, path, [16]byte{0xaa, byte(i)})
+	}
+
+	clearCache := func() {
+		dsymInfoCacheMu.Lock()
+		dsymInfoCache = make(map[string]dsymInfoEntry)
+		dsymInfoCacheMu.Unlock()
+	}
+	defer clearCache()
+
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			clearCache()
+			if _, _, err := listDsyms(dir); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		clearCache()
+		listDsyms(dir)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, _, err := listDsyms(dir); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
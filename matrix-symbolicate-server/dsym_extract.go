@@ -0,0 +1,272 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ============================================================================
+// dSYM 解压（限制并发，保证临时目录及时清理）
+// ============================================================================
+
+// maxConcurrentExtractions 同时存在的解压临时目录上限（MAX_CONCURRENT_EXTRACTIONS）
+var maxConcurrentExtractions = envInt("MAX_CONCURRENT_EXTRACTIONS", 4)
+
+var (
+	// extractionSlots 解压信号量，占用期间临时目录存在
+	extractionSlots = make(chan struct{}, maxConcurrentExtractions)
+	// activeExtractions 当前存在的解压临时目录数量
+	activeExtractions int64
+)
+
+// isDsymArchive 判断是否为需要解压的 dSYM 压缩包（.dSYM.zip / .dSYM.tar.gz）
+func isDsymArchive(name string) bool {
+	return strings.HasSuffix(name, ".dSYM.zip") || strings.HasSuffix(name, ".dSYM.tar.gz")
+}
+
+// extractDsymArchive 将 dSYM 压缩包解压到独立的临时目录，返回 DWARF 二进制路径
+// 调用方使用完二进制后必须调用 cleanup：删除临时目录并释放并发名额
+func extractDsymArchive(archivePath string) (binaryPath string, cleanup func(), err error) {
+	extractionSlots <- struct{}{}
+	return extractDsymArchiveWithSlot(archivePath)
+}
+
+// errNoExtractionSlot 没有空闲的解压名额
+var errNoExtractionSlot = fmt.Errorf("解压名额已满")
+
+// tryExtractDsymArchive 与 extractDsymArchive 相同，但没有空闲名额时立即返回 errNoExtractionSlot
+// 已经持有名额的调用方（如同一报告中系统库的符号表）使用，避免互相等待造成死锁
+func tryExtractDsymArchive(archivePath string) (binaryPath string, cleanup func(), err error) {
+	select {
+	case extractionSlots <- struct{}{}:
+	default:
+		return "", nil, errNoExtractionSlot
+	}
+	return extractDsymArchiveWithSlot(archivePath)
+}
+
+// extractDsymArchiveWithSlot 在已占用解压名额的前提下解压，cleanup 负责释放名额
+func extractDsymArchiveWithSlot(archivePath string) (binaryPath string, cleanup func(), err error) {
+	atomic.AddInt64(&activeExtractions, 1)
+
+	var once sync.Once
+	tmpDir := ""
+	cleanup = func() {
+		once.Do(func() {
+			if tmpDir != "" {
+				if err := os.RemoveAll(tmpDir); err != nil {
+					log.Printf("⚠️ 清理临时目录失败 %s: %v", tmpDir, err)
+				}
+			}
+			atomic.AddInt64(&activeExtractions, -1)
+			<-extractionSlots
+		})
+	}
+
+	tmpDir, err = os.MkdirTemp("", "dsym_extract_")
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("创建临时目录失败: %v", err)
+	}
+
+	if strings.HasSuffix(archivePath, ".tar.gz") {
+		err = extractTarGz(archivePath, tmpDir)
+	} else {
+		_, err = commandRunner.Run(context.Background(), "unzip", "-o", "-q", archivePath, "-d", tmpDir)
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("解压 dSYM 失败: %v", err)
+	}
+
+	// 查找 .dSYM 目录中的二进制文件（跳过 macOS 打包产生的 ._ AppleDouble 文件）
+	matches, _ := filepath.Glob(filepath.Join(tmpDir, dsymDwarfPattern))
+	for _, match := range matches {
+		if !strings.HasPrefix(filepath.Base(match), "._") {
+			return match, cleanup, nil
+		}
+	}
+
+	cleanup()
+	return "", nil, fmt.Errorf("未找到 DWARF 文件")
+}
+
+// extractTarGz 使用 archive/tar 解压 .tar.gz，拒绝越出目标目录的条目
+// 只解压普通文件和目录，忽略符号链接和 __MACOSX 等打包残留
+func extractTarGz(archivePath, destDir string) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("不是有效的 gzip 文件: %v", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("读取 tar 失败: %v", err)
+		}
+
+		target, err := tarEntryPath(destDir, hdr.Name)
+		if err != nil {
+			return err
+		}
+		if target == "" {
+			continue
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(out, tr)
+			out.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// tarEntryPath 返回 tar 条目的解压路径，路径穿越时返回错误，需要跳过的条目返回空
+func tarEntryPath(destDir, name string) (string, error) {
+	clean := path.Clean(strings.TrimPrefix(name, "./"))
+	if clean == "." || strings.HasPrefix(clean, "__MACOSX") {
+		return "", nil
+	}
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("tar 条目路径非法: %s", name)
+	}
+
+	base := filepath.Clean(destDir)
+	if !strings.HasSuffix(base, string(filepath.Separator)) {
+		base += string(filepath.Separator)
+	}
+	target := filepath.Join(destDir, filepath.FromSlash(clean))
+	if !strings.HasPrefix(target, base) {
+		return "", fmt.Errorf("tar 条目路径非法: %s", name)
+	}
+	return target, nil
+}
+
+// dsymDwarfPattern 解压后 DWARF 文件所在的路径
+const dsymDwarfPattern = "*.dSYM/Contents/Resources/DWARF/*"
+
+// machoMagics Mach-O（32/64 位，大小端）和通用二进制的文件头
+var machoMagics = map[uint32]bool{
+	0xfeedface: true, 0xcefaedfe: true, // MH_MAGIC / MH_CIGAM
+	0xfeedfacf: true, 0xcffaedfe: true, // MH_MAGIC_64 / MH_CIGAM_64
+	0xcafebabe: true, 0xbebafeca: true, // FAT_MAGIC / FAT_CIGAM
+}
+
+// validateDsymArchive 上传时校验 .dSYM.zip：必须包含 DWARF 目录下的 Mach-O 文件
+// 直接读取 zip 目录，不需要解压到磁盘
+func validateDsymArchive(r io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("不是有效的 zip 文件: %v", err)
+	}
+
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if matched, _ := path.Match(dsymDwarfPattern, f.Name); !matched {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			continue
+		}
+		var magic [4]byte
+		_, err = io.ReadFull(rc, magic[:])
+		rc.Close()
+		if err == nil && machoMagics[binary.BigEndian.Uint32(magic[:])] {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("压缩包中没有找到 %s 的 Mach-O 文件，请确认上传的是 Xcode 生成的 dSYM", dsymDwarfPattern)
+}
+
+// validateDsymTarball 上传时校验 .dSYM.tar.gz：必须包含 DWARF 目录下的 Mach-O 文件，且没有路径穿越
+func validateDsymTarball(r io.Reader) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("不是有效的 gzip 文件: %v", err)
+	}
+	defer gz.Close()
+
+	found := false
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("读取 tar 失败: %v", err)
+		}
+
+		target, err := tarEntryPath("/", hdr.Name)
+		if err != nil {
+			return err
+		}
+		if found || target == "" || hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+		if matched, _ := path.Match(dsymDwarfPattern, name); !matched || strings.HasPrefix(path.Base(name), "._") {
+			continue
+		}
+		var magic [4]byte
+		if _, err := io.ReadFull(tr, magic[:]); err == nil && machoMagics[binary.BigEndian.Uint32(magic[:])] {
+			found = true
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("压缩包中没有找到 %s 的 Mach-O 文件，请确认上传的是 Xcode 生成的 dSYM", dsymDwarfPattern)
+	}
+	return nil
+}
+
+// extractionStats 返回解压并发情况，用于健康检查
+func extractionStats() map[string]interface{} {
+	return map[string]interface{}{
+		"active": atomic.LoadInt64(&activeExtractions),
+		"limit":  cap(extractionSlots),
+	}
+}
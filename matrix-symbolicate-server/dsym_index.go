@@ -0,0 +1,204 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// 符号表 UUID 索引：DsymDir/by-uuid/<UUID> 是指向符号表文件（可能在年/月分区中）的符号链接，
+// 按 UUID 查找时直接读取链接，不需要遍历并解压目录中的每个符号表
+// ============================================================================
+
+// dsymIndexDirName 索引子目录名
+const dsymIndexDirName = "by-uuid"
+
+// uploadingDsymPrefix 上传中的符号表先以隐藏文件保存，查重通过后再改名
+const uploadingDsymPrefix = ".uploading_"
+
+// dsymIndexPath 返回 UUID 对应的索引链接路径
+func dsymIndexPath(dir, uuid string) string {
+	return filepath.Join(dir, dsymIndexDirName, normalizeUUID(uuid))
+}
+
+// storedDsymFiles 返回目录及其年/月分区中已保存的符号表文件，跳过索引目录和上传中的隐藏文件
+func storedDsymFiles(dir string) []string {
+	var paths []string
+	for _, partition := range dsymPartitionDirs(dir) {
+		files, _ := os.ReadDir(partition)
+		for _, file := range files {
+			if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+				continue
+			}
+			paths = append(paths, filepath.Join(partition, file.Name()))
+		}
+	}
+	return paths
+}
+
+// dsymPartitionDirs 返回根目录和其下全部年/月分区目录
+func dsymPartitionDirs(dir string) []string {
+	dirs := []string{dir}
+	years, _ := os.ReadDir(dir)
+	for _, year := range years {
+		if !year.IsDir() || !isDsymPartitionDir(year.Name()) {
+			continue
+		}
+		months, _ := os.ReadDir(filepath.Join(dir, year.Name()))
+		for _, month := range months {
+			if month.IsDir() && isDsymPartitionDir(month.Name()) {
+				dirs = append(dirs, filepath.Join(dir, year.Name(), month.Name()))
+			}
+		}
+	}
+	return dirs
+}
+
+// dsymDirModTime 返回根目录和各分区目录中最新的修改时间，任一分区增删文件都会改变
+func dsymDirModTime(dir string) (latest time.Time, ok bool) {
+	for _, partition := range dsymPartitionDirs(dir) {
+		stat, err := os.Stat(partition)
+		if err != nil {
+			continue
+		}
+		ok = true
+		if stat.ModTime().After(latest) {
+			latest = stat.ModTime()
+		}
+	}
+	return latest, ok
+}
+
+// indexDsym 为符号表的每个 UUID 建立索引，同一 UUID 已有的索引改为指向该文件
+func indexDsym(dir, dsymPath string, slices []DsymSlice) error {
+	if err := os.MkdirAll(filepath.Join(dir, dsymIndexDirName), 0755); err != nil {
+		return err
+	}
+
+	// 使用相对路径，整个目录移动或挂载到其它位置后索引仍然有效
+	target, err := filepath.Rel(filepath.Join(dir, dsymIndexDirName), dsymPath)
+	if err != nil {
+		return err
+	}
+	for _, slice := range slices {
+		if slice.UUID == "" {
+			continue
+		}
+		link := dsymIndexPath(dir, slice.UUID)
+		os.Remove(link)
+		if err := os.Symlink(target, link); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unindexDsym 删除指向指定符号表文件的全部索引
+func unindexDsym(dir, filename string) {
+	indexDir := filepath.Join(dir, dsymIndexDirName)
+	entries, _ := os.ReadDir(indexDir)
+	for _, entry := range entries {
+		link := filepath.Join(indexDir, entry.Name())
+		if target, err := os.Readlink(link); err == nil && filepath.Base(target) == filename {
+			os.Remove(link)
+		}
+	}
+}
+
+// relinkDsymIndex 符号表移动到 newPath 后，将指向该文件的索引改为指向新位置
+func relinkDsymIndex(dir, filename, newPath string) {
+	indexDir := filepath.Join(dir, dsymIndexDirName)
+	target, err := filepath.Rel(indexDir, newPath)
+	if err != nil {
+		return
+	}
+
+	entries, _ := os.ReadDir(indexDir)
+	for _, entry := range entries {
+		link := filepath.Join(indexDir, entry.Name())
+		if old, err := os.Readlink(link); err == nil && filepath.Base(old) == filename {
+			os.Remove(link)
+			os.Symlink(target, link)
+		}
+	}
+}
+
+// lookupDsymIndex 按 UUID 读取索引，指向的文件已不存在时删除该索引
+func lookupDsymIndex(dir, uuid string) string {
+	link := dsymIndexPath(dir, uuid)
+	target, err := os.Readlink(link)
+	if err != nil {
+		return ""
+	}
+
+	// 旧版本的索引可能是绝对路径，按文件名在目录中查找
+	dsymPath := filepath.Join(dir, dsymIndexDirName, target)
+	if filepath.IsAbs(target) {
+		dsymPath, _ = resolveDsymFile(dir, filepath.Base(target))
+	}
+	if _, err := os.Stat(dsymPath); err != nil {
+		os.Remove(link)
+		return ""
+	}
+	return dsymPath
+}
+
+// dsymIndexScans 记录每个目录上次全量建立索引时的修改时间，目录未变化时索引未命中即视为不存在
+var (
+	dsymIndexScans   = make(map[string]time.Time)
+	dsymIndexScansMu sync.Mutex
+)
+
+// reindexDsyms 为目录（含分区）中的全部符号表补建索引（兼容建立索引之前上传的文件）
+// 目录和分区自上次全量索引后没有增删文件时直接返回，返回本次建立索引的符号表数量
+func reindexDsyms(dir string) int {
+	modTime, ok := dsymDirModTime(dir)
+	if !ok {
+		return 0
+	}
+
+	dsymIndexScansMu.Lock()
+	defer dsymIndexScansMu.Unlock()
+	if scanned, ok := dsymIndexScans[dir]; ok && scanned.Equal(modTime) {
+		return 0
+	}
+
+	indexed := 0
+	for _, dsymPath := range storedDsymFiles(dir) {
+		slices, err := cachedDsymInfo(dsymPath)
+		if err != nil {
+			continue
+		}
+		if err := indexDsym(dir, dsymPath, slices); err != nil {
+			log.Printf("⚠️ 建立符号表索引失败 %s: %v", filepath.Base(dsymPath), err)
+			continue
+		}
+		indexed++
+	}
+
+	// 创建索引目录本身会改变目录的修改时间，扫描结束后再记录
+	if modTime, ok := dsymDirModTime(dir); ok {
+		dsymIndexScans[dir] = modTime
+	}
+	return indexed
+}
+
+// findDsymByUUID 按 UUID 查找已上传的符号表（带不带横线、大小写均可），返回符号表路径
+// 先查索引；未命中且目录有变化时全量补建一次索引后再查
+func findDsymByUUID(dir, uuid string) string {
+	// 不是 UUID 的参数（如文件名）不查索引，也避免拼出索引目录之外的路径
+	if !validUUID(uuid) {
+		return ""
+	}
+	if dsymPath := lookupDsymIndex(dir, uuid); dsymPath != "" {
+		return dsymPath
+	}
+	if reindexDsyms(dir) == 0 {
+		return ""
+	}
+	return lookupDsymIndex(dir, uuid)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// 额外的符号表搜索路径（共享存储上未上传的 .dSYM）
+// ============================================================================
+
+// dsymSearchPaths 额外搜索 *.dSYM 的目录（DSYM_SEARCH_PATHS，冒号分隔）
+// 目录中的符号表只读使用，不复制到 DsymDir
+var dsymSearchPaths = filepath.SplitList(os.Getenv("DSYM_SEARCH_PATHS"))
+
+// searchDsymBinaries 递归查找目录下所有 *.dSYM 包中的 DWARF 二进制
+func searchDsymBinaries(root string) []string {
+	var binaries []string
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// 无权限等错误只跳过该目录
+			return nil
+		}
+		if !d.IsDir() || !strings.HasSuffix(d.Name(), ".dSYM") {
+			return nil
+		}
+
+		matches, _ := filepath.Glob(filepath.Join(path, "Contents", "Resources", "DWARF", "*"))
+		for _, match := range matches {
+			if !strings.HasPrefix(filepath.Base(match), "._") {
+				binaries = append(binaries, match)
+			}
+		}
+		return filepath.SkipDir
+	})
+	return binaries
+}
+
+// dsymSearchIndexTTL 搜索路径 UUID 索引的有效期（DSYM_SEARCH_INDEX_TTL，秒）
+// 共享存储上新增的符号表最多在有效期之后被发现
+var dsymSearchIndexTTL = time.Duration(envInt("DSYM_SEARCH_INDEX_TTL", 300)) * time.Second
+
+// dsymSearchIndex 一个搜索路径下 UUID → DWARF 二进制的索引
+type dsymSearchIndex struct {
+	builtAt time.Time
+	byUUID  map[string]string
+}
+
+var (
+	dsymSearchIndexes   = make(map[string]*dsymSearchIndex)
+	dsymSearchIndexesMu sync.Mutex
+)
+
+// lookupSearchPathDsym 在搜索路径的 UUID 索引中查找二进制，索引不存在或过期时遍历一次目录重建
+// 与 DsymDir 的 by-uuid 索引一样，符号化时不再为每个 UUID 遍历共享存储
+func lookupSearchPathDsym(root, uuid string) string {
+	uuid = normalizeUUID(uuid)
+
+	dsymSearchIndexesMu.Lock()
+	defer dsymSearchIndexesMu.Unlock()
+
+	index, ok := dsymSearchIndexes[root]
+	if !ok || time.Since(index.builtAt) >= dsymSearchIndexTTL {
+		index = &dsymSearchIndex{builtAt: time.Now(), byUUID: make(map[string]string)}
+		for _, binaryPath := range searchDsymBinaries(root) {
+			slices, err := cachedDsymInfo(binaryPath)
+			if err != nil {
+				continue
+			}
+			for _, slice := range slices {
+				if key := normalizeUUID(slice.UUID); key != "" && index.byUUID[key] == "" {
+					index.byUUID[key] = binaryPath
+				}
+			}
+		}
+		dsymSearchIndexes[root] = index
+	}
+
+	binaryPath := index.byUUID[uuid]
+	if binaryPath == "" {
+		return ""
+	}
+	// 索引建立后被删除的符号表
+	if _, err := os.Stat(binaryPath); err != nil {
+		delete(index.byUUID, uuid)
+		return ""
+	}
+	return binaryPath
+}
+
+// findDsymInSearchPaths 在搜索路径中查找包含指定 UUID 的 DWARF 二进制，返回二进制路径
+func findDsymInSearchPaths(paths []string, uuid string) string {
+	for _, root := range paths {
+		if binaryPath := lookupSearchPathDsym(root, uuid); binaryPath != "" {
+			return binaryPath
+		}
+	}
+	return ""
+}
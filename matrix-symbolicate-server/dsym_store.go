@@ -0,0 +1,96 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// 符号表按月份分区存储
+// ============================================================================
+//
+// 新上传的符号表保存在 dsyms/YYYY/MM/ 下，分区由文件名中的上传时间（20060102_150405_ 前缀）算出，
+// 接口中仍然只使用文件名，不需要知道分区。启动时把旧版本平铺在 dsyms/ 根目录的符号表迁移到分区中。
+
+// dsymPartition 由符号表文件名的上传时间前缀计算分区子目录，没有时间前缀时返回空字符串（根目录）
+func dsymPartition(filename string) string {
+	if len(filename) < 16 || filename[15] != '_' {
+		return ""
+	}
+	uploaded, err := time.Parse("20060102_150405", filename[:15])
+	if err != nil {
+		return ""
+	}
+	return filepath.FromSlash(uploaded.Format("2006/01"))
+}
+
+// dsymStorePath 返回新上传的符号表保存路径（所在分区）
+func dsymStorePath(dir, filename string) string {
+	return filepath.Join(dir, dsymPartition(filename), filename)
+}
+
+// isDsymPartitionDir 分区目录名（年或月）只包含数字，用于与 UUID 索引目录区分
+func isDsymPartitionDir(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveDsymFile 将接口中的符号表文件名解析为路径：先查分区，再兼容根目录中尚未迁移的文件
+// 文件不存在时返回分区中的路径，由调用方报告不存在
+func resolveDsymFile(dir, filename string) (string, error) {
+	flatPath, err := safeJoin(dir, filename)
+	if err != nil {
+		return "", err
+	}
+
+	storePath := dsymStorePath(dir, filename)
+	if _, err := os.Stat(storePath); err == nil {
+		return storePath, nil
+	}
+	if _, err := os.Stat(flatPath); err == nil {
+		return flatPath, nil
+	}
+	return storePath, nil
+}
+
+// migrateFlatDsyms 将根目录中带上传时间前缀的符号表移动到所在分区，并更新 UUID 索引
+// 返回迁移的文件数量；没有时间前缀的文件留在根目录
+func migrateFlatDsyms(dir string) int {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+
+	migrated := 0
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || strings.HasPrefix(name, ".") || dsymPartition(name) == "" {
+			continue
+		}
+
+		oldPath := filepath.Join(dir, name)
+		newPath := dsymStorePath(dir, name)
+		if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+			log.Printf("⚠️ 迁移符号表失败 %s: %v", name, err)
+			continue
+		}
+		if err := os.Rename(oldPath, newPath); err != nil {
+			log.Printf("⚠️ 迁移符号表失败 %s: %v", name, err)
+			continue
+		}
+		moveDsymInfo(oldPath, newPath)
+		relinkDsymIndex(dir, name, newPath)
+		migrated++
+	}
+	return migrated
+}
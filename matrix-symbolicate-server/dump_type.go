@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// Matrix dump_type（EDumpType）
+// ============================================================================
+
+// DumpType Matrix 报告的 dump_type
+type DumpType int
+
+const (
+	DumpTypeNoLag                   DumpType = 2000 // 无卡顿
+	DumpTypeMainThreadLag           DumpType = 2001 // 主线程卡顿
+	DumpTypeBackgroundMainThreadLag DumpType = 2002 // 后台主线程卡顿
+	DumpTypeCPUBlock                DumpType = 2003 // CPU 占用过高
+	DumpTypeLaunchBlock             DumpType = 2007 // 启动阻塞
+	DumpTypeThreadTooMany           DumpType = 2009 // 线程过多
+	DumpTypeBlockAndBeKilled        DumpType = 2010 // 被杀死前卡顿
+	DumpTypePowerConsume            DumpType = 2011 // 耗电监控
+	DumpTypeDiskIO                  DumpType = 2013 // 磁盘 I/O
+	DumpTypeFPS                     DumpType = 2014 // FPS 掉帧
+	DumpTypeOOM                     DumpType = 3000 // 内存溢出
+)
+
+// dumpTypeNames 各语言的类型名称
+var dumpTypeNames = map[DumpType]map[string]string{
+	DumpTypeNoLag:                   {"zh": "无卡顿", "en": "No Lag"},
+	DumpTypeMainThreadLag:           {"zh": "主线程卡顿", "en": "Main Thread Lag"},
+	DumpTypeBackgroundMainThreadLag: {"zh": "后台主线程卡顿", "en": "Background Main Thread Lag"},
+	DumpTypeCPUBlock:                {"zh": "CPU 占用过高", "en": "High CPU Usage"},
+	DumpTypeLaunchBlock:             {"zh": "启动阻塞", "en": "Launch Blocked"},
+	DumpTypeThreadTooMany:           {"zh": "线程过多", "en": "Too Many Threads"},
+	DumpTypeBlockAndBeKilled:        {"zh": "被杀死前卡顿", "en": "Lag Before Killed"},
+	DumpTypePowerConsume:            {"zh": "耗电监控", "en": "Power Consumption"},
+	DumpTypeDiskIO:                  {"zh": "磁盘 I/O", "en": "Disk I/O"},
+	DumpTypeFPS:                     {"zh": "FPS 掉帧", "en": "FPS Drop"},
+	DumpTypeOOM:                     {"zh": "内存溢出 (OOM)", "en": "Out of Memory (OOM)"},
+}
+
+// defaultLanguage 未指定或不支持的语言使用中文
+const defaultLanguage = "zh"
+
+// String 返回中文类型名称
+func (t DumpType) String() string {
+	return t.Localized(defaultLanguage)
+}
+
+// Localized 返回指定语言（zh / en）的类型名称，未知类型返回 "类型 N" / "Type N"
+func (t DumpType) Localized(lang string) string {
+	if names, ok := dumpTypeNames[t]; ok {
+		if name, ok := names[lang]; ok {
+			return name
+		}
+		return names[defaultLanguage]
+	}
+	if lang == "en" {
+		return fmt.Sprintf("Type %d", int(t))
+	}
+	return fmt.Sprintf("类型 %d", int(t))
+}
+
+// preferredLanguage 从 Accept-Language 中选出权重最高的受支持语言（zh / en），都不支持时返回中文
+func preferredLanguage(acceptLanguage string) string {
+	best, bestQ := defaultLanguage, -1.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		lang, _, _ := strings.Cut(tag, "-")
+		if lang != "zh" && lang != "en" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > bestQ {
+			best, bestQ = lang, q
+		}
+	}
+	return best
+}
@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+func TestDumpTypeLocalized(t *testing.T) {
+	tests := []struct {
+		code   DumpType
+		lang   string
+		expect string
+	}{
+		{DumpTypeMainThreadLag, "zh", "主线程卡顿"},
+		{DumpTypeMainThreadLag, "en", "Main Thread Lag"},
+		{DumpTypeOOM, "en", "Out of Memory (OOM)"},
+		{DumpTypePowerConsume, "fr", "耗电监控"},
+		{DumpType(2099), "zh", "类型 2099"},
+		{DumpType(2099), "en", "Type 2099"},
+	}
+	for _, tt := range tests {
+		if got := tt.code.Localized(tt.lang); got != tt.expect {
+			t.Errorf("DumpType(%d).Localized(%q) = %q, want %q", int(tt.code), tt.lang, got, tt.expect)
+		}
+	}
+
+	if got := DumpTypeLaunchBlock.String(); got != "启动阻塞" {
+		t.Errorf("String() = %q, want 启动阻塞", got)
+	}
+}
+
+func TestPreferredLanguage(t *testing.T) {
+	tests := map[string]string{
+		"":                        "zh",
+		"en-US,en;q=0.9":          "en",
+		"zh-CN,zh;q=0.9,en;q=0.8": "zh",
+		"fr-FR,en;q=0.5":          "en",
+		"en;q=0.3,zh-TW;q=0.7":    "zh",
+		"de-DE":                   "zh",
+	}
+	for header, want := range tests {
+		if got := preferredLanguage(header); got != want {
+			t.Errorf("preferredLanguage(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
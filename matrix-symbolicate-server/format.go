@@ -4,33 +4,151 @@ import (
 	"fmt"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
-// 将 Matrix JSON 报告转换为 Apple crash report 格式
-func formatReportToAppleStyle(report map[string]interface{}) string {
-	var result strings.Builder
+// ReportStyle 报告格式化策略
+type ReportStyle string
+
+const (
+	ReportStyleCrash  ReportStyle = "crash"  // 崩溃：完整异常信息 + 线程 + 寄存器
+	ReportStyleLag    ReportStyle = "lag"    // 卡顿：异常信息 + 线程，不输出寄存器
+	ReportStyleCPU    ReportStyle = "cpu"    // CPU 过高：只关注线程堆栈
+	ReportStyleMemory ReportStyle = "memory" // 内存：OOM head + items
+	ReportStylePower  ReportStyle = "power"  // 耗电：stack_string 树状堆栈
+)
+
+// dumpTypeStyles dump_type -> 格式化策略
+// Matrix 新增 dump 类型时只需要在这里（或通过 registerDumpTypeStyle）注册即可
+var dumpTypeStyles = map[DumpType]ReportStyle{
+	DumpTypeNoLag:                   ReportStyleLag,
+	DumpTypeMainThreadLag:           ReportStyleLag,
+	DumpTypeBackgroundMainThreadLag: ReportStyleLag,
+	DumpTypeCPUBlock:                ReportStyleCPU,
+	DumpTypeLaunchBlock:             ReportStyleLag,
+	DumpTypeThreadTooMany:           ReportStyleLag,
+	DumpTypeBlockAndBeKilled:        ReportStyleLag,
+	DumpTypePowerConsume:            ReportStylePower,
+	DumpTypeDiskIO:                  ReportStyleLag,
+	DumpTypeFPS:                     ReportStyleLag,
+	DumpTypeOOM:                     ReportStyleMemory,
+}
+
+// defaultReportStyle 未注册的 dump 类型使用的兜底策略（可通过 DEFAULT_REPORT_STYLE 配置）
+var defaultReportStyle = ReportStyleCrash
+
+// registerDumpTypeStyle 为 dump 类型注册格式化策略
+func registerDumpTypeStyle(dumpType DumpType, style ReportStyle) {
+	dumpTypeStyles[dumpType] = style
+}
+
+// parseReportStyle 解析策略名称，未知名称返回 false
+func parseReportStyle(name string) (ReportStyle, bool) {
+	style := ReportStyle(strings.ToLower(strings.TrimSpace(name)))
+	switch style {
+	case ReportStyleCrash, ReportStyleLag, ReportStyleCPU, ReportStyleMemory, ReportStylePower:
+		return style, true
+	}
+	return "", false
+}
 
-	// 检查是否是 OOM 报告
+// resolveReportStyle 决定报告使用的格式化策略
+// 优先级：报告结构（OOM / 耗电）> dump_type 注册表 > 兜底策略
+func resolveReportStyle(report map[string]interface{}) ReportStyle {
+	// OOM 报告格式：head + items[]
 	if _, hasHead := report["head"].(map[string]interface{}); hasHead {
 		if _, hasItems := report["items"].([]interface{}); hasItems {
-			return formatOOMReport(report)
+			return ReportStyleMemory
 		}
 	}
 
-	// 检查是否是耗电日志
-	dumpType := 0
 	if dt, ok := report["dump_type"].(float64); ok {
-		dumpType = int(dt)
+		if style, ok := dumpTypeStyles[DumpType(dt)]; ok {
+			return style
+		}
+	}
+
+	// 未注册的类型：有 stack_string 的一定是树状堆栈
+	if _, ok := report["stack_string"].([]interface{}); ok {
+		return ReportStylePower
 	}
 
-	// 耗电日志使用不同的格式化方式
-	if dumpType == 2011 { // EDumpType_PowerConsume
-		return formatPowerConsumeReport(report)
+	return defaultReportStyle
+}
+
+// 将 Matrix JSON 报告转换为 Apple crash report 格式
+func formatReportToAppleStyle(report map[string]interface{}) string {
+	// 多份报告的合并结果
+	if _, ok := report[combinedReportsKey].([]interface{}); ok {
+		return formatCombinedReports(report)
+	}
+
+	var formatted string
+	switch resolveReportStyle(report) {
+	case ReportStyleMemory:
+		formatted = formatOOMReport(report)
+	case ReportStylePower:
+		formatted = formatPowerConsumeReport(report)
+	case ReportStyleLag:
+		formatted = formatLagReport(report)
+	case ReportStyleCPU:
+		formatted = formatCPUReport(report)
+	default:
+		formatted = formatCrashReport(report)
+	}
+
+	// 崩溃处理器自身崩溃时，附加 recrash 报告
+	return formatted + formatRecrashReport(report)
+}
+
+// recrashReport 返回 KSCrash 的 recrash_report，binary_images 缺失时沿用外层报告的
+func recrashReport(report map[string]interface{}) map[string]interface{} {
+	recrash, ok := report["recrash_report"].(map[string]interface{})
+	if !ok || len(recrash) == 0 {
+		return nil
 	}
 
-	// 卡顿/崩溃日志的格式化
+	if _, ok := recrash["binary_images"]; ok {
+		return recrash
+	}
+
+	merged := make(map[string]interface{}, len(recrash)+1)
+	for k, v := range recrash {
+		merged[k] = v
+	}
+	merged["binary_images"] = report["binary_images"]
+	return merged
+}
+
+// formatRecrashReport 格式化 recrash_report：崩溃处理器在处理崩溃时再次崩溃
+// 只输出异常信息和崩溃线程
+func formatRecrashReport(report map[string]interface{}) string {
+	recrash := recrashReport(report)
+	if recrash == nil {
+		return ""
+	}
+
+	var result strings.Builder
+	result.WriteString("\n==================== Recrash Report ====================\n")
+	result.WriteString("崩溃处理器在记录上面的崩溃时自身发生了崩溃，原始报告可能不完整\n")
+
+	result.WriteString(formatErrorInfo(recrash))
+
+	if thread := findCrashedThread(recrash); thread != nil {
+		result.WriteString(formatThread(thread, recrash))
+	}
+
+	result.WriteString("========================================================\n")
+	return result.String()
+}
+
+// formatCrashReport 崩溃日志的格式化
+func formatCrashReport(report map[string]interface{}) string {
+	var result strings.Builder
+
 	// 解析系统信息
 	result.WriteString(formatSystemInfo(report))
 	result.WriteString("\n")
@@ -55,12 +173,59 @@ func formatReportToAppleStyle(report map[string]interface{}) string {
 	result.WriteString(formatCPUState(report))
 	result.WriteString("\n")
 
-	// 二进制镜像列表通常很长且对日常分析用处不大，已省略
-	// 如需查看完整的二进制镜像列表，请查看 JSON 格式报告
+	// 二进制镜像列表通常很长且对日常分析用处不大，默认省略
+	// 需要时通过 /api/report/:id/formatted?binary_images=1 附加
+
+	return result.String()
+}
+
+// formatLagReport 卡顿日志的格式化
+// 卡顿时的寄存器状态是采样时刻的快照，对分析帮助不大，因此不输出
+func formatLagReport(report map[string]interface{}) string {
+	var result strings.Builder
+
+	result.WriteString(formatDumpTypeHeader(report))
+	result.WriteString(formatSystemInfo(report))
+	result.WriteString("\n")
+	result.WriteString(formatErrorInfo(report))
+	result.WriteString("\n")
+	result.WriteString(formatUserInfo(report))
+	result.WriteString("\n")
+	result.WriteString(formatAppInfo(report))
+	result.WriteString("\n")
+	result.WriteString(formatThreadList(report))
+	result.WriteString("\n")
+
+	return result.String()
+}
+
+// formatCPUReport CPU 过高日志的格式化
+// 没有真正的异常，只需要关注各线程在做什么
+func formatCPUReport(report map[string]interface{}) string {
+	var result strings.Builder
+
+	result.WriteString(formatDumpTypeHeader(report))
+	result.WriteString(formatSystemInfo(report))
+	result.WriteString("\n")
+	result.WriteString(formatAppInfo(report))
+	result.WriteString("\n")
+	result.WriteString(formatUserInfo(report))
+	result.WriteString("\n")
+	result.WriteString(formatThreadList(report))
+	result.WriteString("\n")
 
 	return result.String()
 }
 
+// formatDumpTypeHeader 输出报告类型行
+func formatDumpTypeHeader(report map[string]interface{}) string {
+	dt, ok := report["dump_type"].(float64)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("Dump Type:       %s (%d)\n\n", DumpType(dt), int(dt))
+}
+
 func formatSystemInfo(report map[string]interface{}) string {
 	system, ok := report["system"].(map[string]interface{})
 	if !ok {
@@ -71,16 +236,15 @@ func formatSystemInfo(report map[string]interface{}) string {
 	result.WriteString("System Info: {\n")
 
 	// 设备信息
-	if machine, ok := system["machine"].(string); ok {
-		deviceName := getDeviceName(machine)
-		result.WriteString(fmt.Sprintf("    Device:      %s\n", deviceName))
+	if device := reportDevice(system); device != "" {
+		result.WriteString(fmt.Sprintf("    Device:      %s\n", getDeviceName(device)))
 	}
 
-	// 系统版本
-	if systemName, ok := system["system_name"].(string); ok {
+	// 系统版本：平台名统一为 iOS / watchOS / tvOS / macOS
+	if platform := reportPlatform(system); platform != "" {
 		systemVersion := getString(system, "system_version")
 		osVersion := getString(system, "os_version")
-		result.WriteString(fmt.Sprintf("    OS Version:  %s %s (%s)\n", systemName, systemVersion, osVersion))
+		result.WriteString(fmt.Sprintf("    OS Version:  %s %s (%s)\n", platform, systemVersion, osVersion))
 	}
 
 	// 内存信息
@@ -118,15 +282,21 @@ func formatErrorInfo(report map[string]interface{}) string {
 	sigName := ""
 
 	if mach, ok := error["mach"].(map[string]interface{}); ok {
+		// 优先使用 exception_name，否则按 exception 编号查表
 		excName = getString(mach, "exception_name")
+		if excName == "" {
+			if exc := getInt64(mach, "exception"); exc != 0 {
+				excName = machExceptionName(int(exc))
+			}
+		}
 	}
 
 	if signal, ok := error["signal"].(map[string]interface{}); ok {
-		// 优先使用 name，否则使用 signal 数字
+		// 优先使用 name，否则按 signal 编号查表
 		sigName = getString(signal, "name")
 		if sigName == "" {
 			if sigNum := getInt64(signal, "signal"); sigNum != 0 {
-				sigName = fmt.Sprintf("SIG%d", sigNum)
+				sigName = signalName(int(sigNum))
 			}
 		}
 	}
@@ -147,19 +317,120 @@ func formatErrorInfo(report map[string]interface{}) string {
 	addr := getInt64(error, "address")
 	result.WriteString(fmt.Sprintf("Exception Codes: %s at 0x%016x\n", codeName, addr))
 
-	// Crashed Thread
+	// Crashed Thread；卡顿报告没有崩溃线程，标记被阻塞的线程
 	crashedThreadIdx := getCrashedThreadIndex(report)
-	result.WriteString(fmt.Sprintf("Crashed Thread:  %d\n", crashedThreadIdx))
+	if dumpType, hung := hungDumpType(report); hung {
+		result.WriteString(fmt.Sprintf("Hung Thread:     %d (%s)\n", crashedThreadIdx, DumpType(dumpType)))
+	} else {
+		result.WriteString(fmt.Sprintf("Crashed Thread:  %d\n", crashedThreadIdx))
+	}
+
+	// Application Specific Information
+	result.WriteString(formatApplicationSpecificInfo(report))
 
 	return result.String()
 }
 
+// signalNames Darwin 的信号编号（sys/signal.h）
+var signalNames = map[int]string{
+	1: "SIGHUP", 2: "SIGINT", 3: "SIGQUIT", 4: "SIGILL", 5: "SIGTRAP", 6: "SIGABRT", 7: "SIGEMT", 8: "SIGFPE",
+	9: "SIGKILL", 10: "SIGBUS", 11: "SIGSEGV", 12: "SIGSYS", 13: "SIGPIPE", 14: "SIGALRM", 15: "SIGTERM", 16: "SIGURG",
+	17: "SIGSTOP", 18: "SIGTSTP", 19: "SIGCONT", 20: "SIGCHLD", 21: "SIGTTIN", 22: "SIGTTOU", 23: "SIGIO", 24: "SIGXCPU",
+	25: "SIGXFSZ", 26: "SIGVTALRM", 27: "SIGPROF", 28: "SIGWINCH", 29: "SIGINFO", 30: "SIGUSR1", 31: "SIGUSR2",
+}
+
+// signalName 信号编号对应的名称，未知编号输出 SIG<编号>
+func signalName(sig int) string {
+	if name, ok := signalNames[sig]; ok {
+		return name
+	}
+	return fmt.Sprintf("SIG%d", sig)
+}
+
+// machExceptionNames Mach 异常类型编号（mach/exception_types.h）
+var machExceptionNames = map[int]string{
+	1: "EXC_BAD_ACCESS", 2: "EXC_BAD_INSTRUCTION", 3: "EXC_ARITHMETIC", 4: "EXC_EMULATION",
+	5: "EXC_SOFTWARE", 6: "EXC_BREAKPOINT", 7: "EXC_SYSCALL", 8: "EXC_MACH_SYSCALL",
+	9: "EXC_RPC_ALERT", 10: "EXC_CRASH", 11: "EXC_RESOURCE", 12: "EXC_GUARD", 13: "EXC_CORPSE_NOTIFY",
+}
+
+// machExceptionName Mach 异常编号对应的名称，未知编号输出十六进制
+func machExceptionName(exc int) string {
+	if name, ok := machExceptionNames[exc]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%x", exc)
+}
+
+// formatApplicationSpecificInfo 输出 abort、断言失败、dyld 错误等附加信息，通常能直接定位问题
+func formatApplicationSpecificInfo(report map[string]interface{}) string {
+	messages := applicationSpecificMessages(report)
+	if len(messages) == 0 {
+		return ""
+	}
+
+	var result strings.Builder
+	result.WriteString("\nApplication Specific Information:\n")
+	for _, message := range messages {
+		result.WriteString(message)
+		result.WriteString("\n")
+	}
+	return result.String()
+}
+
+// applicationSpecificMessages 收集报告中的附加信息，去重并保持出现顺序
+// 来源：未捕获 NSException、crash.error.reason、各镜像 __crash_info 中的 crash_info_message(2)
+func applicationSpecificMessages(report map[string]interface{}) []string {
+	var messages []string
+	seen := make(map[string]bool)
+	add := func(message string) {
+		message = strings.TrimSpace(message)
+		if message != "" && !seen[message] {
+			seen[message] = true
+			messages = append(messages, message)
+		}
+	}
+
+	if crash, ok := report["crash"].(map[string]interface{}); ok {
+		if crashError, ok := crash["error"].(map[string]interface{}); ok {
+			if nsexception, ok := crashError["nsexception"].(map[string]interface{}); ok {
+				name := getString(nsexception, "name")
+				reason := getString(nsexception, "reason")
+				if reason == "" {
+					reason = getString(crashError, "reason")
+				}
+				if name != "" || reason != "" {
+					add(fmt.Sprintf("*** Terminating app due to uncaught exception '%s', reason: '%s'", name, reason))
+					seen[strings.TrimSpace(reason)] = true
+				}
+			}
+			add(getString(crashError, "reason"))
+			add(getString(crashError, "crash_info_message"))
+		}
+	}
+
+	if images, ok := report["binary_images"].([]interface{}); ok {
+		for _, imgData := range images {
+			img, ok := imgData.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			add(getString(img, "crash_info_message"))
+			add(getString(img, "crash_info_message2"))
+		}
+	}
+
+	return messages
+}
+
 func formatUserInfo(report map[string]interface{}) string {
 	user, ok := report["user"].(map[string]interface{})
 	if !ok || len(user) == 0 {
 		return ""
 	}
 
+	reportInfo, _ := report["report"].(map[string]interface{})
+
 	var result strings.Builder
 	result.WriteString("\nUser Info: {\n")
 
@@ -172,8 +443,15 @@ func formatUserInfo(report map[string]interface{}) string {
 			}
 			if blockTime, ok := appInfo["blockTime"]; ok {
 				result.WriteString(fmt.Sprintf("    blockTime:       %v\n", blockTime))
+				if value, ok := blockTimeValue(blockTime); ok {
+					if d, ok := hangDuration(value, getInt64(reportInfo, "timestamp")); ok {
+						result.WriteString(fmt.Sprintf("    Hang duration:   %s\n", formatHangDuration(d)))
+					}
+				}
 			}
-			if dumpType, ok := appInfo["DumpType"]; ok {
+			if code, ok := appInfo["DumpType"].(float64); ok {
+				result.WriteString(fmt.Sprintf("    dumpType:        %d (%s)\n", int(code), DumpType(code)))
+			} else if dumpType, ok := appInfo["DumpType"]; ok {
 				result.WriteString(fmt.Sprintf("    dumpType:        %v\n", dumpType))
 			}
 		}
@@ -224,6 +502,13 @@ func formatAppInfo(report map[string]interface{}) string {
 		if launchTime := getInt64(appStats, "app_launch_time"); launchTime > 0 {
 			launchTimeStr := time.Unix(launchTime, 0).Format("2006-01-02 15:04:05")
 			result.WriteString(fmt.Sprintf("    app_launch_time:                     %s\n", launchTimeStr))
+
+			// 启动阻塞：卡顿发生时距离启动的时间
+			if dumpType, ok := reportDumpType(report); ok && DumpType(dumpType) == DumpTypeLaunchBlock {
+				if timestamp := getInt64(reportInfo, "timestamp"); timestamp >= launchTime {
+					result.WriteString(fmt.Sprintf("    Time since launch:                   %s\n", formatHangDuration(time.Duration(timestamp-launchTime)*time.Second)))
+				}
+			}
 		}
 	}
 
@@ -243,28 +528,157 @@ func formatThreadList(report map[string]interface{}) string {
 	}
 
 	var result strings.Builder
-	seenThreads := make(map[int64]bool)
+	seenThreads := make(map[threadKey]bool)
 
-	for _, threadData := range threads {
+	for i, threadData := range threads {
 		thread, ok := threadData.(map[string]interface{})
 		if !ok {
 			continue
 		}
 
-		// 去重：检查线程索引
-		index := getInt64(thread, "index")
-		if seenThreads[index] {
+		// 去重：索引、线程名和首帧地址都相同才视为同一线程
+		key := threadDedupKey(thread)
+		if seenThreads[key] {
 			continue
 		}
-		seenThreads[index] = true
+		seenThreads[key] = true
+
+		// 没有 index 的线程按在列表中的位置显示
+		if _, ok := thread["index"]; !ok {
+			thread = withSyntheticIndex(thread, i)
+		}
 
 		result.WriteString(formatThread(thread, report))
 		result.WriteString("\n")
 	}
 
+	if omitted := getInt64(crash, omittedThreadsKey); omitted > 0 {
+		result.WriteString(fmt.Sprintf("... %d more threads omitted\n\n", omitted))
+	}
+
 	return result.String()
 }
 
+// threadKey 线程去重使用的组合键
+// 部分报告多个线程共用 index 0 或缺少 index，只按 index 去重会丢掉真实的线程
+type threadKey struct {
+	index     int64
+	name      string
+	firstAddr int64
+}
+
+// threadDedupKey 返回线程的去重键：index + 线程名（或队列名）+ 首帧地址
+func threadDedupKey(thread map[string]interface{}) threadKey {
+	key := threadKey{index: getInt64(thread, "index"), name: getString(thread, "name")}
+	if key.name == "" {
+		key.name = getString(thread, "dispatch_queue")
+	}
+	backtrace, _ := thread["backtrace"].(map[string]interface{})
+	if contents, _ := backtrace["contents"].([]interface{}); len(contents) > 0 {
+		if frame, ok := contents[0].(map[string]interface{}); ok {
+			key.firstAddr = getInt64(frame, "instruction_addr")
+		}
+	}
+	return key
+}
+
+// withSyntheticIndex 返回带显示用 index 的线程副本，原始报告不会被修改
+func withSyntheticIndex(thread map[string]interface{}, index int) map[string]interface{} {
+	result := make(map[string]interface{}, len(thread)+1)
+	for k, v := range thread {
+		result[k] = v
+	}
+	result["index"] = float64(index)
+	return result
+}
+
+// conciseMaxThreads 简洁模式下最多输出的线程数（CONCISE_MAX_THREADS）
+var conciseMaxThreads = envInt("CONCISE_MAX_THREADS", 10)
+
+// omittedThreadsKey 简洁模式下记录被省略线程数的字段，只存在于 conciseReport 返回的副本中
+const omittedThreadsKey = "_omitted_threads"
+
+// conciseReport 返回只保留关键线程的副本：崩溃/阻塞线程和包含应用代码的线程，最多 maxThreads 个
+// 原始报告不会被修改
+func conciseReport(report map[string]interface{}, maxThreads int) map[string]interface{} {
+	crash, ok := report["crash"].(map[string]interface{})
+	if !ok {
+		return report
+	}
+	threads, ok := crash["threads"].([]interface{})
+	if !ok {
+		return report
+	}
+	if maxThreads < 1 {
+		maxThreads = 1
+	}
+
+	crashedIdx := getCrashedThreadIndex(report)
+	appName := appImageName(report)
+
+	// 崩溃线程优先占用名额，其余按原顺序
+	keep := make([]bool, len(threads))
+	kept := 0
+	for i, threadData := range threads {
+		if thread, ok := threadData.(map[string]interface{}); ok && getInt64(thread, "index") == crashedIdx {
+			keep[i] = true
+			kept++
+			break
+		}
+	}
+	for i, threadData := range threads {
+		if kept >= maxThreads {
+			break
+		}
+		thread, ok := threadData.(map[string]interface{})
+		if !ok || keep[i] || !threadHasAppCode(thread, appName) {
+			continue
+		}
+		keep[i] = true
+		kept++
+	}
+
+	filtered := make([]interface{}, 0, kept)
+	for i, threadData := range threads {
+		if keep[i] {
+			filtered = append(filtered, threadData)
+		}
+	}
+
+	newCrash := make(map[string]interface{}, len(crash)+1)
+	for k, v := range crash {
+		newCrash[k] = v
+	}
+	newCrash["threads"] = filtered
+	newCrash[omittedThreadsKey] = float64(len(threads) - len(filtered))
+
+	result := make(map[string]interface{}, len(report))
+	for k, v := range report {
+		result[k] = v
+	}
+	result["crash"] = newCrash
+	return result
+}
+
+// threadHasAppCode 判断线程是否包含应用代码帧（符号化标记或镜像名为应用）
+func threadHasAppCode(thread map[string]interface{}, appName string) bool {
+	backtrace, _ := thread["backtrace"].(map[string]interface{})
+	contents, _ := backtrace["contents"].([]interface{})
+	for _, frameData := range contents {
+		frame, ok := frameData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if getBool(frame, "is_app_code") {
+			return true
+		}
+		if appName != "" && filepath.Base(getString(frame, "object_name")) == appName {
+			return true
+		}
+	}
+	return false
+}
+
 func formatThread(thread map[string]interface{}, report map[string]interface{}) string {
 	var result strings.Builder
 
@@ -281,6 +695,8 @@ func formatThread(thread map[string]interface{}, report map[string]interface{})
 	// Thread header
 	if crashed {
 		result.WriteString(fmt.Sprintf("Thread %d Crashed:\n", index))
+	} else if _, hung := hungDumpType(report); hung && index == getCrashedThreadIndex(report) {
+		result.WriteString(fmt.Sprintf("Thread %d Hung:\n", index))
 	} else {
 		result.WriteString(fmt.Sprintf("Thread %d:\n", index))
 	}
@@ -299,21 +715,36 @@ func formatBacktrace(backtrace map[string]interface{}, report map[string]interfa
 		return ""
 	}
 
-	var result strings.Builder
+	// 先生成每一帧的各列，再按最长的符号对齐文件位置列
+	type backtraceLine struct {
+		preamble, symbol, location, addressing string
+		inlined                                bool
+	}
+	var lines []backtraceLine
+	symbolWidth := 0
+
+	// 指定了 max_frames 时只输出栈顶的帧，其余给出省略的数量
+	maxFrames := int(getInt64(report, maxFramesKey))
+	omitted := 0
+
 	for i, frameData := range contents {
 		frame, ok := frameData.(map[string]interface{})
 		if !ok {
 			continue
 		}
+		if maxFrames > 0 && len(lines) >= maxFrames {
+			omitted++
+			continue
+		}
 
-		pc := getInt64(frame, "instruction_addr")
+		pc := getAddress(frame, "instruction_addr")
+		img := findImageForFrame(report, frame)
 
 		// 获取模块名，优先从 frame 中获取
 		objectName := getString(frame, "object_name")
 
 		// 如果没有，尝试从镜像信息中获取
 		if objectName == "" || objectName == "unknown" {
-			img := findImageForAddress(report, pc)
 			if img != nil {
 				imgName := getString(img, "name")
 				if imgName != "" {
@@ -327,37 +758,162 @@ func formatBacktrace(backtrace map[string]interface{}, report map[string]interfa
 			objectName = "???"
 		}
 
-		// 获取对应的镜像信息
-		img := findImageForAddress(report, pc)
+		// 只取模块文件名部分
+		if strings.Contains(objectName, "/") {
+			objectName = filepath.Base(objectName)
+		}
+
+		// 获取对应的镜像基址：优先 binary_images，其次 frame 自带的 object_addr
+		objAddr := uint64(0)
 		if img != nil {
-			objAddr := getInt64(img, "image_addr")
-			offset := pc - objAddr
-
-			// 格式：序号 模块名 地址 符号信息
-			preamble := fmt.Sprintf("%-4d%-31s 0x%016x", i, objectName, pc)
-
-			// 优先使用符号化后的名称
-			symbolicatedName := getString(frame, "symbolicated_name")
-			symbolName := getString(frame, "symbol_name")
-
-			if symbolicatedName != "" {
-				// 使用符号化后的结果
-				result.WriteString(fmt.Sprintf("%s %s\n", preamble, symbolicatedName))
-			} else if symbolName != "" && symbolName != "<redacted>" {
-				// 使用原始符号名
-				result.WriteString(fmt.Sprintf("%s %s\n", preamble, symbolName))
+			objAddr = getAddress(img, "image_addr")
+		}
+
+		// 格式：序号 模块名 地址 符号信息 (文件:行号)
+		line := backtraceLine{preamble: fmt.Sprintf("%-4d%-31s 0x%016x", i, objectName, pc)}
+
+		// 优先使用符号化后的名称
+		symbolicatedName := getString(frame, "symbolicated_name")
+		symbolName := getString(frame, "symbol_name")
+
+		if symbolicatedName != "" {
+			// 使用符号化后的结果，文件位置单独成列
+			line.symbol = backtraceSymbol(symbolicatedName)
+		} else if symbolName != "" && symbolName != "<redacted>" {
+			// 使用原始符号名，有 symbol_addr 时补充函数内偏移
+			if symAddr := getAddress(frame, "symbol_addr"); symAddr > 0 && pc >= symAddr {
+				line.symbol = fmt.Sprintf("%s + %d", symbolName, pc-symAddr)
 			} else {
-				// 未符号化，显示地址+偏移
-				result.WriteString(fmt.Sprintf("%s 0x%x + %d\n", preamble, objAddr, offset))
+				line.symbol = symbolName
 			}
-		} else {
-			result.WriteString(fmt.Sprintf("%-4d%-31s 0x%016x\n", i, objectName, pc))
+		} else if objAddr > 0 && pc >= objAddr {
+			// 未符号化，显示地址+偏移
+			line.symbol = fmt.Sprintf("0x%x + %d", objAddr, pc-objAddr)
+		}
+		line.location = frameSourceLocation(frame)
+		line.inlined = getBool(frame, "inlined")
+		if line.location != "" {
+			symbolWidth = max(symbolWidth, min(utf8.RuneCountInString(line.symbol), backtraceSymbolMaxWidth))
+		}
+
+		// 详细模式：附加符号化时的地址计算
+		if getBool(report, verboseFramesKey) {
+			line.addressing = formatFrameAddressing(frame)
+		}
+		lines = append(lines, line)
+	}
+
+	var result strings.Builder
+	for _, line := range lines {
+		result.WriteString(line.preamble)
+		if line.symbol != "" {
+			result.WriteString(" " + line.symbol)
+		}
+		if line.location != "" {
+			if pad := symbolWidth - utf8.RuneCountInString(line.symbol); pad > 0 {
+				result.WriteString(strings.Repeat(" ", pad))
+			}
+			result.WriteString(" (" + line.location + ")")
 		}
+		// 内联帧与外层实际函数地址相同，按 Apple 崩溃报告的格式标注
+		if line.inlined {
+			result.WriteString(" [inlined]")
+		}
+		result.WriteString("\n")
+		result.WriteString(line.addressing)
+	}
+	if omitted > 0 {
+		result.WriteString(fmt.Sprintf("... (%d more frames omitted)\n", omitted))
+	}
+	// 采集时被省略的帧位于已采集帧的下方（栈底方向），放在最后
+	if skipped := backtraceSkipped(backtrace); skipped > 0 {
+		result.WriteString(fmt.Sprintf("... %d frames skipped during capture\n", skipped))
 	}
 
 	return result.String()
 }
 
+// backtraceSkipped 返回 backtrace.skipped：采集堆栈时因深度限制被省略的帧数
+func backtraceSkipped(backtrace map[string]interface{}) int64 {
+	return getInt64(backtrace, "skipped")
+}
+
+// backtraceSymbolMaxWidth 对齐文件位置列时符号列的最大宽度，更长的符号不参与对齐
+const backtraceSymbolMaxWidth = 60
+
+// backtraceSymbol 将 atos 结果转换为 Apple 崩溃报告中的符号列：去掉 (in 模块) 和 (文件:行号)，保留 + 偏移
+// "-[Foo bar] (in Demo) (Foo.mm:42)" → "-[Foo bar]"，"objc_msgSend (in libobjc.A.dylib) + 32" → "objc_msgSend + 32"
+func backtraceSymbol(symbolicatedName string) string {
+	symbol := symbolFileRegex.ReplaceAllString(symbolicatedName, "")
+	if idx := strings.Index(symbol, " (in "); idx != -1 {
+		if end := strings.Index(symbol[idx:], ")"); end != -1 {
+			symbol = symbol[:idx] + symbol[idx+end+1:]
+		}
+	}
+	return strings.Join(strings.Fields(symbol), " ")
+}
+
+// frameSourceLocation 返回帧的 文件:行号，优先使用符号化时解析出的 file_name / line_number
+func frameSourceLocation(frame map[string]interface{}) string {
+	fileName := getString(frame, "file_name")
+	lineNum := getString(frame, "line_number")
+	if n, ok := frame["line_number"].(float64); ok {
+		lineNum = strconv.Itoa(int(n))
+	}
+
+	if fileName == "" {
+		matches := symbolFileRegex.FindStringSubmatch(getString(frame, "symbolicated_name"))
+		if matches == nil {
+			return ""
+		}
+		fileName, lineNum = matches[1], matches[2]
+	}
+
+	fileName = filepath.Base(fileName)
+	if lineNum == "" || lineNum == "0" {
+		return fileName
+	}
+	return fileName + ":" + lineNum
+}
+
+// maxFramesKey 每个线程最多输出的帧数，只存在于 maxFramesReport 返回的副本中
+const maxFramesKey = "_max_frames"
+
+// maxFramesReport 返回带帧数上限的副本，格式化时每个线程只输出前 maxFrames 帧
+func maxFramesReport(report map[string]interface{}, maxFrames int) map[string]interface{} {
+	result := make(map[string]interface{}, len(report)+1)
+	for k, v := range report {
+		result[k] = v
+	}
+	result[maxFramesKey] = float64(maxFrames)
+	return result
+}
+
+// verboseFramesKey 详细模式标记，只存在于 verboseReport 返回的副本中
+const verboseFramesKey = "_verbose_frames"
+
+// verboseReport 返回带详细模式标记的副本，格式化时每一帧附加镜像基址、slide 和文件内偏移
+func verboseReport(report map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(report)+1)
+	for k, v := range report {
+		result[k] = v
+	}
+	result[verboseFramesKey] = true
+	return result
+}
+
+// formatFrameAddressing 输出符号化时记录的地址计算，未符号化的帧返回空字符串
+func formatFrameAddressing(frame map[string]interface{}) string {
+	if _, ok := frame["image_base"]; !ok {
+		return ""
+	}
+	line := fmt.Sprintf("    image_base: 0x%x  file_offset: 0x%x", getInt64(frame, "image_base"), getInt64(frame, "file_offset"))
+	if _, ok := frame["slide"]; ok {
+		line += fmt.Sprintf("  slide: %#x", getInt64(frame, "slide"))
+	}
+	return line + "\n"
+}
+
 func formatCPUState(report map[string]interface{}) string {
 	crash, ok := report["crash"].(map[string]interface{})
 	if !ok {
@@ -405,26 +961,84 @@ func formatCPUState(report map[string]interface{}) string {
 		return result.String()
 	}
 
-	// 根据架构确定寄存器顺序
+	// 根据架构确定寄存器顺序和数值宽度
 	regOrder := getRegisterOrder(cpuArch)
+	result.WriteString(formatRegisterRows(basic, regOrder, registerHexWidth(cpuArch)))
 
-	line := ""
-	for i, reg := range regOrder {
-		if i != 0 && i%4 == 0 {
-			result.WriteString(line + "\n")
-			line = ""
-		}
-		if val, ok := basic[reg].(float64); ok {
-			line += fmt.Sprintf("%6s: 0x%016x ", reg, int64(val))
+	// 异常寄存器：far/esr（arm64）、trapno/err（x86）等
+	if exception, ok := registers["exception"].(map[string]interface{}); ok && len(exception) > 0 {
+		result.WriteString("\nException State:\n")
+		result.WriteString(formatRegisterRows(exception, exceptionRegisterOrder(exception), registerHexWidth(cpuArch)))
+	}
+
+	return result.String()
+}
+
+// registersPerRow 寄存器转储每行的寄存器数量
+const registersPerRow = 4
+
+// formatRegisterRows 按 order 输出 values 中存在的寄存器，每行 registersPerRow 个
+// 缺失的寄存器直接跳过，后续寄存器顺延补位；名称按最长者右对齐（至少 6 个字符），每列宽度固定，不会错位
+func formatRegisterRows(values map[string]interface{}, order []string, hexWidth int) string {
+	var present []string
+	nameWidth := 6
+	for _, reg := range order {
+		if _, ok := values[reg].(float64); ok {
+			present = append(present, reg)
+			if len(reg) > nameWidth {
+				nameWidth = len(reg)
+			}
 		}
 	}
-	if line != "" {
-		result.WriteString(line + "\n")
+
+	var cells []string
+	for _, reg := range present {
+		val := values[reg].(float64)
+		cells = append(cells, fmt.Sprintf("%*s: 0x%0*x", nameWidth, reg, hexWidth, uint64(int64(val))))
 	}
 
+	var result strings.Builder
+	for i := 0; i < len(cells); i += registersPerRow {
+		end := i + registersPerRow
+		if end > len(cells) {
+			end = len(cells)
+		}
+		result.WriteString(strings.Join(cells[i:end], " ") + "\n")
+	}
 	return result.String()
 }
 
+// exceptionRegisterOrder 异常寄存器的输出顺序：已知寄存器在前，其余按名称排序
+func exceptionRegisterOrder(exception map[string]interface{}) []string {
+	known := []string{"far", "esr", "exception", "trapno", "err", "faultvaddr"}
+	order := []string{}
+	seen := make(map[string]bool)
+	for _, reg := range known {
+		if _, ok := exception[reg]; ok {
+			order = append(order, reg)
+			seen[reg] = true
+		}
+	}
+
+	var rest []string
+	for reg := range exception {
+		if !seen[reg] {
+			rest = append(rest, reg)
+		}
+	}
+	sort.Strings(rest)
+	return append(order, rest...)
+}
+
+// registerHexWidth 寄存器数值的十六进制位数：32 位 x86 为 8 位，其余为 16 位
+func registerHexWidth(cpuArch string) int {
+	switch strings.ToLower(cpuArch) {
+	case "x86", "i386":
+		return 8
+	}
+	return 16
+}
+
 func formatBinaryImages(report map[string]interface{}) string {
 	images, ok := report["binary_images"].([]interface{})
 	if !ok {
@@ -464,9 +1078,9 @@ func formatBinaryImages(report map[string]interface{}) string {
 			addr:  addr,
 			size:  size,
 			name:  name,
-			uuid:  strings.ReplaceAll(strings.ToLower(uuid), "-", ""),
+			uuid:  strings.ToLower(strings.ReplaceAll(normalizeUUID(uuid), "-", "")), // Apple 格式：小写、不带横线，仅用于展示
 			path:  path,
-			isApp: path == exePath,
+			isApp: isAppImagePath(path, exePath),
 		})
 	}
 
@@ -488,6 +1102,30 @@ func formatBinaryImages(report map[string]interface{}) string {
 
 // 辅助函数
 
+// isAppImagePath 判断镜像路径是否为应用主二进制
+// CFBundleExecutablePath 经常是设备相对路径（如缺少 /private 前缀），不能直接比较
+func isAppImagePath(imagePath, exePath string) bool {
+	if imagePath == "" || exePath == "" {
+		return false
+	}
+	if imagePath == exePath {
+		return true
+	}
+	if hasPathSuffix(imagePath, exePath) || hasPathSuffix(exePath, imagePath) {
+		return true
+	}
+	return filepath.Base(imagePath) == filepath.Base(exePath)
+}
+
+// hasPathSuffix 判断 path 是否以 suffix 结尾，且 suffix 从路径分隔处开始（/a/MyApp 不匹配 /a/NotMyApp）
+func hasPathSuffix(path, suffix string) bool {
+	if !strings.HasSuffix(path, suffix) {
+		return false
+	}
+	rest := path[:len(path)-len(suffix)]
+	return rest == "" || strings.HasSuffix(rest, "/") || strings.HasPrefix(suffix, "/")
+}
+
 func getString(m map[string]interface{}, key string) string {
 	if val, ok := m[key].(string); ok {
 		return val
@@ -508,6 +1146,20 @@ func getInt64(m map[string]interface{}, key string) int64 {
 	return 0
 }
 
+// getAddress 读取地址字段并转换为 uint64
+// JSON 数字解析为 float64，直接转换为 uint64，避免 int64 在高位地址上溢出或以 %v 打印成科学计数法
+func getAddress(m map[string]interface{}, key string) uint64 {
+	switch val := m[key].(type) {
+	case float64:
+		return uint64(val)
+	case int64:
+		return uint64(val)
+	case int:
+		return uint64(val)
+	}
+	return 0
+}
+
 func getBool(m map[string]interface{}, key string) bool {
 	if val, ok := m[key].(bool); ok {
 		return val
@@ -515,6 +1167,8 @@ func getBool(m map[string]interface{}, key string) bool {
 	return false
 }
 
+// getCrashedThreadIndex 返回崩溃线程的索引
+// 卡顿类报告通常没有 crashed 标记，此时回退到主线程：index 0，或名称/队列包含 main 的线程
 func getCrashedThreadIndex(report map[string]interface{}) int64 {
 	crash, ok := report["crash"].(map[string]interface{})
 	if !ok {
@@ -536,9 +1190,133 @@ func getCrashedThreadIndex(report map[string]interface{}) int64 {
 		}
 	}
 
+	return mainThreadIndex(threads)
+}
+
+// mainThreadIndex 返回主线程索引：优先 index 0，其次名称或队列包含 main 的线程
+func mainThreadIndex(threads []interface{}) int64 {
+	named := int64(-1)
+	for _, threadData := range threads {
+		thread, ok := threadData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if getInt64(thread, "index") == 0 {
+			return 0
+		}
+		name := strings.ToLower(getString(thread, "name") + " " + getString(thread, "dispatch_queue"))
+		if named == -1 && strings.Contains(name, "main") {
+			named = getInt64(thread, "index")
+		}
+	}
+	if named != -1 {
+		return named
+	}
 	return 0
 }
 
+// hasCrashedThread 报告中是否有线程带 crashed 标记
+func hasCrashedThread(report map[string]interface{}) bool {
+	crash, _ := report["crash"].(map[string]interface{})
+	threads, _ := crash["threads"].([]interface{})
+	for _, threadData := range threads {
+		if thread, ok := threadData.(map[string]interface{}); ok && getBool(thread, "crashed") {
+			return true
+		}
+	}
+	return false
+}
+
+// hangDumpTypes 卡顿类 dump 类型：没有崩溃线程，被阻塞的线程标记为 Hung
+var hangDumpTypes = map[DumpType]bool{
+	DumpTypeMainThreadLag:           true,
+	DumpTypeBackgroundMainThreadLag: true,
+	DumpTypeLaunchBlock:             true,
+	DumpTypeBlockAndBeKilled:        true,
+}
+
+// reportDumpType 返回报告的 dump_type，顶层没有时读取 user[app].DumpType
+func reportDumpType(report map[string]interface{}) (int, bool) {
+	if dt, ok := report["dump_type"].(float64); ok {
+		return int(dt), true
+	}
+	user, _ := report["user"].(map[string]interface{})
+	for _, appData := range user {
+		appInfo, ok := appData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch dt := appInfo["DumpType"].(type) {
+		case float64:
+			return int(dt), true
+		case string:
+			if n, err := strconv.Atoi(dt); err == nil {
+				return n, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// hungDumpType 卡顿报告（没有 crashed 线程且 dump_type 属于卡顿类）返回 dump_type
+func hungDumpType(report map[string]interface{}) (int, bool) {
+	dumpType, ok := reportDumpType(report)
+	if !ok || !hangDumpTypes[DumpType(dumpType)] || hasCrashedThread(report) {
+		return 0, false
+	}
+	return dumpType, true
+}
+
+// findCrashedThread 返回崩溃线程；卡顿类报告没有 crashed 标记时回退到被阻塞的线程
+// （getCrashedThreadIndex 给出的索引，即主线程）
+func findCrashedThread(report map[string]interface{}) map[string]interface{} {
+	crash, ok := report["crash"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	threads, ok := crash["threads"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	crashedIdx := getCrashedThreadIndex(report)
+	var first map[string]interface{}
+	for _, threadData := range threads {
+		thread, ok := threadData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if first == nil {
+			first = thread
+		}
+		if getInt64(thread, "index") == crashedIdx {
+			return thread
+		}
+	}
+
+	return first
+}
+
+// findImageForFrame 查找帧所属的镜像
+// binary_images 缺失或不包含该地址时，用帧自带的 object_addr / object_name 作为镜像信息
+func findImageForFrame(report map[string]interface{}, frame map[string]interface{}) map[string]interface{} {
+	pc := getInt64(frame, "instruction_addr")
+	if img := findImageForAddress(report, pc); img != nil {
+		return img
+	}
+
+	objAddr := getInt64(frame, "object_addr")
+	if objAddr <= 0 || pc < objAddr {
+		return nil
+	}
+	return map[string]interface{}{
+		"name":       getString(frame, "object_name"),
+		"image_addr": float64(objAddr),
+	}
+}
+
+// findImageForAddress 在 binary_images 中查找包含地址的镜像
 func findImageForAddress(report map[string]interface{}, addr int64) map[string]interface{} {
 	images, ok := report["binary_images"].([]interface{})
 	if !ok {
@@ -565,6 +1343,7 @@ func findImageForAddress(report map[string]interface{}, addr int64) map[string]i
 func getRegisterOrder(cpuArch string) []string {
 	cpuArch = strings.ToLower(cpuArch)
 
+	// arm64_32（watchOS）与 arm64 使用相同的 64 位寄存器组
 	if strings.HasPrefix(cpuArch, "arm64") || cpuArch == "arm64" {
 		regs := []string{}
 		for i := 0; i < 30; i++ {
@@ -591,48 +1370,374 @@ func getRegisterOrder(cpuArch string) []string {
 	return regs
 }
 
+// deviceNames 内置的设备标识符 -> 型号名称，devices.json 可以覆盖和补充
+var deviceNames = map[string]string{
+	// iPhone
+	"iPhone9,2":  "iPhone 7 Plus",
+	"iPhone9,4":  "iPhone 7 Plus",
+	"iPhone10,1": "iPhone 8",
+	"iPhone10,4": "iPhone 8",
+	"iPhone10,2": "iPhone 8 Plus",
+	"iPhone10,5": "iPhone 8 Plus",
+	"iPhone10,3": "iPhone X",
+	"iPhone10,6": "iPhone X",
+	"iPhone11,2": "iPhone XS",
+	"iPhone11,4": "iPhone XS Max",
+	"iPhone11,6": "iPhone XS Max",
+	"iPhone11,8": "iPhone XR",
+	"iPhone12,1": "iPhone 11",
+	"iPhone12,3": "iPhone 11 Pro",
+	"iPhone12,5": "iPhone 11 Pro Max",
+	"iPhone13,1": "iPhone 12 mini",
+	"iPhone13,2": "iPhone 12",
+	"iPhone13,3": "iPhone 12 Pro",
+	"iPhone13,4": "iPhone 12 Pro Max",
+	"iPhone14,2": "iPhone 13 Pro",
+	"iPhone14,3": "iPhone 13 Pro Max",
+	"iPhone14,4": "iPhone 13 mini",
+	"iPhone14,5": "iPhone 13",
+	"iPhone14,6": "iPhone SE (3rd generation)",
+	"iPhone14,7": "iPhone 14",
+	"iPhone14,8": "iPhone 14 Plus",
+	"iPhone15,2": "iPhone 14 Pro",
+	"iPhone15,3": "iPhone 14 Pro Max",
+	"iPhone15,4": "iPhone 15",
+	"iPhone15,5": "iPhone 15 Plus",
+	"iPhone16,1": "iPhone 15 Pro",
+	"iPhone16,2": "iPhone 15 Pro Max",
+	"iPhone17,1": "iPhone 16 Pro",
+	"iPhone17,2": "iPhone 16 Pro Max",
+	"iPhone17,3": "iPhone 16",
+	"iPhone17,4": "iPhone 16 Plus",
+	"iPhone17,5": "iPhone 16e",
+	"iPhone18,1": "iPhone 17 Pro",
+	"iPhone18,2": "iPhone 17 Pro Max",
+	"iPhone18,3": "iPhone 17",
+	"iPhone18,4": "iPhone Air",
+
+	// iPad
+	"iPad7,5":   "iPad (6th generation)",
+	"iPad7,6":   "iPad (6th generation)",
+	"iPad7,11":  "iPad (7th generation)",
+	"iPad7,12":  "iPad (7th generation)",
+	"iPad11,6":  "iPad (8th generation)",
+	"iPad11,7":  "iPad (8th generation)",
+	"iPad12,1":  "iPad (9th generation)",
+	"iPad12,2":  "iPad (9th generation)",
+	"iPad13,18": "iPad (10th generation)",
+	"iPad13,19": "iPad (10th generation)",
+	"iPad15,7":  "iPad (A16)",
+	"iPad15,8":  "iPad (A16)",
+
+	// iPad Air
+	"iPad11,3":  "iPad Air (3rd generation)",
+	"iPad11,4":  "iPad Air (3rd generation)",
+	"iPad13,1":  "iPad Air (4th generation)",
+	"iPad13,2":  "iPad Air (4th generation)",
+	"iPad13,16": "iPad Air (5th generation)",
+	"iPad13,17": "iPad Air (5th generation)",
+	"iPad14,8":  "iPad Air 11-inch (M2)",
+	"iPad14,9":  "iPad Air 11-inch (M2)",
+	"iPad14,10": "iPad Air 13-inch (M2)",
+	"iPad14,11": "iPad Air 13-inch (M2)",
+	"iPad15,3":  "iPad Air 11-inch (M3)",
+	"iPad15,4":  "iPad Air 11-inch (M3)",
+	"iPad15,5":  "iPad Air 13-inch (M3)",
+	"iPad15,6":  "iPad Air 13-inch (M3)",
+
+	// iPad mini
+	"iPad11,1": "iPad mini (5th generation)",
+	"iPad11,2": "iPad mini (5th generation)",
+	"iPad14,1": "iPad mini (6th generation)",
+	"iPad14,2": "iPad mini (6th generation)",
+	"iPad16,1": "iPad mini (A17 Pro)",
+	"iPad16,2": "iPad mini (A17 Pro)",
+
+	// iPad Pro
+	"iPad6,3":   "iPad Pro (9.7-inch)",
+	"iPad6,4":   "iPad Pro (9.7-inch)",
+	"iPad6,7":   "iPad Pro (12.9-inch)",
+	"iPad6,8":   "iPad Pro (12.9-inch)",
+	"iPad7,1":   "iPad Pro (12.9-inch) (2nd generation)",
+	"iPad7,2":   "iPad Pro (12.9-inch) (2nd generation)",
+	"iPad7,3":   "iPad Pro (10.5-inch)",
+	"iPad7,4":   "iPad Pro (10.5-inch)",
+	"iPad8,1":   "iPad Pro (11-inch)",
+	"iPad8,2":   "iPad Pro (11-inch)",
+	"iPad8,3":   "iPad Pro (11-inch)",
+	"iPad8,4":   "iPad Pro (11-inch)",
+	"iPad8,5":   "iPad Pro (12.9-inch) (3rd generation)",
+	"iPad8,6":   "iPad Pro (12.9-inch) (3rd generation)",
+	"iPad8,7":   "iPad Pro (12.9-inch) (3rd generation)",
+	"iPad8,8":   "iPad Pro (12.9-inch) (3rd generation)",
+	"iPad8,9":   "iPad Pro (11-inch) (2nd generation)",
+	"iPad8,10":  "iPad Pro (11-inch) (2nd generation)",
+	"iPad8,11":  "iPad Pro (12.9-inch) (4th generation)",
+	"iPad8,12":  "iPad Pro (12.9-inch) (4th generation)",
+	"iPad13,4":  "iPad Pro (11-inch) (3rd generation)",
+	"iPad13,5":  "iPad Pro (11-inch) (3rd generation)",
+	"iPad13,6":  "iPad Pro (11-inch) (3rd generation)",
+	"iPad13,7":  "iPad Pro (11-inch) (3rd generation)",
+	"iPad13,8":  "iPad Pro (12.9-inch) (5th generation)",
+	"iPad13,9":  "iPad Pro (12.9-inch) (5th generation)",
+	"iPad13,10": "iPad Pro (12.9-inch) (5th generation)",
+	"iPad13,11": "iPad Pro (12.9-inch) (5th generation)",
+	"iPad14,3":  "iPad Pro (11-inch) (4th generation)",
+	"iPad14,4":  "iPad Pro (11-inch) (4th generation)",
+	"iPad14,5":  "iPad Pro (12.9-inch) (6th generation)",
+	"iPad14,6":  "iPad Pro (12.9-inch) (6th generation)",
+	"iPad16,3":  "iPad Pro 11-inch (M4)",
+	"iPad16,4":  "iPad Pro 11-inch (M4)",
+	"iPad16,5":  "iPad Pro 13-inch (M4)",
+	"iPad16,6":  "iPad Pro 13-inch (M4)",
+
+	// iPod touch
+	"iPod7,1": "iPod touch (6th generation)",
+	"iPod9,1": "iPod touch (7th generation)",
+
+	// Apple Watch
+	"Watch5,1":  "Apple Watch Series 5 (40mm)",
+	"Watch5,2":  "Apple Watch Series 5 (44mm)",
+	"Watch5,3":  "Apple Watch Series 5 (40mm)",
+	"Watch5,4":  "Apple Watch Series 5 (44mm)",
+	"Watch5,9":  "Apple Watch SE (40mm)",
+	"Watch5,10": "Apple Watch SE (44mm)",
+	"Watch5,11": "Apple Watch SE (40mm)",
+	"Watch5,12": "Apple Watch SE (44mm)",
+	"Watch6,1":  "Apple Watch Series 6 (40mm)",
+	"Watch6,2":  "Apple Watch Series 6 (44mm)",
+	"Watch6,3":  "Apple Watch Series 6 (40mm)",
+	"Watch6,4":  "Apple Watch Series 6 (44mm)",
+	"Watch6,6":  "Apple Watch Series 7 (41mm)",
+	"Watch6,7":  "Apple Watch Series 7 (45mm)",
+	"Watch6,8":  "Apple Watch Series 7 (41mm)",
+	"Watch6,9":  "Apple Watch Series 7 (45mm)",
+	"Watch6,10": "Apple Watch SE (2nd generation) (40mm)",
+	"Watch6,11": "Apple Watch SE (2nd generation) (44mm)",
+	"Watch6,12": "Apple Watch SE (2nd generation) (40mm)",
+	"Watch6,13": "Apple Watch SE (2nd generation) (44mm)",
+	"Watch6,14": "Apple Watch Series 8 (41mm)",
+	"Watch6,15": "Apple Watch Series 8 (45mm)",
+	"Watch6,16": "Apple Watch Series 8 (41mm)",
+	"Watch6,17": "Apple Watch Series 8 (45mm)",
+	"Watch6,18": "Apple Watch Ultra",
+	"Watch7,1":  "Apple Watch Series 9 (41mm)",
+	"Watch7,2":  "Apple Watch Series 9 (45mm)",
+	"Watch7,3":  "Apple Watch Series 9 (41mm)",
+	"Watch7,4":  "Apple Watch Series 9 (45mm)",
+	"Watch7,5":  "Apple Watch Ultra 2",
+	"Watch7,8":  "Apple Watch Series 10 (42mm)",
+	"Watch7,9":  "Apple Watch Series 10 (46mm)",
+	"Watch7,10": "Apple Watch Series 10 (42mm)",
+	"Watch7,11": "Apple Watch Series 10 (46mm)",
+
+	// Apple TV
+	"AppleTV5,3":  "Apple TV HD",
+	"AppleTV6,2":  "Apple TV 4K",
+	"AppleTV11,1": "Apple TV 4K (2nd generation)",
+	"AppleTV14,1": "Apple TV 4K (3rd generation)",
+
+	// Mac（macOS 报告的 model 字段）
+	"MacBookAir9,1":  "MacBook Air (Retina, 13-inch, 2020)",
+	"MacBookAir10,1": "MacBook Air (M1, 2020)",
+	"MacBookPro16,1": "MacBook Pro (16-inch, 2019)",
+	"MacBookPro16,2": "MacBook Pro (13-inch, 2020)",
+	"MacBookPro17,1": "MacBook Pro (13-inch, M1, 2020)",
+	"MacBookPro18,1": "MacBook Pro (16-inch, 2021)",
+	"MacBookPro18,2": "MacBook Pro (16-inch, 2021)",
+	"MacBookPro18,3": "MacBook Pro (14-inch, 2021)",
+	"MacBookPro18,4": "MacBook Pro (14-inch, 2021)",
+	"Macmini8,1":     "Mac mini (2018)",
+	"Macmini9,1":     "Mac mini (M1, 2020)",
+	"iMac20,1":       "iMac (Retina 5K, 27-inch, 2020)",
+	"iMac21,1":       "iMac (24-inch, M1, 2021)",
+	"iMac21,2":       "iMac (24-inch, M1, 2021)",
+	"MacPro7,1":      "Mac Pro (2019)",
+	"Mac13,1":        "Mac Studio (2022)",
+	"Mac13,2":        "Mac Studio (2022)",
+	"Mac14,2":        "MacBook Air (M2, 2022)",
+	"Mac14,3":        "Mac mini (2023)",
+	"Mac14,5":        "MacBook Pro (14-inch, 2023)",
+	"Mac14,6":        "MacBook Pro (16-inch, 2023)",
+	"Mac14,7":        "MacBook Pro (13-inch, M2, 2022)",
+	"Mac14,8":        "Mac Pro (2023)",
+	"Mac14,9":        "MacBook Pro (14-inch, 2023)",
+	"Mac14,10":       "MacBook Pro (16-inch, 2023)",
+	"Mac14,12":       "Mac mini (2023)",
+	"Mac14,13":       "Mac Studio (2023)",
+	"Mac14,14":       "Mac Studio (2023)",
+	"Mac14,15":       "MacBook Air (15-inch, M2, 2023)",
+	"Mac15,3":        "MacBook Pro (14-inch, M3, Nov 2023)",
+	"Mac15,4":        "iMac (24-inch, 2023)",
+	"Mac15,5":        "iMac (24-inch, 2023)",
+	"Mac15,12":       "MacBook Air (13-inch, M3, 2024)",
+	"Mac15,13":       "MacBook Air (15-inch, M3, 2024)",
+}
+
+// deviceFamilies 未收录的标识符按前缀回退到设备家族名
+var deviceFamilies = []struct {
+	prefix string
+	name   string
+}{
+	{"iPhone", "iPhone"},
+	{"iPad", "iPad"},
+	{"iPod", "iPod touch"},
+	{"Watch", "Apple Watch"},
+	{"AppleTV", "Apple TV"},
+	{"MacBookPro", "MacBook Pro"},
+	{"MacBookAir", "MacBook Air"},
+	{"Macmini", "Mac mini"},
+	{"MacPro", "Mac Pro"},
+	{"iMacPro", "iMac Pro"},
+	{"iMac", "iMac"},
+	{"Mac", "Mac"},
+}
+
+// getDeviceName 返回 "型号名称 (标识符)"，优先使用 devices.json 中的映射
 func getDeviceName(machine string) string {
-	deviceMap := map[string]string{
-		"iPhone9,2":  "iPhone 7 Plus",
-		"iPhone9,4":  "iPhone 7 Plus",
-		"iPhone10,1": "iPhone 8",
-		"iPhone10,4": "iPhone 8",
-		"iPhone10,2": "iPhone 8 Plus",
-		"iPhone10,5": "iPhone 8 Plus",
-		"iPhone10,3": "iPhone X",
-		"iPhone10,6": "iPhone X",
-		"iPhone11,2": "iPhone XS",
-		"iPhone11,4": "iPhone XS Max",
-		"iPhone11,6": "iPhone XS Max",
-		"iPhone11,8": "iPhone XR",
-		"iPhone12,1": "iPhone 11",
-		"iPhone12,3": "iPhone 11 Pro",
-		"iPhone12,5": "iPhone 11 Pro Max",
-		"iPhone13,1": "iPhone 12 mini",
-		"iPhone13,2": "iPhone 12",
-		"iPhone13,3": "iPhone 12 Pro",
-		"iPhone13,4": "iPhone 12 Pro Max",
-		"iPhone14,2": "iPhone 13 Pro",
-		"iPhone14,3": "iPhone 13 Pro Max",
-		"iPhone14,4": "iPhone 13 mini",
-		"iPhone14,5": "iPhone 13",
-		"iPhone14,6": "iPhone SE (3rd generation)",
-		"iPhone14,7": "iPhone 14",
-		"iPhone14,8": "iPhone 14 Plus",
-		"iPhone15,2": "iPhone 14 Pro",
-		"iPhone15,3": "iPhone 14 Pro Max",
-		"iPhone15,4": "iPhone 15",
-		"iPhone15,5": "iPhone 15 Plus",
-		"iPhone16,1": "iPhone 15 Pro",
-		"iPhone16,2": "iPhone 15 Pro Max",
-	}
-
-	if name, ok := deviceMap[machine]; ok {
+	if name, ok := lookupDeviceName(machine); ok {
 		return fmt.Sprintf("%s (%s)", name, machine)
 	}
+
+	// 新机型：至少显示设备家族
+	for _, family := range deviceFamilies {
+		if strings.HasPrefix(machine, family.prefix) {
+			return fmt.Sprintf("%s (%s)", family.name, machine)
+		}
+	}
+	return machine
+}
+
+// reportDevice 返回报告的设备标识符
+// macOS 上 machine 是架构名（arm64 / x86_64），型号标识符在 model 字段中
+func reportDevice(system map[string]interface{}) string {
+	machine := getString(system, "machine")
+	if model := getString(system, "model"); model != "" && (machine == "" || isArchName(machine)) {
+		return model
+	}
 	return machine
 }
 
+// isArchName 判断 machine 是否只是架构名而不是设备型号
+func isArchName(machine string) bool {
+	switch strings.ToLower(machine) {
+	case "arm64", "arm64e", "x86_64", "x86_64h", "i386":
+		return true
+	}
+	return false
+}
+
+// reportPlatform 返回报告的平台名：iOS / iPadOS / watchOS / tvOS / macOS
+// system_name 有多种写法（iPhone OS、Mac OS X 等），无法识别时根据设备标识符推断，仍无法确定时原样返回
+func reportPlatform(system map[string]interface{}) string {
+	systemName := getString(system, "system_name")
+	switch name := strings.ToLower(systemName); {
+	case strings.Contains(name, "watch"):
+		return "watchOS"
+	case strings.Contains(name, "tv"):
+		return "tvOS"
+	case strings.Contains(name, "mac") || strings.Contains(name, "os x"):
+		return "macOS"
+	case strings.Contains(name, "ipados"):
+		return "iPadOS"
+	case name == "ios" || strings.Contains(name, "iphone"):
+		return "iOS"
+	}
+	if systemName != "" {
+		return systemName
+	}
+
+	device := reportDevice(system)
+	switch {
+	case strings.HasPrefix(device, "Watch"):
+		return "watchOS"
+	case strings.HasPrefix(device, "AppleTV"):
+		return "tvOS"
+	case strings.HasPrefix(device, "Mac") || strings.HasPrefix(device, "iMac"):
+		return "macOS"
+	case strings.HasPrefix(device, "iPhone") || strings.HasPrefix(device, "iPad") || strings.HasPrefix(device, "iPod"):
+		return "iOS"
+	}
+	return ""
+}
+
+// formatOneline 生成一行摘要，便于粘贴到聊天工具：
+// Crash in -[Foo bar] (Foo.mm:42) on iPhone 14 Pro, iOS 17.1
+// 取崩溃线程最顶层的应用帧，没有应用帧时取栈顶帧；缺失的部分直接省略
+func formatOneline(report map[string]interface{}) string {
+	var result strings.Builder
+	result.WriteString("Crash in ")
+	result.WriteString(onelineFrame(report))
+
+	var where []string
+	if system, ok := report["system"].(map[string]interface{}); ok {
+		if device := reportDevice(system); device != "" {
+			where = append(where, strings.TrimSuffix(getDeviceName(device), " ("+device+")"))
+		}
+		if osName := strings.TrimSpace(reportPlatform(system) + " " + getString(system, "system_version")); osName != "" {
+			where = append(where, osName)
+		}
+	}
+	if len(where) > 0 {
+		result.WriteString(" on ")
+		result.WriteString(strings.Join(where, ", "))
+	}
+
+	return result.String()
+}
+
+// onelineFrame 描述崩溃线程的关键帧：函数名 + (文件:行号)，没有符号时使用 地址 in 镜像
+func onelineFrame(report map[string]interface{}) string {
+	thread := findCrashedThread(report)
+	if thread == nil {
+		return "unknown location"
+	}
+	backtrace, _ := thread["backtrace"].(map[string]interface{})
+	contents, _ := backtrace["contents"].([]interface{})
+
+	appName := appImageName(report)
+	var frame map[string]interface{}
+	for _, frameData := range contents {
+		f, ok := frameData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if frame == nil {
+			frame = f // 栈顶帧作为兜底
+		}
+		if appName != "" && filepath.Base(getString(f, "object_name")) == appName {
+			frame = f
+			break
+		}
+	}
+	if frame == nil {
+		return "unknown location"
+	}
+
+	symbol := getString(frame, "symbolicated_name")
+	if symbol == "" {
+		symbol = getString(frame, "symbol_name")
+	}
+	// atos 结果形如 "-[Foo bar] (in Demo) (Foo.mm:42)"，只保留函数名
+	if idx := strings.Index(symbol, " (in "); idx != -1 {
+		symbol = symbol[:idx]
+	}
+	if symbol == "" || symbol == "<redacted>" {
+		symbol = fmt.Sprintf("0x%x", uint64(getInt64(frame, "instruction_addr")))
+		if objName := getString(frame, "object_name"); objName != "" {
+			symbol += " in " + filepath.Base(objName)
+		}
+	}
+
+	if fileName := getString(frame, "file_name"); fileName != "" {
+		if lineNum := getString(frame, "line_number"); lineNum != "" {
+			return fmt.Sprintf("%s (%s:%s)", symbol, fileName, lineNum)
+		}
+		return fmt.Sprintf("%s (%s)", symbol, fileName)
+	}
+	return symbol
+}
+
 // formatPowerConsumeReport 格式化耗电监控报告
 func formatPowerConsumeReport(report map[string]interface{}) string {
 	var result strings.Builder
@@ -0,0 +1,925 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveReportStyle(t *testing.T) {
+	tests := []struct {
+		name   string
+		report map[string]interface{}
+		want   ReportStyle
+	}{
+		{
+			name:   "主线程卡顿",
+			report: map[string]interface{}{"dump_type": float64(2001), "crash": map[string]interface{}{}},
+			want:   ReportStyleLag,
+		},
+		{
+			name:   "CPU 过高",
+			report: map[string]interface{}{"dump_type": float64(2003), "crash": map[string]interface{}{}},
+			want:   ReportStyleCPU,
+		},
+		{
+			name:   "耗电",
+			report: map[string]interface{}{"dump_type": float64(2011), "stack_string": []interface{}{}},
+			want:   ReportStylePower,
+		},
+		{
+			name: "OOM 按结构识别",
+			report: map[string]interface{}{
+				"head":  map[string]interface{}{},
+				"items": []interface{}{},
+			},
+			want: ReportStyleMemory,
+		},
+		{
+			name:   "未注册类型带 stack_string",
+			report: map[string]interface{}{"dump_type": float64(2099), "stack_string": []interface{}{}},
+			want:   ReportStylePower,
+		},
+		{
+			name:   "未注册类型兜底",
+			report: map[string]interface{}{"dump_type": float64(2099), "crash": map[string]interface{}{}},
+			want:   ReportStyleCrash,
+		},
+		{
+			name:   "没有 dump_type 的崩溃",
+			report: map[string]interface{}{"crash": map[string]interface{}{}},
+			want:   ReportStyleCrash,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveReportStyle(tt.report); got != tt.want {
+				t.Errorf("resolveReportStyle() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterDumpTypeStyle(t *testing.T) {
+	const newType = 2099
+	defer delete(dumpTypeStyles, newType)
+
+	report := map[string]interface{}{
+		"dump_type": float64(newType),
+		"crash":     map[string]interface{}{"threads": []interface{}{}},
+	}
+
+	registerDumpTypeStyle(newType, ReportStyleCPU)
+	if got := resolveReportStyle(report); got != ReportStyleCPU {
+		t.Fatalf("注册后 resolveReportStyle() = %v, want %v", got, ReportStyleCPU)
+	}
+
+	formatted := formatReportToAppleStyle(report)
+	if !strings.Contains(formatted, "Dump Type:       类型 2099 (2099)") {
+		t.Errorf("格式化结果缺少 Dump Type 行:\n%s", formatted)
+	}
+}
+
+func TestFormatBacktraceImageMissingFromBinaryImages(t *testing.T) {
+	// UIKitCore 不在 binary_images 中，只能依赖 frame 自带的 object_addr / symbol_addr
+	report := map[string]interface{}{
+		"binary_images": []interface{}{
+			map[string]interface{}{
+				"name":       "/var/containers/Bundle/Application/XXX/Demo.app/Demo",
+				"image_addr": float64(0x100000000),
+				"image_size": float64(0x10000),
+			},
+		},
+	}
+	backtrace := map[string]interface{}{
+		"contents": []interface{}{
+			map[string]interface{}{
+				"object_name":      "/System/Library/PrivateFrameworks/UIKitCore.framework/UIKitCore",
+				"object_addr":      float64(0x180000000),
+				"instruction_addr": float64(0x180000100),
+			},
+			map[string]interface{}{
+				"object_name":      "UIKitCore",
+				"object_addr":      float64(0x180000000),
+				"instruction_addr": float64(0x180000240),
+				"symbol_name":      "-[UIApplication _run]",
+				"symbol_addr":      float64(0x180000200),
+			},
+		},
+	}
+
+	got := formatBacktrace(backtrace, report)
+	lines := strings.Split(strings.TrimRight(got, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("期望 2 行，实际 %d 行:\n%s", len(lines), got)
+	}
+
+	want0 := "0   UIKitCore                       0x0000000180000100 0x180000000 + 256"
+	if lines[0] != want0 {
+		t.Errorf("第 0 帧:\n got: %q\nwant: %q", lines[0], want0)
+	}
+	want1 := "1   UIKitCore                       0x0000000180000240 -[UIApplication _run] + 64"
+	if lines[1] != want1 {
+		t.Errorf("第 1 帧:\n got: %q\nwant: %q", lines[1], want1)
+	}
+}
+
+func TestFormatBinaryImagesMarksAppImage(t *testing.T) {
+	report := map[string]interface{}{
+		"system": map[string]interface{}{
+			// 设备相对路径，与镜像 name 不完全相同
+			"CFBundleExecutablePath": "/var/containers/Bundle/Application/ABC/Demo.app/Demo",
+		},
+		"binary_images": []interface{}{
+			map[string]interface{}{
+				"name":       "/usr/lib/system/libsystem_kernel.dylib",
+				"uuid":       "11111111-1111-1111-1111-111111111111",
+				"image_addr": float64(0x180000000),
+				"image_size": float64(0x1000),
+			},
+			map[string]interface{}{
+				"name":       "/private/var/containers/Bundle/Application/ABC/Demo.app/Demo",
+				"uuid":       "22222222-2222-2222-2222-222222222222",
+				"image_addr": float64(0x100000000),
+				"image_size": float64(0x1000),
+			},
+		},
+	}
+
+	got := formatBinaryImages(report)
+	var appLine, sysLine string
+	for _, line := range strings.Split(got, "\n") {
+		if strings.Contains(line, "Demo.app") {
+			appLine = line
+		} else if strings.Contains(line, "libsystem_kernel") {
+			sysLine = line
+		}
+	}
+
+	if !strings.Contains(appLine, "+Demo ") {
+		t.Errorf("应用镜像缺少 + 标记: %q", appLine)
+	}
+	if strings.Contains(sysLine, "+") {
+		t.Errorf("系统镜像不应有 + 标记: %q", sysLine)
+	}
+}
+
+func TestIsAppImagePath(t *testing.T) {
+	tests := []struct {
+		image, exe string
+		want       bool
+	}{
+		{"/var/a/Demo.app/Demo", "/var/a/Demo.app/Demo", true},
+		{"/private/var/a/Demo.app/Demo", "/var/a/Demo.app/Demo", true},
+		{"/private/var/a/Demo.app/Demo", "Demo", true},
+		{"/usr/lib/libobjc.A.dylib", "/var/a/Demo.app/Demo", false},
+		{"/usr/lib/libobjc.A.dylib", "", false},
+		{"/var/a/NotDemo", "/var/a/Demo", false},
+		{"/var/a/NotDemo", "Demo", false},
+		{"a/Demo.app/Demo", "/private/var/a/Demo.app/Demo", true},
+	}
+
+	for _, tt := range tests {
+		if got := isAppImagePath(tt.image, tt.exe); got != tt.want {
+			t.Errorf("isAppImagePath(%q, %q) = %v, want %v", tt.image, tt.exe, got, tt.want)
+		}
+	}
+}
+
+func TestFindCrashedThread(t *testing.T) {
+	thread := func(index int, crashed bool) map[string]interface{} {
+		return map[string]interface{}{"index": float64(index), "crashed": crashed}
+	}
+
+	t.Run("崩溃报告", func(t *testing.T) {
+		report := map[string]interface{}{
+			"crash": map[string]interface{}{
+				"threads": []interface{}{thread(0, false), thread(3, true)},
+			},
+		}
+		got := findCrashedThread(report)
+		if got == nil || getInt64(got, "index") != 3 {
+			t.Errorf("findCrashedThread() = %v, want 线程 3", got)
+		}
+	})
+
+	t.Run("卡顿报告回退到主线程", func(t *testing.T) {
+		report := map[string]interface{}{
+			"dump_type": float64(2001),
+			"crash": map[string]interface{}{
+				"threads": []interface{}{thread(1, false), thread(0, false)},
+			},
+		}
+		got := findCrashedThread(report)
+		if got == nil || getInt64(got, "index") != 0 {
+			t.Errorf("findCrashedThread() = %v, want 线程 0", got)
+		}
+	})
+
+	t.Run("没有线程", func(t *testing.T) {
+		if got := findCrashedThread(map[string]interface{}{}); got != nil {
+			t.Errorf("findCrashedThread() = %v, want nil", got)
+		}
+	})
+}
+
+func TestFormatRecrashReport(t *testing.T) {
+	report := map[string]interface{}{
+		"binary_images": []interface{}{
+			map[string]interface{}{
+				"name":       "/var/containers/Bundle/Application/XXX/Demo.app/Demo",
+				"image_addr": float64(0x100000000),
+				"image_size": float64(0x10000),
+			},
+		},
+		"crash": map[string]interface{}{
+			"threads": []interface{}{
+				map[string]interface{}{"index": float64(0), "crashed": true},
+			},
+		},
+		"recrash_report": map[string]interface{}{
+			"crash": map[string]interface{}{
+				"error": map[string]interface{}{
+					"mach":   map[string]interface{}{"exception_name": "EXC_BAD_ACCESS"},
+					"signal": map[string]interface{}{"name": "SIGSEGV"},
+				},
+				"threads": []interface{}{
+					map[string]interface{}{
+						"index":   float64(4),
+						"crashed": true,
+						"backtrace": map[string]interface{}{
+							"contents": []interface{}{
+								map[string]interface{}{
+									"object_name":       "Demo",
+									"instruction_addr":  float64(0x100000100),
+									"symbolicated_name": "kscrash_writeReport (in Demo)",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	formatted := formatReportToAppleStyle(report)
+	idx := strings.Index(formatted, "Recrash Report")
+	if idx < 0 {
+		t.Fatalf("格式化结果缺少 Recrash Report 段落:\n%s", formatted)
+	}
+
+	recrash := formatted[idx:]
+	for _, want := range []string{
+		"Exception Type:  EXC_BAD_ACCESS (SIGSEGV)",
+		"Thread 4 Crashed:",
+		"0x0000000100000100 kscrash_writeReport",
+	} {
+		if !strings.Contains(recrash, want) {
+			t.Errorf("Recrash 段落缺少 %q:\n%s", want, recrash)
+		}
+	}
+
+	// 没有 recrash_report 时不输出该段落
+	delete(report, "recrash_report")
+	if strings.Contains(formatReportToAppleStyle(report), "Recrash Report") {
+		t.Error("没有 recrash_report 时不应输出 Recrash Report")
+	}
+}
+
+func TestGetDeviceName(t *testing.T) {
+	tests := []struct {
+		machine string
+		want    string
+	}{
+		{"iPhone14,2", "iPhone 13 Pro (iPhone14,2)"},
+		{"iPad13,1", "iPad Air (4th generation) (iPad13,1)"},
+		{"iPad16,5", "iPad Pro 13-inch (M4) (iPad16,5)"},
+		{"iPad99,1", "iPad (iPad99,1)"},
+		{"iPhone99,1", "iPhone (iPhone99,1)"},
+		{"x86_64", "x86_64"},
+	}
+
+	for _, tt := range tests {
+		if got := getDeviceName(tt.machine); got != tt.want {
+			t.Errorf("getDeviceName(%q) = %q, want %q", tt.machine, got, tt.want)
+		}
+	}
+}
+
+func TestFormatApplicationSpecificInfo(t *testing.T) {
+	report := map[string]interface{}{
+		"crash": map[string]interface{}{
+			"error": map[string]interface{}{
+				"type":   "signal",
+				"signal": map[string]interface{}{"signal": float64(6), "name": "SIGABRT"},
+				"mach":   map[string]interface{}{"exception_name": "EXC_CRASH"},
+			},
+			"threads": []interface{}{},
+		},
+		"binary_images": []interface{}{
+			map[string]interface{}{"name": "/usr/lib/system/libsystem_c.dylib", "crash_info_message": "abort() called"},
+			map[string]interface{}{"name": "/usr/lib/dyld", "crash_info_message": "abort() called"},
+			map[string]interface{}{"name": "/usr/lib/libc++abi.dylib", "crash_info_message2": "libc++abi: terminating due to uncaught exception of type std::runtime_error"},
+		},
+	}
+
+	formatted := formatErrorInfo(report)
+	want := "\nApplication Specific Information:\nabort() called\nlibc++abi: terminating due to uncaught exception of type std::runtime_error\n"
+	if !strings.HasSuffix(formatted, want) {
+		t.Errorf("formatErrorInfo() 缺少 Application Specific Information:\n%s", formatted)
+	}
+
+	// 未捕获的 NSException 按 Apple 的格式输出，reason 不重复
+	report["crash"].(map[string]interface{})["error"].(map[string]interface{})["nsexception"] = map[string]interface{}{
+		"name":   "NSInvalidArgumentException",
+		"reason": "-[__NSArrayM insertObject:atIndex:]: object cannot be nil",
+	}
+	report["crash"].(map[string]interface{})["error"].(map[string]interface{})["reason"] = "-[__NSArrayM insertObject:atIndex:]: object cannot be nil"
+	messages := applicationSpecificMessages(report)
+	if len(messages) != 3 || messages[0] != "*** Terminating app due to uncaught exception 'NSInvalidArgumentException', reason: '-[__NSArrayM insertObject:atIndex:]: object cannot be nil'" {
+		t.Errorf("applicationSpecificMessages() = %q", messages)
+	}
+
+	// 没有附加信息时不输出段落
+	if got := formatApplicationSpecificInfo(map[string]interface{}{}); got != "" {
+		t.Errorf("没有附加信息时应为空，得到 %q", got)
+	}
+}
+
+func TestFormatErrorInfoResolvesNumbers(t *testing.T) {
+	tests := []struct {
+		name   string
+		signal map[string]interface{}
+		mach   map[string]interface{}
+		want   string
+	}{
+		{"SIGSEGV", map[string]interface{}{"signal": float64(11)}, map[string]interface{}{"exception": float64(1)}, "EXC_BAD_ACCESS (SIGSEGV)"},
+		{"SIGABRT", map[string]interface{}{"signal": float64(6)}, map[string]interface{}{"exception": float64(10)}, "EXC_CRASH (SIGABRT)"},
+		{"SIGBUS", map[string]interface{}{"signal": float64(10)}, map[string]interface{}{"exception": float64(1)}, "EXC_BAD_ACCESS (SIGBUS)"},
+		{"SIGILL", map[string]interface{}{"signal": float64(4)}, map[string]interface{}{"exception": float64(2)}, "EXC_BAD_INSTRUCTION (SIGILL)"},
+		{"SIGTRAP", map[string]interface{}{"signal": float64(5)}, map[string]interface{}{"exception": float64(6)}, "EXC_BREAKPOINT (SIGTRAP)"},
+		{"已有名称优先", map[string]interface{}{"signal": float64(11), "name": "SIGSEGV"}, map[string]interface{}{"exception": float64(1), "exception_name": "EXC_BAD_ACCESS"}, "EXC_BAD_ACCESS (SIGSEGV)"},
+		{"未知编号", map[string]interface{}{"signal": float64(64)}, map[string]interface{}{"exception": float64(0x20)}, "0x20 (SIG64)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := map[string]interface{}{
+				"crash": map[string]interface{}{
+					"error":   map[string]interface{}{"signal": tt.signal, "mach": tt.mach},
+					"threads": []interface{}{},
+				},
+			}
+			if got := formatErrorInfo(report); !strings.Contains(got, "Exception Type:  "+tt.want+"\n") {
+				t.Errorf("formatErrorInfo() = %q, want Exception Type %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatOneline(t *testing.T) {
+	report := map[string]interface{}{
+		"system": map[string]interface{}{
+			"machine":            "iPhone15,2",
+			"system_name":        "iOS",
+			"system_version":     "17.1",
+			"CFBundleExecutable": "Demo",
+		},
+		"binary_images": []interface{}{
+			map[string]interface{}{"name": "/private/var/containers/Bundle/Application/X/Demo.app/Demo", "image_addr": float64(0x100000000), "image_size": float64(0x10000)},
+		},
+		"crash": map[string]interface{}{
+			"error": map[string]interface{}{},
+			"threads": []interface{}{
+				map[string]interface{}{
+					"index":   float64(0),
+					"crashed": true,
+					"backtrace": map[string]interface{}{
+						"contents": []interface{}{
+							map[string]interface{}{"object_name": "libsystem_kernel.dylib", "instruction_addr": float64(0x1a0000300), "symbol_name": "__pthread_kill"},
+							map[string]interface{}{
+								"object_name":       "Demo",
+								"instruction_addr":  float64(0x100004000),
+								"symbolicated_name": "-[Foo bar] (in Demo) (Foo.mm:42)",
+								"file_name":         "Foo.mm",
+								"line_number":       "42",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	want := "Crash in -[Foo bar] (Foo.mm:42) on iPhone 14 Pro, iOS 17.1"
+	if got := formatOneline(report); got != want {
+		t.Errorf("formatOneline() = %q, want %q", got, want)
+	}
+
+	// 未符号化、没有设备信息时退化为地址和镜像名
+	delete(report, "system")
+	frames := report["crash"].(map[string]interface{})["threads"].([]interface{})[0].(map[string]interface{})["backtrace"].(map[string]interface{})
+	frames["contents"] = frames["contents"].([]interface{})[:1]
+	if got, want := formatOneline(report), "Crash in __pthread_kill"; got != want {
+		t.Errorf("formatOneline() = %q, want %q", got, want)
+	}
+	frames["contents"] = []interface{}{map[string]interface{}{"object_name": "/usr/lib/dyld", "instruction_addr": float64(0x1000)}}
+	if got, want := formatOneline(report), "Crash in 0x1000 in dyld"; got != want {
+		t.Errorf("formatOneline() = %q, want %q", got, want)
+	}
+	if got, want := formatOneline(map[string]interface{}{}), "Crash in unknown location"; got != want {
+		t.Errorf("formatOneline() = %q, want %q", got, want)
+	}
+}
+
+func TestLoadDeviceNames(t *testing.T) {
+	defer func() {
+		loadedDeviceNamesMu.Lock()
+		loadedDeviceNames = nil
+		loadedDeviceNamesMu.Unlock()
+	}()
+
+	path := filepath.Join(t.TempDir(), "devices.json")
+	os.WriteFile(path, []byte(`{"iPhone18,1": "iPhone 17 Pro", "iPhone14,2": "iPhone 13 Pro (覆盖)"}`), 0644)
+
+	if n, err := loadDeviceNames(path); err != nil || n != 2 {
+		t.Fatalf("loadDeviceNames() = %d, %v", n, err)
+	}
+	tests := map[string]string{
+		"iPhone18,1": "iPhone 17 Pro (iPhone18,1)",
+		"iPhone14,2": "iPhone 13 Pro (覆盖) (iPhone14,2)",
+		"Watch7,5":   "Apple Watch Ultra 2 (Watch7,5)", // 内置映射仍然生效
+	}
+	for machine, want := range tests {
+		if got := getDeviceName(machine); got != want {
+			t.Errorf("getDeviceName(%q) = %q, want %q", machine, got, want)
+		}
+	}
+
+	// 格式错误时保留之前的映射
+	os.WriteFile(path, []byte(`{broken`), 0644)
+	if _, err := loadDeviceNames(path); err == nil {
+		t.Error("格式错误的文件应返回错误")
+	}
+	if got := getDeviceName("iPhone18,1"); got != "iPhone 17 Pro (iPhone18,1)" {
+		t.Errorf("加载失败后映射被清空: %q", got)
+	}
+
+	// 文件被删除后重新加载：清空之前的映射，只使用内置映射
+	oldFile := devicesFile
+	devicesFile = path
+	defer func() { devicesFile = oldFile }()
+	os.Remove(path)
+	if n, err := reloadDevices(); err != nil || n != 0 {
+		t.Fatalf("reloadDevices() = %d, %v", n, err)
+	}
+	if name, _ := lookupDeviceName("iPhone14,2"); name != deviceNames["iPhone14,2"] {
+		t.Errorf("文件删除后仍在使用之前加载的映射: %q", name)
+	}
+}
+
+func TestFormatSystemInfoPlatforms(t *testing.T) {
+	tests := []struct {
+		name     string
+		system   map[string]interface{}
+		wantInfo []string
+		wantArch string
+	}{
+		{
+			name: "watchOS",
+			system: map[string]interface{}{
+				"system_name": "watchOS", "system_version": "10.1", "os_version": "21S71",
+				"machine": "Watch7,5", "cpu_arch": "arm64_32",
+			},
+			wantInfo: []string{"Device:      Apple Watch Ultra 2 (Watch7,5)", "OS Version:  watchOS 10.1 (21S71)"},
+			wantArch: "arm64_32",
+		},
+		{
+			name: "Apple 芯片 Mac",
+			system: map[string]interface{}{
+				"system_name": "Mac OS X", "system_version": "14.2", "os_version": "23C64",
+				"machine": "arm64", "model": "MacBookPro18,3", "cpu_arch": "arm64",
+				"binary_cpu_type": float64(0x0100000c), "binary_cpu_subtype": float64(0),
+			},
+			wantInfo: []string{"Device:      MacBook Pro (14-inch, 2021) (MacBookPro18,3)", "OS Version:  macOS 14.2 (23C64)"},
+			wantArch: "arm64",
+		},
+		{
+			name: "Rosetta 运行的 x86_64 应用",
+			system: map[string]interface{}{
+				"system_name": "macOS", "system_version": "14.2", "os_version": "23C64",
+				"machine": "arm64", "model": "Mac99,1", "cpu_arch": "arm64",
+				"binary_cpu_type": float64(0x01000007), "binary_cpu_subtype": float64(3),
+			},
+			wantInfo: []string{"Device:      Mac (Mac99,1)", "OS Version:  macOS 14.2 (23C64)"},
+			wantArch: "x86_64",
+		},
+		{
+			name: "Intel Mac",
+			system: map[string]interface{}{
+				"system_name": "macOS", "system_version": "13.6", "os_version": "22G120",
+				"machine": "x86_64", "model": "MacBookPro16,1",
+			},
+			wantInfo: []string{"Device:      MacBook Pro (16-inch, 2019) (MacBookPro16,1)", "OS Version:  macOS 13.6 (22G120)"},
+			wantArch: "x86_64",
+		},
+		{
+			name: "tvOS 缺少 system_name",
+			system: map[string]interface{}{
+				"system_version": "17.0", "os_version": "21J354", "machine": "AppleTV14,1", "cpu_arch": "arm64",
+			},
+			wantInfo: []string{"Device:      Apple TV 4K (3rd generation) (AppleTV14,1)", "OS Version:  tvOS 17.0 (21J354)"},
+			wantArch: "arm64",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := map[string]interface{}{"system": tt.system}
+			info := formatSystemInfo(report)
+			for _, want := range tt.wantInfo {
+				if !strings.Contains(info, want) {
+					t.Errorf("formatSystemInfo() 缺少 %q:\n%s", want, info)
+				}
+			}
+			if got := reportArch(report); got != tt.wantArch {
+				t.Errorf("reportArch() = %q, want %q", got, tt.wantArch)
+			}
+		})
+	}
+}
+
+func TestFormatBacktraceMaxFrames(t *testing.T) {
+	contents := []interface{}{}
+	for i := 0; i < 250; i++ {
+		contents = append(contents, map[string]interface{}{
+			"object_name":       "Demo",
+			"instruction_addr":  float64(0x100000000 + i*4),
+			"symbolicated_name": fmt.Sprintf("recurse%d (in Demo) (Recurse.m:%d)", i, i+1),
+		})
+	}
+	report := map[string]interface{}{
+		"crash": map[string]interface{}{
+			"threads": []interface{}{
+				map[string]interface{}{"index": float64(0), "crashed": true, "backtrace": map[string]interface{}{"contents": contents}},
+			},
+		},
+	}
+	backtrace := report["crash"].(map[string]interface{})["threads"].([]interface{})[0].(map[string]interface{})["backtrace"].(map[string]interface{})
+
+	// 默认不截断
+	if got := formatBacktrace(backtrace, report); strings.Count(got, "\n") != 250 || strings.Contains(got, "omitted") {
+		t.Errorf("默认输出了 %d 行，want 250 行且没有省略标记", strings.Count(got, "\n"))
+	}
+
+	got := formatBacktrace(backtrace, maxFramesReport(report, 10))
+	lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	if len(lines) != 11 {
+		t.Fatalf("max_frames=10 输出 %d 行，want 10 帧 + 省略标记:\n%s", len(lines), got)
+	}
+	if !strings.Contains(lines[9], "recurse9") || strings.Contains(got, "recurse10 ") {
+		t.Errorf("没有保留栈顶的 10 帧:\n%s", got)
+	}
+	if lines[10] != "... (240 more frames omitted)" {
+		t.Errorf("省略标记 = %q, want ... (240 more frames omitted)", lines[10])
+	}
+
+	// 完整报告的格式化结果同样截断，原始报告不受影响
+	if formatted := formatReportToAppleStyle(maxFramesReport(report, 10)); !strings.Contains(formatted, "... (240 more frames omitted)") {
+		t.Errorf("formatReportToAppleStyle() 没有截断:\n%s", formatted)
+	}
+	if _, ok := report[maxFramesKey]; ok {
+		t.Error("maxFramesReport 修改了原始报告")
+	}
+}
+
+func TestConciseReport(t *testing.T) {
+	thread := func(index int, crashed bool, objectName string) interface{} {
+		return map[string]interface{}{
+			"index":   float64(index),
+			"crashed": crashed,
+			"backtrace": map[string]interface{}{
+				"contents": []interface{}{
+					map[string]interface{}{"object_name": objectName, "instruction_addr": float64(0x1000)},
+				},
+			},
+		}
+	}
+	report := map[string]interface{}{
+		"system": map[string]interface{}{"CFBundleExecutable": "Demo"},
+		"binary_images": []interface{}{
+			map[string]interface{}{"name": "/var/containers/Demo.app/Demo", "image_addr": float64(0x100000000)},
+		},
+		"crash": map[string]interface{}{
+			"threads": []interface{}{
+				thread(0, false, "Demo"),
+				thread(1, false, "libsystem_kernel.dylib"),
+				thread(2, false, "/var/containers/Demo.app/Demo"),
+				thread(3, true, "libsystem_kernel.dylib"),
+				thread(4, false, "Demo"),
+			},
+		},
+	}
+
+	indexes := func(r map[string]interface{}) []int64 {
+		var result []int64
+		for _, th := range r["crash"].(map[string]interface{})["threads"].([]interface{}) {
+			result = append(result, getInt64(th.(map[string]interface{}), "index"))
+		}
+		return result
+	}
+
+	concise := conciseReport(report, 10)
+	if got := indexes(concise); !reflect.DeepEqual(got, []int64{0, 2, 3, 4}) {
+		t.Errorf("threads = %v, want [0 2 3 4]", got)
+	}
+
+	// 名额不足时崩溃线程优先
+	concise = conciseReport(report, 2)
+	if got := indexes(concise); !reflect.DeepEqual(got, []int64{0, 3}) {
+		t.Errorf("threads = %v, want [0 3]", got)
+	}
+	if !strings.Contains(formatThreadList(concise), "... 3 more threads omitted") {
+		t.Errorf("formatThreadList() 缺少省略提示:\n%s", formatThreadList(concise))
+	}
+
+	// 原始报告不受影响
+	if got := len(indexes(report)); got != 5 {
+		t.Errorf("原始报告线程数 = %d, want 5", got)
+	}
+	if strings.Contains(formatThreadList(report), "omitted") {
+		t.Error("完整模式不应出现省略提示")
+	}
+}
+
+func TestFormatHangReportLabelsHungThread(t *testing.T) {
+	newThread := func(index int, name string) map[string]interface{} {
+		return map[string]interface{}{
+			"index": float64(index),
+			"name":  name,
+			"backtrace": map[string]interface{}{
+				"contents": []interface{}{
+					map[string]interface{}{"object_name": "libsystem_kernel.dylib", "instruction_addr": float64(0x1000), "symbol_name": "mach_msg_trap"},
+				},
+			},
+		}
+	}
+	report := map[string]interface{}{
+		"dump_type": float64(2001),
+		"crash": map[string]interface{}{
+			"error": map[string]interface{}{},
+			"threads": []interface{}{
+				newThread(0, ""),
+				newThread(1, "com.apple.uikit.eventfetch-thread"),
+			},
+		},
+	}
+
+	formatted := formatReportToAppleStyle(report)
+	for _, want := range []string{"Hung Thread:     0 (主线程卡顿)", "Thread 0 Hung:", "Thread 1:"} {
+		if !strings.Contains(formatted, want) {
+			t.Errorf("格式化结果缺少 %q:\n%s", want, formatted)
+		}
+	}
+	if strings.Contains(formatted, "Crashed") {
+		t.Errorf("卡顿报告不应出现 Crashed:\n%s", formatted)
+	}
+
+	// 没有 index 0 时按名称识别主线程；dump_type 只在 user 中
+	delete(report, "dump_type")
+	report["user"] = map[string]interface{}{"Demo": map[string]interface{}{"DumpType": "2010"}}
+	report["crash"].(map[string]interface{})["threads"] = []interface{}{
+		newThread(3, "worker"),
+		newThread(5, "com.apple.main-thread"),
+	}
+	if got := getCrashedThreadIndex(report); got != 5 {
+		t.Errorf("getCrashedThreadIndex() = %d, want 5", got)
+	}
+	if got := formatErrorInfo(report); !strings.Contains(got, "Hung Thread:     5 (被杀死前卡顿)") {
+		t.Errorf("formatErrorInfo() = %q", got)
+	}
+
+	// 有 crashed 标记时仍然是崩溃线程
+	report["crash"].(map[string]interface{})["threads"].([]interface{})[0].(map[string]interface{})["crashed"] = true
+	if got := formatErrorInfo(report); !strings.Contains(got, "Crashed Thread:  3") {
+		t.Errorf("formatErrorInfo() = %q", got)
+	}
+}
+
+func TestFormatThreadListKeepsThreadsWithoutIndex(t *testing.T) {
+	newThread := func(name string, addr float64) map[string]interface{} {
+		return map[string]interface{}{
+			"name": name,
+			"backtrace": map[string]interface{}{
+				"contents": []interface{}{
+					map[string]interface{}{"object_name": "libsystem_kernel.dylib", "instruction_addr": addr, "symbol_name": "mach_msg_trap"},
+				},
+			},
+		}
+	}
+	duplicate := newThread("worker", 0x1a0000200)
+	report := map[string]interface{}{
+		"crash": map[string]interface{}{
+			"threads": []interface{}{
+				newThread("main", 0x1a0000100),
+				duplicate,
+				duplicate, // 完全相同的线程只输出一次
+			},
+		},
+	}
+
+	got := formatThreadList(report)
+	if !strings.Contains(got, "Thread 0 name:  main") || !strings.Contains(got, "Thread 1 name:  worker") {
+		t.Errorf("缺少 index 的线程被丢弃或编号错误:\n%s", got)
+	}
+	if n := strings.Count(got, "name:  worker"); n != 1 {
+		t.Errorf("重复线程输出了 %d 次，want 1:\n%s", n, got)
+	}
+	if _, ok := duplicate["index"]; ok {
+		t.Error("不应修改原始报告中的线程")
+	}
+}
+
+// updateGolden 为 true 时用当前输出重写 testdata 下的 golden 文件：go test -run Golden -update
+var updateGolden = flag.Bool("update", false, "重写 golden 文件")
+
+func TestFormatCPUStateGolden(t *testing.T) {
+	basic := map[string]interface{}{}
+	for i := 0; i < 29; i++ {
+		if i == 5 {
+			continue // 缺失的寄存器不应导致后续列错位
+		}
+		basic[fmt.Sprintf("x%d", i)] = float64(0x1000 + i)
+	}
+	basic["fp"] = float64(0x16b0a2f50)
+	basic["sp"] = float64(0x16b0a2f30)
+	basic["lr"] = float64(0x1a2b3c4d8)
+	basic["pc"] = float64(0x1a2b3c4e0)
+	basic["cpsr"] = float64(0x60000000)
+
+	report := map[string]interface{}{
+		"system": map[string]interface{}{"cpu_arch": "arm64"},
+		"crash": map[string]interface{}{
+			"threads": []interface{}{
+				map[string]interface{}{"index": float64(0), "crashed": false},
+				map[string]interface{}{
+					"index":   float64(3),
+					"crashed": true,
+					"registers": map[string]interface{}{
+						"basic": basic,
+						"exception": map[string]interface{}{
+							"exception": float64(0),
+							"esr":       float64(0x92000006),
+							"far":       float64(0x10),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	got := formatCPUState(report)
+	golden := filepath.Join("testdata", "cpu_state_arm64.golden")
+	if *updateGolden {
+		if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+			t.Fatalf("写入 golden 文件失败: %v", err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("读取 golden 文件失败: %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("formatCPUState() 与 %s 不一致:\n--- got ---\n%s\n--- want ---\n%s", golden, got, want)
+	}
+}
+
+func TestFormatBacktraceGolden(t *testing.T) {
+	report := map[string]interface{}{
+		"binary_images": []interface{}{
+			map[string]interface{}{"name": "/var/containers/Bundle/Application/X/Demo.app/Demo", "image_addr": float64(0x100000000), "image_size": float64(0x100000)},
+		},
+	}
+	backtrace := map[string]interface{}{
+		"contents": []interface{}{
+			// 文件位置只在 atos 结果中
+			map[string]interface{}{"object_name": "Demo", "instruction_addr": float64(0x100010000), "symbolicated_name": "-[LagViewController simulateLag] (in Demo) (LagViewController.mm:145)"},
+			// atos 只给出 符号 + 偏移，文件位置来自 file_name / line_number
+			map[string]interface{}{"object_name": "Demo", "instruction_addr": float64(0x100010400), "symbolicated_name": "Demo.ViewController.viewDidLoad() -> () (in Demo) + 64", "file_name": "/Users/dev/Demo/ViewController.swift", "line_number": "27"},
+			// 系统库：没有文件位置
+			map[string]interface{}{"object_name": "libobjc.A.dylib", "instruction_addr": float64(0x1a0001020), "symbolicated_name": "objc_msgSend (in libobjc.A.dylib) + 32"},
+			map[string]interface{}{"object_name": "UIKitCore", "instruction_addr": float64(0x1b0000100), "symbol_name": "-[UIApplication sendAction:to:from:forEvent:]", "symbol_addr": float64(0x1b0000000)},
+			map[string]interface{}{"object_name": "Demo", "instruction_addr": float64(0x100020000), "symbolicated_name": "main (in Demo) (main.m:9)"},
+			map[string]interface{}{"object_name": "Demo", "instruction_addr": float64(0x100030000)},
+		},
+	}
+
+	got := formatBacktrace(backtrace, report)
+	golden := filepath.Join("testdata", "backtrace_apple_style.golden")
+	if *updateGolden {
+		if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+			t.Fatalf("写入 golden 文件失败: %v", err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("读取 golden 文件失败: %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("formatBacktrace() 与 %s 不一致:\n--- got ---\n%s\n--- want ---\n%s", golden, got, want)
+	}
+}
+
+func TestFormatAddressesGolden(t *testing.T) {
+	// 0x100010000 以 %v 打印 float64 时为 4.295016448e+09
+	report := map[string]interface{}{
+		"dump_type": float64(2001),
+		"binary_images": []interface{}{
+			map[string]interface{}{"name": "/var/containers/Bundle/Application/X/Demo.app/Demo", "image_addr": float64(0x100000000), "image_size": float64(0x100000)},
+		},
+		"crash": map[string]interface{}{
+			"threads": []interface{}{
+				map[string]interface{}{
+					"index":   float64(0),
+					"crashed": true,
+					"backtrace": map[string]interface{}{
+						"contents": []interface{}{
+							map[string]interface{}{"object_name": "Demo", "instruction_addr": float64(0x100010000), "symbolicated_name": "-[Foo bar] (in Demo) (Foo.m:12)", "is_app_code": true, "symbol_language": "Objective-C", "file_type": "Objective-C"},
+							map[string]interface{}{"object_name": "libsystem_kernel.dylib", "instruction_addr": float64(0x1a2b3c4d8), "symbol_name": "mach_msg_trap", "symbol_addr": float64(0x1a2b3c4d0)},
+							map[string]interface{}{"object_name": "Demo", "instruction_addr": float64(0x100020000)},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	thread := report["crash"].(map[string]interface{})["threads"].([]interface{})[0].(map[string]interface{})
+	got := formatBacktrace(thread["backtrace"].(map[string]interface{}), report) + "\n" + FormatSymbolicatedReport(report)
+	if strings.Contains(got, "e+") {
+		t.Errorf("地址以科学计数法输出:\n%s", got)
+	}
+
+	golden := filepath.Join("testdata", "frame_addresses.golden")
+	if *updateGolden {
+		if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+			t.Fatalf("写入 golden 文件失败: %v", err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("读取 golden 文件失败: %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("输出与 %s 不一致:\n--- got ---\n%s\n--- want ---\n%s", golden, got, want)
+	}
+}
+
+func TestFormatHangDuration(t *testing.T) {
+	const reportTime = 1700000000
+	report := map[string]interface{}{
+		"report": map[string]interface{}{"timestamp": float64(reportTime)},
+		"system": map[string]interface{}{
+			"process_name":      "Demo",
+			"application_stats": map[string]interface{}{"app_launch_time": float64(reportTime - 5)},
+		},
+		"user": map[string]interface{}{
+			"Demo": map[string]interface{}{"blockTime": float64(3200), "DumpType": float64(2007)},
+		},
+	}
+
+	if got := formatUserInfo(report); !strings.Contains(got, "Hang duration:   3.2s") {
+		t.Errorf("formatUserInfo() 缺少卡顿时长:\n%s", got)
+	}
+	if got := formatAppInfo(report); !strings.Contains(got, "Time since launch:                   5.0s") {
+		t.Errorf("formatAppInfo() 缺少启动阻塞时长:\n%s", got)
+	}
+
+	tests := []struct {
+		blockTime float64
+		want      string
+	}{
+		{850, "850ms"},                   // 毫秒
+		{3.2e9, "3.2s"},                  // 纳秒
+		{125000, "2m5.0s"},               // 毫秒，超过 1 分钟
+		{reportTime - 2, "2.0s"},         // 秒级时间戳
+		{reportTime*1000 - 1500, "1.5s"}, // 毫秒级时间戳
+	}
+	for _, tt := range tests {
+		d, ok := hangDuration(tt.blockTime, reportTime)
+		if got := formatHangDuration(d); !ok || got != tt.want {
+			t.Errorf("hangDuration(%v) = %q (ok=%v), want %q", tt.blockTime, got, ok, tt.want)
+		}
+	}
+
+	// 其他卡顿类型不显示启动后时长
+	report["user"].(map[string]interface{})["Demo"].(map[string]interface{})["DumpType"] = float64(2001)
+	if got := formatAppInfo(report); strings.Contains(got, "Time since launch") {
+		t.Errorf("非启动阻塞报告不应显示启动后时长:\n%s", got)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ============================================================================
+// 卡顿时长：user[app].blockTime 的单位不固定（毫秒、纳秒，部分版本记录的是卡顿开始的时间戳），
+// 按数量级推断单位后换算成可读的时长
+// ============================================================================
+
+const (
+	// blockTimeMillisLimit 小于该值按毫秒处理（约 2.7 小时）
+	blockTimeMillisLimit = 1e7
+	// maxTimestampHangAge 按时间戳处理时，卡顿开始时间距报告时间的最大间隔
+	maxTimestampHangAge = 24 * time.Hour
+)
+
+// blockTimeValue 读取 blockTime 数值，兼容字符串形式
+func blockTimeValue(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case int64:
+		return float64(val), true
+	case int:
+		return float64(val), true
+	case string:
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			return f, true
+		}
+	}
+	return 0, false
+}
+
+// hangDuration 根据 blockTime 推断卡顿时长；reportTime 为报告时间（秒），用于识别时间戳形式
+// 识别规则：距报告时间 24 小时内的秒级 / 毫秒级时间戳按"报告时间 - 卡顿开始时间"计算，
+// 其余小于 1e7 的值按毫秒，更大的值按纳秒
+func hangDuration(blockTime float64, reportTime int64) (time.Duration, bool) {
+	if blockTime <= 0 {
+		return 0, false
+	}
+
+	if reportTime > 0 {
+		report := time.Unix(reportTime, 0)
+		for _, start := range []time.Time{
+			time.Unix(0, int64(blockTime*float64(time.Second))),
+			time.UnixMilli(int64(blockTime)),
+		} {
+			// 报告时间只精确到秒，允许卡顿开始时间比报告时间晚不到 1 秒
+			if age := report.Sub(start); age > -time.Second && age <= maxTimestampHangAge {
+				return max(age, 0), true
+			}
+		}
+	}
+
+	if blockTime < blockTimeMillisLimit {
+		return time.Duration(blockTime * float64(time.Millisecond)), true
+	}
+	return time.Duration(blockTime), true
+}
+
+// formatHangDuration 将时长格式化为 850ms、3.2s、2m5.0s
+func formatHangDuration(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	case d < time.Minute:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+	minutes := int(d / time.Minute)
+	return fmt.Sprintf("%dm%.1fs", minutes, (d - time.Duration(minutes)*time.Minute).Seconds())
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+)
+
+// ============================================================================
+// 健康检查：外部工具、存储目录和数据量
+// GET /api/health 是开放的存活检查，只返回启动时诊断的状态和并发情况；
+// 完整诊断（工具和目录路径、可写检查、数据量）在 GET /api/health/details，需要 X-API-Key
+// ============================================================================
+
+// startupHealth 启动时的完整诊断结果，存活检查据此给出 status，避免每次探测都遍历目录和写文件
+var startupHealth atomic.Value // map[string]interface{}
+
+// recordStartupHealth 执行一次完整诊断并缓存，返回诊断结果
+func recordStartupHealth() map[string]interface{} {
+	report := healthReport()
+	startupHealth.Store(report)
+	return report
+}
+
+// healthLiveness 存活检查结果：不遍历目录、不创建文件、不暴露路径
+// status 取启动时诊断的结果，没有诊断时为 ok
+func healthLiveness() map[string]interface{} {
+	status := "ok"
+	if report, ok := startupHealth.Load().(map[string]interface{}); ok {
+		if cached, ok := report["status"].(string); ok {
+			status = cached
+		}
+	}
+	return map[string]interface{}{
+		"status":         status,
+		"symbolizer":     activeSymbolizer.name(),
+		"extractions":    extractionStats(),
+		"symbolications": symbolicationStats(),
+	}
+}
+
+// healthTool 健康检查探测的外部工具
+type healthTool struct {
+	name     string
+	required bool // 缺失时服务降级；可选工具有内置替代
+}
+
+// healthTools 返回需要探测的工具：当前符号化后端、unzip（解压 .dSYM.zip）、dwarfdump（有内置 Mach-O 解析兜底）
+// native 后端不依赖外部工具，不参与探测
+func healthTools() []healthTool {
+	tools := []healthTool{
+		{"unzip", true},
+		{"dwarfdump", false},
+	}
+	if _, native := activeSymbolizer.(nativeSymbolizer); !native {
+		tools = append([]healthTool{{activeSymbolizer.name(), true}}, tools...)
+	}
+	return tools
+}
+
+// healthReport 生成完整诊断结果，必需工具缺失或目录不可写时 status 为 degraded
+func healthReport() map[string]interface{} {
+	var problems []string
+
+	tools := make(map[string]interface{})
+	for _, tool := range healthTools() {
+		path, err := lookPath(tool.name)
+		info := map[string]interface{}{"available": err == nil, "required": tool.required}
+		if err == nil {
+			info["path"] = path
+		} else if tool.required {
+			problems = append(problems, fmt.Sprintf("未找到 %s", tool.name))
+		}
+		tools[tool.name] = info
+	}
+
+	dirs := map[string]string{"uploads": UploadDir, "dsyms": DsymDir, "reports": ReportsDir}
+	directories := make(map[string]interface{})
+	for name, dir := range dirs {
+		info := map[string]interface{}{"path": dir, "writable": true}
+		if err := checkDirWritable(dir); err != nil {
+			info["writable"] = false
+			info["error"] = err.Error()
+			problems = append(problems, fmt.Sprintf("目录 %s 不可写", dir))
+		}
+		directories[name] = info
+	}
+	sort.Strings(problems)
+
+	status := "ok"
+	if len(problems) > 0 {
+		status = "degraded"
+	}
+
+	result := map[string]interface{}{
+		"status":         status,
+		"symbolizer":     activeSymbolizer.name(),
+		"tools":          tools,
+		"directories":    directories,
+		"dsyms":          len(storedDsymFiles(DsymDir)),
+		"reports":        countReports(ReportsDir),
+		"extractions":    extractionStats(),
+		"symbolications": symbolicationStats(),
+	}
+	if len(problems) > 0 {
+		result["problems"] = problems
+	}
+	return result
+}
+
+// checkDirWritable 在目录中创建并删除一个临时文件，确认可写
+func checkDirWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".health-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
+
+// countReports 统计原始报告数量（含日期分区，不含符号化结果和 sidecar）
+func countReports(dir string) int {
+	count := 0
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if _, ok := reportIDFromFilename(d.Name()); ok {
+				count++
+			}
+		}
+		return nil
+	})
+	return count
+}
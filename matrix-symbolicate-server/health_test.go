@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHealthReport(t *testing.T) {
+	oldUpload, oldDsym, oldReports := UploadDir, DsymDir, ReportsDir
+	UploadDir, DsymDir, ReportsDir = t.TempDir(), t.TempDir(), t.TempDir()
+	defer func() { UploadDir, DsymDir, ReportsDir = oldUpload, oldDsym, oldReports }()
+
+	os.WriteFile(filepath.Join(DsymDir, "20240101_000000_Demo.dSYM.zip"), []byte("x"), 0644)
+	partition := reportPartitionDir(ReportsDir, "1700000000000000000")
+	os.MkdirAll(partition, 0755)
+	for _, name := range []string{"1700000000000000000_a.json", "1700000000000000000_a_symbolicated.json", "1700000000000000000.meta.json"} {
+		os.WriteFile(filepath.Join(partition, name), []byte("{}"), 0644)
+	}
+
+	oldLookPath, oldSymbolizer := lookPath, activeSymbolizer
+	defer func() { lookPath, activeSymbolizer = oldLookPath, oldSymbolizer }()
+	activeSymbolizer = atosSymbolizer{}
+	missing := map[string]bool{}
+	lookPath = func(name string) (string, error) {
+		if missing[name] {
+			return "", os.ErrNotExist
+		}
+		return "/usr/bin/" + name, nil
+	}
+
+	report := healthReport()
+	if report["status"] != "ok" || report["dsyms"] != 1 || report["reports"] != 1 {
+		t.Errorf("status = %v, dsyms = %v, reports = %v, want ok/1/1", report["status"], report["dsyms"], report["reports"])
+	}
+
+	// dwarfdump 有内置替代，缺失不降级
+	missing["dwarfdump"] = true
+	if report := healthReport(); report["status"] != "ok" {
+		t.Errorf("缺少 dwarfdump: status = %v, want ok", report["status"])
+	}
+
+	missing["atos"] = true
+	report = healthReport()
+	if report["status"] != "degraded" {
+		t.Errorf("缺少 atos: status = %v, want degraded", report["status"])
+	}
+	atos := report["tools"].(map[string]interface{})["atos"].(map[string]interface{})
+	if atos["available"] != false {
+		t.Errorf("tools.atos = %v", atos)
+	}
+	if problems, _ := report["problems"].([]string); len(problems) != 1 || problems[0] != "未找到 atos" {
+		t.Errorf("problems = %v", report["problems"])
+	}
+
+	// 存活检查使用缓存的启动诊断结果，不暴露工具和目录路径
+	startupHealth.Store(report)
+	defer startupHealth.Store(map[string]interface{}{})
+	liveness := healthLiveness()
+	if liveness["status"] != "degraded" || liveness["tools"] != nil || liveness["directories"] != nil {
+		t.Errorf("healthLiveness() = %v", liveness)
+	}
+}
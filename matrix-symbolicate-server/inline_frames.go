@@ -0,0 +1,89 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+)
+
+// ============================================================================
+// 内联帧：atos -i 对被内联的地址输出多行（从最内层的内联函数到外层实际所在的函数），
+// 每个地址的结果之间以空行分隔。同一地址的多行结果以换行拼接保存，线程符号化结束后展开为多个帧
+// ============================================================================
+
+// atosInlineFrames 是否让 atos 输出内联帧（ATOS_INLINE_FRAMES=1），默认关闭
+var atosInlineFrames = os.Getenv("ATOS_INLINE_FRAMES") == "1" || os.Getenv("ATOS_INLINE_FRAMES") == "true"
+
+// parseAtosInlineOutput 解析 atos -i 的输出：按空行分组，每组对应一个地址，组内每行一个内联层级
+// 分组数量与地址数量不一致时（例如 atos 版本不输出空行）按每行一个地址解析
+func parseAtosInlineOutput(output string, addrs []uint64) []string {
+	var groups [][]string
+	var current []string
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			groups = append(groups, current)
+			current = nil
+			continue
+		}
+		current = append(current, line)
+	}
+	if current != nil {
+		groups = append(groups, current)
+	}
+	if len(groups) != len(addrs) {
+		log.Printf("⚠️ atos 内联输出 %d 组，与请求的 %d 个地址不一致，按单行解析", len(groups), len(addrs))
+		return parseAtosBatchOutput(output, addrs)
+	}
+
+	results := make([]string, len(addrs))
+	for i, addr := range addrs {
+		var chain []string
+		for _, line := range groups[i] {
+			if symbol := postProcessSymbol(line, addr); symbol != "" {
+				chain = append(chain, symbol)
+			}
+		}
+		results[i] = strings.Join(chain, "\n")
+	}
+	return results
+}
+
+// physicalFrameSymbol 返回内联链中外层实际所在的函数（最后一行），没有内联时原样返回
+func physicalFrameSymbol(symbol string) string {
+	if i := strings.LastIndexByte(symbol, '\n'); i >= 0 {
+		return symbol[i+1:]
+	}
+	return symbol
+}
+
+// symbolDerivedKeys applySymbolToFrame 从符号中解析出的、不一定每次都会覆盖的字段
+var symbolDerivedKeys = []string{"function_name", "mangled_name", "module_name", "file_name", "line_number", "file_type"}
+
+// expandInlinedFrames 将带内联链的帧展开为多个帧：地址相同，依次为各层内联函数和外层实际函数
+// 除最后一个（外层实际函数）外都标记 inlined
+func expandInlinedFrames(frames []interface{}) []interface{} {
+	expanded := make([]interface{}, 0, len(frames))
+	for _, f := range frames {
+		frame, ok := f.(map[string]interface{})
+		symbol, _ := frame["symbolicated_name"].(string)
+		if !ok || !strings.Contains(symbol, "\n") {
+			expanded = append(expanded, f)
+			continue
+		}
+
+		chain := strings.Split(symbol, "\n")
+		for i, line := range chain {
+			// 去掉按整条内联链解析出的字段，再按本层的符号重新解析
+			inlined := deepCopyMap(frame)
+			for _, key := range symbolDerivedKeys {
+				delete(inlined, key)
+			}
+			applySymbolToFrame(inlined, line)
+			if i < len(chain)-1 {
+				inlined["inlined"] = true
+			}
+			expanded = append(expanded, inlined)
+		}
+	}
+	return expanded
+}
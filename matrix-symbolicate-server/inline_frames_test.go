@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSymbolicateThreadExpandsInlinedFrames(t *testing.T) {
+	oldInline := atosInlineFrames
+	atosInlineFrames = true
+	defer func() { atosInlineFrames = oldInline }()
+
+	// 假的 atos -i：0x100000100 被内联了两层，0x100000200 没有内联，每个地址的结果以空行结束
+	installFakeRunner(t, func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		if args[6] != "-i" {
+			t.Errorf("atos 参数缺少 -i: %v", args)
+		}
+		groups := map[string]string{
+			"0x100000100": "inlineHelper (in Demo) (Helper.h:12)\n-[Foo layout] (in Demo) (Foo.m:40)\n-[Foo run] (in Demo) (Foo.m:88)\n",
+			"0x100000200": "main (in Demo) (main.m:10)\n",
+		}
+		var out strings.Builder
+		for _, addr := range args[7:] {
+			out.WriteString(groups[addr] + "\n")
+		}
+		return []byte(out.String()), nil
+	})
+
+	thread := map[string]interface{}{
+		"index":   float64(0),
+		"crashed": true,
+		"backtrace": map[string]interface{}{
+			"contents": []interface{}{
+				map[string]interface{}{"object_name": "Demo", "instruction_addr": float64(0x100000100)},
+				map[string]interface{}{"object_name": "Demo", "instruction_addr": float64(0x100000200)},
+			},
+		},
+	}
+	binaries := &imageBinaries{appPath: "/nonexistent/Demo", appLoadAddr: 0x100000000, binaryImages: []interface{}{}}
+	result := symbolicateThread(thread, binaries, "arm64", "Demo", newSymbolCache())
+	backtrace := result["backtrace"].(map[string]interface{})
+
+	contents := backtrace["contents"].([]interface{})
+	if len(contents) != 4 {
+		t.Fatalf("展开后 %d 帧, want 4", len(contents))
+	}
+	wantFiles := []string{"Helper.h", "Foo.m", "Foo.m", "main.m"}
+	for i, f := range contents {
+		frame := f.(map[string]interface{})
+		if got := frame["file_name"]; got != wantFiles[i] {
+			t.Errorf("帧 %d file_name = %v, want %s", i, got, wantFiles[i])
+		}
+		if inlined := getBool(frame, "inlined"); inlined != (i < 2) {
+			t.Errorf("帧 %d inlined = %v", i, inlined)
+		}
+	}
+
+	formatted := formatBacktrace(backtrace, map[string]interface{}{})
+	wantLines := []string{
+		"0   Demo                            0x0000000100000100 inlineHelper  (Helper.h:12) [inlined]",
+		"1   Demo                            0x0000000100000100 -[Foo layout] (Foo.m:40) [inlined]",
+		"2   Demo                            0x0000000100000100 -[Foo run]    (Foo.m:88)",
+		"3   Demo                            0x0000000100000200 main          (main.m:10)",
+	}
+	if got := strings.Split(strings.TrimRight(formatted, "\n"), "\n"); strings.Join(got, "\n") != strings.Join(wantLines, "\n") {
+		t.Errorf("formatBacktrace() =\n%s\nwant\n%s", formatted, strings.Join(wantLines, "\n"))
+	}
+
+	// 其它调用方只取外层实际所在的函数
+	if got := symbolicateAddress("/nonexistent/Demo", 0x100000000, 0x100000100, "arm64"); got != "-[Foo run] (in Demo) (Foo.m:88)" {
+		t.Errorf("symbolicateAddress() = %q", got)
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// Apple .ips 崩溃报告（iOS 15+ / macOS 12+）格式转换
+// ============================================================================
+//
+// .ips 文件由两段 JSON 组成：第一行是头部（app_name、app_version、os_version、bug_type 等），
+// 之后是正文：
+//   threads[]       {triggered, name, queue, threadState, frames[] {imageIndex, imageOffset, symbol, symbolLocation}}
+//   usedImages[]    {base, size, uuid, path, name, arch}
+//   exception       {type, signal, codes, subtype}
+// 上传时识别并转换为内部（KSCrash）结构，之后复用 symbolicateReport / formatReportToAppleStyle
+
+// parseIPSReport 解析 .ips 内容，不是带线程和镜像列表的 .ips 崩溃报告时 ok 为 false
+func parseIPSReport(data []byte) (header, body map[string]interface{}, ok bool) {
+	newline := bytes.IndexByte(data, '\n')
+	if newline < 0 {
+		return nil, nil, false
+	}
+	if err := json.Unmarshal(data[:newline], &header); err != nil {
+		return nil, nil, false
+	}
+	if err := json.Unmarshal(data[newline+1:], &body); err != nil {
+		return nil, nil, false
+	}
+
+	_, hasThreads := body["threads"].([]interface{})
+	_, hasImages := body["usedImages"].([]interface{})
+	return header, body, hasThreads && hasImages
+}
+
+// ipsOSVersionRegex 匹配头部的 os_version: "iPhone OS 17.3 (21D50)"、"macOS 14.2.1 (23C71)"
+var ipsOSVersionRegex = regexp.MustCompile(`^(.*?)\s+(\d[\d.]*)(?:\s+\((\w+)\))?$`)
+
+// ipsRegisterExceptionState 属于异常状态的寄存器，其余放入 basic
+var ipsRegisterExceptionState = map[string]bool{"far": true, "esr": true, "trapno": true, "err": true, "faultvaddr": true}
+
+// convertIPSReport 将 .ips 报告转换为内部报告结构
+func convertIPSReport(header, body map[string]interface{}) map[string]interface{} {
+	bundleInfo, _ := body["bundleInfo"].(map[string]interface{})
+	procPath := getString(body, "procPath")
+
+	// binary_images，帧通过 imageIndex 引用
+	images := []interface{}{}
+	var imagesByIndex []map[string]interface{}
+	cpuArch := ""
+	if srcImages, ok := body["usedImages"].([]interface{}); ok {
+		for _, imgData := range srcImages {
+			img, _ := imgData.(map[string]interface{})
+			path := getString(img, "path")
+			if path == "" {
+				path = getString(img, "name")
+			}
+
+			converted := map[string]interface{}{
+				"name":       path,
+				"uuid":       normalizeUUID(getString(img, "uuid")),
+				"image_addr": float64(crashlyticsAddress(img["base"])),
+				"image_size": float64(crashlyticsAddress(img["size"])),
+			}
+			images = append(images, converted)
+			imagesByIndex = append(imagesByIndex, converted)
+
+			if cpuArch == "" && path != "" && path == procPath {
+				cpuArch = getString(img, "arch")
+			}
+		}
+	}
+	if cpuArch == "" {
+		cpuArch = ipsCPUArch(getString(body, "cpuType"))
+	}
+
+	// 各镜像 __crash_info 中的信息（asi: 镜像名 → 消息列表）
+	if asi, ok := body["asi"].(map[string]interface{}); ok {
+		for _, img := range imagesByIndex {
+			messages, _ := asi[filepath.Base(getString(img, "name"))].([]interface{})
+			for i, key := range []string{"crash_info_message", "crash_info_message2"} {
+				if i < len(messages) {
+					if message, ok := messages[i].(string); ok {
+						img[key] = message
+					}
+				}
+			}
+		}
+	}
+
+	// 线程
+	threads := []interface{}{}
+	if srcThreads, ok := body["threads"].([]interface{}); ok {
+		for i, threadData := range srcThreads {
+			thread, ok := threadData.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			contents := []interface{}{}
+			if frames, ok := thread["frames"].([]interface{}); ok {
+				for _, frameData := range frames {
+					if frame, ok := frameData.(map[string]interface{}); ok {
+						contents = append(contents, convertIPSFrame(frame, imagesByIndex))
+					}
+				}
+			}
+
+			converted := map[string]interface{}{
+				"index":          float64(i),
+				"crashed":        getBool(thread, "triggered"),
+				"name":           getString(thread, "name"),
+				"dispatch_queue": getString(thread, "queue"),
+				"backtrace": map[string]interface{}{
+					"contents": contents,
+				},
+			}
+			if state, ok := thread["threadState"].(map[string]interface{}); ok {
+				converted["registers"] = convertIPSThreadState(state)
+			}
+			threads = append(threads, converted)
+		}
+	}
+
+	systemName, systemVersion, osBuild := getString(header, "os_version"), "", ""
+	if matches := ipsOSVersionRegex.FindStringSubmatch(systemName); matches != nil {
+		systemName, systemVersion, osBuild = matches[1], matches[2], matches[3]
+	}
+	if systemName == "iPhone OS" {
+		systemName = "iOS"
+	}
+
+	appVersion := getString(bundleInfo, "CFBundleShortVersionString")
+	if appVersion == "" {
+		appVersion = getString(header, "app_version")
+	}
+	buildVersion := getString(bundleInfo, "CFBundleVersion")
+	if buildVersion == "" {
+		buildVersion = getString(header, "build_version")
+	}
+	bundleID := getString(bundleInfo, "CFBundleIdentifier")
+	if bundleID == "" {
+		bundleID = getString(header, "bundleID")
+	}
+
+	return map[string]interface{}{
+		"report": map[string]interface{}{
+			"id":        getString(header, "incident_id"),
+			"timestamp": float64(ipsTimestamp(getString(header, "timestamp"))),
+			"type":      "ips",
+		},
+		"system": map[string]interface{}{
+			"process_name":               getString(body, "procName"),
+			"CFBundleExecutable":         getString(body, "procName"),
+			"CFBundleIdentifier":         bundleID,
+			"CFBundleShortVersionString": appVersion,
+			"CFBundleVersion":            buildVersion,
+			"CFBundleExecutablePath":     procPath,
+			"cpu_arch":                   cpuArch,
+			"machine":                    getString(body, "modelCode"),
+			"system_name":                systemName,
+			"system_version":             systemVersion,
+			"os_version":                 osBuild,
+		},
+		"binary_images": images,
+		"crash": map[string]interface{}{
+			"error":   convertIPSException(body),
+			"threads": threads,
+		},
+	}
+}
+
+// convertIPSFrame 转换单帧：运行时地址 = 镜像基地址 + imageOffset
+func convertIPSFrame(frame map[string]interface{}, imagesByIndex []map[string]interface{}) map[string]interface{} {
+	offset := crashlyticsAddress(frame["imageOffset"])
+	converted := map[string]interface{}{}
+
+	imageIndex := int(getInt64(frame, "imageIndex"))
+	if imageIndex >= 0 && imageIndex < len(imagesByIndex) {
+		img := imagesByIndex[imageIndex]
+		converted["object_name"] = filepath.Base(getString(img, "name"))
+		converted["object_addr"] = img["image_addr"]
+		offset += uint64(img["image_addr"].(float64))
+	}
+	converted["instruction_addr"] = float64(offset)
+
+	if symbol := getString(frame, "symbol"); symbol != "" {
+		converted["symbol_name"] = symbol
+		// symbolLocation 为相对符号起始的偏移，可以还原 symbol_addr
+		if location := crashlyticsAddress(frame["symbolLocation"]); location <= offset {
+			converted["symbol_addr"] = float64(offset - location)
+		}
+	}
+
+	return converted
+}
+
+// convertIPSThreadState 将 threadState 转换为 registers.basic / registers.exception
+// arm64 的 x0-x28 以数组给出，其余寄存器为 {"value": n}
+func convertIPSThreadState(state map[string]interface{}) map[string]interface{} {
+	basic := make(map[string]interface{})
+	exception := make(map[string]interface{})
+
+	for name, value := range state {
+		if name == "x" {
+			values, _ := value.([]interface{})
+			for i, v := range values {
+				if reg, ok := v.(map[string]interface{}); ok {
+					if n, ok := reg["value"].(float64); ok {
+						basic[fmt.Sprintf("x%d", i)] = n
+					}
+				}
+			}
+			continue
+		}
+
+		reg, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		n, ok := reg["value"].(float64)
+		if !ok {
+			continue
+		}
+		if ipsRegisterExceptionState[name] {
+			exception[name] = n
+		} else {
+			basic[name] = n
+		}
+	}
+
+	return map[string]interface{}{"basic": basic, "exception": exception}
+}
+
+// convertIPSException 将 exception / termination 转换为 crash.error
+func convertIPSException(body map[string]interface{}) map[string]interface{} {
+	exc, _ := body["exception"].(map[string]interface{})
+	termination, _ := body["termination"].(map[string]interface{})
+
+	// subtype 形如 "KERN_INVALID_ADDRESS at 0x0000000000000010"
+	codeName, address := getString(exc, "subtype"), uint64(0)
+	if i := strings.LastIndex(codeName, " at "); i >= 0 {
+		address = crashlyticsAddress(codeName[i+len(" at "):])
+		codeName = codeName[:i]
+	}
+	if codeName == "" {
+		codeName = getString(exc, "codes")
+	}
+
+	signal := map[string]interface{}{"name": getString(exc, "signal")}
+	if getString(termination, "namespace") == "SIGNAL" {
+		signal["signal"] = float64(getInt64(termination, "code"))
+	}
+
+	return map[string]interface{}{
+		"type":    "mach",
+		"address": float64(address),
+		"mach": map[string]interface{}{
+			"exception_name": getString(exc, "type"),
+			"code_name":      codeName,
+		},
+		"signal": signal,
+	}
+}
+
+// ipsCPUArch 将 cpuType（ARM-64、X86-64）转换为内部的 cpu_arch
+func ipsCPUArch(cpuType string) string {
+	switch strings.ToUpper(cpuType) {
+	case "ARM-64":
+		return "arm64"
+	case "ARM-64E":
+		return "arm64e"
+	case "X86-64":
+		return "x86_64"
+	case "ARM":
+		return "armv7"
+	}
+	return strings.ToLower(cpuType)
+}
+
+// ipsTimestamp 解析头部的时间："2024-03-01 10:00:00.00 +0800"
+func ipsTimestamp(value string) int64 {
+	// 秒之后的小数部分解析时可以省略
+	if t, err := time.Parse("2006-01-02 15:04:05 -0700", value); err == nil {
+		return t.Unix()
+	}
+	return 0
+}
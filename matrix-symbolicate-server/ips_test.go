@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestConvertIPSReport(t *testing.T) {
+	data, err := os.ReadFile("testdata/sample_crash.ips")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header, body, ok := parseIPSReport(data)
+	if !ok {
+		t.Fatal("未识别为 .ips 报告")
+	}
+	if _, _, ok := parseIPSReport([]byte(`{"crash": {"threads": []}}`)); ok {
+		t.Error("内部格式被误判为 .ips 报告")
+	}
+
+	report := convertIPSReport(header, body)
+
+	// 应用镜像可以被 findMatchingDsym 使用的逻辑找到，UUID 已规范化
+	appImage := findAppImage(report)
+	if appImage == nil {
+		t.Fatal("未找到应用镜像")
+	}
+	if got := getString(appImage, "uuid"); got != "FD7CB3D0-06EF-3582-9C99-432ABD79F29C" {
+		t.Errorf("应用镜像 UUID = %s", got)
+	}
+	if arch := reportArch(report); arch != "arm64" {
+		t.Errorf("reportArch() = %s, want arm64", arch)
+	}
+
+	thread := findCrashedThread(report)
+	if thread == nil || getInt64(thread, "index") != 0 {
+		t.Fatalf("崩溃线程错误: %v", thread)
+	}
+
+	frames := thread["backtrace"].(map[string]interface{})["contents"].([]interface{})
+	first := frames[0].(map[string]interface{})
+	if getInt64(first, "instruction_addr") != 0x1000041a0 || getInt64(first, "object_addr") != 0x100000000 || first["object_name"] != "MatrixTestApp" {
+		t.Errorf("第 0 帧错误: %v", first)
+	}
+	second := frames[1].(map[string]interface{})
+	if getInt64(second, "symbol_addr") != 0x1800001c0 {
+		t.Errorf("第 1 帧 symbol_addr 错误: %v", second)
+	}
+
+	formatted := formatReportToAppleStyle(report)
+	for _, want := range []string{
+		"Exception Type:  EXC_BAD_ACCESS (SIGSEGV)",
+		"Exception Codes: KERN_INVALID_ADDRESS at 0x0000000000000010",
+		"abort() called",
+		"Thread 0 Crashed:",
+		"-[UIApplication _run] + 64",
+		"Process:                             MatrixTestApp",
+		"far:",
+	} {
+		if !strings.Contains(formatted, want) {
+			t.Errorf("格式化结果缺少 %q:\n%s", want, formatted)
+		}
+	}
+}
+
+func TestStoreReportConvertsIPS(t *testing.T) {
+	oldReportsDir := ReportsDir
+	ReportsDir = t.TempDir()
+	defer func() { ReportsDir = oldReportsDir }()
+
+	data, err := os.ReadFile("testdata/sample_crash.ips")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, filename, savePath, report, err := storeReport("MatrixTestApp-2024-03-01-101530.ips", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(filename, ".ips.json") || normalizeReportFormat(report) == nil {
+		t.Fatalf("storeReport() = %s, %v", filename, report)
+	}
+
+	// 保存的是转换后的 JSON，sidecar 记录原始文件名和格式
+	meta, ok := readReportMeta(savePath)
+	if !ok || meta.Format != "ips" || meta.OriginalFilename != "MatrixTestApp-2024-03-01-101530.ips" || meta.Device != "iPhone14,2" {
+		t.Errorf("sidecar = %+v, %v", meta, ok)
+	}
+	if problems := validateReportValue(report); len(problems) > 0 {
+		t.Errorf("转换后的报告结构不完整: %v", problems)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ============================================================================
+// 列表接口的分页和排序（/api/report/list、/api/dsym/list）
+// ============================================================================
+
+const (
+	// defaultPageSize 未指定 page_size 时每页的条数
+	defaultPageSize = 50
+	// maxPageSize page_size 的上限，避免一次返回过多数据
+	maxPageSize = 500
+)
+
+// listPage 分页和排序参数
+type listPage struct {
+	Page     int    // 从 1 开始
+	PageSize int    // 每页条数
+	SortKey  string // 排序使用的字段名
+	Desc     bool   // 是否降序
+}
+
+// parseListPage 解析 page、page_size、sort、order 查询参数
+// sortKeys 为 sort 参数可选值到列表项字段名的映射，默认按 defaultSort 降序
+func parseListPage(c *gin.Context, sortKeys map[string]string, defaultSort string) (listPage, error) {
+	p := listPage{Page: 1, PageSize: defaultPageSize, SortKey: sortKeys[defaultSort], Desc: true}
+
+	if value := c.Query("page"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return p, fmt.Errorf("无效的 page: %s", value)
+		}
+		p.Page = n
+	}
+
+	if value := c.Query("page_size"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxPageSize {
+			return p, fmt.Errorf("无效的 page_size: %s（1-%d）", value, maxPageSize)
+		}
+		p.PageSize = n
+	}
+
+	if value := c.Query("sort"); value != "" {
+		key, ok := sortKeys[value]
+		if !ok {
+			return p, fmt.Errorf("不支持的 sort: %s", value)
+		}
+		p.SortKey = key
+	}
+
+	switch c.Query("order") {
+	case "", "desc":
+	case "asc":
+		p.Desc = false
+	default:
+		return p, fmt.Errorf("无效的 order: %s（asc 或 desc）", c.Query("order"))
+	}
+
+	return p, nil
+}
+
+// apply 排序后返回当前页，页码超出范围时返回空列表
+// 排序字段相同时按文件名排序（报告文件名以 ID 开头，即上传顺序），保证翻页结果稳定
+func (p listPage) apply(items []map[string]interface{}) []map[string]interface{} {
+	sort.SliceStable(items, func(i, j int) bool {
+		cmp := compareListValues(items[i][p.SortKey], items[j][p.SortKey])
+		if cmp == 0 {
+			cmp = compareListValues(items[i]["filename"], items[j]["filename"])
+		}
+		if p.Desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+
+	start := (p.Page - 1) * p.PageSize
+	if start >= len(items) {
+		return []map[string]interface{}{}
+	}
+	end := start + p.PageSize
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end]
+}
+
+// compareListValues 比较列表项中的字段值（时间、整数、字符串），类型不同时视为相等
+func compareListValues(a, b interface{}) int {
+	switch av := a.(type) {
+	case time.Time:
+		if bv, ok := b.(time.Time); ok {
+			return av.Compare(bv)
+		}
+	case int64:
+		if bv, ok := b.(int64); ok {
+			return cmp.Compare(av, bv)
+		}
+	case int:
+		if bv, ok := b.(int); ok {
+			return cmp.Compare(av, bv)
+		}
+	case string:
+		if bv, ok := b.(string); ok {
+			return cmp.Compare(av, bv)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestListPage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	parse := func(query string) (listPage, error) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/api/report/list?"+query, nil)
+		return parseListPage(c, reportSortKeys, "uploaded")
+	}
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newItems := func() []map[string]interface{} {
+		var items []map[string]interface{}
+		for i := 0; i < 7; i++ {
+			items = append(items, map[string]interface{}{
+				"filename":       string(rune('a'+i)) + ".json",
+				"uploaded":       base.Add(time.Duration(i) * time.Hour),
+				"size":           int64(100 - i),
+				"dump_type_code": i % 2,
+			})
+		}
+		return items
+	}
+	names := func(items []map[string]interface{}) string {
+		var result string
+		for _, item := range items {
+			result += item["filename"].(string)[:1]
+		}
+		return result
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "gfedcba"},
+		{"page_size=3", "gfe"},
+		{"page=3&page_size=3", "a"},
+		{"page=4&page_size=3", ""},
+		{"page_size=7", "gfedcba"},
+		{"sort=size&order=asc&page_size=2", "gf"},
+		{"sort=dump_type&order=asc", "acegbdf"},
+	}
+	for _, tt := range tests {
+		p, err := parse(tt.query)
+		if err != nil {
+			t.Errorf("%q: parseListPage() error = %v", tt.query, err)
+			continue
+		}
+		if got := names(p.apply(newItems())); got != tt.want {
+			t.Errorf("%q: 返回 %q, want %q", tt.query, got, tt.want)
+		}
+	}
+
+	p, _ := parse("")
+	if p.Page != 1 || p.PageSize != defaultPageSize {
+		t.Errorf("默认分页 = %d/%d, want 1/%d", p.Page, p.PageSize, defaultPageSize)
+	}
+
+	for _, query := range []string{"page=0", "page=x", "page_size=0", "page_size=501", "sort=name", "order=up"} {
+		if _, err := parse(query); err == nil {
+			t.Errorf("%q: 期望返回错误", query)
+		}
+	}
+}
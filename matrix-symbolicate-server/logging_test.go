@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestIDPropagatesIntoHandlerLogs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	oldDir := ReportsDir
+	ReportsDir = t.TempDir()
+	defer func() { ReportsDir = oldDir }()
+
+	// slog.SetDefault 会同时重定向标准库 log，测试结束后一并恢复
+	var logs bytes.Buffer
+	oldLogger, oldWriter, oldFlags := slog.Default(), log.Writer(), log.Flags()
+	slog.SetDefault(slog.New(newLogHandler("json", &logs)))
+	defer func() {
+		slog.SetDefault(oldLogger)
+		log.SetOutput(oldWriter)
+		log.SetFlags(oldFlags)
+	}()
+
+	r := gin.New()
+	r.Use(requestIDMiddleware())
+	r.POST("/upload", uploadReportHandler)
+
+	upload := func(requestID string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		part, _ := mw.CreateFormFile("file", "crash.json")
+		part.Write([]byte(`{"crash": {}}`))
+		mw.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		if requestID != "" {
+			req.Header.Set(requestIDHeader, requestID)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := upload("req-123")
+	if w.Code != http.StatusOK {
+		t.Fatalf("状态码 = %d, body = %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get(requestIDHeader); got != "req-123" {
+		t.Errorf("响应头 %s = %q, want req-123", requestIDHeader, got)
+	}
+
+	var resp struct {
+		ReportID string `json:"report_id"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+
+	uploaded, completed := false, false
+	scanner := bufio.NewScanner(&logs)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("日志不是 JSON: %s", scanner.Text())
+		}
+		if entry["request_id"] != "req-123" {
+			continue
+		}
+		switch entry["msg"] {
+		case "报告已上传":
+			uploaded = entry["report_id"] == resp.ReportID
+		case "请求完成":
+			completed = entry["status"] == float64(http.StatusOK)
+		}
+	}
+	if !uploaded || !completed {
+		t.Errorf("handler 日志中缺少 request_id / report_id (uploaded=%v, completed=%v):\n%s", uploaded, completed, logs.String())
+	}
+
+	// 未携带时自动生成
+	if got := upload("").Header().Get(requestIDHeader); len(got) != 16 {
+		t.Errorf("自动生成的 request_id = %q, want 16 位十六进制", got)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"debug/macho"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ============================================================================
+// 内置 Mach-O 解析（不依赖 dwarfdump）
+// ============================================================================
+
+const (
+	loadCmdUUID     macho.LoadCmd = 0x1b       // LC_UUID
+	cpuArchABI64_32 macho.Cpu     = 0x02000000 // CPU_ARCH_ABI64_32
+	cpuArm64_32                   = macho.CpuArm | cpuArchABI64_32
+)
+
+// readMachOSlices 使用 debug/macho 读取二进制每个架构 slice 的 UUID
+// 通用二进制（fat）返回所有 slice，单架构二进制返回一个
+func readMachOSlices(binaryPath string) ([]DsymSlice, error) {
+	if fat, err := macho.OpenFat(binaryPath); err == nil {
+		defer fat.Close()
+		var slices []DsymSlice
+		for _, fa := range fat.Arches {
+			if uuid := machoFileUUID(fa.File); uuid != "" {
+				slices = append(slices, DsymSlice{UUID: uuid, Arch: machoArchName(fa.Cpu, fa.SubCpu)})
+			}
+		}
+		if len(slices) == 0 {
+			return nil, fmt.Errorf("未找到 LC_UUID")
+		}
+		return slices, nil
+	}
+
+	f, err := macho.Open(binaryPath)
+	if err != nil {
+		return nil, fmt.Errorf("不是有效的 Mach-O 文件: %v", err)
+	}
+	defer f.Close()
+
+	uuid := machoFileUUID(f)
+	if uuid == "" {
+		return nil, fmt.Errorf("未找到 LC_UUID")
+	}
+	return []DsymSlice{{UUID: uuid, Arch: machoArchName(f.Cpu, f.SubCpu)}}, nil
+}
+
+// readMachOUUID 使用 debug/macho 读取二进制的 UUID 和架构
+// 通用二进制（fat）返回第一个 slice
+func readMachOUUID(binaryPath string) (uuid string, arch string, err error) {
+	slices, err := readMachOSlices(binaryPath)
+	if err != nil {
+		return "", "", err
+	}
+	return slices[0].UUID, slices[0].Arch, nil
+}
+
+// machoFileUUID 从 load commands 中读取 LC_UUID
+func machoFileUUID(f *macho.File) string {
+	for _, load := range f.Loads {
+		raw := load.Raw()
+		if len(raw) < 24 {
+			continue
+		}
+		if macho.LoadCmd(f.ByteOrder.Uint32(raw[0:4])) != loadCmdUUID {
+			continue
+		}
+		u := raw[8:24]
+		return normalizeUUID(fmt.Sprintf("%x", u))
+	}
+	return ""
+}
+
+// machoArchName 将 Mach-O cpu type / subtype 转换为 atos 使用的架构名
+func machoArchName(cpu macho.Cpu, subCpu uint32) string {
+	sub := subCpu & 0x00ffffff // 去掉 CPU_SUBTYPE_MASK 中的能力位
+
+	switch cpu {
+	case macho.CpuArm64:
+		if sub == 2 {
+			return "arm64e"
+		}
+		return "arm64"
+	case cpuArm64_32:
+		return "arm64_32"
+	case macho.CpuArm:
+		switch sub {
+		case 11:
+			return "armv7s"
+		case 12:
+			return "armv7k"
+		}
+		return "armv7"
+	case macho.CpuAmd64:
+		return "x86_64"
+	case macho.Cpu386:
+		return "i386"
+	}
+
+	return strings.ToLower(cpu.String())
+}
+
+// machoSymbol 符号表中的函数及其地址范围（未加 slide 的 vmaddr）
+type machoSymbol struct {
+	Name  string `json:"name"`
+	Start uint64 `json:"start"`
+	Size  uint64 `json:"size"`
+	Arch  string `json:"arch"`
+}
+
+// lookupMachOSymbol 在二进制的符号表（LC_SYMTAB）中按名称查找函数
+// 大小按同一 section 中下一个符号的地址推算；arch 为空时使用第一个 slice
+func lookupMachOSymbol(binaryPath, name, arch string) (*machoSymbol, error) {
+	f, sliceArch, closeFile, err := openMachOSlice(binaryPath, arch)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFile()
+
+	if f.Symtab == nil {
+		return nil, fmt.Errorf("二进制中没有符号表")
+	}
+
+	// 只保留定义在 section 中的符号（N_SECT），按地址排序用于推算大小
+	const nTypeMask, nSect = 0x0e, 0x0e
+	var defined []macho.Symbol
+	for _, sym := range f.Symtab.Syms {
+		if sym.Type&nTypeMask == nSect && sym.Sect != 0 {
+			defined = append(defined, sym)
+		}
+	}
+	sort.Slice(defined, func(i, j int) bool { return defined[i].Value < defined[j].Value })
+
+	for i, sym := range defined {
+		// C 函数在符号表中带有前导下划线
+		if sym.Name != name && sym.Name != "_"+name {
+			continue
+		}
+
+		result := &machoSymbol{Name: sym.Name, Start: sym.Value, Arch: sliceArch}
+		for _, next := range defined[i+1:] {
+			if next.Sect == sym.Sect && next.Value > sym.Value {
+				result.Size = next.Value - sym.Value
+				break
+			}
+		}
+		if result.Size == 0 && int(sym.Sect) <= len(f.Sections) {
+			// 最后一个符号：延伸到 section 末尾
+			sect := f.Sections[sym.Sect-1]
+			result.Size = sect.Addr + sect.Size - sym.Value
+		}
+		return result, nil
+	}
+
+	return nil, nil
+}
+
+// openMachOSlice 打开二进制中指定架构的 slice，arch 为空时使用第一个 slice
+// 使用完后调用 closeFile 关闭文件
+func openMachOSlice(binaryPath, arch string) (f *macho.File, sliceArch string, closeFile func(), err error) {
+	if fat, err := macho.OpenFat(binaryPath); err == nil {
+		for _, fa := range fat.Arches {
+			if arch == "" || machoArchName(fa.Cpu, fa.SubCpu) == arch {
+				return fa.File, machoArchName(fa.Cpu, fa.SubCpu), func() { fat.Close() }, nil
+			}
+		}
+		fat.Close()
+		return nil, "", nil, fmt.Errorf("符号表中没有架构 %s", arch)
+	}
+
+	thin, err := macho.Open(binaryPath)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("不是有效的 Mach-O 文件: %v", err)
+	}
+	sliceArch = machoArchName(thin.Cpu, thin.SubCpu)
+	if arch != "" && arch != sliceArch {
+		thin.Close()
+		return nil, "", nil, fmt.Errorf("符号表中没有架构 %s", arch)
+	}
+	return thin, sliceArch, func() { thin.Close() }, nil
+}
+
+// machoTextVMAddr 返回指定架构 slice 中 __TEXT 段的 vmaddr（未加 slide 的基址）
+func machoTextVMAddr(binaryPath, arch string) (uint64, error) {
+	f, _, closeFile, err := openMachOSlice(binaryPath, arch)
+	if err != nil {
+		return 0, err
+	}
+	defer closeFile()
+
+	seg := f.Segment("__TEXT")
+	if seg == nil {
+		return 0, fmt.Errorf("二进制中没有 __TEXT 段")
+	}
+	return seg.Addr, nil
+}
@@ -1,12 +1,19 @@
 package main
 
 import (
+	"archive/zip"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,14 +21,22 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-const (
-	UploadDir     = "./uploads"
-	DsymDir       = "./dsyms"
-	ReportsDir    = "./reports"
-	MaxUploadSize = 500 * 1024 * 1024 // 500MB
+// 存储目录和上传大小上限：环境变量优先（UPLOAD_DIR、DSYM_DIR、REPORTS_DIR、MAX_UPLOAD_SIZE），
+// 未设置或无效时使用默认值
+var (
+	UploadDir     = envString("UPLOAD_DIR", "./uploads")
+	DsymDir       = envString("DSYM_DIR", "./dsyms")
+	ReportsDir    = envString("REPORTS_DIR", "./reports")
+	MaxUploadSize = int64(envInt("MAX_UPLOAD_SIZE", 500*1024*1024)) // 500MB
 )
 
+// maxMultipartMemory multipart 解析时留在内存中的上限，超出部分写入临时文件
+const maxMultipartMemory = 32 << 20
+
 func main() {
+	// 结构化日志：LOG_FORMAT=json 输出 JSON，默认输出文本
+	setupLogging(os.Getenv("LOG_FORMAT"))
+
 	// 创建必要的目录
 	dirs := []string{UploadDir, DsymDir, ReportsDir}
 	for _, dir := range dirs {
@@ -30,19 +45,49 @@ func main() {
 		}
 	}
 
+	// 旧版本平铺存放的报告和符号表迁移到日期分区
+	if migrated := migrateFlatReports(ReportsDir); migrated > 0 {
+		log.Printf("📦 已将 %d 个报告文件迁移到日期分区", migrated)
+	}
+	if migrated := migrateFlatDsyms(DsymDir); migrated > 0 {
+		log.Printf("📦 已将 %d 个符号表迁移到月份分区", migrated)
+	}
+
+	// 后台清理超过保留期限的报告（REPORT_RETENTION_DAYS，默认永久保留）
+	startReportJanitor(ReportsDir, reportRetentionDays())
+
+	// 为建立索引之前上传的符号表补建 UUID 索引
+	if indexed := reindexDsyms(DsymDir); indexed > 0 {
+		log.Printf("🗂️  已为 %d 个符号表建立 UUID 索引", indexed)
+	}
+
+	// 未注册 dump 类型的兜底格式化策略
+	if name := os.Getenv("DEFAULT_REPORT_STYLE"); name != "" {
+		if style, ok := parseReportStyle(name); ok {
+			defaultReportStyle = style
+		} else {
+			log.Printf("警告: 未知的 DEFAULT_REPORT_STYLE=%s，使用默认值 %s", name, defaultReportStyle)
+		}
+	}
+
+	// 设备型号映射：devices.json 覆盖内置映射
+	reloadDevices()
+
+	// 符号化后端：SYMBOLIZER 指定，未指定时自动检测 atos / llvm-symbolizer
+	activeSymbolizer = selectSymbolizer(os.Getenv("SYMBOLIZER"))
+
 	// 设置 Gin
 	gin.SetMode(gin.ReleaseMode)
-	r := gin.Default()
+	// 访问日志由 requestIDMiddleware 以结构化格式输出，不使用 gin 自带的 Logger
+	r := gin.New()
+	r.Use(gin.Recovery(), requestIDMiddleware())
+	r.MaxMultipartMemory = maxMultipartMemory
+	if MaxUploadSize < maxMultipartMemory {
+		r.MaxMultipartMemory = MaxUploadSize
+	}
 
 	// 配置 CORS
-	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
-	}))
+	r.Use(cors.New(corsConfig(corsAllowedOrigins)))
 
 	// 静态文件服务
 	r.Static("/static", "./static")
@@ -57,24 +102,75 @@ func main() {
 	})
 
 	// API 路由
+	// 写操作（POST/DELETE）需要 X-API-Key，只读接口保持开放
 	api := r.Group("/api")
+	api.Use(requireAPIKey(apiKeys))
 	{
 		// 符号表管理
 		api.POST("/dsym/upload", uploadDsymHandler)
 		api.GET("/dsym/list", listDsymHandler)
 		api.DELETE("/dsym/:uuid", deleteDsymHandler)
+		api.GET("/dsym/:uuid/symbol", lookupDsymSymbolHandler)
+		api.GET("/dsym/:uuid/download", downloadDsymHandler)
 
 		// 日志上传和符号化
 		api.POST("/report/upload", uploadReportHandler)
-		api.POST("/report/symbolicate", symbolicateReportHandler)
+		api.POST("/report/symbolicate", limitSymbolication(), symbolicateReportHandler)
+		api.POST("/report/symbolicate/batch", limitSymbolication(), symbolicateBatchHandler)
+		api.POST("/report/upload-and-symbolicate", limitSymbolication(), uploadAndSymbolicateHandler)
+		api.POST("/report/bulk-upload", bulkUploadReportsHandler)
+		api.GET("/report/bulk-upload/:job", getBulkJobHandler)
 		api.GET("/report/list", listReportsHandler)
+		api.GET("/report/list.csv", listReportsCSVHandler)
+		api.GET("/report/export", exportReportsHandler)
 		api.GET("/report/:id", getReportHandler)
 		api.GET("/report/:id/formatted", getFormattedReportHandler)
+		api.GET("/report/:id/download", downloadReportHandler)
+		api.GET("/report/:id/oneline", getOnelineHandler)
+		api.GET("/report/:id/type", getReportTypeHandler)
+		api.GET("/report/:id/crashed-thread", getCrashedThreadHandler)
+		api.GET("/report/:id/crashed-thread/resolved", getCrashedThreadResolvedHandler)
+		api.GET("/report/:id/top-thread", getTopThreadHandler)
+		api.GET("/report/:id/coverage", getReportCoverageHandler)
+		api.POST("/report/:id/resymbolicate", limitSymbolication(), resymbolicateReportHandler)
+		api.POST("/report/:id/symbolicate-addresses", limitSymbolication(), symbolicateAddressesHandler)
 		api.DELETE("/report/:id", deleteReportHandler)
 
-		// 健康检查
+		// 直接符号化日志中的地址，无需上传报告
+		api.POST("/symbolicate/raw", limitSymbolication(), symbolicateRawHandler)
+
+		// 符号缓存
+		api.GET("/cache/stats", func(c *gin.Context) {
+			c.JSON(http.StatusOK, atosSymbolCache.stats())
+		})
+
+		// 重新加载 devices.json，更新新机型无需重启
+		api.POST("/devices/reload", func(c *gin.Context) {
+			n, err := reloadDevices()
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"message": "设备映射已重新加载", "file": devicesFile, "count": n})
+		})
+
+		// 报告统计：按 dump_type 汇总数量、符号化情况和磁盘占用
+		api.GET("/stats", func(c *gin.Context) {
+			stats, err := reportStats(ReportsDir)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, stats)
+		})
+
+		// 健康检查：启动时工具缺失或目录不可写时 status 为 degraded（HTTP 状态码仍为 200）
 		api.GET("/health", func(c *gin.Context) {
-			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+			c.JSON(http.StatusOK, healthLiveness())
+		})
+		// 完整诊断：包含工具和目录路径，需要 X-API-Key；同时刷新存活检查使用的状态
+		api.GET("/health/details", requireAPIKeyForRead(apiKeys), func(c *gin.Context) {
+			c.JSON(http.StatusOK, recordStartupHealth())
 		})
 	}
 
@@ -88,6 +184,27 @@ func main() {
 	log.Printf("📱 访问地址: http://localhost:%s", port)
 	log.Printf("📂 符号表目录: %s", DsymDir)
 	log.Printf("📋 报告目录: %s", ReportsDir)
+	log.Printf("📦 上传大小上限: %s", formatBytes(MaxUploadSize))
+	if health := recordStartupHealth(); health["status"] != "ok" {
+		log.Printf("警告: 健康检查未通过: %v", health["problems"])
+	}
+	if activeSymbolizer.available() {
+		log.Printf("🔧 符号化工具: %s", activeSymbolizer.name())
+	} else {
+		log.Printf("警告: 未找到符号化工具 %s，报告将无法符号化", activeSymbolizer.name())
+	}
+	if len(apiKeys) == 0 {
+		log.Printf("警告: 未配置 API_KEYS，上传、符号化和删除接口对所有人开放")
+	} else {
+		log.Printf("🔑 写操作需要 %s（已配置 %d 个）", apiKeyHeader, len(apiKeys))
+	}
+	if developerDir != "" {
+		if _, err := os.Stat(developerDir); err != nil {
+			log.Printf("警告: DEVELOPER_DIR=%s 不存在，atos/dwarfdump 可能无法执行", developerDir)
+		} else {
+			log.Printf("🛠️  Xcode 工具链: %s", developerDir)
+		}
+	}
 
 	if err := r.Run(":" + port); err != nil {
 		log.Fatalf("启动服务器失败: %v", err)
@@ -96,378 +213,1573 @@ func main() {
 
 // uploadDsymHandler 处理符号表上传
 func uploadDsymHandler(c *gin.Context) {
-	file, err := c.FormFile("file")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "文件上传失败: " + err.Error()})
+	file, ok := uploadedFile(c)
+	if !ok {
 		return
 	}
 
 	// 验证文件类型
-	if !strings.HasSuffix(file.Filename, ".dSYM.zip") && !strings.HasSuffix(file.Filename, ".app") {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "仅支持 .dSYM.zip 或 .app 文件"})
+	if !isDsymArchive(file.Filename) && !strings.HasSuffix(file.Filename, ".app") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "仅支持 .dSYM.zip、.dSYM.tar.gz 或 .app 文件"})
 		return
 	}
 
-	// 保存文件
+	// ?symbolicate_report=<id>：上传成功后符号化等待符号表的报告，报告不存在时不保存符号表
+	pendingReportID := c.Query("symbolicate_report")
+	if pendingReportID != "" && findReportFile(pendingReportID) == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "报告不存在: " + pendingReportID})
+		return
+	}
+
+	// 校验 dSYM 内容，只保存有效的符号表
+	if isDsymArchive(file.Filename) {
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "读取上传文件失败: " + err.Error()})
+			return
+		}
+		if strings.HasSuffix(file.Filename, ".tar.gz") {
+			err = validateDsymTarball(f)
+		} else {
+			err = validateDsymArchive(f, file.Size)
+		}
+		f.Close()
+		if err != nil {
+			log.Printf("⚠️ 拒绝无效的符号表 %s: %v", file.Filename, err)
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "无效的 dSYM: " + err.Error()})
+			return
+		}
+	}
+
+	// 先以隐藏文件保存，读取 UUID 并查重后再改为正式文件名
 	timestamp := time.Now().Format("20060102_150405")
 	filename := fmt.Sprintf("%s_%s", timestamp, filepath.Base(file.Filename))
-	filepath := filepath.Join(DsymDir, filename)
+	savePath := dsymStorePath(DsymDir, filename)
+	uploadingPath := filepath.Join(DsymDir, uploadingDsymPrefix+filename)
 
-	if err := c.SaveUploadedFile(file, filepath); err != nil {
+	if err := c.SaveUploadedFile(file, uploadingPath); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存文件失败: " + err.Error()})
 		return
 	}
+	discard := func() {
+		os.RemoveAll(uploadingPath)
+		evictDsymInfo(uploadingPath)
+	}
 
-	// 提取 UUID
-	uuid, arch, err := extractDsymInfo(filepath)
-	if err != nil {
-		log.Printf("警告: 提取 dSYM 信息失败: %v", err)
+	// 提取 UUID（通用 dSYM 包含多个架构），读不出 UUID 的符号表无法匹配任何报告，删除并拒绝
+	slices, err := cachedDsymInfo(uploadingPath)
+	uuid, arch := primarySlice(slices)
+	if uuid == "" {
+		discard()
+
+		reason := "无法从符号表中提取 UUID"
+		if strings.HasSuffix(file.Filename, ".app") {
+			reason = "未找到 .app 内的二进制文件或无法读取其 UUID"
+		}
+		if err != nil {
+			reason += ": " + err.Error()
+		}
+		log.Printf("⚠️ 拒绝无效的符号表 %s: %s", file.Filename, reason)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": reason})
+		return
 	}
 
-	log.Printf("✅ 符号表上传成功: %s (UUID: %s, Arch: %s)", filename, uuid, arch)
+	// 查重：任意架构的 UUID 已存在即视为重复，?overwrite=1 时替换已有的符号表
+	var existing, replaced []string
+	for _, slice := range slices {
+		dsymPath := findDsymByUUID(DsymDir, slice.UUID)
+		if dsymPath == "" || (len(existing) > 0 && existing[len(existing)-1] == dsymPath) {
+			continue
+		}
+		existing = append(existing, dsymPath)
+		replaced = append(replaced, filepath.Base(dsymPath))
+	}
+	if len(existing) > 0 {
+		if q := c.Query("overwrite"); q != "1" && q != "true" {
+			discard()
+			log.Printf("⚠️ 拒绝重复的符号表 %s (UUID: %s)，已存在: %s", file.Filename, uuid, strings.Join(replaced, ", "))
+			c.JSON(http.StatusConflict, gin.H{
+				"error":          "相同 UUID 的符号表已存在，使用 ?overwrite=1 替换",
+				"uuid":           uuid,
+				"existing_files": replaced,
+			})
+			return
+		}
+		for _, dsymPath := range existing {
+			os.Remove(dsymPath)
+			evictDsymInfo(dsymPath)
+			unindexDsym(DsymDir, filepath.Base(dsymPath))
+			removeEmptyPartitions(DsymDir, filepath.Dir(dsymPath))
+		}
+		log.Printf("♻️ 替换已有的符号表: %s", strings.Join(replaced, ", "))
+	}
 
-	c.JSON(http.StatusOK, gin.H{
+	if err := os.MkdirAll(filepath.Dir(savePath), 0755); err != nil {
+		discard()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存文件失败: " + err.Error()})
+		return
+	}
+	if err := os.Rename(uploadingPath, savePath); err != nil {
+		discard()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存文件失败: " + err.Error()})
+		return
+	}
+	moveDsymInfo(uploadingPath, savePath)
+	if err := indexDsym(DsymDir, savePath, slices); err != nil {
+		log.Printf("⚠️ 建立符号表索引失败 %s: %v", filename, err)
+	}
+
+	log.Printf("✅ 符号表上传成功: %s (UUID: %s, Arch: %s, 共 %d 个架构)", filename, uuid, arch, len(slices))
+
+	resp := gin.H{
 		"message":  "符号表上传成功",
 		"filename": filename,
 		"uuid":     uuid,
 		"arch":     arch,
+		"slices":   slices,
 		"size":     file.Size,
-	})
+	}
+	if len(replaced) > 0 {
+		resp["replaced"] = replaced
+	}
+	if pendingReportID != "" {
+		resp["symbolication"] = symbolicatePendingReport(c, pendingReportID, filename, slices)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// symbolicatePendingReport 用刚上传的符号表符号化指定报告，返回结果放入上传响应的 symbolication 字段
+// 符号表的 UUID 与报告应用镜像不一致时不符号化；符号表已保存，失败不影响上传结果
+func symbolicatePendingReport(c *gin.Context, reportID, dsymFile string, slices []DsymSlice) gin.H {
+	result := gin.H{"report_id": reportID}
+
+	reportUUID, err := storedReportAppUUID(reportID)
+	if err != nil {
+		result["status"] = "failed"
+		result["error"] = err.Error()
+		return result
+	}
+	if reportUUID == "" || !dsymHasUUID(slices, reportUUID) {
+		result["status"] = "uuid_mismatch"
+		result["error"] = fmt.Sprintf("符号表的 UUID 与报告应用镜像的 UUID %s 不一致，未符号化", normalizeUUID(reportUUID))
+		return result
+	}
+
+	// 与符号化接口共用并发名额
+	release, err := acquireSymbolicationSlot(c.Request.Context())
+	if err != nil {
+		result["status"] = "busy"
+		result["error"] = err.Error()
+		return result
+	}
+	defer release()
+
+	symbolicated, _, errBody := symbolicateStoredReport(requestLogger(c), reportID, dsymFile, symbolicateOptions{})
+	if errBody != nil {
+		result["status"] = "failed"
+		result["error"] = errBody["error"]
+		return result
+	}
+	result["status"] = "symbolicated"
+	result["timing"] = symbolicationTiming(symbolicated)
+	result["result"] = symbolicated
+	return result
+}
+
+// storedReportAppUUID 读取已保存报告中应用镜像的 UUID
+func storedReportAppUUID(reportID string) (string, error) {
+	data, err := os.ReadFile(findReportFile(reportID))
+	if err != nil {
+		return "", fmt.Errorf("读取报告失败: %v", err)
+	}
+	var report interface{}
+	if err := parseReportData(data, &report); err != nil {
+		return "", fmt.Errorf("报告格式错误: %v", err)
+	}
+	return getString(findAppImage(normalizeReportFormat(report)), "uuid"), nil
+}
+
+// dsymSortKeys 符号表列表 sort 参数对应的字段
+var dsymSortKeys = map[string]string{
+	"uploaded": "modified",
+	"size":     "size",
 }
 
-// listDsymHandler 列出所有符号表
+// listDsymHandler 分页列出符号表，默认按上传时间倒序
 func listDsymHandler(c *gin.Context) {
-	files, err := os.ReadDir(DsymDir)
+	page, err := parseListPage(c, dsymSortKeys, "uploaded")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dsyms, warning, err := listDsyms(DsymDir)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	var dsyms []map[string]interface{}
-	for _, file := range files {
-		if file.IsDir() {
-			continue
+	resp := gin.H{
+		"dsyms":     page.apply(dsyms),
+		"total":     len(dsyms),
+		"page":      page.Page,
+		"page_size": page.PageSize,
+	}
+	if warning != "" {
+		resp["warning"] = warning
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// listDsyms 读取目录（含年/月分区）中的符号表信息
+// 有符号表无法读取 UUID 且外部工具缺失时，返回解释原因的 warning
+func listDsyms(dir string) (dsyms []map[string]interface{}, warning string, err error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, "", err
+	}
+
+	// 只检测一次工具是否可用
+	var missingTools []string
+	for _, tool := range []string{"dwarfdump", "unzip"} {
+		if !toolAvailable(tool) {
+			missingTools = append(missingTools, tool)
 		}
+	}
 
-		info, _ := file.Info()
-		filepath := filepath.Join(DsymDir, file.Name())
-		uuid, arch, _ := extractDsymInfo(filepath)
+	blankCount := 0
+	for _, filepath := range storedDsymFiles(dir) {
+		info, err := os.Stat(filepath)
+		if err != nil {
+			continue
+		}
+		slices, _ := cachedDsymInfo(filepath)
+		uuid, arch := primarySlice(slices)
+		if uuid == "" {
+			blankCount++
+		}
 
 		dsyms = append(dsyms, map[string]interface{}{
-			"filename": file.Name(),
+			"filename": info.Name(),
 			"size":     info.Size(),
 			"modified": info.ModTime(),
 			"uuid":     uuid,
 			"arch":     arch,
+			"slices":   slices,
 		})
 	}
 
-	c.JSON(http.StatusOK, gin.H{"dsyms": dsyms})
+	if blankCount > 0 && len(missingTools) > 0 {
+		warning = fmt.Sprintf("未找到工具 %s，内置 Mach-O 解析也无法读取其中 %d 个符号表，因此 UUID/架构为空",
+			strings.Join(missingTools, ", "), blankCount)
+	}
+
+	return dsyms, warning, nil
 }
 
 // deleteDsymHandler 删除符号表
 func deleteDsymHandler(c *gin.Context) {
 	filename := c.Param("uuid")
-	filepath := filepath.Join(DsymDir, filename)
+	dsymPath, err := resolveDsymFile(DsymDir, filename)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	if err := os.Remove(filepath); err != nil {
+	if err := os.Remove(dsymPath); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	evictDsymInfo(dsymPath)
+	unindexDsym(DsymDir, filename)
+	removeEmptyPartitions(DsymDir, filepath.Dir(dsymPath))
 
 	log.Printf("🗑️  删除符号表: %s", filename)
 	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
 }
 
-// uploadReportHandler 处理报告上传
-func uploadReportHandler(c *gin.Context) {
-	file, err := c.FormFile("file")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "文件上传失败: " + err.Error()})
+// lookupDsymSymbolHandler 反向符号化：查找函数名在符号表中的起始地址和大小
+// :uuid 可以是 dSYM 的 UUID（任意架构），也可以是符号表文件名
+func lookupDsymSymbolHandler(c *gin.Context) {
+	name := c.Query("name")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少 name 参数"})
 		return
 	}
 
-	// 验证文件类型
-	if !strings.HasSuffix(file.Filename, ".json") && !strings.HasSuffix(file.Filename, ".txt") {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "仅支持 .json 或 .txt 文件"})
+	dsymPath := resolveDsymParam(c.Param("uuid"))
+	if dsymPath == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "符号表不存在"})
 		return
 	}
 
-	// 生成唯一ID
-	reportID := fmt.Sprintf("%d", time.Now().UnixNano())
-	filename := fmt.Sprintf("%s_%s", reportID, filepath.Base(file.Filename))
-	savePath := filepath.Join(ReportsDir, filename)
-
-	if err := c.SaveUploadedFile(file, savePath); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存文件失败: " + err.Error()})
+	binaryPath, _, cleanup, err := getBinaryInfo(dsymPath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	defer cleanup()
 
-	// 检测报告格式
-	data, err := os.ReadFile(savePath)
-	if err == nil {
-		var jsonData interface{}
-		if err := json.Unmarshal(data, &jsonData); err == nil {
-			if _, isArray := jsonData.([]interface{}); isArray {
-				log.Printf("📥 报告上传成功: %s [数组格式]", filename)
-			} else if _, isMap := jsonData.(map[string]interface{}); isMap {
-				log.Printf("📥 报告上传成功: %s [字典格式]", filename)
-			} else {
-				log.Printf("📥 报告上传成功: %s [未知格式]", filename)
-			}
-		} else {
-			log.Printf("📥 报告上传成功: %s [非JSON格式]", filename)
-		}
-	} else {
-		log.Printf("📥 报告上传成功: %s", filename)
+	// 直接读取 LC_SYMTAB（与 nm 输出一致），不依赖外部工具
+	symbol, err := lookupMachOSymbol(binaryPath, name, c.Query("arch"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if symbol == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未找到符号: " + name})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":   "报告上传成功",
-		"report_id": reportID,
-		"filename":  filename,
+		"name":          symbol.Name,
+		"arch":          symbol.Arch,
+		"start_address": fmt.Sprintf("0x%x", symbol.Start),
+		"end_address":   fmt.Sprintf("0x%x", symbol.Start+symbol.Size),
+		"size":          symbol.Size,
+		"dsym_file":     filepath.Base(dsymPath),
 	})
 }
 
-// symbolicateReportHandler 符号化报告
-func symbolicateReportHandler(c *gin.Context) {
-	var req struct {
-		ReportID string `json:"report_id" binding:"required"`
-		DsymFile string `json:"dsym_file"`
+// downloadDsymHandler 按 UUID（或文件名）下载符号表，支持 Range 断点续传
+// 供其它服务把本服务当作共享的符号表仓库使用
+func downloadDsymHandler(c *gin.Context) {
+	dsymPath := resolveDsymParam(c.Param("uuid"))
+	if dsymPath == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "符号表不存在"})
+		return
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	f, err := os.Open(dsymPath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取符号表失败"})
 		return
 	}
+	defer f.Close()
 
-	// 查找报告文件
-	reportFile := findReportFile(req.ReportID)
-	if reportFile == "" {
-		c.JSON(http.StatusNotFound, gin.H{"error": "报告不存在"})
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取符号表失败"})
 		return
 	}
 
-	// 读取报告
-	data, err := os.ReadFile(reportFile)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取报告失败"})
-		return
+	name := originalDsymName(filepath.Base(dsymPath))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	if strings.HasSuffix(name, ".app") {
+		c.Header("Content-Type", "application/octet-stream")
 	}
 
-	// 解析 JSON
-	var report interface{}
-	if err := json.Unmarshal(data, &report); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "报告格式错误"})
-		return
+	// ServeContent 处理 Range / If-Modified-Since，并按扩展名设置 Content-Type
+	http.ServeContent(c.Writer, c.Request, name, info.ModTime(), f)
+}
+
+// originalDsymName 去掉上传时添加的 YYYYMMDD_HHMMSS_ 前缀，还原原始文件名
+func originalDsymName(filename string) string {
+	if len(filename) > 16 && filename[8] == '_' && filename[15] == '_' {
+		if _, err := time.Parse("20060102_150405", filename[:15]); err == nil {
+			return filename[16:]
+		}
 	}
+	return filename
+}
 
-	// 查找匹配的符号表
-	dsymPath := ""
-	if req.DsymFile != "" {
-		dsymPath = filepath.Join(DsymDir, req.DsymFile)
-	} else {
-		// 自动匹配
-		dsymPath = findMatchingDsym(report)
+// resolveDsymParam 将路由参数解析为符号表路径：先按文件名，再按 UUID 匹配
+func resolveDsymParam(param string) string {
+	if path, err := resolveDsymFile(DsymDir, param); err == nil {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
 	}
 
-	if dsymPath == "" {
-		c.JSON(http.StatusNotFound, gin.H{"error": "未找到匹配的符号表"})
+	return findDsymByUUID(DsymDir, param)
+}
+
+// uploadReportHandler 处理报告上传
+func uploadReportHandler(c *gin.Context) {
+	file, ok := uploadedFile(c)
+	if !ok {
 		return
 	}
 
-	// 执行符号化
-	log.Printf("🔍 开始符号化: report=%s, dsym=%s", reportFile, dsymPath)
-	symbolicated, err := symbolicateReport(report, dsymPath)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "符号化失败: " + err.Error()})
+	// 验证文件类型
+	if !isSupportedReportFile(file.Filename) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "仅支持 .json、.txt 或 .ips 文件"})
 		return
 	}
 
-	// 保存符号化结果
-	outputFile := strings.Replace(reportFile, ".json", "_symbolicated.json", 1)
-	outputData, _ := json.MarshalIndent(symbolicated, "", "  ")
-	os.WriteFile(outputFile, outputData, 0644)
-
-	log.Printf("✅ 符号化完成: %s", outputFile)
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "符号化成功",
-		"result":  symbolicated,
-	})
-}
-
-// listReportsHandler 列出所有报告
-func listReportsHandler(c *gin.Context) {
-	files, err := os.ReadDir(ReportsDir)
+	reportID, filename, savePath, report, err := saveUploadedReport(c, file)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存文件失败: " + err.Error()})
 		return
 	}
+	requestLogger(c).Info("报告已上传", "report_id", reportID, "filename", filename, "size", file.Size)
 
-	var reports []map[string]interface{}
-	for _, file := range files {
-		if file.IsDir() || strings.HasSuffix(file.Name(), "_symbolicated.json") {
-			continue
-		}
-
-		info, _ := file.Info()
-		parts := strings.SplitN(file.Name(), "_", 2)
-		reportID := parts[0]
-
-		// 检查是否已符号化
-		symbolicatedPath := filepath.Join(ReportsDir, strings.Replace(file.Name(), ".json", "_symbolicated.json", 1))
-		symbolicated := false
-		if _, err := os.Stat(symbolicatedPath); err == nil {
-			symbolicated = true
-		}
-
-		// 尝试读取dump_type信息
-		dumpType := ""
-		dumpTypeCode := -1
-		reportPath := filepath.Join(ReportsDir, file.Name())
-		if data, err := os.ReadFile(reportPath); err == nil {
-			var reportData map[string]interface{}
-			if err := json.Unmarshal(data, &reportData); err == nil {
-				// 检查是否是 OOM 报告
-				if head, hasHead := reportData["head"].(map[string]interface{}); hasHead {
-					if _, hasItems := reportData["items"].([]interface{}); hasItems {
-						dumpTypeCode = 3000
-						dumpType = "内存溢出 (OOM)"
-						
-						// 尝试从 head 中获取更多信息
-						if scene, ok := head["foom_scene"].(string); ok && scene != "" {
-							dumpType = fmt.Sprintf("内存溢出 (OOM) - %s", scene)
-						}
-					}
-				} else if dt, ok := reportData["dump_type"].(float64); ok {
-					// 卡顿/崩溃报告
-					dumpTypeCode = int(dt)
-					dumpType = getDumpTypeName(dumpTypeCode)
-				}
-			}
-		}
-
-		reports = append(reports, map[string]interface{}{
-			"id":            reportID,
-			"filename":      file.Name(),
-			"size":          info.Size(),
-			"uploaded":      info.ModTime(),
-			"symbolicated":  symbolicated,
-			"dump_type":     dumpType,
-			"dump_type_code": dumpTypeCode,
-		})
+	response := gin.H{
+		"message":   "报告上传成功",
+		"report_id": reportID,
+		"filename":  filename,
 	}
-
-	c.JSON(http.StatusOK, gin.H{"reports": reports})
-}
-
-// getDumpTypeName 根据dump_type代码返回类型名称
-func getDumpTypeName(dumpType int) string {
-	switch dumpType {
-	case 2000:
-		return "无卡顿"
-	case 2001:
-		return "主线程卡顿"
-	case 2002:
-		return "后台主线程卡顿"
-	case 2003:
-		return "CPU 占用过高"
-	case 2007:
-		return "启动阻塞"
-	case 2009:
-		return "线程过多"
-	case 2010:
-		return "被杀死前卡顿"
-	case 2011:
-		return "耗电监控"
-	case 2013:
-		return "磁盘 I/O"
-	case 2014:
-		return "FPS 掉帧"
-	case 3000:
-		return "内存溢出 (OOM)"
-	default:
-		return fmt.Sprintf("类型 %d", dumpType)
+	// 非 JSON 报告仍然保存，但无法符号化，提前说明原因
+	if report == nil {
+		data, _ := os.ReadFile(savePath)
+		var value interface{}
+		response["warning"] = "报告不是有效的 JSON，无法符号化: " + parseReportData(data, &value).Error()
 	}
+	c.JSON(http.StatusOK, response)
 }
 
-// getReportHandler 获取报告详情
-func getReportHandler(c *gin.Context) {
-	reportID := c.Param("id")
-	reportFile := findReportFile(reportID)
-
-	if reportFile == "" {
-		c.JSON(http.StatusNotFound, gin.H{"error": "报告不存在"})
+// uploadAndSymbolicateHandler 上传报告并立即符号化
+// 自动匹配不到符号表时仍然保存报告，返回原始报告并说明原因
+func uploadAndSymbolicateHandler(c *gin.Context) {
+	file, ok := uploadedFile(c)
+	if !ok {
 		return
 	}
 
-	// 优先返回符号化的版本
-	symbolicatedFile := strings.Replace(reportFile, ".json", "_symbolicated.json", 1)
-	if _, err := os.Stat(symbolicatedFile); err == nil {
-		reportFile = symbolicatedFile
+	// 验证文件类型
+	if !isSupportedReportFile(file.Filename) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "仅支持 .json、.txt 或 .ips 文件"})
+		return
 	}
 
-	data, err := os.ReadFile(reportFile)
+	reportID, filename, savePath, report, err := saveUploadedReport(c, file)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取报告失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存文件失败: " + err.Error()})
 		return
 	}
 
-	var report interface{}
-	if err := json.Unmarshal(data, &report); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "报告格式错误"})
+	if report == nil {
+		data, _ := os.ReadFile(savePath)
+		var value interface{}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "报告格式错误",
+			"detail":    parseReportData(data, &value).Error(),
+			"report_id": reportID,
+			"filename":  filename,
+		})
 		return
 	}
 
-	c.JSON(http.StatusOK, report)
-}
-
-// getFormattedReportHandler 获取格式化的可读报告
-func getFormattedReportHandler(c *gin.Context) {
-	reportID := c.Param("id")
-	reportFile := findReportFile(reportID)
-
-	if reportFile == "" {
-		c.JSON(http.StatusNotFound, gin.H{"error": "报告不存在"})
+	// 报告已保存，结构不完整时不进行符号化
+	if problems := validateReportValue(report); len(problems) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":     "报告结构不完整",
+			"problems":  problems,
+			"report_id": reportID,
+			"filename":  filename,
+		})
 		return
 	}
 
-	// 优先返回符号化的版本
-	symbolicatedFile := strings.Replace(reportFile, ".json", "_symbolicated.json", 1)
-	if _, err := os.Stat(symbolicatedFile); err == nil {
-		reportFile = symbolicatedFile
+	// 自动匹配符号表
+	matchStart := time.Now()
+	dsymPath := findMatchingDsym(report)
+	matchingTime := time.Since(matchStart)
+	if dsymPath == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"message":      "报告上传成功，但未找到匹配的符号表",
+			"report_id":    reportID,
+			"filename":     filename,
+			"symbolicated": false,
+			"note":         "no dsym",
+			"result":       report,
+		})
+		return
 	}
 
-	data, err := os.ReadFile(reportFile)
+	symbolicated, err := symbolicateAndSave(requestLogger(c), reportID, savePath, report, dsymPath, matchingTime, symbolicateOptions{})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取报告失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":     "符号化失败: " + err.Error(),
+			"report_id": reportID,
+			"filename":  filename,
+		})
 		return
 	}
 
-	var report map[string]interface{}
-	if err := json.Unmarshal(data, &report); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "报告格式错误"})
-		return
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "上传并符号化成功",
+		"report_id":    reportID,
+		"filename":     filename,
+		"symbolicated": true,
+		"timing":       symbolicationTiming(symbolicated),
+		"result":       symbolicated,
+	})
+}
+
+// safeJoin 将用户提供的文件名拼接到目录下，拒绝路径穿越
+// 文件名不能包含路径分隔符或 ..，拼接结果必须仍在 dir 内
+func safeJoin(dir, name string) (string, error) {
+	if name == "" || name == "." || name == ".." ||
+		strings.ContainsAny(name, `/\`) || strings.Contains(name, "..") || filepath.IsAbs(name) {
+		return "", fmt.Errorf("非法的文件名: %s", name)
 	}
 
-	// 检查是否已经有格式化的报告
-	if symbInfo, ok := report["symbolication_info"].(map[string]interface{}); ok {
-		if formatted, ok := symbInfo["formatted_report"].(string); ok && formatted != "" {
-			// 返回纯文本格式
-			c.Header("Content-Type", "text/plain; charset=utf-8")
-			c.String(http.StatusOK, formatted)
-			return
-		}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	absPath, err := filepath.Abs(filepath.Join(dir, name))
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(absPath, absDir+string(filepath.Separator)) {
+		return "", fmt.Errorf("非法的文件名: %s", name)
 	}
 
-	// 如果没有格式化报告，现场生成
-	formattedText := formatReportToAppleStyle(report)
-	c.Header("Content-Type", "text/plain; charset=utf-8")
-	c.String(http.StatusOK, formattedText)
+	return filepath.Join(dir, name), nil
 }
 
-// deleteReportHandler 删除报告
+// uploadedFile 读取表单中的 file 字段，超过 MaxUploadSize 时返回 413
+// 失败时已写入错误响应，ok 为 false
+func uploadedFile(c *gin.Context) (*multipart.FileHeader, bool) {
+	if c.Request.ContentLength > MaxUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("文件超过大小上限 %s", formatBytes(MaxUploadSize))})
+		return nil, false
+	}
+	// 分块传输没有 Content-Length，读取请求体时同样限制大小
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxUploadSize)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("文件超过大小上限 %s", formatBytes(MaxUploadSize))})
+			return nil, false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "文件上传失败: " + err.Error()})
+		return nil, false
+	}
+
+	return file, true
+}
+
+// isSupportedReportFile 检查报告文件类型
+func isSupportedReportFile(filename string) bool {
+	return strings.HasSuffix(filename, ".json") || strings.HasSuffix(filename, ".txt") || strings.HasSuffix(filename, ".ips")
+}
+
+// saveUploadedReport 保存上传的报告并写入元数据 sidecar
+// 报告不是 JSON 时 report 返回 nil
+func saveUploadedReport(c *gin.Context, file *multipart.FileHeader) (reportID, filename, savePath string, report interface{}, err error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", "", "", nil, err
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return "", "", "", nil, err
+	}
+
+	return storeReport(file.Filename, data)
+}
+
+// storeReport 以新的报告 ID 保存报告内容（单个上传和批量导入共用）并写入元数据 sidecar
+// 报告不是 JSON 时 report 返回 nil
+func storeReport(name string, data []byte) (reportID, filename, savePath string, report interface{}, err error) {
+	originalName, format := filepath.Base(name), ""
+
+	// Apple .ips 报告（头部和正文两段 JSON）：转换为内部结构后按 JSON 保存
+	if header, body, ok := parseIPSReport(data); ok {
+		converted, err := json.MarshalIndent(convertIPSReport(header, body), "", "  ")
+		if err != nil {
+			return "", "", "", nil, fmt.Errorf("转换 .ips 报告失败: %v", err)
+		}
+		data, format = converted, "ips"
+		name += ".json"
+		log.Printf("🔄 已将 .ips 报告转换为内部格式: %s", originalName)
+	}
+
+	// 宽松解析：去掉 BOM 以及 JSON 前后的说明文字，保存提取出的 JSON
+	if format == "" && !json.Valid(data) {
+		if extracted, ok := extractReportJSON(data); ok {
+			data = extracted
+			log.Printf("🔧 已从 %s 中提取 JSON 内容", originalName)
+		}
+	}
+
+	// 生成唯一ID
+	reportID = newReportID()
+	filename = fmt.Sprintf("%s_%s", reportID, filepath.Base(name))
+	partitionDir := reportPartitionDir(ReportsDir, reportID)
+	if err := os.MkdirAll(partitionDir, 0755); err != nil {
+		return "", "", "", nil, err
+	}
+	savePath = filepath.Join(partitionDir, filename)
+
+	if err := writeFileAtomic(savePath, data, 0644); err != nil {
+		return "", "", "", nil, err
+	}
+
+	// 检测报告格式
+	var jsonData interface{}
+	if err := json.Unmarshal(data, &jsonData); err != nil {
+		meta := buildReportMeta(nil)
+		meta.OriginalFilename, meta.Format = originalName, reportFormat(nil)
+		if err := writeReportMeta(savePath, meta); err != nil {
+			log.Printf("警告: 写入报告元数据失败: %v", err)
+		}
+		log.Printf("📥 报告上传成功: %s [非JSON格式]", filename)
+		return reportID, filename, savePath, nil, nil
+	}
+
+	// Crashlytics 导出格式：原始导出另存一份，报告文件保存转换后的内部结构，后续流程无需区分来源
+	if isCrashlyticsReport(jsonData) {
+		converted := convertCrashlyticsReport(jsonData.(map[string]interface{}))
+		convertedData, err := json.MarshalIndent(converted, "", "  ")
+		if err != nil {
+			return "", "", "", nil, fmt.Errorf("转换 Crashlytics 报告失败: %v", err)
+		}
+		if err := writeFileAtomic(crashlyticsOriginalPath(savePath), data, 0644); err != nil {
+			return "", "", "", nil, err
+		}
+		if err := writeFileAtomic(savePath, convertedData, 0644); err != nil {
+			return "", "", "", nil, err
+		}
+		jsonData = converted
+		log.Printf("🔄 已将 Crashlytics 报告转换为内部格式: %s", filename)
+	}
+
+	// 写入元数据 sidecar，列表接口不再需要解析完整报告
+	meta := buildReportMeta(jsonData)
+	meta.OriginalFilename, meta.Format = originalName, reportFormat(jsonData)
+	if format != "" {
+		meta.Format = format
+	}
+	if err := writeReportMeta(savePath, meta); err != nil {
+		log.Printf("警告: 写入报告元数据失败: %v", err)
+	}
+
+	if _, isArray := jsonData.([]interface{}); isArray {
+		log.Printf("📥 报告上传成功: %s [数组格式]", filename)
+	} else if _, isMap := jsonData.(map[string]interface{}); isMap {
+		log.Printf("📥 报告上传成功: %s [字典格式]", filename)
+	} else {
+		log.Printf("📥 报告上传成功: %s [未知格式]", filename)
+	}
+
+	return reportID, filename, savePath, jsonData, nil
+}
+
+// symbolicateReportHandler 符号化报告
+func symbolicateReportHandler(c *gin.Context) {
+	var req struct {
+		ReportID string `json:"report_id" binding:"required"`
+		DsymFile string `json:"dsym_file"`
+		// LoadAddress 可选，十六进制的应用加载地址，覆盖报告中的 image_addr
+		LoadAddress string `json:"load_address"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var opts symbolicateOptions
+	if req.LoadAddress != "" {
+		loadAddr, err := parseHexAddress(req.LoadAddress)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "load_address: " + err.Error()})
+			return
+		}
+		opts.loadAddress = &loadAddr
+	}
+
+	symbolicateReportByID(c, req.ReportID, req.DsymFile, opts, "符号化成功")
+}
+
+// resymbolicateReportHandler 重新符号化报告
+// 忽略已有的符号化结果，使用后来上传的（或 dsym_file 指定的）符号表重新符号化并覆盖结果
+func resymbolicateReportHandler(c *gin.Context) {
+	var req struct {
+		DsymFile string `json:"dsym_file"`
+	}
+
+	// 请求体可选
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	symbolicateReportByID(c, c.Param("id"), req.DsymFile, symbolicateOptions{}, "重新符号化成功")
+}
+
+// symbolicateReportByID 读取原始报告（不使用已有的符号化结果），匹配符号表后符号化并覆盖保存
+// dsymFile 为空时自动匹配
+func symbolicateReportByID(c *gin.Context, reportID, dsymFile string, opts symbolicateOptions, message string) {
+	symbolicated, status, errBody := symbolicateStoredReport(requestLogger(c), reportID, dsymFile, opts)
+	if errBody != nil {
+		c.JSON(status, errBody)
+		return
+	}
+
+	response := gin.H{
+		"message": message,
+		"timing":  symbolicationTiming(symbolicated),
+		"result":  symbolicated,
+	}
+	// 手动指定的符号表可能与报告不匹配，仍返回结果但给出警告
+	if warning := symbolicationWarning(symbolicated); warning != "" {
+		response["warning"] = warning
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// symbolicateStoredReport 符号化已保存的报告，供单个和批量符号化接口共用
+// 失败时返回 HTTP 状态码和错误响应体，成功时 errBody 为 nil
+func symbolicateStoredReport(logger *slog.Logger, reportID, dsymFile string, opts symbolicateOptions) (symbolicated map[string]interface{}, status int, errBody gin.H) {
+	// 查找报告文件
+	reportFile := findReportFile(reportID)
+	if reportFile == "" {
+		return nil, http.StatusNotFound, gin.H{"error": "报告不存在"}
+	}
+
+	// 读取报告
+	data, err := os.ReadFile(reportFile)
+	if err != nil {
+		return nil, http.StatusInternalServerError, gin.H{"error": "读取报告失败"}
+	}
+
+	// 解析 JSON
+	var report interface{}
+	if err := parseReportData(data, &report); err != nil {
+		return nil, http.StatusBadRequest, gin.H{"error": "报告格式错误", "detail": err.Error()}
+	}
+
+	// 结构不完整时不进行符号化，返回具体问题
+	if problems := validateReportValue(report); len(problems) > 0 {
+		return nil, http.StatusUnprocessableEntity, gin.H{"error": "报告结构不完整", "problems": problems}
+	}
+	if opts.loadAddress != nil {
+		if err := validateLoadAddressOverride(report, *opts.loadAddress); err != nil {
+			return nil, http.StatusBadRequest, gin.H{"error": err.Error()}
+		}
+	}
+
+	// 查找匹配的符号表
+	dsymPath := ""
+	var matchingTime time.Duration
+	if dsymFile != "" {
+		path, err := resolveDsymFile(DsymDir, dsymFile)
+		if err != nil {
+			return nil, http.StatusBadRequest, gin.H{"error": err.Error()}
+		}
+		dsymPath = path
+	} else {
+		// 自动匹配
+		matchStart := time.Now()
+		dsymPath = findMatchingDsym(report)
+		matchingTime = time.Since(matchStart)
+	}
+
+	if dsymPath == "" {
+		return nil, http.StatusNotFound, gin.H{"error": "未找到匹配的符号表"}
+	}
+
+	symbolicated, err = symbolicateAndSave(logger, reportID, reportFile, report, dsymPath, matchingTime, opts)
+	if err != nil {
+		return nil, http.StatusInternalServerError, gin.H{"error": "符号化失败: " + err.Error()}
+	}
+	return symbolicated, http.StatusOK, nil
+}
+
+// symbolicateAndSave 执行符号化，保存结果并刷新元数据 sidecar
+// matchingTime 为调用方自动匹配符号表的耗时，记录到 symbolication_info.timing
+func symbolicateAndSave(logger *slog.Logger, reportID, reportFile string, report interface{}, dsymPath string, matchingTime time.Duration, opts symbolicateOptions) (map[string]interface{}, error) {
+	// 标记正在符号化，过期清理不会在写入结果前删除原始报告
+	defer beginReportSymbolication(reportID)()
+
+	// 执行符号化
+	logger.Info("开始符号化", "report_id", reportID, "dsym", filepath.Base(dsymPath))
+	startTime := time.Now()
+	symbolicated, err := symbolicateReportWithOptions(report, dsymPath, opts)
+	if err != nil {
+		logger.Warn("符号化失败", "report_id", reportID, "dsym", filepath.Base(dsymPath), "error", err)
+		return nil, err
+	}
+	recordMatchingTime(symbolicated, matchingTime)
+
+	// 保存符号化结果
+	// SLIM_SYMBOLICATED_REPORTS 开启时只保存相对原始报告变化的部分
+	outputFile := symbolicatedReportPath(reportFile)
+	outputData, err := marshalSymbolicatedReport(reportID, report, symbolicated)
+	if err != nil {
+		logger.Warn("序列化符号化结果失败", "report_id", reportID, "error", err)
+		return nil, fmt.Errorf("序列化符号化结果失败: %v", err)
+	}
+	if err := writeFileAtomic(outputFile, outputData, 0644); err != nil {
+		logger.Warn("保存符号化结果失败", "report_id", reportID, "file", filepath.Base(outputFile), "error", err)
+		return nil, fmt.Errorf("保存符号化结果失败: %v", err)
+	}
+
+	// 符号化结果写入成功后才成为权威文件，刷新 sidecar
+	if err := refreshReportMeta(reportFile, symbolicated); err != nil {
+		log.Printf("警告: 写入报告元数据失败: %v", err)
+	}
+
+	info, _ := symbolicated["symbolication_info"].(map[string]interface{})
+	stats, _ := info["statistics"].(map[string]interface{})
+	logger.Info("符号化完成",
+		"report_id", reportID,
+		"dsym_uuid", info["dsym_uuid"],
+		"arch", info["architecture"],
+		"frames_total", stats["total_frames"],
+		"frames_symbolicated", stats["symbolicated_frames"],
+		"duration", time.Since(startTime)+matchingTime)
+	return symbolicated, nil
+}
+
+// symbolicateAddressesHandler 只符号化报告中指定的几个地址
+// 使用报告匹配的符号表和报告自身的镜像基址，不处理整个报告
+func symbolicateAddressesHandler(c *gin.Context) {
+	var req struct {
+		Addresses []string `json:"addresses" binding:"required"`
+		DsymFile  string   `json:"dsym_file"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(req.Addresses) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "addresses 不能为空"})
+		return
+	}
+
+	reportID := c.Param("id")
+	reportFile := findReportFile(reportID)
+	if reportFile == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "报告不存在"})
+		return
+	}
+
+	data, err := os.ReadFile(reportFile)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取报告失败"})
+		return
+	}
+
+	var report interface{}
+	if err := parseReportData(data, &report); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "报告格式错误", "detail": err.Error()})
+		return
+	}
+
+	reportMap := normalizeReportFormat(report)
+	if reportMap == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "报告格式错误", "detail": "报告需要是 JSON 对象，或包含报告对象的数组"})
+		return
+	}
+
+	// 查找匹配的符号表
+	dsymPath := ""
+	if req.DsymFile != "" {
+		path, err := resolveDsymFile(DsymDir, req.DsymFile)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		dsymPath = path
+	} else {
+		dsymPath = findMatchingDsym(report)
+	}
+
+	if dsymPath == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未找到匹配的符号表"})
+		return
+	}
+
+	binaryPath, loadAddr, cleanup, err := getBinaryInfo(dsymPath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "符号化失败: " + err.Error()})
+		return
+	}
+	defer cleanup()
+	if addr, ok := reportLoadAddress(reportMap); ok {
+		loadAddr = addr
+	}
+	arch := reportArch(reportMap)
+	if slices, err := readMachOSlices(binaryPath); err == nil {
+		arch = selectDsymArch(arch, slices, getString(findAppImage(reportMap), "uuid"))
+	}
+
+	results := make([]map[string]interface{}, 0, len(req.Addresses))
+	for _, rawAddr := range req.Addresses {
+		item := map[string]interface{}{"address": rawAddr}
+
+		addr, err := parseHexAddress(rawAddr)
+		if err != nil {
+			item["error"] = err.Error()
+			results = append(results, item)
+			continue
+		}
+
+		symbol := symbolicateAddress(binaryPath, loadAddr, addr, arch)
+		if symbol == "" {
+			item["error"] = "符号化失败"
+			results = append(results, item)
+			continue
+		}
+
+		item["symbol"] = symbol
+		functionName, moduleName, fileName, lineNum := parseSymbolOutput(symbol)
+		item["function_name"] = functionName
+		if mangled := mangledSwiftName(functionName); mangled != "" {
+			item["mangled_name"] = mangled
+		}
+		item["module_name"] = moduleName
+		if fileName != "" {
+			item["file_name"] = fileName
+			item["line_number"] = lineNum
+		}
+		results = append(results, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"report_id":    reportID,
+		"dsym_path":    dsymPath,
+		"load_address": fmt.Sprintf("0x%x", loadAddr),
+		"architecture": arch,
+		"results":      results,
+	})
+}
+
+// symbolicateRawHandler 使用指定的符号表和加载地址符号化一组地址，不需要报告
+// 请求体: {"dsym_file": "...", "load_address": "0x...", "arch": "arm64", "addresses": ["0x..."]}
+func symbolicateRawHandler(c *gin.Context) {
+	var req struct {
+		DsymFile    string   `json:"dsym_file" binding:"required"`
+		LoadAddress string   `json:"load_address" binding:"required"`
+		Arch        string   `json:"arch"`
+		Addresses   []string `json:"addresses" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(req.Addresses) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "addresses 不能为空"})
+		return
+	}
+
+	loadAddr, err := parseHexAddress(req.LoadAddress)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "load_address: " + err.Error()})
+		return
+	}
+
+	dsymPath, err := resolveDsymFile(DsymDir, req.DsymFile)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := os.Stat(dsymPath); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "符号表不存在"})
+		return
+	}
+
+	binaryPath, _, cleanup, err := getBinaryInfo(dsymPath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "符号化失败: " + err.Error()})
+		return
+	}
+	defer cleanup()
+
+	// 未指定架构时按 arm64 处理；符号表中没有对应 slice 时直接拒绝，避免 atos 返回错误的符号
+	arch := "arm64"
+	if req.Arch != "" {
+		arch = normalizeArch(req.Arch)
+	}
+	if slices, err := readMachOSlices(binaryPath); err == nil && len(slices) > 0 {
+		var available []string
+		matched := false
+		for _, slice := range slices {
+			available = append(available, slice.Arch)
+			if slice.Arch == arch {
+				matched = true
+			}
+		}
+		if !matched {
+			if req.Arch != "" || len(slices) > 1 {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error":     fmt.Sprintf("符号表不包含架构 %s", arch),
+					"available": available,
+				})
+				return
+			}
+			arch = slices[0].Arch
+		}
+	}
+
+	// 先解析全部地址，合法的地址通过一次 atos 批量符号化
+	results := make([]map[string]interface{}, len(req.Addresses))
+	var addrs []uint64
+	var addrIndexes []int
+	for i, rawAddr := range req.Addresses {
+		results[i] = map[string]interface{}{"address": rawAddr}
+		addr, err := parseHexAddress(rawAddr)
+		if err != nil {
+			results[i]["error"] = err.Error()
+			continue
+		}
+		addrs = append(addrs, addr)
+		addrIndexes = append(addrIndexes, i)
+	}
+
+	resolved := 0
+	if len(addrs) > 0 {
+		symbols := symbolicateAddresses(binaryPath, loadAddr, addrs, arch)
+		for j, symbol := range symbols {
+			item := results[addrIndexes[j]]
+			if symbol == "" {
+				item["error"] = "符号化失败"
+				continue
+			}
+			item["symbol"] = symbol
+			functionName, moduleName, fileName, lineNum := parseSymbolOutput(symbol)
+			item["function_name"] = functionName
+			if mangled := mangledSwiftName(functionName); mangled != "" {
+				item["mangled_name"] = mangled
+			}
+			item["module_name"] = moduleName
+			if fileName != "" {
+				item["file_name"] = fileName
+				item["line_number"] = lineNum
+			}
+			resolved++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dsym_file":    req.DsymFile,
+		"load_address": fmt.Sprintf("0x%x", loadAddr),
+		"architecture": arch,
+		"total":        len(req.Addresses),
+		"resolved":     resolved,
+		"results":      results,
+	})
+}
+
+// reportSortKeys 报告列表 sort 参数对应的字段
+var reportSortKeys = map[string]string{
+	"uploaded":  "uploaded",
+	"size":      "size",
+	"dump_type": "dump_type_code",
+}
+
+// listReportsHandler 分页列出报告，默认按上传时间倒序
+// dump_type 来自 sidecar，不需要解析报告内容
+func listReportsHandler(c *gin.Context) {
+	filter, err := parseReportFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := parseListPage(c, reportSortKeys, "uploaded")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	groupBy := c.Query("group_by")
+	if groupBy != "" && groupBy != "signature" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的 group_by: " + groupBy + "（仅支持 signature）"})
+		return
+	}
+
+	reports, err := listReports(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 按崩溃签名聚合：签名在符号化时写入 sidecar，未符号化（没有签名）的报告只计数
+	if groupBy == "signature" {
+		grouper := newCrashGrouper()
+		unsigned := 0
+		for _, report := range reports {
+			signature, _ := report["signature"].(string)
+			if signature == "" {
+				unsigned++
+				continue
+			}
+			grouper.addSigned(report["id"].(string), signature, report["crash_title"].(string))
+		}
+		groups := grouper.issues()
+		c.JSON(http.StatusOK, gin.H{
+			"group_by": groupBy,
+			"groups":   groups,
+			"total":    len(groups),
+			"unsigned": unsigned,
+		})
+		return
+	}
+
+	// sidecar 中保存的是中文名称，其它语言按 Accept-Language 重新生成
+	if lang := preferredLanguage(c.GetHeader("Accept-Language")); lang != defaultLanguage {
+		for _, report := range reports {
+			if code := report["dump_type_code"].(int); code >= 0 {
+				report["dump_type"] = DumpType(code).Localized(lang)
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reports":   page.apply(reports),
+		"total":     len(reports),
+		"page":      page.Page,
+		"page_size": page.PageSize,
+	})
+}
+
+// exportReportsHandler 将满足筛选条件的报告打包为 zip 流式下载
+// 每个报告一个目录：符号化结果（未符号化时为原始报告）和格式化的 .crash 文本
+func exportReportsHandler(c *gin.Context) {
+	filter, err := parseReportFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reports, err := listReports(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("reports_%s.zip", time.Now().Format("20060102_150405"))
+	if filter.HasDumpType {
+		filename = fmt.Sprintf("reports_%d_%s.zip", filter.DumpType, time.Now().Format("20060102_150405"))
+	}
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	// 直接写入响应，不在内存中缓存整个 zip
+	zw := zip.NewWriter(c.Writer)
+	for _, report := range reports {
+		reportID := report["id"].(string)
+		if err := writeReportToZip(zw, reportID, findReportFile(reportID)); err != nil {
+			log.Printf("⚠️ 导出报告 %s 失败: %v", reportID, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		log.Printf("⚠️ 导出 zip 失败: %v", err)
+		return
+	}
+
+	log.Printf("📦 导出报告: %d 个", len(reports))
+}
+
+// writeReportToZip 写入单个报告的权威文件（符号化结果优先）和格式化文本
+func writeReportToZip(zw *zip.Writer, reportID, reportFile string) error {
+	// 精简格式的符号化结果合并后再导出，导出的文件可以脱离原始报告单独使用
+	data, err := loadReportData(reportFile)
+	if err != nil {
+		return err
+	}
+	w, err := zw.Create(reportID + "/" + filepath.Base(authoritativeReportFile(reportFile)))
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+
+	// 格式化文本基于权威文件生成，非 JSON 报告跳过
+	var report map[string]interface{}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil
+	}
+
+	w, err = zw.Create(reportID + "/" + reportID + ".crash")
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, formattedReportText(report))
+	return err
+}
+
+// getReportHandler 获取报告详情
+func getReportHandler(c *gin.Context) {
+	reportID := c.Param("id")
+	reportFile := findReportFile(reportID)
+
+	if reportFile == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "报告不存在"})
+		return
+	}
+
+	meta := loadReportMeta(reportFile)
+
+	// 优先返回符号化的版本（精简格式与原始报告合并）
+	data, err := loadReportData(reportFile)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取报告失败"})
+		return
+	}
+
+	var report interface{}
+	if err := parseReportData(data, &report); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "报告格式错误", "detail": err.Error()})
+		return
+	}
+
+	requestLogger(c).Info("读取报告", "report_id", reportID, "symbolicated", authoritativeReportFile(reportFile) != reportFile)
+
+	// 响应体是报告本身，原始文件名和格式通过响应头返回（文件名做 URL 编码）
+	c.Header("X-Report-Original-Filename", url.PathEscape(meta.OriginalFilename))
+	c.Header("X-Report-Format", meta.Format)
+	c.JSON(http.StatusOK, report)
+}
+
+// getFormattedReportHandler 获取格式化的可读报告
+func getFormattedReportHandler(c *gin.Context) {
+	_, report, ok := loadReportForFormatting(c)
+	if !ok {
+		return
+	}
+
+	formatted := formattedReportText(report)
+
+	// 缓存的格式化文本只对应默认输出，简洁/详细模式需要重新格式化
+	formatReport, reformat := report, false
+
+	// mode=concise 或指定 max_threads：只输出崩溃/阻塞线程和包含应用代码的线程
+	if c.Query("mode") == "concise" || c.Query("max_threads") != "" {
+		maxThreads := conciseMaxThreads
+		if value := c.Query("max_threads"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的 max_threads: " + value})
+				return
+			}
+			maxThreads = n
+		}
+		formatReport, reformat = conciseReport(report, maxThreads), true
+	}
+
+	// max_frames=N：每个线程只输出前 N 帧，默认不限制
+	if value := c.Query("max_frames"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的 max_frames: " + value})
+			return
+		}
+		formatReport, reformat = maxFramesReport(formatReport, n), true
+	}
+
+	// verbose=1：每一帧附加符号化时使用的镜像基址、slide 和文件内偏移，便于核对符号
+	if q := c.Query("verbose"); q == "1" || q == "true" {
+		formatReport, reformat = verboseReport(formatReport), true
+	}
+
+	if reformat {
+		formatted = formatReportToAppleStyle(formatReport)
+	}
+
+	formatted = appendRequestedBinaryImages(c, formatted, report)
+
+	// include_json=true：同时返回格式化文本和结构化数据，一次请求渲染两种视图
+	if c.Query("include_json") == "true" {
+		c.JSON(http.StatusOK, gin.H{
+			"formatted": formatted,
+			"report":    report,
+		})
+		return
+	}
+
+	// 返回纯文本格式
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.String(http.StatusOK, formatted)
+}
+
+// downloadReportHandler 以 <id>.crash 附件下载 Apple 格式报告，可直接导入 Xcode Organizer
+func downloadReportHandler(c *gin.Context) {
+	reportID, report, ok := loadReportForFormatting(c)
+	if !ok {
+		return
+	}
+
+	formatted := appendRequestedBinaryImages(c, formattedReportText(report), report)
+
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", reportID+".crash"))
+	c.String(http.StatusOK, formatted)
+}
+
+// getOnelineHandler 返回一行文本摘要，便于在聊天工具中分享
+func getOnelineHandler(c *gin.Context) {
+	_, reportMap, ok := loadAuthoritativeReport(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.String(http.StatusOK, formatOneline(reportMap))
+}
+
+// loadReportForFormatting 读取 :id 对应报告的权威文件（优先符号化版本）用于格式化输出
+// 与 loadAuthoritativeReport 不同，不做格式统一：多份报告的合并结果需要整体格式化
+// 失败时已写入错误响应，ok 为 false
+func loadReportForFormatting(c *gin.Context) (reportID string, report map[string]interface{}, ok bool) {
+	reportID = c.Param("id")
+	reportFile := findReportFile(reportID)
+
+	if reportFile == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "报告不存在"})
+		return reportID, nil, false
+	}
+
+	data, err := loadReportData(reportFile)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取报告失败"})
+		return reportID, nil, false
+	}
+
+	if err := parseReportData(data, &report); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "报告格式错误", "detail": err.Error()})
+		return reportID, nil, false
+	}
+	return reportID, report, true
+}
+
+// appendRequestedBinaryImages binary_images=1 时附加按地址排序的 Binary Images 段落，便于离线重新符号化
+func appendRequestedBinaryImages(c *gin.Context, formatted string, report map[string]interface{}) string {
+	if q := c.Query("binary_images"); q == "1" || q == "true" {
+		formatted += formatBinaryImages(report)
+	}
+	return formatted
+}
+
+// formattedReportText 优先使用符号化时保存的格式化报告，没有则现场生成
+func formattedReportText(report map[string]interface{}) string {
+	if symbInfo, ok := report["symbolication_info"].(map[string]interface{}); ok {
+		if formatted, ok := symbInfo["formatted_report"].(string); ok && formatted != "" {
+			return formatted
+		}
+	}
+	return formatReportToAppleStyle(report)
+}
+
+// getReportTypeHandler 获取报告的 dump_type（读取 sidecar）
+func getReportTypeHandler(c *gin.Context) {
+	reportID := c.Param("id")
+	reportFile := findReportFile(reportID)
+
+	if reportFile == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "报告不存在"})
+		return
+	}
+
+	meta := loadReportMeta(reportFile)
+	c.JSON(http.StatusOK, gin.H{
+		"report_id":      reportID,
+		"dump_type":      meta.DumpType,
+		"dump_type_code": meta.DumpTypeCode,
+	})
+}
+
+// getCrashedThreadHandler 只返回崩溃/阻塞线程的格式化堆栈和结构化帧
+func getCrashedThreadHandler(c *gin.Context) {
+	reportID, reportMap, ok := loadAuthoritativeReport(c)
+	if !ok {
+		return
+	}
+
+	thread := findCrashedThread(reportMap)
+	if thread == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "报告中没有线程信息"})
+		return
+	}
+
+	frames := []interface{}{}
+	backtrace, _ := thread["backtrace"].(map[string]interface{})
+	if contents, ok := backtrace["contents"].([]interface{}); ok {
+		frames = contents
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"report_id":    reportID,
+		"thread_index": getInt64(thread, "index"),
+		"crashed":      getBool(thread, "crashed"),
+		"name":         getString(thread, "name"),
+		"formatted":    formatThread(thread, reportMap),
+		"frames":       frames,
+		"skipped":      backtraceSkipped(backtrace),
+	})
+}
+
+// getTopThreadHandler 返回崩溃线程（卡顿报告为被阻塞的主线程）的符号化帧，供快速定位
+// 报告尚未符号化时先自动匹配符号表并符号化；符号化失败时返回原始帧和 symbolication_error
+func getTopThreadHandler(c *gin.Context) {
+	reportID := c.Param("id")
+	reportFile := findReportFile(reportID)
+	if reportFile == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "报告不存在"})
+		return
+	}
+
+	symbolicationError := ""
+	if authoritativeReportFile(reportFile) == reportFile {
+		// 尚未符号化时才需要名额，与符号化接口共用并发上限
+		release, err := acquireSymbolicationSlot(c.Request.Context())
+		if errors.Is(err, errSymbolicationBusy) {
+			rejectSymbolication(c)
+			return
+		}
+		if err != nil {
+			c.Abort()
+			return
+		}
+		_, _, errBody := symbolicateStoredReport(requestLogger(c), reportID, "", symbolicateOptions{})
+		release()
+		if errBody != nil {
+			symbolicationError, _ = errBody["error"].(string)
+			log.Printf("⚠️ 报告 %s 符号化失败，返回原始帧: %s", reportID, symbolicationError)
+		}
+	}
+
+	_, reportMap, ok := loadAuthoritativeReport(c)
+	if !ok {
+		return
+	}
+
+	thread := findCrashedThread(reportMap)
+	if thread == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "报告中没有线程信息"})
+		return
+	}
+
+	frames := []interface{}{}
+	backtrace, _ := thread["backtrace"].(map[string]interface{})
+	if contents, ok := backtrace["contents"].([]interface{}); ok {
+		frames = contents
+	}
+	_, hung := hungDumpType(reportMap)
+
+	resp := gin.H{
+		"report_id":    reportID,
+		"thread_index": getInt64(thread, "index"),
+		"crashed":      getBool(thread, "crashed"),
+		"hung":         hung,
+		"name":         getString(thread, "name"),
+		"symbolicated": authoritativeReportFile(reportFile) != reportFile,
+		"frames":       frames,
+		"skipped":      backtraceSkipped(backtrace),
+	}
+	if symbolicationError != "" {
+		resp["symbolication_error"] = symbolicationError
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// getCrashedThreadResolvedHandler 检查崩溃/阻塞线程中的应用代码帧是否全部符号化，供 CI 断言
+func getCrashedThreadResolvedHandler(c *gin.Context) {
+	reportID, reportMap, ok := loadAuthoritativeReport(c)
+	if !ok {
+		return
+	}
+
+	thread := findCrashedThread(reportMap)
+	if thread == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "报告中没有线程信息"})
+		return
+	}
+
+	appFrames, unresolved := unresolvedAppFrames(thread, appImageName(reportMap))
+
+	c.JSON(http.StatusOK, gin.H{
+		"report_id":               reportID,
+		"thread_index":            getInt64(thread, "index"),
+		"app_frames":              appFrames,
+		"all_app_frames_resolved": len(unresolved) == 0,
+		"unresolved":              unresolved,
+	})
+}
+
+// unresolvedAppFrames 统计线程中的应用代码帧，返回应用帧数量和没有 symbolicated_name 的帧
+func unresolvedAppFrames(thread map[string]interface{}, appName string) (int, []map[string]interface{}) {
+	unresolved := []map[string]interface{}{}
+	if appName == "" {
+		return 0, unresolved
+	}
+
+	backtrace, _ := thread["backtrace"].(map[string]interface{})
+	contents, _ := backtrace["contents"].([]interface{})
+
+	appFrames := 0
+	for i, frameData := range contents {
+		frame, ok := frameData.(map[string]interface{})
+		if !ok || filepath.Base(getString(frame, "object_name")) != appName {
+			continue
+		}
+		appFrames++
+
+		if getString(frame, "symbolicated_name") == "" {
+			unresolved = append(unresolved, map[string]interface{}{
+				"index":            i,
+				"object_name":      getString(frame, "object_name"),
+				"instruction_addr": fmt.Sprintf("0x%x", uint64(getInt64(frame, "instruction_addr"))),
+			})
+		}
+	}
+
+	return appFrames, unresolved
+}
+
+// getReportCoverageHandler 列出报告中每个镜像是否有匹配的符号表
+func getReportCoverageHandler(c *gin.Context) {
+	reportID, reportMap, ok := loadAuthoritativeReport(c)
+	if !ok {
+		return
+	}
+
+	coverage := reportCoverage(DsymDir, reportMap)
+	coverage["report_id"] = reportID
+	c.JSON(http.StatusOK, coverage)
+}
+
+// loadAuthoritativeReport 读取 :id 对应报告的权威文件（优先符号化版本）并统一格式
+// 失败时已写入错误响应，ok 为 false
+func loadAuthoritativeReport(c *gin.Context) (reportID string, reportMap map[string]interface{}, ok bool) {
+	reportID = c.Param("id")
+	reportFile := findReportFile(reportID)
+
+	if reportFile == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "报告不存在"})
+		return reportID, nil, false
+	}
+
+	data, err := loadReportData(reportFile)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取报告失败"})
+		return reportID, nil, false
+	}
+
+	var report interface{}
+	if err := parseReportData(data, &report); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "报告格式错误", "detail": err.Error()})
+		return reportID, nil, false
+	}
+
+	reportMap = normalizeReportFormat(report)
+	if reportMap == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "报告格式错误", "detail": "报告需要是 JSON 对象，或包含报告对象的数组"})
+		return reportID, nil, false
+	}
+
+	return reportID, reportMap, true
+}
+
+// deleteReportHandler 删除报告
 func deleteReportHandler(c *gin.Context) {
 	reportID := c.Param("id")
 	reportFile := findReportFile(reportID)
@@ -477,27 +1789,70 @@ func deleteReportHandler(c *gin.Context) {
 		return
 	}
 
-	// 删除原始报告和符号化版本
-	os.Remove(reportFile)
-	symbolicatedFile := strings.Replace(reportFile, ".json", "_symbolicated.json", 1)
-	os.Remove(symbolicatedFile)
+	deleteReportFiles(ReportsDir, reportFile)
 
 	log.Printf("🗑️  删除报告: %s", reportFile)
 	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
 }
 
-// findReportFile 根据 ID 查找报告文件
+// deleteReportFiles 删除原始报告、符号化版本、元数据和 Crashlytics 原始导出，并清理空的分区目录
+func deleteReportFiles(root, reportFile string) {
+	os.Remove(reportFile)
+	os.Remove(symbolicatedReportPath(reportFile))
+	os.Remove(reportMetaPath(reportFile))
+	os.Remove(crashlyticsOriginalPath(reportFile))
+	removeEmptyPartitions(root, filepath.Dir(reportFile))
+}
+
+// symbolicatedReportPath 返回报告符号化结果的路径：<id>_<原文件名去掉扩展名>_symbolicated.json
+// 按扩展名替换，.txt 等报告的符号化结果不会与原始报告同名
+func symbolicatedReportPath(reportFile string) string {
+	return strings.TrimSuffix(reportFile, filepath.Ext(reportFile)) + "_symbolicated.json"
+}
+
+// authoritativeReportFile 返回报告的权威文件：优先使用符号化版本
+func authoritativeReportFile(reportFile string) string {
+	symbolicatedFile := symbolicatedReportPath(reportFile)
+	if _, err := os.Stat(symbolicatedFile); err == nil {
+		return symbolicatedFile
+	}
+	return reportFile
+}
+
+// findReportFile 根据 ID 查找原始报告文件
 func findReportFile(reportID string) string {
-	files, err := os.ReadDir(ReportsDir)
-	if err != nil {
+	return findReportFileIn(ReportsDir, reportID)
+}
+
+// findReportFileIn 在报告目录中查找 ID 完全相等的原始报告（不返回符号化结果和 sidecar）
+func findReportFileIn(dir, reportID string) string {
+	if reportID == "" {
 		return ""
 	}
 
-	for _, file := range files {
-		if strings.HasPrefix(file.Name(), reportID+"_") {
-			return filepath.Join(ReportsDir, file.Name())
+	// 先查 ID 对应的日期分区，再兼容旧版本平铺在根目录的报告
+	if partition := reportPartition(reportID); partition != "" {
+		if reportFile := findReportFileInDir(filepath.Join(dir, partition), reportID); reportFile != "" {
+			return reportFile
 		}
 	}
+	return findReportFileInDir(dir, reportID)
+}
 
-	return ""
+// reportIDFromFilename 从原始报告文件名 <id>_<原文件名> 中取出 ID
+// 符号化结果和 sidecar 不是原始报告，返回 false
+func reportIDFromFilename(name string) (string, bool) {
+	// 隐藏文件是写入中的临时文件（writeFileAtomic）
+	if strings.HasPrefix(name, ".") {
+		return "", false
+	}
+	if strings.HasSuffix(name, "_symbolicated.json") || strings.HasSuffix(name, ".meta.json") || strings.HasSuffix(name, ".crashlytics.json") {
+		return "", false
+	}
+
+	parts := strings.SplitN(name, "_", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", false
+	}
+	return parts[0], true
 }
@@ -0,0 +1,1082 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSafeJoin(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"20240301_120000_Demo.dSYM.zip", false},
+		{"../main.go", true},
+		{"../../etc/passwd", true},
+		{"..", true},
+		{"/etc/passwd", true},
+		{`..\main.go`, true},
+		{"sub/Demo.dSYM.zip", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		_, err := safeJoin(DsymDir, tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("safeJoin(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDeleteDsymHandlerRejectsTraversal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// 符号表目录放在临时目录中，穿越的目标是它上层的哨兵文件
+	root := t.TempDir()
+	oldDir := DsymDir
+	DsymDir = filepath.Join(root, "data", "dsyms")
+	defer func() { DsymDir = oldDir }()
+	if err := os.MkdirAll(DsymDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	sentinels := []string{filepath.Join(root, "data", "sentinel.txt"), filepath.Join(root, "sentinel.txt")}
+	for _, sentinel := range sentinels {
+		if err := os.WriteFile(sentinel, []byte("keep"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, useRawPath := range []bool{false, true} {
+		r := gin.New()
+		// UseRawPath 时 %2f 不会被当作路径分隔符，而是解码后传给参数
+		r.UseRawPath = useRawPath
+		r.DELETE("/api/dsym/:uuid", deleteDsymHandler)
+
+		for _, target := range []string{
+			"/api/dsym/..%2fsentinel.txt",
+			"/api/dsym/..%2F..%2Fsentinel.txt",
+			"/api/dsym/" + strings.ReplaceAll(sentinels[1], "/", "%2F"),
+			"/api/dsym/..",
+		} {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, target, nil))
+			if w.Code == http.StatusOK {
+				t.Errorf("UseRawPath=%v DELETE %s 返回 200", useRawPath, target)
+			}
+		}
+	}
+
+	for _, sentinel := range sentinels {
+		if _, err := os.Stat(sentinel); err != nil {
+			t.Fatalf("%s 被删除: %v", sentinel, err)
+		}
+	}
+}
+
+func TestFindReportFileExactID(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"17000000000000000009_crash_symbolicated.json", // 另一个报告的符号化结果，排序在前
+		"1700000000000000000_crash.json",
+		"17000000000000000009_crash.json",
+		"1700000000000000000.meta.json",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"1700000000000000000", "1700000000000000000_crash.json"},
+		{"17000000000000000009", "17000000000000000009_crash.json"},
+		{"170000000000000000", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		got := findReportFileIn(dir, tt.id)
+		want := ""
+		if tt.want != "" {
+			want = filepath.Join(dir, tt.want)
+		}
+		if got != want {
+			t.Errorf("findReportFileIn(%q) = %q, want %q", tt.id, got, want)
+		}
+	}
+}
+
+func TestReportPartitions(t *testing.T) {
+	dir := t.TempDir()
+
+	// 1700000000000000000 = 2023-11-14T22:13:20Z
+	partitioned := "1700000000000000000"
+	if got, want := reportPartition(partitioned), filepath.Join("2023", "11", "14"); got != want {
+		t.Fatalf("reportPartition() = %q, want %q", got, want)
+	}
+	partitionDir := reportPartitionDir(dir, partitioned)
+	if err := os.MkdirAll(partitionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	newFile := filepath.Join(partitionDir, partitioned+"_crash.json")
+	legacyFile := filepath.Join(dir, "1600000000000000000_crash.json")
+	for _, path := range []string{newFile, legacyFile} {
+		if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := findReportFileIn(dir, partitioned); got != newFile {
+		t.Errorf("findReportFileIn(分区) = %q, want %q", got, newFile)
+	}
+	if got := findReportFileIn(dir, "1600000000000000000"); got != legacyFile {
+		t.Errorf("findReportFileIn(平铺) = %q, want %q", got, legacyFile)
+	}
+
+	reports, err := listReportsIn(dir, reportFilter{})
+	if err != nil {
+		t.Fatalf("listReportsIn() error = %v", err)
+	}
+	if len(reports) != 2 {
+		t.Errorf("listReportsIn() 返回 %d 个报告, want 2", len(reports))
+	}
+	if _, err := os.Stat(reportMetaPath(newFile)); err != nil || filepath.Dir(reportMetaPath(newFile)) != partitionDir {
+		t.Errorf("sidecar 应写在分区目录: %v", err)
+	}
+
+	// 分区清空后逐级删除空目录，根目录保留
+	os.Remove(newFile)
+	os.Remove(reportMetaPath(newFile))
+	removeEmptyPartitions(dir, partitionDir)
+	if _, err := os.Stat(filepath.Join(dir, "2023")); !os.IsNotExist(err) {
+		t.Errorf("空分区目录未被删除")
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Errorf("根目录不应被删除: %v", err)
+	}
+}
+
+func TestMigrateFlatStorage(t *testing.T) {
+	// 报告：原始报告、符号化结果和 sidecar 一起迁移到分区，ID 不变
+	reportsDir := t.TempDir()
+	const reportID = "1700000000000000000"
+	flat := []string{reportID + "_crash.json", reportID + "_crash_symbolicated.json", reportID + ".meta.json", "notes.txt"}
+	for _, name := range flat {
+		if err := os.WriteFile(filepath.Join(reportsDir, name), []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := migrateFlatReports(reportsDir); got != 3 {
+		t.Errorf("migrateFlatReports() = %d, want 3", got)
+	}
+	partitionDir := reportPartitionDir(reportsDir, reportID)
+	if got, want := findReportFileIn(reportsDir, reportID), filepath.Join(partitionDir, reportID+"_crash.json"); got != want {
+		t.Errorf("findReportFileIn() = %q, want %q", got, want)
+	}
+	for _, name := range flat[1:3] {
+		if _, err := os.Stat(filepath.Join(partitionDir, name)); err != nil {
+			t.Errorf("%s 未迁移到分区: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(reportsDir, "notes.txt")); err != nil {
+		t.Errorf("不属于报告的文件不应迁移: %v", err)
+	}
+
+	// 符号表：按文件名中的上传时间迁移到年/月分区，旧索引改为指向新位置
+	dsymDir := t.TempDir()
+	const filename = "20240315_101010_Demo"
+	flatPath := filepath.Join(dsymDir, filename)
+	writeFakeMachO(t, flatPath, 0x0100000c, 0, [16]byte{0xd3})
+	defer evictDsymInfo(dsymStorePath(dsymDir, filename))
+	os.MkdirAll(filepath.Join(dsymDir, dsymIndexDirName), 0755)
+	os.Symlink(filepath.Join("..", filename), dsymIndexPath(dsymDir, "D3000000-0000-0000-0000-000000000000"))
+
+	if got := migrateFlatDsyms(dsymDir); got != 1 {
+		t.Errorf("migrateFlatDsyms() = %d, want 1", got)
+	}
+	want := filepath.Join(dsymDir, "2024", "03", filename)
+	if got := dsymStorePath(dsymDir, filename); got != want {
+		t.Errorf("dsymStorePath() = %q, want %q", got, want)
+	}
+	if got := lookupDsymIndex(dsymDir, "D3000000-0000-0000-0000-000000000000"); got != want {
+		t.Errorf("lookupDsymIndex() = %q, want %q", got, want)
+	}
+	if got, err := resolveDsymFile(dsymDir, filename); err != nil || got != want {
+		t.Errorf("resolveDsymFile() = %q, %v, want %q", got, err, want)
+	}
+	if files := storedDsymFiles(dsymDir); len(files) != 1 || files[0] != want {
+		t.Errorf("storedDsymFiles() = %v, want [%s]", files, want)
+	}
+	if _, err := resolveDsymFile(dsymDir, "../"+filename); err == nil {
+		t.Error("resolveDsymFile() 应拒绝路径穿越")
+	}
+}
+
+func TestUploadDsymHandlerRejectsBogusZip(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	oldDir := DsymDir
+	DsymDir = t.TempDir()
+	defer func() { DsymDir = oldDir }()
+
+	// 合法 zip，但里面不是 dSYM
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	w, _ := zw.Create("Demo.dSYM/Contents/Resources/DWARF/Demo")
+	w.Write([]byte("this is not a mach-o file"))
+	zw.Close()
+
+	// 空 zip
+	var emptyBuf bytes.Buffer
+	zip.NewWriter(&emptyBuf).Close()
+
+	// 缺少 DWARF 目录
+	var noDwarfBuf bytes.Buffer
+	zw = zip.NewWriter(&noDwarfBuf)
+	w, _ = zw.Create("Demo.dSYM/Contents/Info.plist")
+	w.Write([]byte("<plist></plist>"))
+	zw.Close()
+
+	for name, content := range map[string][]byte{
+		"bogus.dSYM.zip":    []byte("not even a zip"),
+		"renamed.dSYM.zip":  zipBuf.Bytes(),
+		"empty.dSYM.zip":    emptyBuf.Bytes(),
+		"no-dwarf.dSYM.zip": noDwarfBuf.Bytes(),
+		"Demo.app":          []byte("not an app bundle"),
+	} {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		part, _ := mw.CreateFormFile("file", name)
+		part.Write(content)
+		mw.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/dsym/upload", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		w := httptest.NewRecorder()
+
+		r := gin.New()
+		r.POST("/api/dsym/upload", uploadDsymHandler)
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: 状态码 = %d, want 422", name, w.Code)
+		}
+		if matches, _ := filepath.Glob(filepath.Join(DsymDir, "*_"+name)); len(matches) > 0 {
+			t.Errorf("%s: 无效的符号表不应被保存: %v", name, matches)
+		}
+	}
+}
+
+func TestUploadDsymHandlerDuplicateUUID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	oldDsymDir := DsymDir
+	DsymDir = t.TempDir()
+	defer func() { DsymDir = oldDsymDir }()
+
+	zipPath := filepath.Join(t.TempDir(), "Demo.dSYM.zip")
+	writeFakeDsymZip(t, zipPath, [16]byte{0xd0, 0x01})
+	content, _ := os.ReadFile(zipPath)
+
+	r := gin.New()
+	r.POST("/api/dsym/upload", uploadDsymHandler)
+	upload := func(name, query string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		part, _ := mw.CreateFormFile("file", name)
+		part.Write(content)
+		mw.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/dsym/upload"+query, &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := upload("Demo.dSYM.zip", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("首次上传状态码 = %d, body = %s", w.Code, w.Body.String())
+	}
+	var first struct {
+		Filename string `json:"filename"`
+		UUID     string `json:"uuid"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &first)
+	firstPath := dsymStorePath(DsymDir, first.Filename)
+	defer evictDsymInfo(firstPath)
+
+	// 通过 UUID 索引直接找到符号表
+	if got := lookupDsymIndex(DsymDir, first.UUID); got != firstPath {
+		t.Errorf("lookupDsymIndex() = %q, want %q", got, firstPath)
+	}
+
+	// 相同 UUID 再次上传：409 并指向已有文件，不保存新文件
+	w = upload("Copy.dSYM.zip", "")
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), first.Filename) {
+		t.Fatalf("重复上传: 状态码 = %d, body = %s", w.Code, w.Body.String())
+	}
+	if files := storedDsymFiles(DsymDir); len(files) != 1 {
+		t.Errorf("重复上传后的符号表 = %v, want 仅 %s", files, first.Filename)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(DsymDir, uploadingDsymPrefix+"*")); len(matches) > 0 {
+		t.Errorf("上传中的临时文件未清理: %v", matches)
+	}
+
+	// ?overwrite=1 替换已有文件
+	w = upload("Copy.dSYM.zip", "?overwrite=1")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"replaced"`) {
+		t.Fatalf("覆盖上传: 状态码 = %d, body = %s", w.Code, w.Body.String())
+	}
+	var second struct {
+		Filename string `json:"filename"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &second)
+	secondPath := dsymStorePath(DsymDir, second.Filename)
+	defer evictDsymInfo(secondPath)
+
+	if files := storedDsymFiles(DsymDir); len(files) != 1 || files[0] != secondPath {
+		t.Errorf("覆盖后的符号表 = %v, want %s", files, secondPath)
+	}
+	if got := findDsymByUUID(DsymDir, first.UUID); got != secondPath {
+		t.Errorf("findDsymByUUID() = %q, want %q", got, secondPath)
+	}
+}
+
+func TestUploadDsymHandlerSymbolicatesPendingReport(t *testing.T) {
+	if !toolAvailable("unzip") {
+		t.Skip("unzip 不可用")
+	}
+	gin.SetMode(gin.TestMode)
+	installFakeAtos(t, `while [ $# -gt 0 ]; do
+  case "$1" in
+    -arch|-l|-o) shift 2 ;;
+    *) echo "-[Foo bar] (in Demo) (Foo.m:12)"; shift ;;
+  esac
+done
+`)
+
+	oldDsymDir, oldReportsDir := DsymDir, ReportsDir
+	DsymDir, ReportsDir = t.TempDir(), t.TempDir()
+	defer func() { DsymDir, ReportsDir = oldDsymDir, oldReportsDir }()
+
+	zipPath := filepath.Join(t.TempDir(), "Demo.dSYM.zip")
+	writeFakeDsymZip(t, zipPath, [16]byte{0xd0, 0x02})
+	content, _ := os.ReadFile(zipPath)
+	uuid := "D0020000-0000-0000-0000-000000000000"
+
+	storePending := func(appUUID string) string {
+		t.Helper()
+		data, _ := json.Marshal(map[string]interface{}{
+			"system": map[string]interface{}{"cpu_arch": "arm64", "CFBundleExecutable": "Demo"},
+			"binary_images": []interface{}{
+				map[string]interface{}{"name": "/private/var/containers/Bundle/Application/X/Demo.app/Demo", "uuid": appUUID, "image_addr": float64(0x100000000), "image_size": float64(0x100000)},
+			},
+			"crash": map[string]interface{}{
+				"threads": []interface{}{
+					map[string]interface{}{
+						"crashed": true,
+						"backtrace": map[string]interface{}{
+							"contents": []interface{}{
+								map[string]interface{}{"object_name": "Demo", "object_addr": float64(0x100000000), "instruction_addr": float64(0x100000400)},
+							},
+						},
+					},
+				},
+			},
+		})
+		reportID, _, _, _, err := storeReport("pending.json", data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reportID
+	}
+
+	r := gin.New()
+	r.POST("/api/dsym/upload", uploadDsymHandler)
+	upload := func(query string) (int, map[string]interface{}) {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		part, _ := mw.CreateFormFile("file", "Demo.dSYM.zip")
+		part.Write(content)
+		mw.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/dsym/upload"+query, &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	// 报告不存在时不保存符号表
+	if code, _ := upload("?symbolicate_report=19990101000000000"); code != http.StatusNotFound {
+		t.Errorf("报告不存在: 状态码 = %d, want 404", code)
+	}
+	if files := storedDsymFiles(DsymDir); len(files) != 0 {
+		t.Errorf("报告不存在时保存了符号表: %v", files)
+	}
+
+	// UUID 与报告一致：上传后立即符号化
+	reportID := storePending(uuid)
+	code, resp := upload("?symbolicate_report=" + reportID)
+	if code != http.StatusOK {
+		t.Fatalf("状态码 = %d, resp = %v", code, resp)
+	}
+	dsymPath := filepath.Join(DsymDir, resp["filename"].(string))
+	defer evictDsymInfo(dsymPath)
+
+	symbolication := resp["symbolication"].(map[string]interface{})
+	if symbolication["status"] != "symbolicated" || symbolication["report_id"] != reportID {
+		t.Fatalf("symbolication = %v", symbolication)
+	}
+	if data, err := os.ReadFile(authoritativeReportFile(findReportFile(reportID))); err != nil || !strings.Contains(string(data), "-[Foo bar]") {
+		t.Errorf("符号化结果未保存: err = %v", err)
+	}
+
+	// UUID 不一致：符号表照常保存，但不符号化
+	otherID := storePending("AAAAAAAA-0000-0000-0000-000000000000")
+	code, resp = upload("?overwrite=1&symbolicate_report=" + otherID)
+	if code != http.StatusOK {
+		t.Fatalf("状态码 = %d, resp = %v", code, resp)
+	}
+	defer evictDsymInfo(filepath.Join(DsymDir, resp["filename"].(string)))
+	if status := resp["symbolication"].(map[string]interface{})["status"]; status != "uuid_mismatch" {
+		t.Errorf("UUID 不一致时 status = %v, want uuid_mismatch", status)
+	}
+	if authoritativeReportFile(findReportFile(otherID)) != findReportFile(otherID) {
+		t.Error("UUID 不一致的报告被符号化")
+	}
+}
+
+func TestValidateDsymArchive(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "Demo.dSYM.zip")
+	writeFakeDsymZip(t, zipPath, [16]byte{0x01})
+
+	data, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := validateDsymArchive(bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Errorf("有效的 dSYM 被拒绝: %v", err)
+	}
+}
+
+func TestUnresolvedAppFrames(t *testing.T) {
+	thread := map[string]interface{}{
+		"backtrace": map[string]interface{}{
+			"contents": []interface{}{
+				map[string]interface{}{"object_name": "libsystem_kernel.dylib", "instruction_addr": float64(0x1a0000300)},
+				map[string]interface{}{"object_name": "Demo", "instruction_addr": float64(0x100004000), "symbolicated_name": "main (in Demo) (main.m:10)"},
+				map[string]interface{}{"object_name": "/private/var/containers/Bundle/Application/X/Demo.app/Demo", "instruction_addr": float64(0x100004100)},
+			},
+		},
+	}
+
+	appFrames, unresolved := unresolvedAppFrames(thread, "Demo")
+	if appFrames != 2 {
+		t.Errorf("appFrames = %d, want 2", appFrames)
+	}
+	if len(unresolved) != 1 || unresolved[0]["index"] != 2 || unresolved[0]["instruction_addr"] != "0x100004100" {
+		t.Errorf("unresolved = %v", unresolved)
+	}
+
+	// 无法确定应用镜像时不统计
+	if appFrames, unresolved := unresolvedAppFrames(thread, ""); appFrames != 0 || len(unresolved) != 0 {
+		t.Errorf("appName 为空时应返回 0 帧，得到 %d, %v", appFrames, unresolved)
+	}
+}
+
+func TestConcurrentUploadsGetUniqueIDs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	oldDir := ReportsDir
+	ReportsDir = t.TempDir()
+	defer func() { ReportsDir = oldDir }()
+
+	r := gin.New()
+	r.POST("/upload", uploadReportHandler)
+
+	const uploads = 50
+	ids := make([]string, uploads)
+	var wg sync.WaitGroup
+	for i := 0; i < uploads; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var body bytes.Buffer
+			mw := multipart.NewWriter(&body)
+			part, _ := mw.CreateFormFile("file", "crash.json")
+			fmt.Fprintf(part, `{"n": %d}`, i)
+			mw.Close()
+
+			req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+			req.Header.Set("Content-Type", mw.FormDataContentType())
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			var resp struct {
+				ReportID string `json:"report_id"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			ids[i] = resp.ReportID
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool)
+	for i, id := range ids {
+		if id == "" || seen[id] {
+			t.Fatalf("上传 %d 的报告 ID %q 为空或重复", i, id)
+		}
+		seen[id] = true
+
+		data, err := os.ReadFile(findReportFile(id))
+		if err != nil || string(data) != fmt.Sprintf(`{"n": %d}`, i) {
+			t.Errorf("报告 %s 内容 = %q, err = %v", id, data, err)
+		}
+	}
+
+	// 较长的 ID 以较短 ID 开头时不能误匹配
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "17000000000000000001_crash.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := findReportFileIn(dir, "1700000000000000000"); got != "" {
+		t.Errorf("findReportFileIn() = %q, want 空", got)
+	}
+}
+
+func TestUploadRejectsOversizedFile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	oldMax := MaxUploadSize
+	MaxUploadSize = 1024
+	defer func() { MaxUploadSize = oldMax }()
+
+	newUpload := func(name string, chunked bool) *http.Request {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		part, _ := mw.CreateFormFile("file", name)
+		part.Write(bytes.Repeat([]byte("x"), 4096))
+		mw.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		if chunked {
+			// 没有 Content-Length，只能在读取请求体时拦截
+			req.ContentLength = -1
+		}
+		return req
+	}
+
+	handlers := map[string]gin.HandlerFunc{
+		"big.dSYM.zip": uploadDsymHandler,
+		"big.json":     uploadReportHandler,
+	}
+	for name, handler := range handlers {
+		for _, chunked := range []bool{false, true} {
+			r := gin.New()
+			r.POST("/upload", handler)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, newUpload(name, chunked))
+
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("%s (chunked=%v): 状态码 = %d, want 413", name, chunked, w.Code)
+			}
+		}
+	}
+}
+
+func TestDownloadDsymHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	oldDir := DsymDir
+	DsymDir = t.TempDir()
+	defer func() { DsymDir = oldDir }()
+
+	dsymPath := filepath.Join(DsymDir, "20240102_030405_Demo.dSYM.zip")
+	writeFakeDsymZip(t, dsymPath, [16]byte{0xd0, 0x01})
+	defer evictDsymInfo(dsymPath)
+	content, _ := os.ReadFile(dsymPath)
+
+	slices, err := cachedDsymInfo(dsymPath)
+	if err != nil {
+		t.Skipf("无法读取 UUID: %v", err)
+	}
+
+	r := gin.New()
+	r.GET("/api/dsym/:uuid/download", downloadDsymHandler)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/dsym/"+strings.ToLower(slices[0].UUID)+"/download", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), content) {
+		t.Fatalf("状态码 = %d, 内容长度 = %d, want 200 / %d", w.Code, w.Body.Len(), len(content))
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="Demo.dSYM.zip"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+
+	// Range 请求只返回指定片段
+	req = httptest.NewRequest(http.MethodGet, "/api/dsym/"+slices[0].UUID+"/download", nil)
+	req.Header.Set("Range", "bytes=0-3")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusPartialContent || !bytes.Equal(w.Body.Bytes(), content[:4]) {
+		t.Errorf("Range: 状态码 = %d, 内容 = %q", w.Code, w.Body.Bytes())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/dsym/00000000-0000-0000-0000-000000000000/download", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("未知 UUID: 状态码 = %d, want 404", w.Code)
+	}
+}
+
+func TestSymbolicateRawHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	installFakeAtos(t, `while [ $# -gt 0 ]; do
+  case "$1" in
+    -arch|-o|-l) shift 2 ;;
+    *) echo "func_$1 (in Demo) (Demo.m:7)"; shift ;;
+  esac
+done
+`)
+
+	oldDir := DsymDir
+	DsymDir = t.TempDir()
+	defer func() { DsymDir = oldDir }()
+	writeFakeMachO(t, filepath.Join(DsymDir, "Demo"), 0x0100000c, 0, [16]byte{0x7a, 0x01})
+
+	r := gin.New()
+	r.POST("/api/symbolicate/raw", symbolicateRawHandler)
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/symbolicate/raw", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(`{"dsym_file": "Demo", "load_address": "0x100000000", "arch": "arm64", "addresses": ["0x100004000", "zzz"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("状态码 = %d, body = %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Resolved int                      `json:"resolved"`
+		Results  []map[string]interface{} `json:"results"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Resolved != 1 || len(resp.Results) != 2 {
+		t.Fatalf("resolved = %d, results = %v", resp.Resolved, resp.Results)
+	}
+	if resp.Results[0]["symbol"] != "func_0x100004000 (in Demo) (Demo.m:7)" || resp.Results[0]["file_name"] != "Demo.m" {
+		t.Errorf("results[0] = %v", resp.Results[0])
+	}
+	if resp.Results[1]["error"] == nil || resp.Results[1]["symbol"] != nil {
+		t.Errorf("非法地址应单独报错: %v", resp.Results[1])
+	}
+
+	// 架构不匹配、加载地址非法、符号表不存在
+	for body, want := range map[string]int{
+		`{"dsym_file": "Demo", "load_address": "0x100000000", "arch": "armv7", "addresses": ["0x1"]}`: http.StatusBadRequest,
+		`{"dsym_file": "Demo", "load_address": "nope", "addresses": ["0x1"]}`:                         http.StatusBadRequest,
+		`{"dsym_file": "Missing", "load_address": "0x100000000", "addresses": ["0x1"]}`:               http.StatusNotFound,
+		`{"dsym_file": "../Demo", "load_address": "0x100000000", "addresses": ["0x1"]}`:               http.StatusBadRequest,
+	} {
+		if w := post(body); w.Code != want {
+			t.Errorf("%s: 状态码 = %d, want %d", body, w.Code, want)
+		}
+	}
+}
+
+func TestResymbolicateReportHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// 假的 atos：函数名以 -o 的文件名开头，用于区分使用了哪个符号表
+	installFakeAtos(t, `while [ $# -gt 0 ]; do
+  case "$1" in
+    -arch|-l) shift 2 ;;
+    -o) bin=$(basename "$2"); shift 2 ;;
+    *) echo "${bin}_func (in Demo) (Demo.m:7)"; shift ;;
+  esac
+done
+`)
+
+	oldDsymDir, oldReportsDir := DsymDir, ReportsDir
+	DsymDir, ReportsDir = t.TempDir(), t.TempDir()
+	defer func() { DsymDir, ReportsDir = oldDsymDir, oldReportsDir }()
+
+	wrongPath := filepath.Join(DsymDir, "Old")
+	rightPath := filepath.Join(DsymDir, "Demo")
+	writeFakeMachO(t, wrongPath, 0x0100000c, 0, [16]byte{0x5e, 0x01})
+	writeFakeMachO(t, rightPath, 0x0100000c, 0, [16]byte{0x5e, 0x02})
+	defer evictDsymInfo(wrongPath)
+	defer evictDsymInfo(rightPath)
+	uuid, _, _ := readMachOUUID(rightPath)
+
+	data, _ := json.Marshal(map[string]interface{}{
+		"system": map[string]interface{}{"cpu_arch": "arm64", "CFBundleExecutable": "Demo"},
+		"binary_images": []interface{}{
+			map[string]interface{}{"name": "/private/var/containers/Bundle/Application/X/Demo.app/Demo", "uuid": uuid, "image_addr": float64(0x100000000), "image_size": float64(0x10000)},
+		},
+		"crash": map[string]interface{}{
+			"threads": []interface{}{
+				map[string]interface{}{
+					"crashed": true,
+					"backtrace": map[string]interface{}{
+						"contents": []interface{}{
+							map[string]interface{}{"object_name": "Demo", "object_addr": float64(0x100000000), "instruction_addr": float64(0x100000400)},
+						},
+					},
+				},
+			},
+		},
+	})
+	reportID, _, reportFile, _, err := storeReport("report.json", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.POST("/api/report/symbolicate", symbolicateReportHandler)
+	r.POST("/api/report/:id/resymbolicate", resymbolicateReportHandler)
+	post := func(path, body string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: 状态码 = %d, body = %s", path, w.Code, w.Body.String())
+		}
+	}
+	firstFrame := func() string {
+		t.Helper()
+		data, err := os.ReadFile(authoritativeReportFile(reportFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result map[string]interface{}
+		json.Unmarshal(data, &result)
+		thread := result["crash"].(map[string]interface{})["threads"].([]interface{})[0].(map[string]interface{})
+		frame := thread["backtrace"].(map[string]interface{})["contents"].([]interface{})[0].(map[string]interface{})
+		name, _ := frame["function_name"].(string)
+		return name
+	}
+
+	// 先用错误的符号表符号化
+	post("/api/report/symbolicate", `{"report_id": "`+reportID+`", "dsym_file": "Old"}`)
+	if got := firstFrame(); got != "Old_func" {
+		t.Fatalf("错误符号表的结果 = %q, want Old_func", got)
+	}
+
+	// 重新符号化：不带请求体时自动匹配 UUID 一致的符号表
+	post("/api/report/"+reportID+"/resymbolicate", "")
+	if got := firstFrame(); got != "Demo_func" {
+		t.Errorf("重新符号化后 = %q, want Demo_func", got)
+	}
+
+	// 指定符号表
+	post("/api/report/"+reportID+"/resymbolicate", `{"dsym_file": "Old"}`)
+	if got := firstFrame(); got != "Old_func" {
+		t.Errorf("指定 dsym_file 后 = %q, want Old_func", got)
+	}
+}
+
+func TestSymbolicateReportHandlerLoadAddress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// 假的 atos：函数名中带上 -l 的加载地址
+	installFakeAtos(t, `while [ $# -gt 0 ]; do
+  case "$1" in
+    -arch|-o) shift 2 ;;
+    -l) load=$2; shift 2 ;;
+    *) echo "func_${load} (in Demo) (Demo.m:7)"; shift ;;
+  esac
+done
+`)
+
+	oldDsymDir, oldReportsDir := DsymDir, ReportsDir
+	DsymDir, ReportsDir = t.TempDir(), t.TempDir()
+	defer func() { DsymDir, ReportsDir = oldDsymDir, oldReportsDir }()
+
+	dsymPath := filepath.Join(DsymDir, "Demo")
+	writeFakeMachO(t, dsymPath, 0x0100000c, 0, [16]byte{0x1a, 0x01})
+	defer evictDsymInfo(dsymPath)
+	uuid, _, _ := readMachOUUID(dsymPath)
+
+	data, _ := json.Marshal(map[string]interface{}{
+		"system": map[string]interface{}{"cpu_arch": "arm64", "CFBundleExecutable": "Demo"},
+		"binary_images": []interface{}{
+			map[string]interface{}{"name": "/private/var/containers/Bundle/Application/X/Demo.app/Demo", "uuid": uuid, "image_addr": float64(0x100000000), "image_size": float64(0x100000)},
+		},
+		"crash": map[string]interface{}{
+			"threads": []interface{}{
+				map[string]interface{}{
+					"crashed": true,
+					"backtrace": map[string]interface{}{
+						"contents": []interface{}{
+							map[string]interface{}{"object_name": "Demo", "object_addr": float64(0x100000000), "instruction_addr": float64(0x100000400)},
+						},
+					},
+				},
+			},
+		},
+	})
+	reportID, _, _, _, err := storeReport("report.json", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.POST("/api/report/symbolicate", symbolicateReportHandler)
+	post := func(body string) (int, map[string]interface{}) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/report/symbolicate", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+	check := func(resp map[string]interface{}, wantFunc, wantSource string) {
+		t.Helper()
+		result := resp["result"].(map[string]interface{})
+		thread := result["crash"].(map[string]interface{})["threads"].([]interface{})[0].(map[string]interface{})
+		frame := thread["backtrace"].(map[string]interface{})["contents"].([]interface{})[0].(map[string]interface{})
+		if got := frame["function_name"]; got != wantFunc {
+			t.Errorf("function_name = %v, want %s", got, wantFunc)
+		}
+		info := result["symbolication_info"].(map[string]interface{})
+		if got := info["load_address_source"]; got != wantSource {
+			t.Errorf("load_address_source = %v, want %s", got, wantSource)
+		}
+		if got := info["signature"]; got != crashSignature(result) {
+			t.Errorf("signature = %v, want %s", got, crashSignature(result))
+		}
+	}
+
+	// 默认使用报告中的 image_addr
+	code, resp := post(`{"report_id": "` + reportID + `"}`)
+	if code != http.StatusOK {
+		t.Fatalf("状态码 = %d, resp = %v", code, resp)
+	}
+	check(resp, "func_0x100000000", loadAddrSourceReport)
+
+	// load_address 覆盖报告中的值，atos -l 随之改变
+	code, resp = post(`{"report_id": "` + reportID + `", "load_address": "0x100004000"}`)
+	if code != http.StatusOK {
+		t.Fatalf("状态码 = %d, resp = %v", code, resp)
+	}
+	check(resp, "func_0x100004000", loadAddrSourceRequest)
+
+	// 非法的十六进制和应用镜像地址非 0 时的 0 被拒绝
+	for _, bogus := range []string{"0xzz", "0x0"} {
+		if code, resp := post(`{"report_id": "` + reportID + `", "load_address": "` + bogus + `"}`); code != http.StatusBadRequest {
+			t.Errorf("load_address=%s: 状态码 = %d, want 400, resp = %v", bogus, code, resp)
+		}
+	}
+}
+
+func TestGetTopThreadHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	installFakeAtos(t, `while [ $# -gt 0 ]; do
+  case "$1" in
+    -arch|-l|-o) shift 2 ;;
+    *) echo "func_$1 (in Demo) (Demo.m:7)"; shift ;;
+  esac
+done
+`)
+
+	oldDsymDir, oldReportsDir := DsymDir, ReportsDir
+	DsymDir, ReportsDir = t.TempDir(), t.TempDir()
+	defer func() { DsymDir, ReportsDir = oldDsymDir, oldReportsDir }()
+
+	dsymPath := filepath.Join(DsymDir, "Demo")
+	writeFakeMachO(t, dsymPath, 0x0100000c, 0, [16]byte{0xb0, 0x7a})
+	defer evictDsymInfo(dsymPath)
+	uuid, _, _ := readMachOUUID(dsymPath)
+
+	thread := func(index int, crashed bool, addr float64) map[string]interface{} {
+		return map[string]interface{}{
+			"index":   float64(index),
+			"crashed": crashed,
+			"backtrace": map[string]interface{}{
+				"contents": []interface{}{
+					map[string]interface{}{"object_name": "Demo", "object_addr": float64(0x100000000), "instruction_addr": addr},
+				},
+			},
+		}
+	}
+	store := func(report map[string]interface{}) string {
+		t.Helper()
+		data, _ := json.Marshal(report)
+		reportID, _, _, _, err := storeReport("report.json", data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reportID
+	}
+
+	crashID := store(map[string]interface{}{
+		"system": map[string]interface{}{"cpu_arch": "arm64", "CFBundleExecutable": "Demo"},
+		"binary_images": []interface{}{
+			map[string]interface{}{"name": "/var/containers/Bundle/Application/X/Demo.app/Demo", "uuid": uuid, "image_addr": float64(0x100000000)},
+		},
+		"crash": map[string]interface{}{
+			"threads": []interface{}{thread(0, false, 0x100000100), thread(3, true, 0x100000400)},
+		},
+	})
+	// 没有匹配的符号表：返回原始帧
+	hangID := store(map[string]interface{}{
+		"dump_type": float64(2001),
+		"system":    map[string]interface{}{"cpu_arch": "arm64", "CFBundleExecutable": "Demo"},
+		"binary_images": []interface{}{
+			map[string]interface{}{"name": "/var/containers/Bundle/Application/X/Demo.app/Demo", "uuid": "00000000-0000-0000-0000-0000000000AA", "image_addr": float64(0x100000000)},
+		},
+		"crash": map[string]interface{}{
+			"threads": []interface{}{thread(1, false, 0x100000200), thread(0, false, 0x100000300)},
+		},
+	})
+
+	r := gin.New()
+	r.GET("/api/report/:id/top-thread", getTopThreadHandler)
+	get := func(reportID string) map[string]interface{} {
+		t.Helper()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/report/"+reportID+"/top-thread", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("状态码 = %d, body = %s", w.Code, w.Body.String())
+		}
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp
+	}
+
+	resp := get(crashID)
+	frames, _ := resp["frames"].([]interface{})
+	if resp["thread_index"] != float64(3) || resp["crashed"] != true || resp["symbolicated"] != true || len(frames) != 1 {
+		t.Fatalf("崩溃报告: %v", resp)
+	}
+	if name := frames[0].(map[string]interface{})["symbolicated_name"]; name == nil || name == "" {
+		t.Errorf("崩溃线程的帧没有符号化: %v", frames[0])
+	}
+	if authoritativeReportFile(findReportFile(crashID)) == findReportFile(crashID) {
+		t.Error("符号化结果没有保存")
+	}
+
+	resp = get(hangID)
+	if resp["thread_index"] != float64(0) || resp["hung"] != true || resp["symbolicated"] != false || resp["symbolication_error"] != "未找到匹配的符号表" {
+		t.Errorf("卡顿报告: %v", resp)
+	}
+}
+
+func TestFormatBacktraceSkipped(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	oldDir := ReportsDir
+	ReportsDir = t.TempDir()
+	defer func() { ReportsDir = oldDir }()
+
+	reportID, _, _, report, err := storeReport("crash.json", []byte(`{
+		"crash": {"threads": [{"index": 0, "crashed": true, "backtrace": {"skipped": 37, "contents": [
+			{"object_name": "Demo", "instruction_addr": 4294967552, "symbolicated_name": "recurse (in Demo) (Recurse.m:3)"},
+			{"object_name": "Demo", "instruction_addr": 4294967556, "symbolicated_name": "recurse (in Demo) (Recurse.m:3)"}
+		]}}]}
+	}`))
+	if err != nil {
+		t.Fatalf("storeReport() error = %v", err)
+	}
+	reportMap := report.(map[string]interface{})
+	backtrace := reportMap["crash"].(map[string]interface{})["threads"].([]interface{})[0].(map[string]interface{})["backtrace"].(map[string]interface{})
+
+	lines := strings.Split(strings.TrimSuffix(formatBacktrace(backtrace, reportMap), "\n"), "\n")
+	if len(lines) != 3 || lines[2] != "... 37 frames skipped during capture" {
+		t.Errorf("formatBacktrace() =\n%s", strings.Join(lines, "\n"))
+	}
+
+	// 同时按 max_frames 截断时，采集时省略的帧在截断标记之后
+	lines = strings.Split(strings.TrimSuffix(formatBacktrace(backtrace, maxFramesReport(reportMap, 1)), "\n"), "\n")
+	if len(lines) != 3 || lines[1] != "... (1 more frames omitted)" || lines[2] != "... 37 frames skipped during capture" {
+		t.Errorf("max_frames=1 时 formatBacktrace() =\n%s", strings.Join(lines, "\n"))
+	}
+
+	r := gin.New()
+	r.GET("/api/report/:id/crashed-thread", getCrashedThreadHandler)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/report/"+reportID+"/crashed-thread", nil))
+	var resp struct {
+		Skipped   int    `json:"skipped"`
+		Formatted string `json:"formatted"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || resp.Skipped != 37 || !strings.Contains(resp.Formatted, "37 frames skipped") {
+		t.Errorf("crashed-thread: 状态码 = %d, body = %s", w.Code, w.Body.String())
+	}
+}
+
+func TestSymbolicateAndSaveReportsWriteError(t *testing.T) {
+	installFakeAtos(t, `while [ $# -gt 0 ]; do
+  case "$1" in
+    -arch|-o|-l) shift 2 ;;
+    *) echo "main (in Demo) (main.m:10)"; shift ;;
+  esac
+done
+`)
+
+	oldReportsDir := ReportsDir
+	ReportsDir = t.TempDir()
+	defer func() { ReportsDir = oldReportsDir }()
+
+	dsymPath := filepath.Join(t.TempDir(), "Demo")
+	writeFakeMachO(t, dsymPath, 0x0100000c, 0, [16]byte{0x18, 0x24})
+	defer evictDsymInfo(dsymPath)
+	uuid, _, _ := readMachOUUID(dsymPath)
+
+	data, _ := json.Marshal(map[string]interface{}{
+		"system": map[string]interface{}{"cpu_arch": "arm64", "CFBundleExecutable": "Demo"},
+		"binary_images": []interface{}{
+			map[string]interface{}{"name": "/var/containers/Bundle/Application/X/Demo.app/Demo", "uuid": uuid, "image_addr": float64(0x100000000), "image_size": float64(0x100000)},
+		},
+		"crash": map[string]interface{}{"threads": []interface{}{map[string]interface{}{
+			"crashed": true,
+			"backtrace": map[string]interface{}{"contents": []interface{}{
+				map[string]interface{}{"object_name": "Demo", "object_addr": float64(0x100000000), "instruction_addr": float64(0x100000400)},
+			}},
+		}}},
+	})
+	reportID, _, reportFile, report, err := storeReport("report.json", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 分区目录在符号化期间被删除（例如过期清理），结果无法写入
+	if err := os.RemoveAll(filepath.Dir(reportFile)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := symbolicateAndSave(slog.Default(), reportID, reportFile, report, dsymPath, 0, symbolicateOptions{}); err == nil {
+		t.Fatal("写入失败时 symbolicateAndSave() 应返回错误")
+	}
+	if meta, ok := readReportMeta(reportFile); ok && meta.Symbolicated {
+		t.Errorf("写入失败时不应把 sidecar 标记为已符号化: %+v", meta)
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"debug/dwarf"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// 纯 Go 符号化后端：用 debug/macho + debug/dwarf 直接读取 dSYM 中的 DWARF，
+// 不依赖 atos / llvm-symbolizer，适合没有 Xcode 工具链的 Linux 容器（SYMBOLIZER=native）
+// ============================================================================
+
+// nativeSymbolizer 纯 Go 实现的符号化后端
+// 与 llvm-symbolizer 一样使用文件内地址：运行时地址 - slide，slide = 加载地址 - __TEXT vmaddr
+type nativeSymbolizer struct{}
+
+func (nativeSymbolizer) name() string    { return "native" }
+func (nativeSymbolizer) available() bool { return true }
+
+func (nativeSymbolizer) symbolize(binaryPath string, loadAddr uint64, addrs []uint64, arch string) ([]string, error) {
+	startTime := time.Now()
+	results := make([]string, len(addrs))
+
+	index, err := cachedNativeIndex(binaryPath, arch)
+	if err != nil {
+		log.Printf("⚠️ 解析 DWARF 失败: %v", err)
+		return results, err
+	}
+	slide := loadAddr - index.textAddr
+
+	for i, addr := range addrs {
+		if symbol := index.lookup(addr - slide); symbol != "" {
+			results[i] = postProcessSymbol(symbol, addr)
+		}
+	}
+
+	log.Printf("✅ native 批量符号化 %d 个地址 (耗时: %v)", len(addrs), time.Since(startTime))
+	return results, nil
+}
+
+// nativeFunc DWARF 中一个函数的地址范围，inlined 为其中内联进来的函数
+type nativeFunc struct {
+	low, high uint64
+	name      string
+	nameRef   dwarf.Offset // 名称在 DW_AT_abstract_origin / DW_AT_specification 指向的条目上
+	inlined   []nativeFunc
+}
+
+// nativeLine 行号表中的一行，line 为 0 表示序列结束或编译器生成的代码
+type nativeLine struct {
+	addr uint64
+	file string
+	line int
+}
+
+// nativeIndex 一个 slice 解析后的函数范围、行号表和符号表（地址均为未加 slide 的 vmaddr）
+type nativeIndex struct {
+	module   string
+	textAddr uint64
+	funcs    []nativeFunc
+	lines    []nativeLine
+	symbols  []nativeSymbol
+}
+
+// nativeSymbol 符号表中的符号，end 为所在 section 的结束地址
+type nativeSymbol struct {
+	name      string
+	addr, end uint64
+}
+
+// buildNativeIndex 读取指定架构 slice 的 DWARF 和符号表
+// 没有 DWARF 的二进制只使用符号表，结果与 atos 对未带调试信息的二进制一致（函数名 + 偏移）
+func buildNativeIndex(binaryPath, arch string) (*nativeIndex, error) {
+	f, _, closeFile, err := openMachOSlice(binaryPath, arch)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFile()
+
+	seg := f.Segment("__TEXT")
+	if seg == nil {
+		return nil, fmt.Errorf("二进制中没有 __TEXT 段")
+	}
+	index := &nativeIndex{module: filepath.Base(binaryPath), textAddr: seg.Addr}
+
+	if f.Symtab != nil {
+		// 只保留定义在 section 中的符号（N_SECT）
+		const nTypeMask, nSect = 0x0e, 0x0e
+		for _, sym := range f.Symtab.Syms {
+			if sym.Type&nTypeMask != nSect || sym.Sect == 0 || int(sym.Sect) > len(f.Sections) || sym.Name == "" {
+				continue
+			}
+			sect := f.Sections[sym.Sect-1]
+			index.symbols = append(index.symbols, nativeSymbol{name: sym.Name, addr: sym.Value, end: sect.Addr + sect.Size})
+		}
+		sort.Slice(index.symbols, func(i, j int) bool { return index.symbols[i].addr < index.symbols[j].addr })
+	}
+
+	data, err := f.DWARF()
+	if err != nil {
+		log.Printf("⚠️ %s 中没有可用的 DWARF，只使用符号表: %v", index.module, err)
+		return index, nil
+	}
+	if err := index.readDWARF(data); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// readDWARF 遍历全部编译单元，收集函数范围和行号表
+func (index *nativeIndex) readDWARF(data *dwarf.Data) error {
+	names := make(map[dwarf.Offset]string)
+	reader := data.Reader()
+
+	for {
+		entry, err := reader.Next()
+		if err != nil {
+			return fmt.Errorf("读取 DWARF 失败: %v", err)
+		}
+		if entry == nil {
+			break
+		}
+
+		switch entry.Tag {
+		case dwarf.TagCompileUnit:
+			if err := index.readLines(data, entry); err != nil {
+				return err
+			}
+
+		case dwarf.TagSubprogram, dwarf.TagInlinedSubroutine:
+			fn := nativeFunc{name: dwarfFunctionName(entry)}
+			if fn.name != "" {
+				names[entry.Offset] = fn.name
+			} else if ref, ok := entry.Val(dwarf.AttrAbstractOrigin).(dwarf.Offset); ok {
+				fn.nameRef = ref
+			} else if ref, ok := entry.Val(dwarf.AttrSpecification).(dwarf.Offset); ok {
+				fn.nameRef = ref
+			}
+
+			ranges, err := data.Ranges(entry)
+			if err != nil || len(ranges) == 0 {
+				continue
+			}
+			for _, r := range ranges {
+				fn.low, fn.high = r[0], r[1]
+				if entry.Tag == dwarf.TagSubprogram {
+					index.funcs = append(index.funcs, fn)
+				} else if n := len(index.funcs); n > 0 {
+					// 内联函数跟在所属函数之后
+					index.funcs[n-1].inlined = append(index.funcs[n-1].inlined, fn)
+				}
+			}
+		}
+	}
+
+	resolve := func(fn *nativeFunc) {
+		if fn.name == "" && fn.nameRef != 0 {
+			fn.name = names[fn.nameRef]
+		}
+	}
+	for i := range index.funcs {
+		resolve(&index.funcs[i])
+		for j := range index.funcs[i].inlined {
+			resolve(&index.funcs[i].inlined[j])
+		}
+	}
+	sort.Slice(index.funcs, func(i, j int) bool { return index.funcs[i].low < index.funcs[j].low })
+
+	// 同一地址上序列结束标记排在前面，保证查找时取到新序列的第一行
+	sort.SliceStable(index.lines, func(i, j int) bool {
+		a, b := index.lines[i], index.lines[j]
+		return a.addr < b.addr || (a.addr == b.addr && a.line == 0 && b.line != 0)
+	})
+	return nil
+}
+
+// readLines 读取一个编译单元的行号表
+func (index *nativeIndex) readLines(data *dwarf.Data, cu *dwarf.Entry) error {
+	lr, err := data.LineReader(cu)
+	if err != nil {
+		return fmt.Errorf("读取行号表失败: %v", err)
+	}
+	if lr == nil {
+		return nil
+	}
+
+	var le dwarf.LineEntry
+	for {
+		if err := lr.Next(&le); err != nil {
+			break
+		}
+		line := nativeLine{addr: le.Address}
+		if !le.EndSequence && le.File != nil {
+			line.file, line.line = le.File.Name, le.Line
+		}
+		index.lines = append(index.lines, line)
+	}
+	return nil
+}
+
+// dwarfFunctionName 函数名：Swift 优先使用 mangled 的 linkage name，交给 postProcessSymbol 统一 demangle
+func dwarfFunctionName(entry *dwarf.Entry) string {
+	name, _ := entry.Val(dwarf.AttrName).(string)
+	if linkage, ok := entry.Val(dwarf.AttrLinkageName).(string); ok && isSwiftSymbol(linkage) {
+		return linkage
+	}
+	return name
+}
+
+// lookup 返回文件内地址对应的 atos 风格结果，找不到时返回空字符串
+// 有内联时取最内层函数，与 atos 的输出一致
+func (index *nativeIndex) lookup(addr uint64) string {
+	if fn := index.function(addr); fn != nil && fn.name != "" {
+		symbol := fmt.Sprintf("%s (in %s)", fn.name, index.module)
+		if file, line := index.line(addr); line > 0 {
+			symbol += fmt.Sprintf(" (%s:%d)", filepath.Base(file), line)
+		}
+		return symbol
+	}
+
+	// 没有 DWARF 信息：使用最近的符号 + 偏移
+	i := sort.Search(len(index.symbols), func(i int) bool { return index.symbols[i].addr > addr }) - 1
+	if i < 0 || addr >= index.symbols[i].end {
+		return ""
+	}
+	sym := index.symbols[i]
+	// C 函数和 Swift 符号在符号表中带有前导下划线
+	name := strings.TrimPrefix(sym.name, "_")
+	return fmt.Sprintf("%s (in %s) + %d", name, index.module, addr-sym.addr)
+}
+
+// function 查找包含地址的函数，有内联时返回范围最小的内联函数
+func (index *nativeIndex) function(addr uint64) *nativeFunc {
+	i := sort.Search(len(index.funcs), func(i int) bool { return index.funcs[i].low > addr }) - 1
+	if i < 0 || addr >= index.funcs[i].high {
+		return nil
+	}
+
+	outer := &index.funcs[i]
+	fn := outer
+	for j := range outer.inlined {
+		inl := &outer.inlined[j]
+		if inl.name != "" && addr >= inl.low && addr < inl.high && inl.high-inl.low <= fn.high-fn.low {
+			fn = inl
+		}
+	}
+	return fn
+}
+
+// line 查找地址所在的行（行号表中地址不大于 addr 的最后一行）
+func (index *nativeIndex) line(addr uint64) (string, int) {
+	i := sort.Search(len(index.lines), func(i int) bool { return index.lines[i].addr > addr }) - 1
+	if i < 0 {
+		return "", 0
+	}
+	return index.lines[i].file, index.lines[i].line
+}
+
+// nativeIndexEntry 缓存的解析结果，modTime/size 变化即视为失效
+type nativeIndexEntry struct {
+	modTime time.Time
+	size    int64
+	index   *nativeIndex
+}
+
+// nativeIndexCacheLimit 缓存的 slice 数上限，压缩包每次解压到新的临时路径，超过上限时整体清空
+const nativeIndexCacheLimit = 32
+
+var (
+	nativeIndexCache   = make(map[string]nativeIndexEntry)
+	nativeIndexCacheMu sync.RWMutex
+)
+
+// cachedNativeIndex 带缓存的 buildNativeIndex，按 路径 + 架构 + 修改时间 + 大小 命中
+// 同一份报告的各线程复用同一次解析结果，解析失败不缓存
+func cachedNativeIndex(binaryPath, arch string) (*nativeIndex, error) {
+	stat, err := os.Stat(binaryPath)
+	if err != nil {
+		return nil, err
+	}
+
+	key := binaryPath + "|" + arch
+	nativeIndexCacheMu.RLock()
+	entry, ok := nativeIndexCache[key]
+	nativeIndexCacheMu.RUnlock()
+	if ok && entry.modTime.Equal(stat.ModTime()) && entry.size == stat.Size() {
+		return entry.index, nil
+	}
+
+	index, err := buildNativeIndex(binaryPath, arch)
+	if err != nil {
+		return nil, err
+	}
+
+	nativeIndexCacheMu.Lock()
+	if len(nativeIndexCache) >= nativeIndexCacheLimit {
+		nativeIndexCache = make(map[string]nativeIndexEntry)
+	}
+	nativeIndexCache[key] = nativeIndexEntry{modTime: stat.ModTime(), size: stat.Size(), index: index}
+	nativeIndexCacheMu.Unlock()
+	return index, nil
+}
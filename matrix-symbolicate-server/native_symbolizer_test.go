@@ -0,0 +1,79 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// tinyDsymBinary 测试用的最小 dSYM（testdata/tiny_dsym 中的 Tiny.ll 生成）：
+// helper 位于 0x100004000（Foo.m:3-6），-[Foo bar] 位于 0x10000400c（Foo.m:10-13）
+var tinyDsymBinary = filepath.Join("testdata", "Tiny.dSYM", "Contents", "Resources", "DWARF", "Tiny")
+
+func TestNativeSymbolizerTinyDsym(t *testing.T) {
+	slices, err := readMachOSlices(tinyDsymBinary)
+	if err != nil || len(slices) != 1 || slices[0].UUID != "7A1ED50E-3B4C-4D8A-9E01-5A6B7C8D9EAF" || slices[0].Arch != "arm64" {
+		t.Fatalf("readMachOSlices() = %v, %v", slices, err)
+	}
+
+	// 运行时加载地址带 slide
+	const loadAddr = 0x104a00000
+	const slide = loadAddr - 0x100000000
+	addrs := []uint64{
+		0x100004004 + slide,
+		0x100004014 + slide,
+		0x100004020 + slide,
+		0x100005000 + slide, // 不在任何函数中
+	}
+
+	got, err := nativeSymbolizer{}.symbolize(tinyDsymBinary, loadAddr, addrs, "arm64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"helper (in Tiny) (Foo.m:5)",
+		"-[Foo bar] (in Tiny) (Foo.m:11)",
+		"-[Foo bar] (in Tiny) (Foo.m:13)",
+		"",
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("symbolize()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	if _, err := (nativeSymbolizer{}).symbolize(tinyDsymBinary, loadAddr, addrs, "x86_64"); err == nil {
+		t.Error("不存在的架构应返回错误")
+	}
+}
+
+func TestNativeSymbolizerSymtabFallback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Demo")
+	writeFakeMachOWithSymbols(t, path, map[string]uint64{
+		"_main":         0x100000400,
+		"-[Demo run]":   0x100000480,
+		"_$s4Demo3RunV": 0x1000004c0,
+	})
+
+	got, err := nativeSymbolizer{}.symbolize(path, 0x100000000, []uint64{0x100000410, 0x100000484, 0x100000300}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"main (in Demo) + 16", "-[Demo run] (in Demo) + 4", ""}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("symbolize()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSelectNativeSymbolizer(t *testing.T) {
+	if s := selectSymbolizer("native"); s.name() != "native" || !s.available() {
+		t.Errorf("selectSymbolizer(native) = %s", s.name())
+	}
+
+	// 自动检测：atos 和 llvm-symbolizer 都没有时使用 native
+	stubLookPath(t, map[string]bool{"atos": true, "llvm-symbolizer": true})
+	if s := selectSymbolizer(""); s.name() != "native" {
+		t.Errorf("selectSymbolizer(\"\") = %s, want native", s.name())
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ============================================================================
+// 批量符号化：一次请求符号化多份已上传的报告，单份失败不影响其它报告
+// ============================================================================
+
+// symbolicateBatchHandler 批量符号化已上传的报告
+// 逐份复用 symbolicateStoredReport（线程级 worker 池和符号缓存共享），dsym_file 为空时每份报告单独自动匹配
+func symbolicateBatchHandler(c *gin.Context) {
+	var req struct {
+		ReportIDs []string `json:"report_ids" binding:"required"`
+		DsymFile  string   `json:"dsym_file"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.ReportIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "report_ids 不能为空"})
+		return
+	}
+	if len(req.ReportIDs) > maxBulkReports {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("单次最多符号化 %d 份报告", maxBulkReports)})
+		return
+	}
+
+	startTime := time.Now()
+	results := make([]gin.H, 0, len(req.ReportIDs))
+	succeeded := 0
+
+	for _, reportID := range req.ReportIDs {
+		symbolicated, status, errBody := symbolicateStoredReport(requestLogger(c), reportID, req.DsymFile, symbolicateOptions{})
+		item := gin.H{"report_id": reportID, "status": status}
+		results = append(results, item)
+
+		if errBody != nil {
+			item["symbolicated"] = false
+			for key, value := range errBody {
+				item[key] = value
+			}
+			continue
+		}
+
+		succeeded++
+		item["symbolicated"] = true
+		item["timing"] = symbolicationTiming(symbolicated)
+		if info, ok := symbolicated["symbolication_info"].(map[string]interface{}); ok {
+			if dsymPath, ok := info["dsym_path"].(string); ok {
+				item["dsym_file"] = filepath.Base(dsymPath)
+			}
+		}
+		if warning := symbolicationWarning(symbolicated); warning != "" {
+			item["warning"] = warning
+		}
+	}
+
+	log.Printf("📦 批量符号化完成: 共 %d 份报告, 成功 %d, 失败 %d, 耗时 %v",
+		len(req.ReportIDs), succeeded, len(req.ReportIDs)-succeeded, time.Since(startTime))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "批量符号化完成",
+		"total":        len(req.ReportIDs),
+		"symbolicated": succeeded,
+		"failed":       len(req.ReportIDs) - succeeded,
+		"results":      results,
+	})
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSymbolicateBatchHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	installFakeAtos(t, `while [ $# -gt 0 ]; do
+  case "$1" in
+    -arch|-l|-o) shift 2 ;;
+    *) echo "func_$1 (in Demo) (Demo.m:7)"; shift ;;
+  esac
+done
+`)
+
+	oldDsymDir, oldReportsDir := DsymDir, ReportsDir
+	DsymDir, ReportsDir = t.TempDir(), t.TempDir()
+	defer func() { DsymDir, ReportsDir = oldDsymDir, oldReportsDir }()
+
+	dsymPath := filepath.Join(DsymDir, "Demo")
+	writeFakeMachO(t, dsymPath, 0x0100000c, 0, [16]byte{0xba, 0x01})
+	defer evictDsymInfo(dsymPath)
+	uuid, _, _ := readMachOUUID(dsymPath)
+
+	storeWithUUID := func(uuid string) string {
+		t.Helper()
+		data, _ := json.Marshal(map[string]interface{}{
+			"system": map[string]interface{}{"cpu_arch": "arm64", "CFBundleExecutable": "Demo"},
+			"binary_images": []interface{}{
+				map[string]interface{}{"name": "/var/containers/Bundle/Application/X/Demo.app/Demo", "uuid": uuid, "image_addr": float64(0x100000000)},
+			},
+			"crash": map[string]interface{}{
+				"threads": []interface{}{
+					map[string]interface{}{
+						"crashed": true,
+						"backtrace": map[string]interface{}{
+							"contents": []interface{}{
+								map[string]interface{}{"object_name": "Demo", "object_addr": float64(0x100000000), "instruction_addr": float64(0x100000400)},
+							},
+						},
+					},
+				},
+			},
+		})
+		reportID, _, _, _, err := storeReport("report.json", data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reportID
+	}
+	matched := storeWithUUID(uuid)
+	noDsym := storeWithUUID("00000000-0000-0000-0000-0000000000AA")
+
+	r := gin.New()
+	r.POST("/api/report/symbolicate/batch", symbolicateBatchHandler)
+	r.POST("/api/report/:id/resymbolicate", resymbolicateReportHandler)
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/report/symbolicate/batch", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(`{"report_ids": ["` + matched + `", "` + noDsym + `", "1700000000000000000"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("状态码 = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Total        int                      `json:"total"`
+		Symbolicated int                      `json:"symbolicated"`
+		Failed       int                      `json:"failed"`
+		Results      []map[string]interface{} `json:"results"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Total != 3 || resp.Symbolicated != 1 || resp.Failed != 2 || len(resp.Results) != 3 {
+		t.Fatalf("响应 = %s", w.Body.String())
+	}
+
+	if got := resp.Results[0]; got["report_id"] != matched || got["symbolicated"] != true || got["dsym_file"] != "Demo" {
+		t.Errorf("可符号化的报告: %v", got)
+	}
+	if got := resp.Results[1]; got["symbolicated"] != false || got["status"] != float64(http.StatusNotFound) || got["error"] != "未找到匹配的符号表" {
+		t.Errorf("没有符号表的报告: %v", got)
+	}
+	if got := resp.Results[2]; got["status"] != float64(http.StatusNotFound) || got["error"] != "报告不存在" {
+		t.Errorf("不存在的报告: %v", got)
+	}
+	if authoritativeReportFile(findReportFile(matched)) == findReportFile(matched) {
+		t.Error("符号化结果没有保存")
+	}
+
+	// 空列表
+	if w := post(`{"report_ids": []}`); w.Code != http.StatusBadRequest {
+		t.Errorf("空 report_ids 状态码 = %d, want 400", w.Code)
+	}
+}
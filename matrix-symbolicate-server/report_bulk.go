@@ -0,0 +1,384 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ============================================================================
+// 批量导入：一次上传测试产生的一批报告，自动符号化并按崩溃签名聚合
+// ============================================================================
+
+// maxBulkReports 单次批量导入的报告数量上限（MAX_BULK_REPORTS）
+var maxBulkReports = envInt("MAX_BULK_REPORTS", 1000)
+
+// isBulkArchive 判断是否为批量导入支持的压缩包
+func isBulkArchive(name string) bool {
+	return strings.HasSuffix(name, ".zip") || strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz")
+}
+
+// isBulkReportEntry 判断压缩包条目是否为报告，跳过 __MACOSX 和 ._ 等打包残留
+func isBulkReportEntry(name string) bool {
+	name = strings.TrimPrefix(name, "./")
+	base := path.Base(name)
+	if strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(base, "._") {
+		return false
+	}
+	return isSupportedReportFile(base)
+}
+
+// maxBulkTotalSize 单次批量导入解压后的总大小上限（MAX_BULK_TOTAL_SIZE），防止压缩炸弹
+var maxBulkTotalSize = int64(envInt("MAX_BULK_TOTAL_SIZE", 1024*1024*1024)) // 1GB
+
+// readBulkEntry 读取单个条目，解压后超过 MaxUploadSize 或剩余总额度 remaining 时返回错误（防止压缩炸弹）
+func readBulkEntry(name string, r io.Reader, remaining int64) ([]byte, error) {
+	limit := MaxUploadSize
+	if remaining < limit {
+		limit = remaining
+	}
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 失败: %v", name, err)
+	}
+	if int64(len(data)) > limit {
+		if limit < MaxUploadSize {
+			return nil, fmt.Errorf("压缩包解压后超过总大小上限 %s", formatBytes(maxBulkTotalSize))
+		}
+		return nil, fmt.Errorf("%s 解压后超过大小上限 %s", name, formatBytes(MaxUploadSize))
+	}
+	return data, nil
+}
+
+// readBulkArchive 逐个读取压缩包（.zip / .tar.gz）中的 .json / .txt 报告并交给 store 保存
+// 每次只在内存中保留一个条目，解压后的总大小不超过 maxBulkTotalSize；返回读取的报告数量
+func readBulkArchive(r io.ReaderAt, name string, size int64, store func(name string, data []byte) error) (int, error) {
+	count, total := 0, int64(0)
+	add := func(entryName string, open func() (io.Reader, func(), error)) error {
+		if !isBulkReportEntry(entryName) {
+			return nil
+		}
+		if count >= maxBulkReports {
+			return fmt.Errorf("压缩包中的报告超过 %d 个", maxBulkReports)
+		}
+		rc, closeEntry, err := open()
+		if err != nil {
+			return fmt.Errorf("读取 %s 失败: %v", entryName, err)
+		}
+		data, err := readBulkEntry(entryName, rc, maxBulkTotalSize-total)
+		closeEntry()
+		if err != nil {
+			return err
+		}
+		count++
+		total += int64(len(data))
+		return store(entryName, data)
+	}
+
+	if strings.HasSuffix(name, ".zip") {
+		zr, err := zip.NewReader(r, size)
+		if err != nil {
+			return 0, fmt.Errorf("不是有效的 zip 文件: %v", err)
+		}
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			f := f
+			err := add(f.Name, func() (io.Reader, func(), error) {
+				rc, err := f.Open()
+				if err != nil {
+					return nil, nil, err
+				}
+				return rc, func() { rc.Close() }, nil
+			})
+			if err != nil {
+				return count, err
+			}
+		}
+		return count, nil
+	}
+
+	gz, err := gzip.NewReader(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return 0, fmt.Errorf("不是有效的 gzip 文件: %v", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, fmt.Errorf("读取 tar 失败: %v", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		err = add(hdr.Name, func() (io.Reader, func(), error) {
+			return tr, func() {}, nil
+		})
+		if err != nil {
+			return count, err
+		}
+	}
+}
+
+// ============================================================================
+// 批量导入任务：报告保存后立即返回，符号化和聚合在后台逐份执行
+// ============================================================================
+
+// maxBulkJobs 保留的已完成批量导入任务数量，超出时丢弃最早完成的任务
+const maxBulkJobs = 100
+
+// bulkJob 一次批量导入的后台符号化任务
+type bulkJob struct {
+	mu           sync.Mutex
+	id           string
+	filename     string
+	status       string // running / done
+	total        int
+	stored       int
+	symbolicated int
+	issues       []crashIssue
+	reports      []gin.H
+	createdAt    time.Time
+	finishedAt   time.Time
+}
+
+// bulkStoredReport 已保存、等待后台符号化的报告
+type bulkStoredReport struct {
+	item     gin.H
+	reportID string
+	savePath string
+}
+
+var (
+	bulkJobsMu sync.Mutex
+	bulkJobs   = map[string]*bulkJob{}
+)
+
+// registerBulkJob 登记新任务，已完成的任务超过 maxBulkJobs 时删除最早完成的
+func registerBulkJob(job *bulkJob) {
+	bulkJobsMu.Lock()
+	defer bulkJobsMu.Unlock()
+
+	var finished []*bulkJob
+	for _, j := range bulkJobs {
+		j.mu.Lock()
+		if j.status == "done" {
+			finished = append(finished, j)
+		}
+		j.mu.Unlock()
+	}
+	if len(finished) >= maxBulkJobs {
+		sort.Slice(finished, func(a, b int) bool { return finished[a].finishedAt.Before(finished[b].finishedAt) })
+		for _, j := range finished[:len(finished)-maxBulkJobs+1] {
+			delete(bulkJobs, j.id)
+		}
+	}
+	bulkJobs[job.id] = job
+}
+
+// snapshot 返回任务当前状态的副本，后台协程仍在更新各报告的结果
+func (job *bulkJob) snapshot() gin.H {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+
+	reports := make([]gin.H, 0, len(job.reports))
+	for _, item := range job.reports {
+		copied := make(gin.H, len(item))
+		for key, value := range item {
+			copied[key] = value
+		}
+		reports = append(reports, copied)
+	}
+
+	result := gin.H{
+		"job_id":       job.id,
+		"filename":     job.filename,
+		"status":       job.status,
+		"total":        job.total,
+		"stored":       job.stored,
+		"symbolicated": job.symbolicated,
+		"reports":      reports,
+		"created_at":   job.createdAt.Format(time.RFC3339),
+	}
+	if job.status == "done" {
+		result["issues"] = job.issues
+		result["finished_at"] = job.finishedAt.Format(time.RFC3339)
+	}
+	return result
+}
+
+// run 逐份符号化已保存的报告并按崩溃签名聚合，完成后记录问题列表
+func (job *bulkJob) run(logger *slog.Logger, pending []bulkStoredReport) {
+	startTime := time.Now()
+	grouper := newCrashGrouper()
+
+	for _, stored := range pending {
+		fields := gin.H{}
+		reportMap := symbolicateBulkReport(logger, stored, fields)
+		if reportMap != nil {
+			if signature := grouper.add(stored.reportID, reportMap); signature != "" {
+				fields["signature"] = signature
+			}
+		}
+
+		job.mu.Lock()
+		for key, value := range fields {
+			stored.item[key] = value
+		}
+		if fields["symbolicated"] == true {
+			job.symbolicated++
+		}
+		job.mu.Unlock()
+	}
+
+	issues := grouper.issues()
+	job.mu.Lock()
+	job.issues = issues
+	job.status = "done"
+	job.finishedAt = time.Now()
+	log.Printf("📦 批量导入完成: %s, 共 %d 份报告, 保存 %d, 符号化 %d, 问题 %d 个, 耗时 %v",
+		job.filename, job.total, job.stored, job.symbolicated, len(issues), time.Since(startTime))
+	job.mu.Unlock()
+}
+
+// symbolicateBulkReport 读取并符号化一份已保存的报告，结果字段写入 fields
+// 返回用于聚合的报告（符号化失败时为原始报告），非 JSON 报告返回 nil
+func symbolicateBulkReport(logger *slog.Logger, stored bulkStoredReport, fields gin.H) map[string]interface{} {
+	data, err := os.ReadFile(stored.savePath)
+	if err != nil {
+		fields["error"] = "读取报告失败: " + err.Error()
+		return nil
+	}
+	var report interface{}
+	if err := parseReportData(data, &report); err != nil {
+		fields["note"] = "非 JSON 报告，未符号化"
+		return nil
+	}
+	reportMap := normalizeReportFormat(report)
+
+	matchStart := time.Now()
+	dsymPath := findMatchingDsym(report)
+	if dsymPath == "" {
+		fields["note"] = "no dsym"
+		return reportMap
+	}
+
+	// 逐份占用符号化名额，与符号化接口共用并发上限
+	release := waitSymbolicationSlot()
+	defer release()
+	if symbolicated, err := symbolicateAndSave(logger, stored.reportID, stored.savePath, report, dsymPath, time.Since(matchStart), symbolicateOptions{}); err != nil {
+		fields["error"] = "符号化失败: " + err.Error()
+	} else {
+		reportMap = symbolicated
+		fields["symbolicated"] = true
+	}
+	return reportMap
+}
+
+// bulkUploadReportsHandler 批量导入报告
+// 逐个保存压缩包中的报告后立即返回 202 和任务 ID；能匹配到符号表的报告在后台自动符号化，
+// 完成后通过 GET /api/report/bulk-upload/:job 获取按出现次数排序的问题列表
+func bulkUploadReportsHandler(c *gin.Context) {
+	file, ok := uploadedFile(c)
+	if !ok {
+		return
+	}
+
+	if !isBulkArchive(file.Filename) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "仅支持 .zip 或 .tar.gz 压缩包"})
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取上传文件失败: " + err.Error()})
+		return
+	}
+
+	results := []gin.H{}
+	var pending []bulkStoredReport
+	total, err := readBulkArchive(f, file.Filename, file.Size, func(name string, data []byte) error {
+		item := gin.H{"name": name}
+		results = append(results, item)
+
+		reportID, filename, savePath, report, err := storeReport(name, data)
+		if err != nil {
+			item["error"] = "保存文件失败: " + err.Error()
+			return nil
+		}
+		item["report_id"] = reportID
+		item["filename"] = filename
+		item["symbolicated"] = false
+		if report == nil {
+			item["note"] = "非 JSON 报告，未符号化"
+			return nil
+		}
+		pending = append(pending, bulkStoredReport{item: item, reportID: reportID, savePath: savePath})
+		return nil
+	})
+	f.Close()
+	if err != nil {
+		// 出错前已保存的报告保留，一并返回
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "reports": results})
+		return
+	}
+	if total == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "压缩包中没有 .json 或 .txt 报告"})
+		return
+	}
+
+	stored := 0
+	for _, item := range results {
+		if _, ok := item["report_id"]; ok {
+			stored++
+		}
+	}
+
+	job := &bulkJob{
+		id:        newReportID(),
+		filename:  file.Filename,
+		status:    "running",
+		total:     total,
+		stored:    stored,
+		reports:   results,
+		createdAt: time.Now(),
+	}
+	registerBulkJob(job)
+	go job.run(requestLogger(c), pending)
+
+	response := job.snapshot()
+	response["message"] = "报告已保存，正在后台符号化"
+	response["status_url"] = "/api/report/bulk-upload/" + job.id
+	c.JSON(http.StatusAccepted, response)
+}
+
+// getBulkJobHandler 查询批量导入任务的进度和问题列表
+func getBulkJobHandler(c *gin.Context) {
+	bulkJobsMu.Lock()
+	job, ok := bulkJobs[c.Param("job")]
+	bulkJobsMu.Unlock()
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "批量导入任务不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, job.snapshot())
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBulkUploadReportsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	oldReports, oldDsyms := ReportsDir, DsymDir
+	ReportsDir, DsymDir = t.TempDir(), t.TempDir()
+	defer func() { ReportsDir, DsymDir = oldReports, oldDsyms }()
+
+	crashReport := func(topSymbol string, addr int) string {
+		return fmt.Sprintf(`{"binary_images": [{"name": "/var/containers/Demo.app/Demo", "image_addr": 4096}],
+		"crash": {"threads": [{"index": 0, "crashed": true, "backtrace": {"contents": [
+			{"object_name": "libsystem_kernel.dylib", "instruction_addr": %d, "symbol_name": "__pthread_kill"},
+			{"object_name": "Demo", "instruction_addr": %d, "symbol_name": "%s"}
+		]}}]}}`, addr, addr+0x10, topSymbol)
+	}
+
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	files := map[string]string{
+		"run1/a.json":            crashReport("-[Foo bar]", 0x1000),
+		"run1/b.json":            crashReport("-[Foo bar]", 0x2000), // 地址不同，签名相同
+		"run2/c.json":            crashReport("-[Baz qux]", 0x1000),
+		"run2/notes.txt":         "not json",
+		"__MACOSX/run1/._a.json": "junk",
+		"run1/readme.md":         "ignored",
+	}
+	for name, content := range files {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	zw.Close()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("file", "testrun.zip")
+	part.Write(archive.Bytes())
+	mw.Close()
+
+	r := gin.New()
+	r.POST("/api/report/bulk-upload", bulkUploadReportsHandler)
+	r.GET("/api/report/bulk-upload/:job", getBulkJobHandler)
+	req := httptest.NewRequest(http.MethodPost, "/api/report/bulk-upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("状态码 = %d, body = %s", w.Code, w.Body.String())
+	}
+	var accepted struct {
+		StatusURL string `json:"status_url"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &accepted); err != nil {
+		t.Fatal(err)
+	}
+
+	// 轮询后台任务直到完成
+	var resp struct {
+		Status  string       `json:"status"`
+		Total   int          `json:"total"`
+		Stored  int          `json:"stored"`
+		Issues  []crashIssue `json:"issues"`
+		Reports []struct {
+			ReportID string `json:"report_id"`
+		} `json:"reports"`
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for resp.Status != "done" {
+		if time.Now().After(deadline) {
+			t.Fatalf("批量导入任务未在期限内完成: %+v", resp)
+		}
+		time.Sleep(10 * time.Millisecond)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, accepted.StatusURL, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("查询任务状态码 = %d, body = %s", w.Code, w.Body.String())
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if resp.Total != 4 || resp.Stored != 4 {
+		t.Errorf("total = %d, stored = %d, want 4 / 4", resp.Total, resp.Stored)
+	}
+	if len(resp.Issues) != 2 || resp.Issues[0].Count != 2 || resp.Issues[1].Count != 1 {
+		t.Fatalf("issues = %+v, want 两个问题，次数 2 和 1", resp.Issues)
+	}
+	if resp.Issues[0].Title != "-[Foo bar]" {
+		t.Errorf("issues[0].title = %q", resp.Issues[0].Title)
+	}
+	for _, report := range resp.Reports {
+		if _, err := os.Stat(findReportFile(report.ReportID)); err != nil {
+			t.Errorf("报告 %s 未保存: %v", report.ReportID, err)
+		}
+	}
+}
+
+func TestReadBulkArchiveLimitsTotalSize(t *testing.T) {
+	oldTotal := maxBulkTotalSize
+	maxBulkTotalSize = 1024
+	defer func() { maxBulkTotalSize = oldTotal }()
+
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	for i := 0; i < 3; i++ {
+		w, _ := zw.Create(fmt.Sprintf("report%d.json", i))
+		w.Write(bytes.Repeat([]byte(" "), 500))
+	}
+	zw.Close()
+
+	var stored []string
+	_, err := readBulkArchive(bytes.NewReader(archive.Bytes()), "run.zip", int64(archive.Len()), func(name string, data []byte) error {
+		stored = append(stored, name)
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "总大小上限") {
+		t.Fatalf("err = %v, want 超过总大小上限", err)
+	}
+	if len(stored) != 2 {
+		t.Errorf("stored = %v, want 超限前的 2 个条目", stored)
+	}
+}
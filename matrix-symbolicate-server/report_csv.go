@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// reportCSVHeader CSV 导出的列，与报告列表项的字段对应
+var reportCSVHeader = []string{"id", "filename", "dump_type", "symbolicated", "uploaded", "size", "app_version", "device"}
+
+// listReportsCSVHandler 以 CSV 导出报告列表，便于用表格软件查看
+// 支持与列表接口相同的筛选和排序参数；未指定 page / page_size 时导出全部报告
+func listReportsCSVHandler(c *gin.Context) {
+	filter, err := parseReportFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := parseListPage(c, reportSortKeys, "uploaded")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reports, err := listReports(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Query("page") == "" && c.Query("page_size") == "" {
+		page.Page, page.PageSize = 1, max(len(reports), 1)
+	}
+	lang := preferredLanguage(c.GetHeader("Accept-Language"))
+
+	filename := fmt.Sprintf("reports_%s.csv", time.Now().Format("20060102_150405"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	// 带 BOM，Excel 打开时才能正确识别中文
+	c.Writer.Write(utf8BOM)
+	w := csv.NewWriter(c.Writer)
+	w.Write(reportCSVHeader)
+	for _, report := range page.apply(reports) {
+		dumpType, _ := report["dump_type"].(string)
+		if code := report["dump_type_code"].(int); code >= 0 && lang != defaultLanguage {
+			dumpType = DumpType(code).Localized(lang)
+		}
+		w.Write([]string{
+			report["id"].(string),
+			report["filename"].(string),
+			dumpType,
+			strconv.FormatBool(report["symbolicated"].(bool)),
+			report["uploaded"].(time.Time).Format(time.RFC3339),
+			strconv.FormatInt(report["size"].(int64), 10),
+			report["app_version"].(string),
+			report["device"].(string),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Printf("⚠️ 导出 CSV 失败: %v", err)
+	}
+}
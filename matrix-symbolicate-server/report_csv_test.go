@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestListReportsCSVHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	oldDir := ReportsDir
+	ReportsDir = t.TempDir()
+	defer func() { ReportsDir = oldDir }()
+
+	const reportID = "1709294400000000001"
+	partition := reportPartitionDir(ReportsDir, reportID)
+	os.MkdirAll(partition, 0755)
+	reportFile := filepath.Join(partition, reportID+"_lag, main.json")
+	os.WriteFile(reportFile, []byte(`{
+		"dump_type": 2001,
+		"system": {"CFBundleExecutable": "Demo", "CFBundleShortVersionString": "1.2.0", "machine": "iPhone14,2"},
+		"crash": {"threads": []}
+	}`), 0644)
+	uploaded := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	os.Chtimes(reportFile, uploaded, uploaded)
+
+	// 与 /report/:id 同时注册，确认路由不冲突
+	r := gin.New()
+	r.GET("/api/report/list.csv", listReportsCSVHandler)
+	r.GET("/api/report/:id", getReportHandler)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/report/list.csv", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("状态码 = %d, body = %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/csv") {
+		t.Errorf("Content-Type = %q", got)
+	}
+
+	body := bytes.TrimPrefix(w.Body.Bytes(), utf8BOM)
+	if !strings.HasPrefix(string(body), "id,filename,dump_type,symbolicated,uploaded,size,app_version,device\n") {
+		t.Errorf("CSV 表头错误:\n%s", body)
+	}
+	// 文件名中的逗号需要加引号
+	if !strings.Contains(string(body), `"1709294400000000001_lag, main.json"`) {
+		t.Errorf("含逗号的字段未加引号:\n%s", body)
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+	if err != nil {
+		t.Fatalf("CSV 解析失败: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("CSV 共 %d 行, want 表头 + 1 行", len(records))
+	}
+	info, _ := os.Stat(reportFile)
+	want := []string{reportID, reportID + "_lag, main.json", "主线程卡顿", "false", uploaded.Local().Format(time.RFC3339), strconv.FormatInt(info.Size(), 10), "1.2.0", "iPhone14,2"}
+	if strings.Join(records[1], "|") != strings.Join(want, "|") {
+		t.Errorf("数据行 = %q\nwant     %q", records[1], want)
+	}
+}
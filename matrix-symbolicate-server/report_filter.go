@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// reportFilter 报告列表/导出的筛选条件，零值表示不筛选
+type reportFilter struct {
+	DumpType        int // 仅当 HasDumpType 为 true 时生效
+	HasDumpType     bool
+	Symbolicated    bool // 仅当 HasSymbolicated 为 true 时生效
+	HasSymbolicated bool
+	From            time.Time // 上传时间下限（含）
+	To              time.Time // 上传时间上限（含）
+}
+
+// parseReportFilter 从查询参数解析筛选条件：dump_type、symbolicated、from（或 uploaded_after）、to
+func parseReportFilter(c *gin.Context) (reportFilter, error) {
+	var filter reportFilter
+
+	if value := c.Query("dump_type"); value != "" {
+		code, err := strconv.Atoi(value)
+		if err != nil {
+			return filter, fmt.Errorf("无效的 dump_type: %s", value)
+		}
+		filter.DumpType = code
+		filter.HasDumpType = true
+	}
+
+	if value := c.Query("symbolicated"); value != "" {
+		symbolicated, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("无效的 symbolicated: %s（true 或 false）", value)
+		}
+		filter.Symbolicated = symbolicated
+		filter.HasSymbolicated = true
+	}
+
+	// uploaded_after 是 from 的别名
+	from := c.Query("from")
+	if after := c.Query("uploaded_after"); after != "" {
+		if from != "" {
+			return filter, fmt.Errorf("from 和 uploaded_after 不能同时使用")
+		}
+		from = after
+	}
+
+	var err error
+	if filter.From, err = parseFilterTime(from); err != nil {
+		return filter, fmt.Errorf("无效的 from: %v", err)
+	}
+	if filter.To, err = parseFilterTime(c.Query("to")); err != nil {
+		return filter, fmt.Errorf("无效的 to: %v", err)
+	}
+
+	return filter, nil
+}
+
+// parseFilterTime 支持 RFC3339、YYYY-MM-DD 和秒级时间戳，空字符串返回零值
+func parseFilterTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	if sec, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(sec, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("%s 不是有效的时间", value)
+}
+
+// match 判断报告是否满足筛选条件
+func (f reportFilter) match(uploaded time.Time, meta reportMeta) bool {
+	if f.HasDumpType && meta.DumpTypeCode != f.DumpType {
+		return false
+	}
+	if !f.From.IsZero() && uploaded.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && uploaded.After(f.To) {
+		return false
+	}
+	return true
+}
+
+// matchSymbolicated 判断报告的符号化状态是否满足筛选条件
+func (f reportFilter) matchSymbolicated(symbolicated bool) bool {
+	return !f.HasSymbolicated || symbolicated == f.Symbolicated
+}
+
+// listReports 列出满足筛选条件的原始报告（不含符号化结果和 sidecar）
+func listReports(filter reportFilter) ([]map[string]interface{}, error) {
+	return listReportsIn(ReportsDir, filter)
+}
+
+// listReportsIn 遍历报告目录及其日期分区，兼容旧版本平铺在根目录的报告
+func listReportsIn(dir string, filter reportFilter) ([]map[string]interface{}, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+
+	var reports []map[string]interface{}
+	err := filepath.WalkDir(dir, func(reportFile string, file fs.DirEntry, err error) error {
+		if err != nil || file.IsDir() {
+			// 单个分区读取失败不影响其它报告
+			return nil
+		}
+		reportID, ok := reportIDFromFilename(file.Name())
+		if !ok {
+			return nil
+		}
+
+		info, err := file.Info()
+		if err != nil {
+			return nil
+		}
+
+		// 从 sidecar 读取 dump_type、应用和设备信息
+		meta := loadReportMeta(reportFile)
+		if !filter.match(info.ModTime(), meta) {
+			return nil
+		}
+
+		// 检查是否已符号化
+		symbolicated := authoritativeReportFile(reportFile) != reportFile
+		if !filter.matchSymbolicated(symbolicated) {
+			return nil
+		}
+
+		reports = append(reports, map[string]interface{}{
+			"id":             reportID,
+			"filename":       file.Name(),
+			"size":           info.Size(),
+			"uploaded":       info.ModTime(),
+			"symbolicated":   symbolicated,
+			"dump_type":      meta.DumpType,
+			"dump_type_code": meta.DumpTypeCode,
+			"app_name":       meta.AppName,
+			"app_version":    meta.AppVersion,
+			"device":         meta.Device,
+			// 存储文件名为 <id>_<原始文件名>，original_filename 为上传时的文件名
+			"original_filename": meta.OriginalFilename,
+			"format":            meta.Format,
+			"signature":         meta.Signature,
+			"crash_title":       meta.CrashTitle,
+		})
+		return nil
+	})
+
+	return reports, err
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReportFilterMatch(t *testing.T) {
+	from, _ := parseFilterTime("2024-03-01")
+	to, _ := parseFilterTime("2024-03-31T23:59:59Z")
+	filter := reportFilter{DumpType: 2001, HasDumpType: true, From: from, To: to}
+
+	inRange := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		uploaded time.Time
+		meta     reportMeta
+		want     bool
+	}{
+		{"类型和时间都满足", inRange, reportMeta{DumpTypeCode: 2001}, true},
+		{"类型不同", inRange, reportMeta{DumpTypeCode: 2003}, false},
+		{"早于 from", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), reportMeta{DumpTypeCode: 2001}, false},
+		{"晚于 to", time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC), reportMeta{DumpTypeCode: 2001}, false},
+	}
+
+	for _, tt := range tests {
+		if got := filter.match(tt.uploaded, tt.meta); got != tt.want {
+			t.Errorf("%s: match() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if !(reportFilter{}).match(inRange, reportMeta{DumpTypeCode: -1}) {
+		t.Error("空筛选条件应匹配所有报告")
+	}
+}
+
+func TestParseFilterTime(t *testing.T) {
+	if got, err := parseFilterTime("1709251200"); err != nil || got.Unix() != 1709251200 {
+		t.Errorf("秒级时间戳解析错误: %v, %v", got, err)
+	}
+	if _, err := parseFilterTime("yesterday"); err == nil {
+		t.Error("无效时间应返回错误")
+	}
+}
+
+func TestListReportsHandlerFilters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	oldDir := ReportsDir
+	ReportsDir = t.TempDir()
+	defer func() { ReportsDir = oldDir }()
+
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	writeReport := func(id string, dumpType int, symbolicated bool, uploaded time.Time) {
+		partition := reportPartitionDir(ReportsDir, id)
+		os.MkdirAll(partition, 0755)
+		path := filepath.Join(partition, id+"_report.json")
+		content := fmt.Sprintf(`{"dump_type": %d}`, dumpType)
+		os.WriteFile(path, []byte(content), 0644)
+		os.Chtimes(path, uploaded, uploaded)
+		if symbolicated {
+			os.WriteFile(filepath.Join(partition, id+"_report_symbolicated.json"), []byte(content), 0644)
+		}
+	}
+	writeReport("1709294400000000001", 2001, true, base)
+	writeReport("1709294400000000002", 2001, false, base.Add(24*time.Hour))
+	writeReport("1709294400000000003", 2003, false, base.Add(48*time.Hour))
+	writeReport("1709294400000000004", 2001, false, base.Add(72*time.Hour))
+
+	r := gin.New()
+	r.GET("/api/report/list", listReportsHandler)
+	list := func(query string) (int, int) {
+		req := httptest.NewRequest(http.MethodGet, "/api/report/list?"+query, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var resp struct {
+			Total int `json:"total"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Total
+	}
+
+	after := base.Add(36 * time.Hour).Format(time.RFC3339)
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"", 4},
+		{"dump_type=2001", 3},
+		{"symbolicated=true", 1},
+		{"symbolicated=false", 3},
+		{"uploaded_after=" + after, 2},
+		{"dump_type=2001&symbolicated=false", 2},
+		{"dump_type=2001&symbolicated=false&uploaded_after=" + after, 1},
+		{"dump_type=2001&symbolicated=false&page_size=1", 2},
+	}
+	for _, tt := range tests {
+		code, total := list(tt.query)
+		if code != http.StatusOK || total != tt.want {
+			t.Errorf("%q: 状态码 = %d, total = %d, want %d", tt.query, code, total, tt.want)
+		}
+	}
+
+	for _, query := range []string{"symbolicated=maybe", "from=2024-03-01&uploaded_after=2024-03-02"} {
+		if code, _ := list(query); code != http.StatusBadRequest {
+			t.Errorf("%q: 状态码 = %d, want 400", query, code)
+		}
+	}
+}
+
+func TestExportReportsHandlerZipEntries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	oldDir := ReportsDir
+	ReportsDir = t.TempDir()
+	defer func() { ReportsDir = oldDir }()
+
+	writeReport := func(id string, dumpType int, symbolicated bool) {
+		partition := reportPartitionDir(ReportsDir, id)
+		os.MkdirAll(partition, 0755)
+		content := fmt.Sprintf(`{"dump_type": %d, "crash": {"threads": []}}`, dumpType)
+		os.WriteFile(filepath.Join(partition, id+"_report.json"), []byte(content), 0644)
+		if symbolicated {
+			os.WriteFile(filepath.Join(partition, id+"_report_symbolicated.json"), []byte(content), 0644)
+		}
+	}
+	writeReport("1709294400000000001", 2001, true)
+	writeReport("1709294400000000002", 2001, false)
+	writeReport("1709294400000000003", 2003, false)
+
+	r := gin.New()
+	r.GET("/api/report/export", exportReportsHandler)
+	req := httptest.NewRequest(http.MethodGet, "/api/report/export?dump_type=2001", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("状态码 = %d, body = %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Disposition"); !strings.HasPrefix(got, `attachment; filename="reports_2001_`) {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("响应不是有效的 zip: %v", err)
+	}
+	var got []string
+	for _, f := range zr.File {
+		got = append(got, f.Name)
+	}
+	sort.Strings(got)
+	want := []string{
+		"1709294400000000001/1709294400000000001.crash",
+		"1709294400000000001/1709294400000000001_report_symbolicated.json",
+		"1709294400000000002/1709294400000000002.crash",
+		"1709294400000000002/1709294400000000002_report.json",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("zip 条目 = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ============================================================================
+// 崩溃聚合：按崩溃签名归并相同的问题
+// ============================================================================
+
+// crashSignatureFrames 计算签名使用的崩溃线程栈顶帧数
+const crashSignatureFrames = 5
+
+// crashSignature 由崩溃线程栈顶帧的 函数名 + 镜像名 计算稳定的签名，不包含地址
+// 没有符号的帧只使用镜像名，报告中没有崩溃线程时返回空字符串
+func crashSignature(report map[string]interface{}) string {
+	thread := findCrashedThread(report)
+	if thread == nil {
+		return ""
+	}
+	backtrace, _ := thread["backtrace"].(map[string]interface{})
+	contents, _ := backtrace["contents"].([]interface{})
+
+	var parts []string
+	for _, frameData := range contents {
+		if len(parts) >= crashSignatureFrames {
+			break
+		}
+		frame, ok := frameData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		parts = append(parts, frameFunctionName(frame)+"|"+filepath.Base(getString(frame, "object_name")))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+
+	sum := sha1.Sum([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// frameFunctionName 返回帧的函数名，去掉 atos 结果中的 (in 镜像) (文件:行号)
+func frameFunctionName(frame map[string]interface{}) string {
+	if functionName := getString(frame, "function_name"); functionName != "" {
+		return functionName
+	}
+	symbol := getString(frame, "symbolicated_name")
+	if symbol == "" {
+		symbol = getString(frame, "symbol_name")
+	}
+	if idx := strings.Index(symbol, " (in "); idx != -1 {
+		symbol = symbol[:idx]
+	}
+	if symbol == "<redacted>" {
+		return ""
+	}
+	return symbol
+}
+
+// crashIssue 同一签名的一组报告
+type crashIssue struct {
+	Signature string   `json:"signature"`
+	Title     string   `json:"title"`
+	Count     int      `json:"count"`
+	ReportIDs []string `json:"report_ids"`
+}
+
+// crashGrouper 按签名累计报告
+type crashGrouper struct {
+	bySignature map[string]*crashIssue
+}
+
+// newCrashGrouper 创建空的聚合器
+func newCrashGrouper() *crashGrouper {
+	return &crashGrouper{bySignature: make(map[string]*crashIssue)}
+}
+
+// add 记录一份报告，返回其签名；无法计算签名的报告不参与聚合
+func (g *crashGrouper) add(reportID string, report map[string]interface{}) string {
+	signature := crashSignature(report)
+	if signature == "" {
+		return ""
+	}
+	g.addSigned(reportID, signature, onelineFrame(report))
+	return signature
+}
+
+// addSigned 记录一份已知签名的报告（签名来自 sidecar，不需要解析完整报告）
+func (g *crashGrouper) addSigned(reportID, signature, title string) {
+	issue, ok := g.bySignature[signature]
+	if !ok {
+		issue = &crashIssue{Signature: signature, Title: title}
+		g.bySignature[signature] = issue
+	}
+	issue.Count++
+	issue.ReportIDs = append(issue.ReportIDs, reportID)
+}
+
+// issues 返回按出现次数降序排列的问题列表，次数相同时按标题排序
+func (g *crashGrouper) issues() []crashIssue {
+	issues := make([]crashIssue, 0, len(g.bySignature))
+	for _, issue := range g.bySignature {
+		issues = append(issues, *issue)
+	}
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].Count != issues[j].Count {
+			return issues[i].Count > issues[j].Count
+		}
+		return issues[i].Title < issues[j].Title
+	})
+	return issues
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// signatureTestReport 构造崩溃线程栈顶为指定函数的已符号化报告，base 为帧地址的基址
+func signatureTestReport(base float64, functions ...string) map[string]interface{} {
+	contents := []interface{}{}
+	for i, function := range functions {
+		contents = append(contents, map[string]interface{}{
+			"object_name":      "Demo",
+			"instruction_addr": base + float64(i*0x10),
+			"function_name":    function,
+		})
+	}
+	return map[string]interface{}{
+		"crash": map[string]interface{}{
+			"threads": []interface{}{
+				map[string]interface{}{"index": float64(0), "crashed": false, "backtrace": map[string]interface{}{"contents": []interface{}{}}},
+				map[string]interface{}{"index": float64(1), "crashed": true, "backtrace": map[string]interface{}{"contents": contents}},
+			},
+		},
+	}
+}
+
+func TestCrashSignature(t *testing.T) {
+	a := crashSignature(signatureTestReport(0x100000000, "-[Foo bar]", "-[Foo baz]", "main"))
+	// 地址不同（ASLR）但栈顶帧相同
+	b := crashSignature(signatureTestReport(0x104400000, "-[Foo bar]", "-[Foo baz]", "main"))
+	// 栈顶帧不同
+	c := crashSignature(signatureTestReport(0x100000000, "-[Foo qux]", "-[Foo baz]", "main"))
+
+	if a == "" || a != b {
+		t.Errorf("相同栈顶帧的签名 = %q, %q, want 相同且非空", a, b)
+	}
+	if a == c {
+		t.Errorf("不同栈顶帧的签名相同: %q", a)
+	}
+	// 只取栈顶 crashSignatureFrames 帧
+	long := []string{"f0", "f1", "f2", "f3", "f4"}
+	if crashSignature(signatureTestReport(0, append(long, "x")...)) != crashSignature(signatureTestReport(0, append(long, "y")...)) {
+		t.Error("栈顶之外的帧影响了签名")
+	}
+	if got := crashSignature(map[string]interface{}{"crash": map[string]interface{}{}}); got != "" {
+		t.Errorf("没有线程时签名 = %q, want 空", got)
+	}
+}
+
+func TestListReportsGroupBySignature(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	oldDir := ReportsDir
+	ReportsDir = t.TempDir()
+	defer func() { ReportsDir = oldDir }()
+
+	store := func(report map[string]interface{}, symbolicated bool) string {
+		t.Helper()
+		data, _ := json.Marshal(report)
+		reportID, _, savePath, _, err := storeReport("crash.json", data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if symbolicated {
+			report["symbolication_info"] = map[string]interface{}{"signature": crashSignature(report)}
+			if err := refreshReportMeta(savePath, report); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return reportID
+	}
+	first := store(signatureTestReport(0x100000000, "-[Foo bar]", "main"), true)
+	second := store(signatureTestReport(0x104400000, "-[Foo bar]", "main"), true)
+	other := store(signatureTestReport(0x100000000, "-[Foo qux]", "main"), true)
+	store(signatureTestReport(0x100000000, "-[Foo bar]", "main"), false)
+
+	r := gin.New()
+	r.GET("/api/report/list", listReportsHandler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/report/list?group_by=signature", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("状态码 = %d, body = %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Groups   []crashIssue `json:"groups"`
+		Total    int          `json:"total"`
+		Unsigned int          `json:"unsigned"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+
+	if resp.Total != 2 || resp.Unsigned != 1 || len(resp.Groups) != 2 {
+		t.Fatalf("resp = %+v, want 2 组、1 份未签名", resp)
+	}
+	top := resp.Groups[0]
+	if top.Count != 2 || len(top.ReportIDs) != 2 || top.Title == "" {
+		t.Errorf("第一组 = %+v, want 2 份报告", top)
+	}
+	for _, id := range top.ReportIDs {
+		if id != first && id != second {
+			t.Errorf("第一组包含报告 %s，want %s 和 %s", id, first, second)
+		}
+	}
+	if got := resp.Groups[1]; got.Count != 1 || got.ReportIDs[0] != other {
+		t.Errorf("第二组 = %+v, want 只有 %s", got, other)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/report/list?group_by=app", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("group_by=app 状态码 = %d, want 400", w.Code)
+	}
+}
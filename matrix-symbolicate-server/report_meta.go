@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// reportMeta 报告元数据 sidecar（<id>.meta.json）
+// 上传和符号化时写入一次，列表接口只读 sidecar，不再解析完整报告
+// 旧版本 sidecar 的 dump_type 是字符串，解析失败后按缺失处理并重新生成
+type reportMeta struct {
+	DumpType     string `json:"dump_type_name"`
+	DumpTypeCode int    `json:"dump_type"`
+	Symbolicated bool   `json:"symbolicated"`
+	AppName      string `json:"app_name,omitempty"`
+	AppVersion   string `json:"app_version,omitempty"`
+	// Device 设备标识符（如 iPhone14,2），型号名称在展示时通过 getDeviceName 转换，devices.json 更新后无需重写 sidecar
+	Device string `json:"device,omitempty"`
+	// OriginalFilename 上传时的原始文件名，Format 上传内容的格式（json-array / json-dict / txt）
+	OriginalFilename string `json:"original_filename,omitempty"`
+	Format           string `json:"format,omitempty"`
+	// Signature 符号化时计算的崩溃签名，CrashTitle 为崩溃位置的一行描述，用于按签名聚合
+	Signature  string `json:"signature,omitempty"`
+	CrashTitle string `json:"crash_title,omitempty"`
+}
+
+// readReportFile 读取报告的权威版本，测试中替换以确认列表接口只读 sidecar
+var readReportFile = loadReportData
+
+// reportMetaPath 返回报告 sidecar 的路径，与原始报告放在同一目录
+func reportMetaPath(reportFile string) string {
+	reportID, _ := reportIDFromFilename(filepath.Base(reportFile))
+	return filepath.Join(filepath.Dir(reportFile), reportID+".meta.json")
+}
+
+// detectDumpType 从报告内容中识别 dump_type，无法识别时返回 -1
+func detectDumpType(report interface{}) (code int, name string) {
+	reportMap := normalizeReportFormat(report)
+	if reportMap == nil {
+		return -1, ""
+	}
+
+	// 检查是否是 OOM 报告
+	if head, hasHead := reportMap["head"].(map[string]interface{}); hasHead {
+		if _, hasItems := reportMap["items"].([]interface{}); hasItems {
+			name = DumpTypeOOM.String()
+
+			// 尝试从 head 中获取更多信息
+			if scene, ok := head["foom_scene"].(string); ok && scene != "" {
+				name = fmt.Sprintf("内存溢出 (OOM) - %s", scene)
+			}
+			return int(DumpTypeOOM), name
+		}
+	}
+
+	// 卡顿/崩溃报告
+	if dt, ok := reportMap["dump_type"].(float64); ok {
+		return int(dt), DumpType(dt).String()
+	}
+
+	return -1, ""
+}
+
+// reportFormat 上传内容的格式，report 为 nil 表示不是 JSON
+func reportFormat(report interface{}) string {
+	switch report.(type) {
+	case []interface{}:
+		return "json-array"
+	case map[string]interface{}:
+		return "json-dict"
+	case nil:
+		return "txt"
+	default:
+		return "json"
+	}
+}
+
+// originalReportFilename 从存储文件名（<id>_<原始文件名>）中还原原始文件名，用于补写旧的 sidecar
+func originalReportFilename(reportFile string) string {
+	name := filepath.Base(reportFile)
+	if reportID, ok := reportIDFromFilename(name); ok {
+		return strings.TrimPrefix(name, reportID+"_")
+	}
+	return name
+}
+
+// buildReportMeta 根据报告内容生成元数据，symbolicated 由是否存在 symbolication_info 判断
+func buildReportMeta(report interface{}) reportMeta {
+	code, name := detectDumpType(report)
+	meta := reportMeta{
+		DumpType:     name,
+		DumpTypeCode: code,
+	}
+
+	reportMap := normalizeReportFormat(report)
+	if reportMap == nil {
+		return meta
+	}
+	if root, ok := report.(map[string]interface{}); ok {
+		var info map[string]interface{}
+		info, meta.Symbolicated = root["symbolication_info"].(map[string]interface{})
+		if meta.Signature = getString(info, "signature"); meta.Signature != "" {
+			meta.CrashTitle = onelineFrame(reportMap)
+		}
+	}
+	if system, ok := reportMap["system"].(map[string]interface{}); ok {
+		meta.AppVersion = getString(system, "CFBundleShortVersionString")
+		meta.Device = reportDevice(system)
+	}
+	meta.AppName = appImageName(reportMap)
+	return meta
+}
+
+// refreshReportMeta 符号化后按新的权威文件重新生成 sidecar，保留上传时记录的原始文件名和格式
+func refreshReportMeta(reportFile string, report interface{}) error {
+	meta := buildReportMeta(report)
+	if old, ok := readReportMeta(reportFile); ok {
+		meta.OriginalFilename, meta.Format = old.OriginalFilename, old.Format
+	} else {
+		meta.OriginalFilename, meta.Format = originalReportFilename(reportFile), reportFormat(report)
+	}
+	return writeReportMeta(reportFile, meta)
+}
+
+// writeReportMeta 写入报告 sidecar
+func writeReportMeta(reportFile string, meta reportMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(reportMetaPath(reportFile), data, 0644)
+}
+
+// readReportMeta 读取报告 sidecar
+func readReportMeta(reportFile string) (reportMeta, bool) {
+	data, err := os.ReadFile(reportMetaPath(reportFile))
+	if err != nil {
+		return reportMeta{}, false
+	}
+
+	var meta reportMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return reportMeta{}, false
+	}
+	return meta, true
+}
+
+// loadReportMeta 读取报告元数据，sidecar 不存在时解析权威文件并补写 sidecar
+// 权威文件：存在符号化版本时为符号化结果，否则为原始报告
+func loadReportMeta(reportFile string) reportMeta {
+	if meta, ok := readReportMeta(reportFile); ok {
+		return meta
+	}
+
+	meta := reportMeta{DumpTypeCode: -1}
+
+	data, err := readReportFile(reportFile)
+	if err != nil {
+		return meta
+	}
+
+	// 非 JSON 报告同样写入 sidecar，避免每次都重新解析
+	var report interface{}
+	if err := json.Unmarshal(data, &report); err == nil {
+		meta = buildReportMeta(report)
+	}
+	meta.OriginalFilename = originalReportFilename(reportFile)
+	meta.Format = reportFormat(report)
+
+	writeReportMeta(reportFile, meta)
+	return meta
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestListReportsReadsOnlySidecars(t *testing.T) {
+	dir := t.TempDir()
+	reportID := "1709294400000000001"
+	partition := reportPartitionDir(dir, reportID)
+	os.MkdirAll(partition, 0755)
+	reportFile := filepath.Join(partition, reportID+"_report.json")
+	os.WriteFile(reportFile, []byte(`{
+		"dump_type": 2001,
+		"system": {"CFBundleExecutable": "Demo", "CFBundleShortVersionString": "1.2.0", "machine": "iPhone14,2"},
+		"crash": {"threads": []}
+	}`), 0644)
+
+	reads := 0
+	oldReadReportFile := readReportFile
+	readReportFile = func(name string) ([]byte, error) {
+		reads++
+		return oldReadReportFile(name)
+	}
+	defer func() { readReportFile = oldReadReportFile }()
+
+	list := func() map[string]interface{} {
+		t.Helper()
+		reports, err := listReportsIn(dir, reportFilter{})
+		if err != nil || len(reports) != 1 {
+			t.Fatalf("listReportsIn() = %v, %v", reports, err)
+		}
+		return reports[0]
+	}
+
+	// 没有 sidecar：解析完整报告一次并补写 sidecar
+	report := list()
+	if reads != 1 {
+		t.Fatalf("缺少 sidecar 时读取完整报告 %d 次，want 1", reads)
+	}
+	if report["dump_type_code"] != 2001 || report["app_name"] != "Demo" || report["app_version"] != "1.2.0" || report["device"] != "iPhone14,2" {
+		t.Errorf("列表项 = %v", report)
+	}
+	meta, ok := readReportMeta(reportFile)
+	if !ok || meta.DumpTypeCode != 2001 || meta.AppName != "Demo" || meta.Symbolicated {
+		t.Fatalf("补写的 sidecar = %+v, %v", meta, ok)
+	}
+
+	// 有 sidecar：不再读取完整报告
+	list()
+	if reads != 1 {
+		t.Errorf("存在 sidecar 时仍读取了完整报告（共 %d 次）", reads)
+	}
+
+	// 旧版本 sidecar（dump_type 为字符串）视为缺失并重新生成
+	os.WriteFile(reportMetaPath(reportFile), []byte(`{"dump_type": "主线程卡顿", "dump_type_code": 2001}`), 0644)
+	if report := list(); reads != 2 || report["app_name"] != "Demo" {
+		t.Errorf("旧版本 sidecar 未重新生成: reads = %d, report = %v", reads, report)
+	}
+}
+
+func TestBuildReportMetaSymbolicated(t *testing.T) {
+	meta := buildReportMeta(map[string]interface{}{
+		"dump_type":          float64(2001),
+		"symbolication_info": map[string]interface{}{"symbolicated": true},
+		"system":             map[string]interface{}{"system_name": "macOS", "machine": "arm64", "model": "Mac14,2"},
+	})
+	if !meta.Symbolicated || meta.Device != "Mac14,2" {
+		t.Errorf("buildReportMeta() = %+v", meta)
+	}
+}
+
+func TestReportOriginalFilenameRoundTrip(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	oldReportsDir := ReportsDir
+	ReportsDir = t.TempDir()
+	defer func() { ReportsDir = oldReportsDir }()
+
+	const name = "iPhone 14 Pro 卡顿 1.2.0.json"
+	reportID, _, _, _, err := storeReport("/tmp/exports/"+name, []byte(`{"dump_type": 2001, "crash": {"threads": []}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, _, err := storeReport("device log.txt", []byte("not json")); err != nil {
+		t.Fatal(err)
+	}
+
+	reports, err := listReportsIn(ReportsDir, reportFilter{})
+	if err != nil || len(reports) != 2 {
+		t.Fatalf("listReportsIn() = %v, %v", reports, err)
+	}
+	formats := make(map[string]string)
+	for _, report := range reports {
+		formats[report["original_filename"].(string)] = report["format"].(string)
+	}
+	if formats[name] != "json-dict" || formats["device log.txt"] != "txt" {
+		t.Errorf("original_filename → format = %v", formats)
+	}
+
+	r := gin.New()
+	r.GET("/api/report/:id", getReportHandler)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/report/"+reportID, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("状态码 = %d", w.Code)
+	}
+	if got, _ := url.PathUnescape(w.Header().Get("X-Report-Original-Filename")); got != name {
+		t.Errorf("X-Report-Original-Filename = %q, want %q", got, name)
+	}
+	if got := w.Header().Get("X-Report-Format"); got != "json-dict" {
+		t.Errorf("X-Report-Format = %q, want json-dict", got)
+	}
+}
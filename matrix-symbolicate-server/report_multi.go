@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ============================================================================
+// 多份报告：Matrix 部分导出会把多份报告放在同一个 JSON 数组中
+// ============================================================================
+
+// combinedReportsKey 合并结果中保存各份符号化结果的字段
+// 使用私有标记（与 _omitted_threads 等一致），避免把顶层恰好有 reports 数组的普通报告误认为合并结果
+const combinedReportsKey = "_combined_reports"
+
+// symbolicateReportArray 逐份符号化数组中的报告，返回合并结果：
+// combinedReportsKey 中为各份的符号化结果（失败的保留原始报告），symbolication_info 汇总帧数和耗时
+// 全部失败时返回第一个错误
+func symbolicateReportArray(reportArray []interface{}, dsymPath string) (map[string]interface{}, error) {
+	results := make([]interface{}, 0, len(reportArray))
+	var errs []string
+	var firstErr error
+
+	frameCounts := map[string]int{}
+	timing := map[string]interface{}{}
+	combinedInfo := map[string]interface{}{
+		"symbolicated":     true,
+		"dsym_path":        dsymPath,
+		"symbolicate_time": timeNow(),
+		"report_count":     len(reportArray),
+	}
+
+	for i, element := range reportArray {
+		reportMap := normalizeReportFormat(element)
+		if reportMap == nil {
+			err := fmt.Errorf("第 %d 份报告不是有效的 JSON 对象", i)
+			errs = append(errs, err.Error())
+			if firstErr == nil {
+				firstErr = err
+			}
+			results = append(results, element)
+			continue
+		}
+
+		result, err := symbolicateReport(reportMap, dsymPath)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("第 %d 份报告: %v", i, err))
+			if firstErr == nil {
+				firstErr = err
+			}
+			results = append(results, reportMap)
+			continue
+		}
+		results = append(results, result)
+
+		info := result["symbolication_info"].(map[string]interface{})
+		for _, key := range []string{"total_frames", "symbolicated_frames", "app_frames", "unresolved_frames"} {
+			if count, ok := info[key].(int); ok {
+				frameCounts[key] += count
+			}
+		}
+		if reportTiming, ok := info["timing"].(map[string]interface{}); ok {
+			for key, value := range reportTiming {
+				if ms, ok := value.(int64); ok {
+					total, _ := timing[key].(int64)
+					timing[key] = total + ms
+				}
+			}
+		}
+		// 任意一份 UUID 不一致都需要提示
+		if info["uuid_mismatch"] == true && combinedInfo["uuid_mismatch"] == nil {
+			combinedInfo["uuid_mismatch"] = true
+			combinedInfo["report_uuid"] = info["report_uuid"]
+			combinedInfo["dsym_uuids"] = info["dsym_uuids"]
+		}
+	}
+
+	if len(errs) == len(reportArray) {
+		return nil, firstErr
+	}
+
+	combined := map[string]interface{}{combinedReportsKey: results}
+	for key, count := range frameCounts {
+		combinedInfo[key] = count
+	}
+	combinedInfo["timing"] = timing
+	if len(errs) > 0 {
+		combinedInfo["errors"] = errs
+	}
+	combinedInfo["formatted_report"] = formatCombinedReports(combined)
+	combined["symbolication_info"] = combinedInfo
+	return combined, nil
+}
+
+// formatCombinedReports 逐份格式化合并结果中的报告，以分隔线隔开
+func formatCombinedReports(report map[string]interface{}) string {
+	reports, _ := report[combinedReportsKey].([]interface{})
+
+	var parts []string
+	for i, element := range reports {
+		reportMap, ok := element.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		// 详细模式标记需要传递给每一份报告
+		if getBool(report, verboseFramesKey) {
+			reportMap = verboseReport(reportMap)
+		}
+		header := fmt.Sprintf("========== Report %d/%d ==========\n\n", i+1, len(reports))
+		parts = append(parts, header+formatReportToAppleStyle(reportMap))
+	}
+	return strings.Join(parts, "\n")
+}
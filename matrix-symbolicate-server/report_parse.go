@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// ============================================================================
+// 报告 JSON 解析：失败时给出具体原因（截断、编码、非 JSON 文本），
+// 上传时宽松解析 .txt 等文本报告中夹带的 JSON
+// ============================================================================
+
+// utf8BOM UTF-8 BOM，部分 Windows 编辑器保存的文件带有
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// parseReportData 解析报告 JSON，失败时返回的错误信息说明具体原因，用于响应中的 detail
+func parseReportData(data []byte, v interface{}) error {
+	err := json.Unmarshal(data, v)
+	if err == nil {
+		return nil
+	}
+	return errors.New(describeReportParseError(data, err))
+}
+
+// describeReportParseError 将 json.Unmarshal 的错误转换为可读的原因
+func describeReportParseError(data []byte, err error) string {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case len(trimmed) == 0:
+		return "文件为空"
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}) || bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return "文件是 UTF-16 编码，请转换为 UTF-8 后重新上传"
+	case !utf8.Valid(data):
+		return "文件不是有效的 UTF-8 文本，可能是二进制文件或使用了其它编码"
+	}
+
+	trimmed = bytes.TrimPrefix(trimmed, utf8BOM)
+	if first := trimmed[0]; first != '{' && first != '[' {
+		// 说明文字之后夹带的 JSON 无法提取时，给出 JSON 部分的具体原因（例如被截断）
+		if start := bytes.Index(data, []byte("\n{")); start >= 0 {
+			body := data[start+1:]
+			var v interface{}
+			if err := json.Unmarshal(body, &v); err != nil {
+				line, _ := offsetLineColumn(data, int64(start+1))
+				return fmt.Sprintf("第 %d 行开始的 JSON 无法解析: %s", line, describeReportParseError(body, err))
+			}
+		}
+		return fmt.Sprintf("文件不是 JSON（开头为 %q），报告需要是 Matrix / KSCrash 导出的 JSON 内容，以 { 或 [ 开头", reportPrefix(trimmed))
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr) && syntaxErr.Offset >= int64(len(data)):
+		return fmt.Sprintf("JSON 在第 %d 字节处意外结束，文件可能被截断", len(data))
+	case errors.As(err, &syntaxErr):
+		line, column := offsetLineColumn(data, syntaxErr.Offset)
+		return fmt.Sprintf("JSON 语法错误（第 %d 行第 %d 列，偏移 %d）: %s", line, column, syntaxErr.Offset, syntaxErr.Error())
+	case errors.As(err, &typeErr) && typeErr.Field == "":
+		return fmt.Sprintf("报告顶层是 JSON %s，需要 JSON 对象", typeErr.Value)
+	case errors.As(err, &typeErr):
+		return fmt.Sprintf("字段 %s 的类型错误: 需要 %s，实际为 %s", typeErr.Field, typeErr.Type, typeErr.Value)
+	}
+	return err.Error()
+}
+
+// offsetLineColumn 将字节偏移转换为行号和列号（从 1 开始）
+func offsetLineColumn(data []byte, offset int64) (line, column int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	column = int(offset) - (bytes.LastIndexByte(before, '\n') + 1)
+	if column < 1 {
+		column = 1
+	}
+	return line, column
+}
+
+// reportPrefix 返回内容的前 20 个字符，用于错误信息
+func reportPrefix(data []byte) string {
+	prefix := string(data)
+	if runes := []rune(prefix); len(runes) > 20 {
+		prefix = string(runes[:20]) + "..."
+	}
+	return prefix
+}
+
+// extractReportJSON 宽松解析：去掉 UTF-8 BOM，跳过 JSON 之前的说明文字（例如日志头部），忽略 JSON 之后的多余内容
+// JSON 需要从某一行的第一列开始：缩进的行是嵌套对象，被截断的格式化报告不能被其中第一个完整的嵌套对象（例如单个帧）替换
+// 找不到完整的 JSON 对象或数组时 ok 为 false，调用方保留原始内容
+func extractReportJSON(data []byte) (extracted []byte, ok bool) {
+	data = bytes.TrimPrefix(data, utf8BOM)
+
+	for start := 0; start < len(data); {
+		line := data[start:]
+		if line[0] == '{' || line[0] == '[' {
+			var raw json.RawMessage
+			if err := json.NewDecoder(bytes.NewReader(line)).Decode(&raw); err == nil {
+				return raw, true
+			}
+		}
+
+		next := bytes.IndexByte(line, '\n')
+		if next < 0 {
+			break
+		}
+		start += next + 1
+	}
+	return nil, false
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseReportDataDetail(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"截断", `{"crash": {"threads": [`, "意外结束，文件可能被截断"},
+		{"非 JSON 文本", "Incident Identifier: 1234\nCrashReporter Key: abc\n", `文件不是 JSON（开头为 "Incident Identifier:..."）`},
+		{"语法错误", "{\n  \"crash\": {,}\n}", "JSON 语法错误（第 2 行第 13 列"},
+		{"空文件", "  \n", "文件为空"},
+		{"UTF-16", "\xff\xfe{\x00}\x00", "UTF-16"},
+	}
+	for _, tt := range tests {
+		var report interface{}
+		err := parseReportData([]byte(tt.data), &report)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: parseReportData() error = %v, want 包含 %q", tt.name, err, tt.want)
+		}
+	}
+
+	// 顶层类型与期望不符
+	var reportMap map[string]interface{}
+	if err := parseReportData([]byte(`[{"crash": {}}]`), &reportMap); err == nil || !strings.Contains(err.Error(), "报告顶层是 JSON array") {
+		t.Errorf("parseReportData(数组) error = %v", err)
+	}
+}
+
+func TestExtractReportJSON(t *testing.T) {
+	data := "\xef\xbb\xbf[2024-03-01 10:00:00] Matrix lag report\n" +
+		"{\"crash\": {\"threads\": []}}\n" +
+		"-- end of report --\n"
+	extracted, ok := extractReportJSON([]byte(data))
+	if !ok || string(extracted) != `{"crash": {"threads": []}}` {
+		t.Fatalf("extractReportJSON() = %q, %v", extracted, ok)
+	}
+
+	if _, ok := extractReportJSON([]byte("Incident Identifier: 1234\n{\"crash\": ")); ok {
+		t.Error("extractReportJSON(截断) ok = true, want false")
+	}
+
+	// 被截断的格式化报告：不能用缩进的嵌套对象代替整个报告
+	truncated := "{\n  \"crash\": {\n    \"threads\": [\n      {\"index\": 0, \"crashed\": true}\n      {\"index\": 1"
+	if extracted, ok := extractReportJSON([]byte(truncated)); ok {
+		t.Errorf("extractReportJSON(截断的格式化报告) = %q, want ok = false", extracted)
+	}
+}
+
+func TestSymbolicateReportInvalidJSONDetail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	oldDir := ReportsDir
+	ReportsDir = t.TempDir()
+	defer func() { ReportsDir = oldDir }()
+
+	r := gin.New()
+	r.POST("/api/report/upload", uploadReportHandler)
+	r.POST("/api/report/upload-and-symbolicate", uploadAndSymbolicateHandler)
+	r.POST("/api/report/symbolicate", symbolicateReportHandler)
+	r.GET("/api/report/:id/formatted", getFormattedReportHandler)
+
+	upload := func(path, name, content string) (int, map[string]interface{}) {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		part, _ := mw.CreateFormFile("file", name)
+		part.Write([]byte(content))
+		mw.Close()
+
+		req := httptest.NewRequest(http.MethodPost, path, &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	// 截断的 JSON：上传并符号化返回 400 和具体原因
+	code, resp := upload("/api/report/upload-and-symbolicate", "crash.json", `{"crash": {"threads": [`)
+	if code != http.StatusBadRequest || !strings.Contains(resp["detail"].(string), "截断") {
+		t.Errorf("截断: 状态码 = %d, resp = %v", code, resp)
+	}
+
+	// 非 JSON 的 .txt：上传成功但给出警告，符号化和格式化返回 400 和具体原因
+	code, resp = upload("/api/report/upload", "lag.txt", "Incident Identifier: 1234\nHardware Model: iPhone14,2\n")
+	if code != http.StatusOK || !strings.Contains(resp["warning"].(string), "文件不是 JSON") {
+		t.Fatalf("非 JSON: 状态码 = %d, resp = %v", code, resp)
+	}
+	reportID := resp["report_id"].(string)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/report/symbolicate", strings.NewReader(`{"report_id": "`+reportID+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusBadRequest || resp["error"] != "报告格式错误" || !strings.Contains(resp["detail"].(string), "文件不是 JSON") {
+		t.Errorf("符号化: 状态码 = %d, resp = %v", w.Code, resp)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/report/"+reportID+"/formatted", nil))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "detail") {
+		t.Errorf("格式化: 状态码 = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	// JSON 前带说明文字的 .txt：上传时提取出 JSON 保存
+	code, resp = upload("/api/report/upload", "lag.txt", "Matrix lag report\n{\"crash\": {\"threads\": []}}\n")
+	if code != http.StatusOK || resp["warning"] != nil {
+		t.Fatalf("带说明文字: 状态码 = %d, resp = %v", code, resp)
+	}
+	data, err := os.ReadFile(findReportFile(resp["report_id"].(string)))
+	if err != nil || string(data) != `{"crash": {"threads": []}}` {
+		t.Errorf("保存的内容 = %q, err = %v", data, err)
+	}
+
+	// 被截断的格式化报告：保存原始内容，警告中给出截断原因
+	truncated := "Matrix lag report\n{\n  \"crash\": {\n    \"threads\": [\n      {\"index\": 0}\n"
+	code, resp = upload("/api/report/upload", "lag.txt", truncated)
+	if code != http.StatusOK || !strings.Contains(resp["warning"].(string), "第 2 行开始的 JSON 无法解析: JSON 在第") {
+		t.Fatalf("截断的格式化报告: 状态码 = %d, resp = %v", code, resp)
+	}
+	data, err = os.ReadFile(findReportFile(resp["report_id"].(string)))
+	if err != nil || string(data) != truncated {
+		t.Errorf("截断的格式化报告保存的内容 = %q, err = %v", data, err)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"io/fs"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// 报告过期清理：REPORT_RETENTION_DAYS 大于 0 时，后台定期删除上传时间早于保留期限的报告
+// （连同符号化结果和元数据），默认 0 表示永久保留
+// ============================================================================
+
+// reportJanitorInterval 过期清理的执行间隔
+const reportJanitorInterval = time.Hour
+
+// reportJanitorNow 清理时使用的当前时间，测试中可替换
+var reportJanitorNow = time.Now
+
+// symbolicatingReports 正在符号化的报告 ID 及其并发数，过期清理跳过这些报告，避免删除后才写入符号化结果和 sidecar
+var symbolicatingReports = struct {
+	sync.Mutex
+	ids map[string]int
+}{ids: make(map[string]int)}
+
+// beginReportSymbolication 标记报告正在符号化，返回结束标记的函数
+func beginReportSymbolication(reportID string) (done func()) {
+	symbolicatingReports.Lock()
+	symbolicatingReports.ids[reportID]++
+	symbolicatingReports.Unlock()
+
+	return func() {
+		symbolicatingReports.Lock()
+		defer symbolicatingReports.Unlock()
+		if symbolicatingReports.ids[reportID]--; symbolicatingReports.ids[reportID] <= 0 {
+			delete(symbolicatingReports.ids, reportID)
+		}
+	}
+}
+
+// isReportSymbolicating 判断报告是否正在符号化
+func isReportSymbolicating(reportID string) bool {
+	symbolicatingReports.Lock()
+	defer symbolicatingReports.Unlock()
+	return symbolicatingReports.ids[reportID] > 0
+}
+
+// reportRetentionDays 读取 REPORT_RETENTION_DAYS，未设置、为 0 或无效时返回 0（永久保留）
+func reportRetentionDays() int {
+	value := os.Getenv("REPORT_RETENTION_DAYS")
+	if value == "" {
+		return 0
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 0 {
+		log.Printf("警告: 无效的 REPORT_RETENTION_DAYS=%s，报告将永久保留", value)
+		return 0
+	}
+	return days
+}
+
+// startReportJanitor 启动后台清理协程：启动时执行一次，之后每隔 reportJanitorInterval 执行一次
+func startReportJanitor(dir string, retentionDays int) {
+	if retentionDays <= 0 {
+		return
+	}
+	log.Printf("🧹 已启用报告过期清理，保留 %d 天", retentionDays)
+
+	go func() {
+		ticker := time.NewTicker(reportJanitorInterval)
+		defer ticker.Stop()
+		for {
+			if deleted := cleanupExpiredReports(dir, retentionDays); deleted > 0 {
+				log.Printf("🧹 已删除 %d 个过期报告", deleted)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// cleanupExpiredReports 删除上传时间（原始报告文件的修改时间）早于保留期限的报告，返回删除的数量
+// 只处理 reportIDFromFilename 能识别的原始报告文件；写入中的隐藏临时文件（writeFileAtomic）不会被识别，
+// 改名完成前既不会被列出也不会被删除。正在符号化的报告留到下一轮再删除，
+// 原始报告已不存在的符号化结果和 sidecar（如早期版本清理时正在符号化）一并删除
+func cleanupExpiredReports(dir string, retentionDays int) int {
+	if retentionDays <= 0 {
+		return 0
+	}
+	cutoff := reportJanitorNow().AddDate(0, 0, -retentionDays)
+
+	// 先收集再删除，避免遍历过程中删除目录
+	var expired, sidecars []string
+	originals := make(map[string]bool)
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		reportID, ok := reportIDFromFilename(d.Name())
+		if !ok {
+			sidecars = append(sidecars, path)
+			return nil
+		}
+		originals[filepath.Join(filepath.Dir(path), reportID)] = true
+
+		info, err := d.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			return nil
+		}
+		expired = append(expired, path)
+		return nil
+	})
+
+	deleted := 0
+	for _, reportFile := range expired {
+		info, err := os.Stat(reportFile)
+		if err != nil {
+			continue
+		}
+		reportID, _ := reportIDFromFilename(filepath.Base(reportFile))
+		if isReportSymbolicating(reportID) {
+			continue
+		}
+		deleteReportFiles(dir, reportFile)
+		delete(originals, filepath.Join(filepath.Dir(reportFile), reportID))
+		deleted++
+		slog.Info("删除过期报告",
+			"report_id", reportID,
+			"file", filepath.Base(reportFile),
+			"uploaded", info.ModTime().Format(time.RFC3339),
+			"retention_days", retentionDays,
+		)
+	}
+
+	cleanupOrphanedReportFiles(dir, sidecars, originals)
+	return deleted
+}
+
+// cleanupOrphanedReportFiles 删除原始报告已不存在的符号化结果和 sidecar
+// 文件名都以报告 ID 开头（见 migrateFlatReports）；删除前再确认一次原始报告不存在，避免误删刚上传的报告
+func cleanupOrphanedReportFiles(dir string, files []string, originals map[string]bool) {
+	for _, path := range files {
+		name := filepath.Base(path)
+		end := strings.IndexAny(name, "_.")
+		if end <= 0 {
+			continue
+		}
+		reportID := name[:end]
+		if reportPartition(reportID) == "" || originals[filepath.Join(filepath.Dir(path), reportID)] || isReportSymbolicating(reportID) {
+			continue
+		}
+		if findReportFileInDir(filepath.Dir(path), reportID) != "" {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			continue
+		}
+		removeEmptyPartitions(dir, filepath.Dir(path))
+		slog.Info("删除孤立的报告文件", "report_id", reportID, "file", name)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCleanupExpiredReports(t *testing.T) {
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+	origNow := reportJanitorNow
+	reportJanitorNow = func() time.Time { return now }
+	t.Cleanup(func() { reportJanitorNow = origNow })
+
+	dir := t.TempDir()
+	writeReport := func(reportID string, uploaded time.Time) string {
+		t.Helper()
+		partition := reportPartitionDir(dir, reportID)
+		if err := os.MkdirAll(partition, 0755); err != nil {
+			t.Fatal(err)
+		}
+		reportFile := filepath.Join(partition, reportID+"_crash.json")
+		files := []string{reportFile, filepath.Join(partition, reportID+"_crash_symbolicated.json"), filepath.Join(partition, reportID+".meta.json")}
+		for _, path := range files {
+			if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(path, uploaded, uploaded); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return reportFile
+	}
+
+	oldReport := writeReport("1700000000000000000", now.AddDate(0, 0, -31))
+	newReport := writeReport("1719000000000000000", now.AddDate(0, 0, -29))
+
+	// 写入中的临时文件即使很旧也不能被删除
+	tmpFile := filepath.Join(filepath.Dir(newReport), ".1719000000000000001_crash.json.tmp-1")
+	if err := os.WriteFile(tmpFile, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := now.AddDate(-1, 0, 0)
+	os.Chtimes(tmpFile, old, old)
+
+	if got := cleanupExpiredReports(dir, 30); got != 1 {
+		t.Errorf("cleanupExpiredReports() = %d, want 1", got)
+	}
+
+	oldPartition := filepath.Dir(oldReport)
+	if _, err := os.Stat(oldPartition); !os.IsNotExist(err) {
+		t.Errorf("过期报告及其符号化结果、元数据应被删除，空分区应被清理: %v", err)
+	}
+	for _, path := range []string{newReport, reportMetaPath(newReport), tmpFile} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s 不应被删除: %v", filepath.Base(path), err)
+		}
+	}
+
+	// 正在符号化的过期报告留到下一轮
+	inFlight := writeReport("1700000000000000001", now.AddDate(0, 0, -31))
+	done := beginReportSymbolication("1700000000000000001")
+	if got := cleanupExpiredReports(dir, 30); got != 0 {
+		t.Errorf("正在符号化的报告不应被删除, got %d", got)
+	}
+	if _, err := os.Stat(inFlight); err != nil {
+		t.Errorf("正在符号化的报告被删除: %v", err)
+	}
+	done()
+	if got := cleanupExpiredReports(dir, 30); got != 1 {
+		t.Errorf("符号化结束后应删除过期报告, got %d", got)
+	}
+
+	// 原始报告已不存在的符号化结果和 sidecar 被清理，即使还没有过期
+	orphans := []string{
+		filepath.Join(filepath.Dir(newReport), "1719000000000000002_crash_symbolicated.json"),
+		filepath.Join(filepath.Dir(newReport), "1719000000000000002.meta.json"),
+	}
+	for _, path := range orphans {
+		if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cleanupExpiredReports(dir, 30)
+	for _, path := range orphans {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("孤立文件 %s 应被删除: %v", filepath.Base(path), err)
+		}
+	}
+	for _, path := range []string{newReport, reportMetaPath(newReport), tmpFile} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s 不应被删除: %v", filepath.Base(path), err)
+		}
+	}
+
+	if got := cleanupExpiredReports(dir, 0); got != 0 {
+		t.Errorf("保留天数为 0 时不应删除报告, got %d", got)
+	}
+}
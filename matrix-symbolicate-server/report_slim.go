@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+)
+
+// ============================================================================
+// 精简的符号化结果：_symbolicated.json 只保存相对原始报告变化的部分（符号化后的线程、
+// symbolication_info 等），binary_images 等不变的大字段从原始报告读取，读取时合并还原
+// ============================================================================
+
+// slimSymbolicatedReports 符号化结果是否以精简格式保存（SLIM_SYMBOLICATED_REPORTS=1），默认保存完整结果
+var slimSymbolicatedReports = os.Getenv("SLIM_SYMBOLICATED_REPORTS") == "1" || os.Getenv("SLIM_SYMBOLICATED_REPORTS") == "true"
+
+// slimReportKey 精简格式的标记字段，值为原始报告的 ID，总是写在文件的第一个字段
+const slimReportKey = "_slim_of"
+
+// slimReportPrefix 精简格式文件的开头，读取时据此判断格式，不需要解析整个文件
+var slimReportPrefix = []byte("{\n  \"" + slimReportKey + "\":")
+
+// marshalSymbolicatedReport 生成保存到 _symbolicated.json 的内容
+// 开启精简格式时保存相对原始报告的合并补丁（RFC 7386），无法表示时仍保存完整结果
+func marshalSymbolicatedReport(reportID string, original interface{}, symbolicated map[string]interface{}) ([]byte, error) {
+	if slimSymbolicatedReports {
+		if base := normalizeReportFormat(original); base != nil {
+			if patch, ok := reportMergePatch(base, symbolicated); ok {
+				return marshalSlimPatch(reportID, patch)
+			}
+		}
+	}
+	return json.MarshalIndent(symbolicated, "", "  ")
+}
+
+// marshalSlimPatch 序列化合并补丁，标记字段固定放在最前面（以 slimReportPrefix 开头）
+func marshalSlimPatch(reportID string, patch map[string]interface{}) ([]byte, error) {
+	id, _ := json.Marshal(reportID)
+	header := append(append([]byte(nil), slimReportPrefix...), ' ')
+	header = append(header, id...)
+	if len(patch) == 0 {
+		return append(header, "\n}"...), nil
+	}
+
+	body, err := json.MarshalIndent(patch, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	// body 以 "{\n" 开头，接在标记字段之后
+	return append(append(header, ",\n"...), body[2:]...), nil
+}
+
+// reportMergePatch 计算把 base 变为 full 的合并补丁：相同的字段省略，对象逐层比较，删除的字段为 null
+// full 中有值为 null 的字段时无法与删除区分，返回 false
+func reportMergePatch(base, full map[string]interface{}) (map[string]interface{}, bool) {
+	patch := make(map[string]interface{})
+	for key, fullValue := range full {
+		baseValue, exists := base[key]
+		if exists && reflect.DeepEqual(baseValue, fullValue) {
+			continue
+		}
+		if fullValue == nil {
+			return nil, false
+		}
+
+		fullMap, fullIsMap := fullValue.(map[string]interface{})
+		baseMap, baseIsMap := baseValue.(map[string]interface{})
+		if fullIsMap && baseIsMap {
+			sub, ok := reportMergePatch(baseMap, fullMap)
+			if !ok {
+				return nil, false
+			}
+			patch[key] = sub
+			continue
+		}
+		patch[key] = fullValue
+	}
+
+	for key := range base {
+		if _, ok := full[key]; !ok {
+			patch[key] = nil
+		}
+	}
+	return patch, true
+}
+
+// applyMergePatch 将合并补丁应用到 target（会修改 target），target 不是对象时视为空对象
+func applyMergePatch(target interface{}, patch map[string]interface{}) map[string]interface{} {
+	result, ok := target.(map[string]interface{})
+	if !ok {
+		result = make(map[string]interface{})
+	}
+	for key, value := range patch {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+		if sub, ok := value.(map[string]interface{}); ok {
+			result[key] = applyMergePatch(result[key], sub)
+			continue
+		}
+		result[key] = value
+	}
+	return result
+}
+
+// loadReportData 读取报告的权威版本（符号化结果优先），精简格式的符号化结果与原始报告合并后返回
+func loadReportData(reportFile string) ([]byte, error) {
+	path := authoritativeReportFile(reportFile)
+	data, err := os.ReadFile(path)
+	if err != nil || path == reportFile {
+		return data, err
+	}
+
+	// 完整格式直接返回，不额外解析
+	if !bytes.HasPrefix(data, slimReportPrefix) {
+		return data, nil
+	}
+	var patch map[string]interface{}
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, fmt.Errorf("精简的符号化结果解析失败: %v", err)
+	}
+	delete(patch, slimReportKey)
+
+	originalData, err := os.ReadFile(reportFile)
+	if err != nil {
+		return nil, err
+	}
+	var original interface{}
+	if err := json.Unmarshal(originalData, &original); err != nil {
+		return nil, fmt.Errorf("精简的符号化结果需要原始报告，原始报告解析失败: %v", err)
+	}
+
+	return json.MarshalIndent(applyMergePatch(normalizeReportFormat(original), patch), "", "  ")
+}
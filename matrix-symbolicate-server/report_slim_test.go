@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSlimSymbolicatedReportMergesOnRead(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	oldDir, oldSlim := ReportsDir, slimSymbolicatedReports
+	ReportsDir = t.TempDir()
+	defer func() { ReportsDir, slimSymbolicatedReports = oldDir, oldSlim }()
+
+	// 数组格式的原始报告，binary_images 很大，符号化只改变线程和 symbolication_info
+	images := make([]interface{}, 200)
+	for i := range images {
+		images[i] = map[string]interface{}{"name": "/usr/lib/libfoo.dylib", "image_addr": float64(0x180000000 + i*0x10000), "image_size": float64(0x10000)}
+	}
+	original := []interface{}{map[string]interface{}{
+		"binary_images": images,
+		"system":        map[string]interface{}{"cpu_arch": "arm64", "process_name": "Demo"},
+		"crash": map[string]interface{}{
+			"threads": []interface{}{map[string]interface{}{
+				"index": float64(0), "crashed": true,
+				"backtrace": map[string]interface{}{"contents": []interface{}{
+					map[string]interface{}{"object_name": "Demo", "instruction_addr": float64(0x100000100)},
+				}},
+			}},
+			"error": map[string]interface{}{"type": "signal"},
+		},
+	}}
+	data, _ := json.Marshal(original)
+	reportID, _, reportFile, report, err := storeReport("crash.json", data)
+	if err != nil {
+		t.Fatalf("storeReport() error = %v", err)
+	}
+
+	symbolicated := deepCopyMap(normalizeReportFormat(report))
+	frame := symbolicated["crash"].(map[string]interface{})["threads"].([]interface{})[0].(map[string]interface{})["backtrace"].(map[string]interface{})["contents"].([]interface{})[0].(map[string]interface{})
+	frame["symbolicated_name"] = "-[Foo bar] (in Demo) (Foo.m:12)"
+	frame["image_base"] = int64(0x100000000)
+	symbolicated["symbolication_info"] = map[string]interface{}{"dsym_uuid": "D0010000-0000-0000-0000-000000000000"}
+	delete(symbolicated["crash"].(map[string]interface{}), "error")
+
+	r := gin.New()
+	r.GET("/api/report/:id", getReportHandler)
+	read := func(slim bool) (interface{}, int) {
+		t.Helper()
+		slimSymbolicatedReports = slim
+		out, err := marshalSymbolicatedReport(reportID, report, symbolicated)
+		if err != nil {
+			t.Fatalf("marshalSymbolicatedReport() error = %v", err)
+		}
+		os.WriteFile(symbolicatedReportPath(reportFile), out, 0644)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/report/"+reportID, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("状态码 = %d, body = %s", w.Code, w.Body.String())
+		}
+		var got interface{}
+		json.Unmarshal(w.Body.Bytes(), &got)
+		return got, len(out)
+	}
+
+	full, fullSize := read(false)
+	merged, slimSize := read(true)
+	if !reflect.DeepEqual(merged, full) {
+		t.Errorf("合并后的报告与完整结果不一致:\n merged = %v\n full   = %v", merged, full)
+	}
+	if slimSize*4 > fullSize {
+		t.Errorf("精简结果 %d 字节，完整结果 %d 字节，binary_images 不应重复保存", slimSize, fullSize)
+	}
+}
+
+func TestMarshalSlimPatchPrefix(t *testing.T) {
+	for _, patch := range []map[string]interface{}{{}, {"crash": map[string]interface{}{"error": nil}, "A": float64(1)}} {
+		out, err := marshalSlimPatch("1700000000000000000", patch)
+		if err != nil {
+			t.Fatalf("marshalSlimPatch() error = %v", err)
+		}
+		if !strings.HasPrefix(string(out), string(slimReportPrefix)) {
+			t.Errorf("精简格式不以标记字段开头:\n%s", out)
+		}
+		var got map[string]interface{}
+		if err := json.Unmarshal(out, &got); err != nil || got[slimReportKey] != "1700000000000000000" || len(got) != len(patch)+1 {
+			t.Errorf("marshalSlimPatch() = %s, err = %v", out, err)
+		}
+	}
+}
+
+func TestSlimSymbolicatedTxtReportKeepsOriginal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	installFakeAtos(t, `while [ $# -gt 0 ]; do
+  case "$1" in
+    -arch|-o|-l) shift 2 ;;
+    *) echo "main (in Demo) (main.m:10)"; shift ;;
+  esac
+done
+`)
+
+	oldDsymDir, oldReportsDir, oldSlim := DsymDir, ReportsDir, slimSymbolicatedReports
+	DsymDir, ReportsDir, slimSymbolicatedReports = t.TempDir(), t.TempDir(), true
+	defer func() { DsymDir, ReportsDir, slimSymbolicatedReports = oldDsymDir, oldReportsDir, oldSlim }()
+
+	dsymPath := filepath.Join(DsymDir, "Demo")
+	writeFakeMachO(t, dsymPath, 0x0100000c, 0, [16]byte{0x18, 0x20})
+	defer evictDsymInfo(dsymPath)
+	uuid, _, _ := readMachOUUID(dsymPath)
+
+	data, _ := json.Marshal(map[string]interface{}{
+		"system": map[string]interface{}{"cpu_arch": "arm64", "CFBundleExecutable": "Demo"},
+		"binary_images": []interface{}{
+			map[string]interface{}{"name": "/private/var/containers/Bundle/Application/X/Demo.app/Demo", "uuid": uuid, "image_addr": float64(0x100000000), "image_size": float64(0x100000)},
+		},
+		"crash": map[string]interface{}{"threads": []interface{}{map[string]interface{}{
+			"crashed": true,
+			"backtrace": map[string]interface{}{"contents": []interface{}{
+				map[string]interface{}{"object_name": "Demo", "object_addr": float64(0x100000000), "instruction_addr": float64(0x100000400)},
+			}},
+		}}},
+	})
+	reportID, _, reportFile, _, err := storeReport("crash.txt", append([]byte("导出的崩溃日志\n"), data...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.POST("/api/report/symbolicate", symbolicateReportHandler)
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/api/report/symbolicate", strings.NewReader(`{"report_id": "`+reportID+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("第 %d 次符号化状态码 = %d, body = %s", i+1, w.Code, w.Body.String())
+		}
+	}
+
+	// 原始报告不会被精简的符号化结果覆盖
+	stored, err := os.ReadFile(reportFile)
+	if err != nil || strings.HasPrefix(string(stored), string(slimReportPrefix)) {
+		t.Fatalf("原始报告被覆盖: %v\n%s", err, stored)
+	}
+	if symbolicatedReportPath(reportFile) == reportFile || authoritativeReportFile(reportFile) != symbolicatedReportPath(reportFile) {
+		t.Fatalf("符号化结果路径 = %s", authoritativeReportFile(reportFile))
+	}
+
+	merged, err := loadReportData(reportFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(merged, &got); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got["binary_images"].([]interface{}); !ok || got["symbolication_info"] == nil {
+		t.Errorf("loadReportData() 未合并原始报告: %v", got)
+	}
+	frame := got["crash"].(map[string]interface{})["threads"].([]interface{})[0].(map[string]interface{})["backtrace"].(map[string]interface{})["contents"].([]interface{})[0].(map[string]interface{})
+	if frame["function_name"] != "main" {
+		t.Errorf("function_name = %v, want main", frame["function_name"])
+	}
+
+	deleteReportFiles(ReportsDir, reportFile)
+	if _, err := os.Stat(symbolicatedReportPath(reportFile)); !os.IsNotExist(err) {
+		t.Errorf("删除报告后符号化结果仍然存在: %v", err)
+	}
+}
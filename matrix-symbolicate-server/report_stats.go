@@ -0,0 +1,97 @@
+package main
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ============================================================================
+// 报告统计：按 dump_type 汇总数量、符号化情况和磁盘占用
+// ============================================================================
+
+// dumpTypeStat 单个 dump_type 的统计
+type dumpTypeStat struct {
+	DumpTypeCode int    `json:"dump_type_code"`
+	DumpType     string `json:"dump_type"`
+	Count        int    `json:"count"`
+	Symbolicated int    `json:"symbolicated"`
+}
+
+// reportStats 统计报告目录，dump_type 来自 sidecar 缓存，不重复解析报告内容
+func reportStats(dir string) (map[string]interface{}, error) {
+	reports, err := listReportsIn(dir, reportFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	byType := make(map[int]*dumpTypeStat)
+	symbolicated := 0
+	var oldest, newest time.Time
+	for _, report := range reports {
+		code := report["dump_type_code"].(int)
+		stat, ok := byType[code]
+		if !ok {
+			name := report["dump_type"].(string)
+			if name == "" {
+				name = "未知"
+			}
+			stat = &dumpTypeStat{DumpTypeCode: code, DumpType: name}
+			byType[code] = stat
+		}
+		stat.Count++
+		if report["symbolicated"].(bool) {
+			stat.Symbolicated++
+			symbolicated++
+		}
+
+		uploaded := report["uploaded"].(time.Time)
+		if oldest.IsZero() || uploaded.Before(oldest) {
+			oldest = uploaded
+		}
+		if uploaded.After(newest) {
+			newest = uploaded
+		}
+	}
+
+	// 按数量降序，数量相同时按 dump_type 代码升序
+	types := make([]dumpTypeStat, 0, len(byType))
+	for _, stat := range byType {
+		types = append(types, *stat)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if types[i].Count != types[j].Count {
+			return types[i].Count > types[j].Count
+		}
+		return types[i].DumpTypeCode < types[j].DumpTypeCode
+	})
+
+	stats := map[string]interface{}{
+		"total":          len(reports),
+		"symbolicated":   symbolicated,
+		"unsymbolicated": len(reports) - symbolicated,
+		"dump_types":     types,
+		"total_bytes":    dirSize(dir),
+	}
+	if len(reports) > 0 {
+		stats["oldest"] = oldest
+		stats["newest"] = newest
+	}
+	return stats, nil
+}
+
+// dirSize 统计目录下所有文件（含符号化结果和 sidecar）的总字节数
+func dirSize(dir string) int64 {
+	var total int64
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReportStats(t *testing.T) {
+	dir := t.TempDir()
+
+	writeReport := func(id, content string, symbolicated bool, uploaded time.Time) {
+		partition := reportPartitionDir(dir, id)
+		os.MkdirAll(partition, 0755)
+		path := filepath.Join(partition, id+"_report.json")
+		os.WriteFile(path, []byte(content), 0644)
+		os.Chtimes(path, uploaded, uploaded)
+		if symbolicated {
+			os.WriteFile(filepath.Join(partition, id+"_report_symbolicated.json"), []byte(content), 0644)
+		}
+	}
+
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local)
+	writeReport("1709294400000000001", `{"dump_type": 2001}`, true, base)
+	writeReport("1709294400000000002", `{"dump_type": 2001}`, false, base.Add(time.Hour))
+	writeReport("1709294400000000003", `{"head": {}, "items": []}`, true, base.Add(2*time.Hour))
+	writeReport("1709294400000000004", `not json`, false, base.Add(3*time.Hour))
+
+	stats, err := reportStats(dir)
+	if err != nil {
+		t.Fatalf("reportStats() 失败: %v", err)
+	}
+	if stats["total"] != 4 || stats["symbolicated"] != 2 || stats["unsymbolicated"] != 2 {
+		t.Errorf("total/symbolicated/unsymbolicated = %v/%v/%v, want 4/2/2", stats["total"], stats["symbolicated"], stats["unsymbolicated"])
+	}
+	if !stats["oldest"].(time.Time).Equal(base) || !stats["newest"].(time.Time).Equal(base.Add(3*time.Hour)) {
+		t.Errorf("oldest/newest = %v/%v", stats["oldest"], stats["newest"])
+	}
+	if stats["total_bytes"].(int64) <= 0 {
+		t.Errorf("total_bytes = %v", stats["total_bytes"])
+	}
+
+	types := stats["dump_types"].([]dumpTypeStat)
+	want := []dumpTypeStat{
+		{DumpTypeCode: 2001, DumpType: "主线程卡顿", Count: 2, Symbolicated: 1},
+		{DumpTypeCode: -1, DumpType: "未知", Count: 1},
+		{DumpTypeCode: 3000, DumpType: "内存溢出 (OOM)", Count: 1, Symbolicated: 1},
+	}
+	if len(types) != len(want) {
+		t.Fatalf("dump_types = %+v, want %+v", types, want)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Errorf("dump_types[%d] = %+v, want %+v", i, types[i], want[i])
+		}
+	}
+
+	// 第二次统计直接读取 sidecar
+	if _, ok := readReportMeta(filepath.Join(reportPartitionDir(dir, "1709294400000000001"), "1709294400000000001_report.json")); !ok {
+		t.Error("统计后应写入 sidecar 缓存")
+	}
+}
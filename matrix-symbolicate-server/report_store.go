@@ -0,0 +1,145 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ============================================================================
+// 报告按日期分区存储
+// ============================================================================
+//
+// 新上传的报告保存在 reports/YYYY/MM/DD/ 下，避免单个目录文件过多。
+// 报告 ID 是上传时的纳秒时间戳，可以直接算出所在分区，无需扫描目录。
+// 旧版本平铺在 reports/ 根目录的报告在启动时迁移到分区（migrateFlatReports），
+// 迁移失败留在根目录的报告仍然可以查找、列出和删除。
+
+// lastReportID 最近一次分配的报告 ID
+var lastReportID int64
+
+// newReportID 分配报告 ID：当前纳秒时间戳，与上一个 ID 相同或更小时递增
+// 并发上传（或时钟精度不足的平台）也不会拿到重复的 ID，且 ID 仍是可计算分区的时间戳
+func newReportID() string {
+	for {
+		last := atomic.LoadInt64(&lastReportID)
+		id := time.Now().UnixNano()
+		if id <= last {
+			id = last + 1
+		}
+		if atomic.CompareAndSwapInt64(&lastReportID, last, id) {
+			return strconv.FormatInt(id, 10)
+		}
+	}
+}
+
+// reportPartition 由报告 ID 计算分区子目录（按 UTC 日期），ID 不是时间戳时返回空字符串
+func reportPartition(reportID string) string {
+	nano, err := strconv.ParseInt(reportID, 10, 64)
+	if err != nil || nano <= 0 {
+		return ""
+	}
+	return filepath.FromSlash(time.Unix(0, nano).UTC().Format("2006/01/02"))
+}
+
+// reportPartitionDir 返回报告所在的分区目录
+func reportPartitionDir(dir, reportID string) string {
+	return filepath.Join(dir, reportPartition(reportID))
+}
+
+// findReportFileInDir 只在单个目录中查找 ID 完全相等的原始报告
+func findReportFileInDir(dir, reportID string) string {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		if id, ok := reportIDFromFilename(file.Name()); ok && id == reportID {
+			return filepath.Join(dir, file.Name())
+		}
+	}
+
+	return ""
+}
+
+// removeEmptyPartitions 删除报告后清理空的分区目录，直到 root 为止
+func removeEmptyPartitions(root, dir string) {
+	root = filepath.Clean(root)
+	for dir = filepath.Clean(dir); dir != root && len(dir) > len(root); dir = filepath.Dir(dir) {
+		// 目录非空时 Remove 失败，停止向上清理
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+	}
+}
+
+// migrateFlatReports 将旧版本平铺在根目录的报告文件（原始报告、符号化结果、sidecar）移动到 ID 对应的日期分区
+// 报告 ID 不变；ID 不是时间戳的文件留在根目录。返回迁移的文件数量
+func migrateFlatReports(dir string) int {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+
+	migrated := 0
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() {
+			continue
+		}
+		// 这些文件都以报告 ID 开头：<id>_<原文件名>、<id>_<原文件名>_symbolicated.json、<id>.meta.json、<id>.crashlytics.json
+		reportID := name
+		if end := strings.IndexAny(name, "_."); end > 0 {
+			reportID = name[:end]
+		}
+		partition := reportPartition(reportID)
+		if partition == "" || reportID == name {
+			continue
+		}
+
+		target := filepath.Join(dir, partition, name)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			log.Printf("⚠️ 迁移报告失败 %s: %v", name, err)
+			continue
+		}
+		if err := os.Rename(filepath.Join(dir, name), target); err != nil {
+			log.Printf("⚠️ 迁移报告失败 %s: %v", name, err)
+			continue
+		}
+		migrated++
+	}
+	return migrated
+}
+
+// writeFileAtomic 先写入同目录下的隐藏临时文件，再改名为目标文件
+// 列表、读取和过期清理都只会看到完整的文件，不会读到或删除写了一半的文件
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := f.Name()
+
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+	}
+	return err
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+)
+
+// ============================================================================
+// 符号化前的报告结构校验
+// ============================================================================
+
+// validateReport 检查符号化所需的报告结构，返回具体的问题列表，结构完整时返回 nil
+// OOM（head + items）和耗电（stack_string）报告只检查各自的数据字段；
+// 卡顿/崩溃报告要求 system、crash.threads，以及带 uuid 和 image_addr 的镜像（或自带 object_addr 的帧）
+func validateReport(reportMap map[string]interface{}) []string {
+	if reportMap == nil {
+		return []string{"报告不是 JSON 对象"}
+	}
+
+	if _, ok := reportMap["head"].(map[string]interface{}); ok {
+		if items, ok := reportMap["items"].([]interface{}); !ok || len(items) == 0 {
+			return []string{"OOM 报告缺少 items 或 items 为空"}
+		}
+		return nil
+	}
+	if stackString, ok := reportMap["stack_string"].([]interface{}); ok && len(stackString) > 0 {
+		return nil
+	}
+
+	var problems []string
+
+	if _, ok := reportMap["system"].(map[string]interface{}); !ok {
+		problems = append(problems, "缺少 system 信息")
+	}
+
+	crash, ok := reportMap["crash"].(map[string]interface{})
+	if !ok {
+		if _, exists := reportMap["crash"]; exists {
+			problems = append(problems, "crash 不是对象")
+		} else {
+			problems = append(problems, "缺少 crash（也没有 stack_string 或 head/items）")
+		}
+		return problems
+	}
+
+	threads, ok := crash["threads"].([]interface{})
+	switch {
+	case !ok:
+		problems = append(problems, "缺少 crash.threads")
+	case len(threads) == 0:
+		problems = append(problems, "crash.threads 为空")
+	}
+
+	hasFrames := false
+	hasObjectAddr := false
+	for i, threadData := range threads {
+		thread, ok := threadData.(map[string]interface{})
+		if !ok {
+			problems = append(problems, fmt.Sprintf("crash.threads[%d] 不是对象", i))
+			continue
+		}
+		backtrace, _ := thread["backtrace"].(map[string]interface{})
+		contents, _ := backtrace["contents"].([]interface{})
+		for _, frameData := range contents {
+			frame, ok := frameData.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if _, ok := frame["instruction_addr"].(float64); ok {
+				hasFrames = true
+			}
+			if _, ok := frame["object_addr"].(float64); ok {
+				hasObjectAddr = true
+			}
+		}
+	}
+	if len(threads) > 0 && !hasFrames {
+		problems = append(problems, "所有线程都没有带 instruction_addr 的堆栈帧（crash.threads[].backtrace.contents）")
+	}
+
+	problems = append(problems, validateBinaryImages(reportMap, hasObjectAddr)...)
+	return problems
+}
+
+// validateBinaryImages 检查 binary_images：至少一个镜像同时带 uuid 和 image_addr
+// 没有 binary_images 的变体中帧自带 object_addr，此时不要求镜像列表
+func validateBinaryImages(reportMap map[string]interface{}, hasObjectAddr bool) []string {
+	raw, exists := reportMap["binary_images"]
+	if !exists {
+		if hasObjectAddr {
+			return nil
+		}
+		return []string{"缺少 binary_images，堆栈帧也没有 object_addr，无法确定镜像加载地址"}
+	}
+
+	images, ok := raw.([]interface{})
+	if !ok {
+		return []string{"binary_images 不是数组"}
+	}
+
+	var problems []string
+	usable := 0
+	for i, imgData := range images {
+		img, ok := imgData.(map[string]interface{})
+		if !ok {
+			problems = append(problems, fmt.Sprintf("binary_images[%d] 不是对象", i))
+			continue
+		}
+		if v, exists := img["image_addr"]; exists {
+			if _, ok := v.(float64); !ok {
+				problems = append(problems, fmt.Sprintf("binary_images[%d].image_addr 不是数字", i))
+				continue
+			}
+		}
+		if _, ok := img["image_addr"].(float64); ok && getString(img, "uuid") != "" {
+			usable++
+		}
+	}
+	if usable == 0 {
+		problems = append(problems, "binary_images 中没有同时带 uuid 和 image_addr 的镜像")
+	}
+	return problems
+}
+
+// validateReportValue 校验解析后的报告 JSON
+// 多份报告的数组与 symbolicateReportArray 一致，允许部分报告有问题，只有全部无效时才返回问题（加 reports[i] 前缀）
+func validateReportValue(report interface{}) []string {
+	reportArray, ok := report.([]interface{})
+	if !ok || len(reportArray) <= 1 {
+		return validateReport(normalizeReportFormat(report))
+	}
+
+	var problems []string
+	for i, item := range reportArray {
+		itemProblems := validateReport(normalizeReportFormat(item))
+		if len(itemProblems) == 0 {
+			return nil
+		}
+		for _, problem := range itemProblems {
+			problems = append(problems, fmt.Sprintf("reports[%d]: %s", i, problem))
+		}
+	}
+	return problems
+}
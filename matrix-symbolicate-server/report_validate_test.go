@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidateReport(t *testing.T) {
+	validImages := `"binary_images": [{"name": "/var/Demo.app/Demo", "uuid": "ABC", "image_addr": 4294967296}]`
+	validThreads := `"crash": {"threads": [{"backtrace": {"contents": [{"instruction_addr": 4294968320}]}}]}`
+
+	tests := []struct {
+		name   string
+		report string
+		want   []string
+	}{
+		{
+			name:   "完整的卡顿报告",
+			report: `{"system": {}, ` + validImages + `, ` + validThreads + `}`,
+		},
+		{
+			name:   "没有 binary_images 但帧带 object_addr",
+			report: `{"system": {}, "crash": {"threads": [{"backtrace": {"contents": [{"instruction_addr": 1, "object_addr": 0}]}}]}}`,
+		},
+		{
+			name:   "OOM 报告",
+			report: `{"head": {}, "items": [{}]}`,
+		},
+		{
+			name:   "不是对象",
+			report: `"hello"`,
+			want:   []string{"报告不是 JSON 对象"},
+		},
+		{
+			name:   "缺少 crash 和 system",
+			report: `{` + validImages + `}`,
+			want:   []string{"缺少 system 信息", "缺少 crash（也没有 stack_string 或 head/items）"},
+		},
+		{
+			name:   "crash 不是对象",
+			report: `{"system": {}, "crash": []}`,
+			want:   []string{"crash 不是对象"},
+		},
+		{
+			name:   "缺少 threads",
+			report: `{"system": {}, ` + validImages + `, "crash": {"error": {}}}`,
+			want:   []string{"缺少 crash.threads"},
+		},
+		{
+			name:   "线程没有堆栈帧",
+			report: `{"system": {}, ` + validImages + `, "crash": {"threads": ["bad", {"backtrace": {"contents": []}}]}}`,
+			want: []string{
+				"crash.threads[0] 不是对象",
+				"所有线程都没有带 instruction_addr 的堆栈帧（crash.threads[].backtrace.contents）",
+			},
+		},
+		{
+			name:   "镜像缺少 uuid，image_addr 是字符串",
+			report: `{"system": {}, ` + validThreads + `, "binary_images": [{"name": "A", "image_addr": 1}, {"uuid": "B", "image_addr": "0x1000"}]}`,
+			want: []string{
+				"binary_images[1].image_addr 不是数字",
+				"binary_images 中没有同时带 uuid 和 image_addr 的镜像",
+			},
+		},
+		{
+			name:   "缺少 binary_images",
+			report: `{"system": {}, ` + validThreads + `}`,
+			want:   []string{"缺少 binary_images，堆栈帧也没有 object_addr，无法确定镜像加载地址"},
+		},
+		{
+			name:   "OOM 报告没有 items",
+			report: `{"head": {}}`,
+			want:   []string{"OOM 报告缺少 items 或 items 为空"},
+		},
+		{
+			name:   "多份报告中有一份有效",
+			report: `[{"system": {}}, {"system": {}, ` + validImages + `, ` + validThreads + `}]`,
+		},
+		{
+			name:   "多份报告全部无效",
+			report: `[{"system": {}}, {"crash": []}]`,
+			want: []string{
+				"reports[0]: 缺少 crash（也没有 stack_string 或 head/items）",
+				"reports[1]: 缺少 system 信息",
+				"reports[1]: crash 不是对象",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var report interface{}
+			if err := json.Unmarshal([]byte(tt.report), &report); err != nil {
+				t.Fatalf("测试数据不是合法 JSON: %v", err)
+			}
+			if got := validateReportValue(report); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validateReportValue() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSymbolicateReportHandlerRejectsMalformedReport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	oldReportsDir := ReportsDir
+	ReportsDir = t.TempDir()
+	defer func() { ReportsDir = oldReportsDir }()
+
+	reportID, _, _, _, err := storeReport("report.json", []byte(`{"system": {}, "crash": {"threads": []}}`))
+	if err != nil {
+		t.Fatalf("storeReport() 失败: %v", err)
+	}
+
+	r := gin.New()
+	r.POST("/api/report/symbolicate", symbolicateReportHandler)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/report/symbolicate", strings.NewReader(`{"report_id": "`+reportID+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("状态码 = %d, want 422, body = %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Problems []string `json:"problems"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	want := []string{"crash.threads 为空", "缺少 binary_images，堆栈帧也没有 object_addr，无法确定镜像加载地址"}
+	if !reflect.DeepEqual(resp.Problems, want) {
+		t.Errorf("problems = %q, want %q", resp.Problems, want)
+	}
+}
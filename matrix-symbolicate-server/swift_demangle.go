@@ -0,0 +1,207 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ============================================================================
+// Swift 符号 demangle：atos 未能 demangle 时的后处理
+// ============================================================================
+
+// swiftDemangleEnabled 是否对 atos 输出中的 mangled Swift 符号做 demangle（SWIFT_DEMANGLE=0 关闭）
+var swiftDemangleEnabled = os.Getenv("SWIFT_DEMANGLE") != "0" && os.Getenv("SWIFT_DEMANGLE") != "false"
+
+// swiftDemangleCacheSize demangle 缓存的最大条目数（SWIFT_DEMANGLE_CACHE_SIZE）
+var swiftDemangleCacheSize = envInt("SWIFT_DEMANGLE_CACHE_SIZE", 10000)
+
+// demangleCacheEntry demangle 结果，失败时 demangled 与 mangled 相同
+type demangleCacheEntry struct {
+	mangled   string
+	demangled string
+}
+
+// demangleCache 按最近使用淘汰的 demangle 缓存：mangled → 结果，以及 demangled → mangled 的反向映射，用于在帧中保留原始符号
+// 同一符号在报告中通常出现多次，失败的结果也缓存，避免重复启动 swift-demangle 进程
+type demangleCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	forward  map[string]*list.Element
+	reverse  map[string]*list.Element
+}
+
+func newDemangleCache(capacity int) *demangleCache {
+	return &demangleCache{
+		capacity: capacity,
+		ll:       list.New(),
+		forward:  make(map[string]*list.Element),
+		reverse:  make(map[string]*list.Element),
+	}
+}
+
+// demangledSymbols 所有请求共享的 demangle 缓存
+var demangledSymbols = newDemangleCache(swiftDemangleCacheSize)
+
+func (c *demangleCache) get(mangled string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.forward[mangled]; ok {
+		c.ll.MoveToFront(elem)
+		return elem.Value.(*demangleCacheEntry).demangled, true
+	}
+	return "", false
+}
+
+func (c *demangleCache) put(mangled, demangled string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.forward[mangled]; ok {
+		return
+	}
+
+	elem := c.ll.PushFront(&demangleCacheEntry{mangled: mangled, demangled: demangled})
+	c.forward[mangled] = elem
+	if demangled != mangled {
+		c.reverse[demangled] = elem
+	}
+	for c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		entry := oldest.Value.(*demangleCacheEntry)
+		c.ll.Remove(oldest)
+		delete(c.forward, entry.mangled)
+		// 不同的 mangled 符号可能得到相同的结果，只删除指向自己的反向映射
+		if c.reverse[entry.demangled] == oldest {
+			delete(c.reverse, entry.demangled)
+		}
+	}
+}
+
+// mangled 返回 demangled 对应的原始符号
+func (c *demangleCache) mangled(demangled string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.reverse[demangled]; ok {
+		return elem.Value.(*demangleCacheEntry).mangled
+	}
+	return ""
+}
+
+// demangleSwiftSymbol 解码 Swift 符号，失败或关闭时返回原始符号
+// 依次尝试 swift-demangle、swift demangle，工具都不可用时使用内置的简易 demangler
+func demangleSwiftSymbol(mangledSymbol string) string {
+	if !swiftDemangleEnabled {
+		return mangledSymbol
+	}
+
+	if cached, ok := demangledSymbols.get(mangledSymbol); ok {
+		return cached
+	}
+
+	demangled := runSwiftDemangle(mangledSymbol)
+	if demangled == "" {
+		demangled = builtinSwiftDemangle(mangledSymbol)
+	}
+	if demangled == "" || demangled == mangledSymbol {
+		log.Printf("⚠️ Swift demangle 失败，保留原始符号: %s", mangledSymbol)
+		demangledSymbols.put(mangledSymbol, mangledSymbol)
+		return mangledSymbol
+	}
+
+	demangledSymbols.put(mangledSymbol, demangled)
+	log.Printf("✅ Swift demangle 成功: %s → %s", mangledSymbol, demangled)
+	return demangled
+}
+
+// runSwiftDemangle 调用外部 demangle 工具，工具不可用或输出无法解析时返回空字符串
+func runSwiftDemangle(mangledSymbol string) string {
+	var name string
+	var args []string
+	switch {
+	case toolAvailable("swift-demangle"):
+		name, args = "swift-demangle", []string{mangledSymbol}
+	case toolAvailable("swift"):
+		name, args = "swift", []string{"demangle", mangledSymbol}
+	default:
+		return ""
+	}
+
+	out, err := commandRunner.Run(context.Background(), name, args...)
+	if err != nil {
+		log.Printf("⚠️ %s 失败: %v, 符号: %s", name, err, mangledSymbol)
+		return ""
+	}
+
+	// 输出格式: "原始符号 ---> 解码后的符号"
+	demangled := strings.TrimSpace(string(out))
+	if _, after, found := strings.Cut(demangled, "--->"); found {
+		demangled = strings.TrimSpace(after)
+	}
+	return demangled
+}
+
+// mangledSwiftName 返回 demangle 前的原始符号，demangledName 不是由 demangleSwiftSymbol 生成时返回空字符串
+func mangledSwiftName(demangledName string) string {
+	return demangledSymbols.mangled(demangledName)
+}
+
+// builtinSwiftDemangle 内置的简易 demangler，只处理最常见的 "模块.类型.成员" 形式：
+// $s3App9ViewModelC4loadyyF → App.ViewModel.load() -> ()
+// 无法识别的符号（泛型、替换、元数据等）返回空字符串
+func builtinSwiftDemangle(symbol string) string {
+	s := strings.TrimPrefix(symbol, "_")
+	if !strings.HasPrefix(s, "$s") && !strings.HasPrefix(s, "$S") {
+		return ""
+	}
+	s = s[2:]
+
+	// 长度前缀的标识符序列，类型后跟 C（class）/V（struct）/O（enum）/P（protocol）
+	var names []string
+	i := 0
+	for i < len(s) && isASCIIDigit(s[i]) {
+		j := i
+		for j < len(s) && isASCIIDigit(s[j]) {
+			j++
+		}
+		n, err := strconv.Atoi(s[i:j])
+		// 0 开头表示 punycode 等特殊编码，不处理
+		if err != nil || n == 0 || s[i] == '0' || j+n > len(s) {
+			return ""
+		}
+		names = append(names, s[j:j+n])
+		i = j + n
+
+		if i+1 < len(s) && strings.IndexByte("CVOP", s[i]) >= 0 && isASCIIDigit(s[i+1]) {
+			i++
+		}
+	}
+	if len(names) < 2 {
+		return ""
+	}
+
+	name := strings.Join(names, ".")
+	switch suffix := s[i:]; {
+	case suffix == "yyF":
+		return name + "() -> ()"
+	case strings.HasSuffix(suffix, "vg"):
+		return name + ".getter"
+	case strings.HasSuffix(suffix, "vs"):
+		return name + ".setter"
+	case strings.HasSuffix(suffix, "F"):
+		return name + "(...)"
+	}
+	return ""
+}
+
+// isASCIIDigit 判断字节是否为 0-9
+func isASCIIDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// resetDemangleCache 清空 demangle 缓存，测试结束后再次清空
+func resetDemangleCache(t *testing.T) {
+	t.Helper()
+
+	old := demangledSymbols
+	demangledSymbols = newDemangleCache(swiftDemangleCacheSize)
+	t.Cleanup(func() { demangledSymbols = old })
+}
+
+func TestBuiltinSwiftDemangle(t *testing.T) {
+	tests := map[string]string{
+		"$s3App9ViewModelC4loadyyF":            "App.ViewModel.load() -> ()",
+		"_$s3App9ViewModelC4loadyyF":           "App.ViewModel.load() -> ()",
+		"$s3App9ViewModelC5fetchyySiF":         "App.ViewModel.fetch(...)",
+		"$s3App8SettingsV4nameSSvg":            "App.Settings.name.getter",
+		"$s13MatrixTestApp5PointV1xSdvs":       "MatrixTestApp.Point.x.setter",
+		"$s3App9ViewModelCMa":                  "", // 类型元数据访问器
+		"$s3AppAA9ViewModelC4loadyyF":          "", // 单词替换
+		"-[TestLagViewController simulateLag]": "",
+	}
+
+	for input, want := range tests {
+		if got := builtinSwiftDemangle(input); got != want {
+			t.Errorf("builtinSwiftDemangle(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestPostProcessSymbolDemanglesSwift(t *testing.T) {
+	const mangled = "$s3App9ViewModelC4loadyyF"
+	const atosLine = mangled + " (in App) (ViewModel.swift:12)"
+
+	t.Run("swift-demangle 可用", func(t *testing.T) {
+		resetDemangleCache(t)
+		stubLookPath(t, nil)
+		calls := 0
+		installFakeRunner(t, func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			calls++
+			if name != "swift-demangle" || len(args) != 1 || args[0] != mangled {
+				t.Errorf("调用了 %s %v", name, args)
+			}
+			return []byte(mangled + " ---> App.ViewModel.load() -> ()\n"), nil
+		})
+
+		frame := map[string]interface{}{}
+		applySymbolToFrame(frame, postProcessSymbol(atosLine, 0x1000))
+		if frame["function_name"] != "App.ViewModel.load() -> ()" || frame["mangled_name"] != mangled || frame["file_name"] != "ViewModel.swift" {
+			t.Errorf("帧 = %v", frame)
+		}
+
+		// 同一符号再次出现时使用缓存
+		postProcessSymbol(atosLine, 0x2000)
+		if calls != 1 {
+			t.Errorf("swift-demangle 调用了 %d 次，want 1", calls)
+		}
+	})
+
+	t.Run("工具不可用时使用内置 demangler", func(t *testing.T) {
+		resetDemangleCache(t)
+		stubLookPath(t, map[string]bool{"swift-demangle": true, "swift": true})
+		installFakeRunner(t, func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			t.Errorf("不应调用外部工具: %s %v", name, args)
+			return nil, nil
+		})
+
+		frame := map[string]interface{}{}
+		applySymbolToFrame(frame, postProcessSymbol(atosLine, 0x1000))
+		if frame["function_name"] != "App.ViewModel.load() -> ()" || frame["mangled_name"] != mangled {
+			t.Errorf("帧 = %v", frame)
+		}
+	})
+
+	t.Run("失败的结果也缓存", func(t *testing.T) {
+		resetDemangleCache(t)
+		stubLookPath(t, nil)
+		calls := 0
+		installFakeRunner(t, func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			calls++
+			return nil, errors.New("exit status 1")
+		})
+
+		const unknown = "$s3App9ViewModelCMa"
+		for i := 0; i < 2; i++ {
+			if got := demangleSwiftSymbol(unknown); got != unknown {
+				t.Errorf("demangleSwiftSymbol() = %q, want %q", got, unknown)
+			}
+		}
+		if calls != 1 {
+			t.Errorf("swift-demangle 调用了 %d 次，want 1", calls)
+		}
+		if got := mangledSwiftName(unknown); got != "" {
+			t.Errorf("失败的符号不应有反向映射: %q", got)
+		}
+	})
+
+	t.Run("关闭 demangle", func(t *testing.T) {
+		resetDemangleCache(t)
+		old := swiftDemangleEnabled
+		swiftDemangleEnabled = false
+		defer func() { swiftDemangleEnabled = old }()
+
+		frame := map[string]interface{}{}
+		applySymbolToFrame(frame, postProcessSymbol(atosLine, 0x1000))
+		if frame["function_name"] != mangled {
+			t.Errorf("function_name = %v, want %s", frame["function_name"], mangled)
+		}
+		if _, ok := frame["mangled_name"]; ok {
+			t.Errorf("关闭 demangle 时不应设置 mangled_name: %v", frame)
+		}
+	})
+}
+
+func TestDemangleCacheEvictsOldest(t *testing.T) {
+	cache := newDemangleCache(2)
+	cache.put("$sA", "A")
+	cache.put("$sB", "B")
+	cache.get("$sA")
+	cache.put("$sC", "C")
+
+	if _, ok := cache.get("$sB"); ok {
+		t.Error("最久未使用的条目应被淘汰")
+	}
+	if cache.mangled("B") != "" {
+		t.Error("淘汰条目的反向映射应被删除")
+	}
+	if cache.mangled("A") != "$sA" || cache.mangled("C") != "$sC" {
+		t.Errorf("反向映射错误: A=%q C=%q", cache.mangled("A"), cache.mangled("C"))
+	}
+	if cache.ll.Len() != 2 || len(cache.forward) != 2 || len(cache.reverse) != 2 {
+		t.Errorf("缓存大小超过上限: %d/%d/%d", cache.ll.Len(), len(cache.forward), len(cache.reverse))
+	}
+}
@@ -2,13 +2,21 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"debug/macho"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -29,41 +37,6 @@ func isSwiftSymbol(symbol string) bool {
 		strings.HasPrefix(symbol, "_T")
 }
 
-// demangleSwiftSymbol 使用 swift demangle 工具解码 Swift 符号
-func demangleSwiftSymbol(mangledSymbol string) string {
-	// 尝试使用 swift demangle 命令
-	cmd := exec.Command("swift", "demangle", mangledSymbol)
-
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &out
-
-	if err := cmd.Run(); err != nil {
-		log.Printf("⚠️ Swift demangle 失败: %v, 符号: %s", err, mangledSymbol)
-		return mangledSymbol // 失败则返回原始符号
-	}
-
-	demangled := strings.TrimSpace(out.String())
-
-	// swift demangle 输出格式: "原始符号 ---> 解码后的符号"
-	if strings.Contains(demangled, "--->") {
-		parts := strings.Split(demangled, "--->")
-		if len(parts) >= 2 {
-			demangled = strings.TrimSpace(parts[1])
-		}
-	}
-
-	// 如果解码成功且不同于原始符号
-	if demangled != "" && demangled != mangledSymbol {
-		log.Printf("✅ Swift demangle 成功:")
-		log.Printf("   原始: %s", mangledSymbol)
-		log.Printf("   解码: %s", demangled)
-		return demangled
-	}
-
-	return mangledSymbol
-}
-
 // detectSymbolLanguage 检测符号的编程语言类型
 func detectSymbolLanguage(symbol string) string {
 	if isSwiftSymbol(symbol) {
@@ -108,8 +81,40 @@ func isSymbolWellFormatted(symbol string) bool {
 // dSYM 信息提取
 // ============================================================================
 
-// extractDsymInfo 提取 dSYM 的 UUID 和架构信息
-func extractDsymInfo(dsymPath string) (uuid string, arch string, err error) {
+// developerDir 指定 Xcode 工具链（DEVELOPER_DIR），为空时使用 xcode-select 的默认值
+// 多 Xcode 的构建机上用于固定 atos / dwarfdump 的版本
+var developerDir = os.Getenv("DEVELOPER_DIR")
+
+// toolCommandContext 创建外部工具命令，ctx 结束时终止进程；配置了 developerDir 时显式传给子进程
+func toolCommandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	if developerDir != "" {
+		cmd.Env = append(os.Environ(), "DEVELOPER_DIR="+developerDir)
+	}
+	return cmd
+}
+
+// lookPath 查找外部工具，测试中可替换以模拟工具缺失
+var lookPath = exec.LookPath
+
+// toolAvailable 检查外部工具是否可用
+func toolAvailable(name string) bool {
+	_, err := lookPath(name)
+	return err == nil
+}
+
+// DsymSlice 符号表中单个架构的 UUID
+type DsymSlice struct {
+	UUID string `json:"uuid"`
+	Arch string `json:"arch"`
+}
+
+// dwarfdumpUUIDRegex 匹配 dwarfdump --uuid 的输出行: UUID: XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX (arm64)
+var dwarfdumpUUIDRegex = regexp.MustCompile(`UUID: ([A-Fa-f0-9-]+) \(([^)]+)\)`)
+
+// extractDsymInfo 提取 dSYM 中所有架构 slice 的 UUID
+// 通用（fat）dSYM 每个架构一个 UUID，例如 armv7 + arm64
+func extractDsymInfo(dsymPath string) ([]DsymSlice, error) {
 	// 如果是 .app 文件，查找内部的二进制文件
 	binaryPath := dsymPath
 	if strings.HasSuffix(dsymPath, ".app") {
@@ -117,168 +122,478 @@ func extractDsymInfo(dsymPath string) (uuid string, arch string, err error) {
 		binaryPath = filepath.Join(dsymPath, appName)
 	}
 
-	// 如果是 .dSYM.zip，需要先解压
-	if strings.HasSuffix(dsymPath, ".dSYM.zip") {
-		// 解压到临时目录
-		tmpDir := filepath.Join(os.TempDir(), "dsym_extract")
-		os.MkdirAll(tmpDir, 0755)
-
-		cmd := exec.Command("unzip", "-o", dsymPath, "-d", tmpDir)
-		if err := cmd.Run(); err != nil {
-			return "", "", fmt.Errorf("解压 dSYM 失败: %v", err)
+	// 如果是 .dSYM.zip / .dSYM.tar.gz，需要先解压，读取完 UUID 后立即清理
+	if isDsymArchive(dsymPath) {
+		extracted, cleanup, err := extractDsymArchive(dsymPath)
+		if err != nil {
+			return nil, err
 		}
+		defer cleanup()
+		binaryPath = extracted
+	}
 
-		// 查找 .dSYM 目录中的二进制文件
-		matches, err := filepath.Glob(filepath.Join(tmpDir, "*.dSYM/Contents/Resources/DWARF/*"))
-		if err != nil || len(matches) == 0 {
-			return "", "", fmt.Errorf("未找到 DWARF 文件")
-		}
-		binaryPath = matches[0]
+	// 优先使用内置 Mach-O 解析，不依赖 dwarfdump
+	if slices, err := readMachOSlices(binaryPath); err == nil {
+		return slices, nil
+	}
+
+	if !toolAvailable("dwarfdump") {
+		return nil, fmt.Errorf("dwarfdump 不可用，且内置 Mach-O 解析失败")
 	}
 
 	// 使用 dwarfdump 获取 UUID
-	cmd := exec.Command("dwarfdump", "--uuid", binaryPath)
-	output, err := cmd.Output()
+	output, err := commandRunner.Run(context.Background(), "dwarfdump", "--uuid", binaryPath)
 	if err != nil {
-		return "", "", fmt.Errorf("dwarfdump 执行失败: %v", err)
+		return nil, fmt.Errorf("dwarfdump 执行失败: %v", err)
 	}
 
-	// 解析输出: UUID: XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX (arm64)
-	re := regexp.MustCompile(`UUID: ([A-F0-9-]+) \(([^)]+)\)`)
-	matches := re.FindStringSubmatch(string(output))
-	if len(matches) >= 3 {
-		uuid = strings.ToUpper(matches[1])
-		arch = matches[2]
+	return parseDwarfdumpUUIDs(string(output)), nil
+}
+
+// parseDwarfdumpUUIDs 解析 dwarfdump --uuid 输出中的每一行 UUID
+func parseDwarfdumpUUIDs(output string) []DsymSlice {
+	var slices []DsymSlice
+	for _, matches := range dwarfdumpUUIDRegex.FindAllStringSubmatch(output, -1) {
+		slices = append(slices, DsymSlice{
+			UUID: normalizeUUID(matches[1]),
+			Arch: matches[2],
+		})
+	}
+	return slices
+}
+
+// primarySlice 返回第一个 slice 的 UUID 和架构，没有 slice 时返回空
+func primarySlice(slices []DsymSlice) (uuid string, arch string) {
+	if len(slices) == 0 {
+		return "", ""
 	}
+	return slices[0].UUID, slices[0].Arch
+}
 
-	return uuid, arch, nil
+// dsymHasUUID 判断符号表的任意 slice 是否包含指定 UUID
+func dsymHasUUID(slices []DsymSlice, uuid string) bool {
+	for _, slice := range slices {
+		if compareUUID(slice.UUID, uuid) {
+			return true
+		}
+	}
+	return false
 }
 
 // normalizeReportFormat 统一报告格式（数组转字典）
 func normalizeReportFormat(report interface{}) map[string]interface{} {
 	// 情况1：已经是字典
 	if reportMap, ok := report.(map[string]interface{}); ok {
-		return reportMap
+		// 多份报告符号化后的合并结果，取第一份
+		if reports, ok := reportMap[combinedReportsKey].([]interface{}); ok && len(reports) > 0 {
+			return normalizeReportFormat(reports[0])
+		}
+		return unwrapReportEnvelope(reportMap)
 	}
 
 	// 情况2：是数组，取第一个元素
 	if reportArray, ok := report.([]interface{}); ok && len(reportArray) > 0 {
 		if reportMap, ok := reportArray[0].(map[string]interface{}); ok {
-			return reportMap
+			return unwrapReportEnvelope(reportMap)
 		}
 	}
 
 	return nil
 }
 
-// findMatchingDsym 查找匹配的符号表
-func findMatchingDsym(report interface{}) string {
-	// 统一格式
-	reportMap := normalizeReportFormat(report)
-	if reportMap == nil {
-		return ""
+// unwrapReportEnvelope 部分 Matrix 导出会把报告包在单个字段中（{"report": {...}}、{"crash_report": {...}}），
+// 内层包含 crash 对象时返回内层报告，否则原样返回
+func unwrapReportEnvelope(reportMap map[string]interface{}) map[string]interface{} {
+	if len(reportMap) != 1 {
+		return reportMap
+	}
+	for _, value := range reportMap {
+		if inner, ok := value.(map[string]interface{}); ok {
+			if _, hasCrash := inner["crash"].(map[string]interface{}); hasCrash {
+				return inner
+			}
+		}
+	}
+	return reportMap
+}
+
+// deepCopyMap 深拷贝 JSON 对象，嵌套的 map / slice 都会复制
+func deepCopyMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
 	}
+	return deepCopyValue(m).(map[string]interface{})
+}
 
+// deepCopyValue 深拷贝 json.Unmarshal 产生的任意值
+func deepCopyValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			copied[k] = deepCopyValue(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(val))
+		for i, item := range val {
+			copied[i] = deepCopyValue(item)
+		}
+		return copied
+	default:
+		return val
+	}
+}
+
+// findAppImage 在 binary_images 中查找应用主二进制对应的镜像
+// 依次使用：system.CFBundleExecutablePath → 进程名 → .app/.appex 路径启发式
+// watchOS 应用的代码位于 WatchKit Extension（.appex）中
+func findAppImage(reportMap map[string]interface{}) map[string]interface{} {
 	binaryImages, ok := reportMap["binary_images"].([]interface{})
 	if !ok || len(binaryImages) == 0 {
-		return ""
+		return nil
 	}
 
-	// 查找应用的 UUID
-	var appUUID string
+	var images []map[string]interface{}
 	for _, img := range binaryImages {
-		imgMap, ok := img.(map[string]interface{})
-		if !ok {
+		if imgMap, ok := img.(map[string]interface{}); ok {
+			images = append(images, imgMap)
+		}
+	}
+
+	system, _ := reportMap["system"].(map[string]interface{})
+
+	// 1. 可执行文件路径：先精确匹配，再容忍 /private 等前缀差异
+	if exePath := getString(system, "CFBundleExecutablePath"); exePath != "" {
+		for _, img := range images {
+			if getString(img, "name") == exePath {
+				return img
+			}
+		}
+		if strings.Contains(exePath, "/") {
+			for _, img := range images {
+				if isAppImagePath(getString(img, "name"), exePath) {
+					return img
+				}
+			}
+		}
+	}
+
+	// 2. 进程名与镜像文件名一致
+	for _, key := range []string{"process_name", "CFBundleExecutable"} {
+		processName := getString(system, key)
+		if processName == "" {
 			continue
 		}
+		for _, img := range images {
+			if filepath.Base(getString(img, "name")) == processName {
+				return img
+			}
+		}
+	}
 
-		name := imgMap["name"].(string)
-		if strings.Contains(name, "MatrixTestApp") || strings.Contains(name, ".app/") {
-			appUUID = strings.ToUpper(imgMap["uuid"].(string))
-			break
+	// 3. 启发式：位于 .app / .appex 中、且不是内嵌 framework 的镜像
+	for _, img := range images {
+		name := getString(img, "name")
+		if (strings.Contains(name, ".app/") || strings.Contains(name, ".appex/")) && !strings.Contains(name, ".framework/") {
+			return img
 		}
 	}
 
-	if appUUID == "" {
-		return ""
+	return nil
+}
+
+// appImageName 返回应用主二进制的文件名，用于判断帧是否属于应用代码
+// 报告没有 binary_images 时（帧自带 object_name/object_addr 的变体）使用进程名
+func appImageName(reportMap map[string]interface{}) string {
+	appImage := findAppImage(reportMap)
+	if appImage == nil {
+		system, _ := reportMap["system"].(map[string]interface{})
+		if name := getString(system, "CFBundleExecutable"); name != "" {
+			return name
+		}
+		return getString(system, "process_name")
 	}
+	return filepath.Base(getString(appImage, "name"))
+}
 
-	// 遍历所有符号表文件
-	files, err := os.ReadDir(DsymDir)
-	if err != nil {
-		return ""
+// normalizeArch 将报告中的 cpu_arch 转换为 atos -arch 需要的架构名
+// atos 要求架构名与 dSYM 中的 slice 完全一致，例如 watchOS 的 arm64_32、A12 以后的 arm64e
+func normalizeArch(cpuArch string) string {
+	arch := strings.ToLower(strings.TrimSpace(cpuArch))
+
+	switch {
+	case arch == "arm64_32" || arch == "arm64-32":
+		return "arm64_32"
+	case arch == "arm64e":
+		return "arm64e"
+	case arch == "armv7s" || arch == "armv7k" || arch == "armv7":
+		return arch
+	case strings.HasPrefix(arch, "armv") || arch == "arm":
+		return "armv7"
+	case arch == "i386" || arch == "x86":
+		return "i386"
+	case strings.Contains(arch, "x86"):
+		return "x86_64"
+	}
+
+	return "arm64"
+}
+
+// archFallbacks 报告架构在 dSYM 中不存在时可以代替的 slice
+// 例如 arm64e 设备上运行的 arm64 应用，报告为 arm64e，dSYM 只有 arm64
+var archFallbacks = map[string][]string{
+	"arm64e": {"arm64"},
+	"arm64":  {"arm64e"},
+	"armv7s": {"armv7"},
+	"armv7k": {"armv7"},
+	"armv7":  {"armv7s"},
+}
+
+// selectDsymArch 在 dSYM 实际包含的 slice 中选择 atos 使用的架构
+// 优先 UUID 与报告应用镜像一致的 slice，其次架构完全相同，再次兼容架构；dSYM 只有一个 slice 时直接使用
+func selectDsymArch(arch string, slices []DsymSlice, reportUUID string) string {
+	if len(slices) == 0 {
+		return arch
 	}
 
-	for _, file := range files {
-		if file.IsDir() {
-			continue
+	if reportUUID != "" {
+		for _, slice := range slices {
+			if slice.Arch != "" && compareUUID(slice.UUID, reportUUID) {
+				return slice.Arch
+			}
 		}
+	}
 
-		dsymPath := filepath.Join(DsymDir, file.Name())
-		uuid, _, err := extractDsymInfo(dsymPath)
-		if err != nil {
-			continue
+	candidates := append([]string{arch}, archFallbacks[arch]...)
+	for _, candidate := range candidates {
+		for _, slice := range slices {
+			if slice.Arch == candidate {
+				return candidate
+			}
 		}
+	}
+
+	if len(slices) == 1 && slices[0].Arch != "" {
+		return slices[0].Arch
+	}
+	return arch
+}
 
-		if uuid == appUUID {
-			return dsymPath
+// reportLoadAddress 返回报告中应用镜像的加载地址
+// 没有 binary_images 时使用应用帧自带的 object_addr
+func reportLoadAddress(reportMap map[string]interface{}) (uint64, bool) {
+	appImage := findAppImage(reportMap)
+	if appImage == nil {
+		if _, objectAddrs := sampleAppFrames(reportMap, appImageName(reportMap), 1); len(objectAddrs) > 0 {
+			return objectAddrs[0], true
 		}
+		return 0, false
 	}
+	addr, ok := appImage["image_addr"].(float64)
+	if !ok {
+		return 0, false
+	}
+	return uint64(addr), true
+}
 
-	return ""
+// reportArch 返回报告对应的 atos 架构名，缺失时默认 arm64
+// macOS 报告优先使用进程的 binary_cpu_type：Apple 芯片 Mac 上经 Rosetta 运行的 x86_64 应用，cpu_arch 仍是 arm64
+func reportArch(reportMap map[string]interface{}) string {
+	system, ok := reportMap["system"].(map[string]interface{})
+	if !ok {
+		return "arm64"
+	}
+
+	if reportPlatform(system) == "macOS" {
+		if cpuType, ok := system["binary_cpu_type"].(float64); ok && cpuType > 0 {
+			subType := uint32(getInt64(system, "binary_cpu_subtype"))
+			return normalizeArch(machoArchName(macho.Cpu(uint32(cpuType)), subType))
+		}
+	}
+	if cpuArch, ok := system["cpu_arch"].(string); ok {
+		return normalizeArch(cpuArch)
+	}
+	// macOS 上 machine 就是架构名
+	if machine := getString(system, "machine"); isArchName(machine) {
+		return normalizeArch(machine)
+	}
+	return "arm64"
 }
 
+// parseHexAddress 解析十六进制地址，支持带或不带 0x 前缀
+func parseHexAddress(s string) (uint64, error) {
+	trimmed := strings.TrimSpace(s)
+	trimmed = strings.TrimPrefix(strings.TrimPrefix(trimmed, "0x"), "0X")
+	if trimmed == "" {
+		return 0, fmt.Errorf("地址为空")
+	}
+	addr, err := strconv.ParseUint(trimmed, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("无效的十六进制地址: %s", s)
+	}
+	return addr, nil
+}
+
+// findMatchingDsym 查找匹配的符号表
+func findMatchingDsym(report interface{}) string {
+	// 统一格式
+	reportMap := normalizeReportFormat(report)
+	if reportMap == nil {
+		return ""
+	}
+
+	// 查找应用的 UUID
+	appImage := findAppImage(reportMap)
+	if appImage == nil {
+		return ""
+	}
+
+	appUUID := normalizeUUID(getString(appImage, "uuid"))
+	if appUUID == "" {
+		return ""
+	}
+
+	// 通过 UUID 索引查找（通用 dSYM 的每个架构都建有索引）
+	if dsymPath := findDsymByUUID(DsymDir, appUUID); dsymPath != "" {
+		return dsymPath
+	}
+
+	// 未上传的符号表：在 DSYM_SEARCH_PATHS 中查找
+	return findDsymInSearchPaths(dsymSearchPaths, appUUID)
+}
+
+// symbolicateOptions 符号化请求中的可选参数
+type symbolicateOptions struct {
+	// loadAddress 请求指定的应用加载地址，非 nil 时覆盖报告和符号表中的值，且不再抽样校验
+	loadAddress *uint64
+}
+
+// 加载地址的来源，记录到 symbolication_info.load_address_source
+const (
+	loadAddrSourceRequest  = "request"  // 请求中的 load_address
+	loadAddrSourceReport   = "report"   // 报告中应用镜像的 image_addr（或帧的 object_addr）
+	loadAddrSourceDsym     = "dsym"     // 报告中没有，使用符号表的 __TEXT vmaddr
+	loadAddrSourceVerified = "verified" // 报告中的地址校验失败，改用抽样校验得到的地址
+)
+
 // symbolicateReport 符号化报告
 func symbolicateReport(report interface{}, dsymPath string) (map[string]interface{}, error) {
+	return symbolicateReportWithOptions(report, dsymPath, symbolicateOptions{})
+}
+
+// validateLoadAddressOverride 校验请求指定的加载地址：
+// 多份报告的数组中每份报告的加载地址不同，不支持覆盖；报告中应用镜像地址非 0 时不接受 0
+func validateLoadAddressOverride(report interface{}, loadAddr uint64) error {
+	if reportArray, ok := report.([]interface{}); ok && len(reportArray) > 1 {
+		return fmt.Errorf("包含多份报告的文件不支持指定 load_address")
+	}
+	if loadAddr == 0 {
+		if reportMap := normalizeReportFormat(report); reportMap != nil {
+			if addr, ok := reportLoadAddress(reportMap); ok && addr != 0 {
+				return fmt.Errorf("load_address 为 0，但报告中应用镜像的加载地址为 0x%x", addr)
+			}
+		}
+	}
+	return nil
+}
+
+// symbolicateReportWithOptions 按请求参数符号化报告
+func symbolicateReportWithOptions(report interface{}, dsymPath string, opts symbolicateOptions) (map[string]interface{}, error) {
+	// 包含多份报告的数组：逐份符号化后合并
+	if reportArray, ok := report.([]interface{}); ok && len(reportArray) > 1 {
+		return symbolicateReportArray(reportArray, dsymPath)
+	}
+
 	// 解析报告 - 统一处理数组和字典格式
 	reportMap := normalizeReportFormat(report)
 	if reportMap == nil {
 		return nil, fmt.Errorf("报告格式错误：无法解析为有效的 JSON 对象")
 	}
 
-	// 获取二进制路径和加载地址
-	binaryPath, loadAddr, err := getBinaryInfo(dsymPath)
+	startTime := time.Now()
+
+	// 获取二进制路径和加载地址（解压出的二进制需要保留到所有 atos 调用结束）
+	binaryPath, loadAddr, cleanup, err := getBinaryInfo(dsymPath)
 	if err != nil {
 		return nil, err
 	}
+	defer cleanup()
+	extractionTime := time.Since(startTime)
 
 	// 从报告中获取加载地址
-	binaryImages, ok := reportMap["binary_images"].([]interface{})
-	if ok && len(binaryImages) > 0 {
-		for _, img := range binaryImages {
-			imgMap, ok := img.(map[string]interface{})
-			if !ok {
-				continue
-			}
+	binaryImages, _ := reportMap["binary_images"].([]interface{})
+	loadAddrSource := loadAddrSourceDsym
+	if addr, ok := reportLoadAddress(reportMap); ok {
+		loadAddr = addr
+		loadAddrSource = loadAddrSourceReport
+	}
+	if opts.loadAddress != nil {
+		loadAddr = *opts.loadAddress
+		loadAddrSource = loadAddrSourceRequest
+	}
 
-			name := imgMap["name"].(string)
-			if strings.Contains(name, "MatrixTestApp") || strings.Contains(name, ".app/") {
-				if addr, ok := imgMap["image_addr"].(float64); ok {
-					loadAddr = uint64(addr)
-				}
-				break
+	// 获取架构
+	arch := reportArch(reportMap)
+	appName := appImageName(reportMap)
+
+	// 校验符号表与报告应用镜像的 UUID：不一致时仍然符号化，但结果不可信
+	reportUUID := getString(findAppImage(reportMap), "uuid")
+	var dsymUUIDs []string
+	dsymUUID := ""
+	uuidMismatch := false
+	uuidMatched := false
+	if slices, err := readMachOSlices(binaryPath); err == nil {
+		// atos -arch 必须是 dSYM 中实际存在的 slice
+		if selected := selectDsymArch(arch, slices, reportUUID); selected != arch {
+			log.Printf("🔧 报告架构 %s 在符号表中不存在，使用 %s", arch, selected)
+			arch = selected
+		}
+
+		for _, slice := range slices {
+			dsymUUIDs = append(dsymUUIDs, slice.UUID)
+			if slice.Arch == arch || len(slices) == 1 {
+				dsymUUID = normalizeUUID(slice.UUID)
 			}
 		}
+		uuidMatched = reportUUID != "" && dsymHasUUID(slices, reportUUID)
+		if reportUUID != "" && !uuidMatched {
+			uuidMismatch = true
+			log.Printf("⚠️ 符号表 UUID %v 与报告应用镜像 UUID %s 不一致，符号化结果可能不正确", dsymUUIDs, reportUUID)
+		}
 	}
 
-	// 获取架构
-	arch := "arm64"
-	if system, ok := reportMap["system"].(map[string]interface{}); ok {
-		if cpuArch, ok := system["cpu_arch"].(string); ok {
-			if strings.Contains(strings.ToLower(cpuArch), "x86") {
-				arch = "x86_64"
-			}
+	// 报告中没有应用加载地址时使用所选架构 slice 的 __TEXT vmaddr（slide 为 0）
+	if loadAddrSource == loadAddrSourceDsym {
+		if textAddr, err := machoTextVMAddr(binaryPath, arch); err == nil {
+			loadAddr = textAddr
+		} else {
+			log.Printf("⚠️ 读取符号表 __TEXT 地址失败: %v", err)
 		}
 	}
 
-	// 检查报告类型并符号化
-	result := make(map[string]interface{})
-	for k, v := range reportMap {
-		result[k] = v
+	// 抽样校验应用加载地址，报告中的 image_addr 不可信时改用推算出的地址
+	// 请求明确指定的加载地址不校验，以调用方为准
+	loadAddrCorrected := false
+	loadAddrWarning := ""
+	if loadAddrSource != loadAddrSourceRequest {
+		loadAddr, loadAddrCorrected, loadAddrWarning = verifyLoadAddress(binaryPath, loadAddr, arch, appName, reportMap)
+		if loadAddrCorrected {
+			loadAddrSource = loadAddrSourceVerified
+		}
 	}
+	slide := ""
+	if textAddr, err := machoTextVMAddr(binaryPath, arch); err == nil && loadAddr >= textAddr {
+		slide = fmt.Sprintf("0x%x", loadAddr-textAddr)
+	}
+
+	// 检查报告类型并符号化
+	// 深拷贝：保证修改 crash/threads/backtrace 时不丢失也不污染任何兄弟字段
+	result := deepCopyMap(reportMap)
 
 	var symbolicated []interface{}
 	var dumpType int
+	var imageDsymPaths []string // 系统库等镜像匹配到的符号表
 	
 	// 获取 dump_type
 	if dt, ok := reportMap["dump_type"].(float64); ok {
@@ -286,13 +601,16 @@ func symbolicateReport(report interface{}, dsymPath string) (map[string]interfac
 	}
 
 	// binary_images 已经在第246行获取了，这里直接使用
+
+	// 符号化阶段耗时（绝大部分是 atos 调用）
+	atosStart := time.Now()
 	// 如果之前没有获取到，初始化为空数组
 	if binaryImages == nil {
 		binaryImages = []interface{}{}
 	}
 
 	// 判断报告类型：OOM、卡顿、耗电
-	if head, hasHead := reportMap["head"].(map[string]interface{}); hasHead {
+	if _, hasHead := reportMap["head"].(map[string]interface{}); hasHead {
 		if items, hasItems := reportMap["items"].([]interface{}); hasItems {
 			// OOM 内存溢出报告格式：head + items[]
 			log.Printf("📊 检测到 OOM 内存溢出报告，items数组长度=%d", len(items))
@@ -301,15 +619,14 @@ func symbolicateReport(report interface{}, dsymPath string) (map[string]interfac
 				log.Printf("⚠️  OOM 符号化部分失败: %v", err)
 			}
 			result["items"] = symbolicatedItems
-			result["head"] = head
-			dumpType = 3000 // OOM 类型码
+			dumpType = int(DumpTypeOOM)
 		}
 	} else if stackString, ok := reportMap["stack_string"].([]interface{}); ok && len(stackString) > 0 {
 		// 耗电监控数据格式：stack_string[]
 		log.Printf("📊 检测到耗电监控数据，dump_type=%d, stack_string数组长度=%d", dumpType, len(stackString))
 		symbolicated = symbolicateCustomStack(stackString, binaryPath, loadAddr, arch, binaryImages)
 		result["stack_string"] = symbolicated
-		dumpType = 2011 // 确保设置为耗电类型 (EDumpType_PowerConsume)
+		dumpType = int(DumpTypePowerConsume) // 确保设置为耗电类型
 	} else if crash, ok := reportMap["crash"].(map[string]interface{}); ok {
 		// 卡顿数据格式：crash.threads[]
 		log.Printf("📊 检测到卡顿监控数据，dump_type=%d", dumpType)
@@ -319,55 +636,209 @@ func symbolicateReport(report interface{}, dsymPath string) (map[string]interfac
 			return nil, fmt.Errorf("报告中没有线程信息")
 		}
 
-		// 创建新的 crash 对象
-		newCrash := make(map[string]interface{})
-		for k, v := range crash {
-			newCrash[k] = v
+		// crash 已随 result 深拷贝，直接修改副本
+		newCrash := result["crash"].(map[string]interface{})
+
+		// 系统库等其它镜像：按 UUID 匹配已上传的符号表，帧按所属镜像分别符号化
+		imageStart := time.Now()
+		appImage := findAppImage(reportMap)
+		appUUID := getString(appImage, "uuid")
+		imageDsyms := findImageDsymsIn(DsymDir, binaryImages, appUUID)
+		imagePaths, imageCleanup := openImageBinaries(imageDsyms)
+		defer imageCleanup()
+		for _, path := range imageDsyms {
+			imageDsymPaths = append(imageDsymPaths, path)
 		}
-		result["crash"] = newCrash
-
-		// 符号化线程
-		for _, t := range threads {
-			thread := t.(map[string]interface{})
-			symbolicatedThread := symbolicateThread(thread, binaryPath, loadAddr, arch)
-			symbolicated = append(symbolicated, symbolicatedThread)
+		sort.Strings(imageDsymPaths)
+		extractionTime += time.Since(imageStart)
+		atosStart = time.Now()
+
+		binaries := &imageBinaries{
+			appPath:          binaryPath,
+			appName:          appName,
+			appLoadAddr:      loadAddr,
+			overrideLoadAddr: loadAddrCorrected || loadAddrSource == loadAddrSourceRequest,
+			retryOtherArches: !uuidMatched,
+			binaryImages:     binaryImages,
+			byImageAddr:      imagePaths,
 		}
+		if addr, ok := appImage["image_addr"].(float64); ok {
+			binaries.appImageAddr, binaries.hasAppImage = uint64(addr), true
+		}
+
+		// 符号化线程（并发，结果保持原始顺序）
+		cache := newSymbolCache()
+		symbolicated = symbolicateThreads(threads, symbolicateWorkers, binaries, arch, appName, cache)
 
 		newCrash["threads"] = symbolicated
+
+		// recrash_report：崩溃处理器自身崩溃时的线程，同样需要符号化
+		if recrash, ok := result["recrash_report"].(map[string]interface{}); ok {
+			if recrashCrash, ok := recrash["crash"].(map[string]interface{}); ok {
+				if recrashThreads, ok := recrashCrash["threads"].([]interface{}); ok {
+					for i, t := range recrashThreads {
+						recrashThreads[i] = symbolicateThreadSafe(t, binaries, arch, appName, cache)
+					}
+				}
+			}
+		}
 	} else {
 		return nil, fmt.Errorf("报告格式不支持：既没有 stack_string 也没有 crash 信息")
 	}
 
+	atosTime := time.Since(atosStart)
+
 	// ========================================================================
 	// 符号化统计
 	// ========================================================================
 	stats := calculateSymbolicationStats(symbolicated, dumpType)
+	frameCounts := map[string]int{
+		"total_frames":        stats["total_frames"].(int),
+		"symbolicated_frames": stats["symbolicated_frames"].(int),
+		"app_frames":          stats["app_code_frames"].(int),
+		"unresolved_frames":   stats["total_frames"].(int) - stats["symbolicated_frames"].(int),
+	}
+	if _, ok := reportMap["crash"].(map[string]interface{}); ok {
+		frameCounts = countThreadFrames(symbolicated, appName)
+	}
 
 	// 添加符号化元数据
-	result["symbolication_info"] = map[string]interface{}{
-		"symbolicated":     true,
-		"dsym_path":        dsymPath,
-		"binary_path":      binaryPath,
-		"load_address":     fmt.Sprintf("0x%x", loadAddr),
-		"architecture":     arch,
-		"symbolicate_time": timeNow(),
-		"formatted_report": formatReportToAppleStyle(result),
-		"statistics":       stats, // ✅ 新增：符号化统计
-	}
+	symbInfo := map[string]interface{}{
+		"symbolicated":        true,
+		"dsym_path":           dsymPath,
+		"binary_path":         binaryPath,
+		"load_address":        fmt.Sprintf("0x%x", loadAddr),
+		"load_address_source": loadAddrSource,
+		"architecture":        arch,
+		"symbolicate_time":    timeNow(),
+		"formatted_report":    formatReportToAppleStyle(result),
+		"statistics":          stats, // ✅ 新增：符号化统计
+		"timing": map[string]interface{}{
+			"extraction_ms": extractionTime.Milliseconds(),
+			"matching_ms":   int64(0), // 由调用方通过 recordMatchingTime 补充
+			"atos_ms":       atosTime.Milliseconds(),
+			"total_ms":      time.Since(startTime).Milliseconds(),
+		},
+	}
+	for key, count := range frameCounts {
+		symbInfo[key] = count
+	}
+	if len(imageDsymPaths) > 0 {
+		symbInfo["image_dsyms"] = imageDsymPaths
+	}
+	if dsymUUID != "" {
+		symbInfo["dsym_uuid"] = dsymUUID
+	}
+	// 崩溃签名：基于符号化后的崩溃线程栈顶帧，用于归并相同的崩溃
+	if signature := crashSignature(result); signature != "" {
+		symbInfo["signature"] = signature
+	}
+	if slide != "" {
+		symbInfo["slide"] = slide
+	}
+	if loadAddrCorrected {
+		symbInfo["load_address_corrected"] = true
+	}
+	if loadAddrWarning != "" {
+		symbInfo["load_address_warning"] = loadAddrWarning
+	}
+	// 改用其它架构 slice 解析出的帧：代码布局不同，结果仅供参考
+	if fallback := countArchFallbackFrames(symbolicated); len(fallback) > 0 {
+		symbInfo["arch_fallback_frames"] = fallback
+	}
+	if uuidMismatch {
+		symbInfo["uuid_mismatch"] = true
+		symbInfo["report_uuid"] = normalizeUUID(reportUUID)
+		symbInfo["dsym_uuids"] = dsymUUIDs
+	}
+	result["symbolication_info"] = symbInfo
 
 	// 打印统计信息
-	log.Printf("📊 符号化统计:")
-	log.Printf("   总线程数: %d", stats["total_threads"])
-	log.Printf("   总帧数: %d", stats["total_frames"])
-	log.Printf("   符号化帧数: %d", stats["symbolicated_frames"])
-	log.Printf("   Swift 符号: %d", stats["swift_symbols"])
-	log.Printf("   ObjC 符号: %d", stats["objc_symbols"])
-	log.Printf("   应用代码帧: %d", stats["app_code_frames"])
-	log.Printf("   符号化成功率: %.1f%%", stats["success_rate"])
+	slog.Info("符号化统计",
+		"dsym_uuid", dsymUUID,
+		"arch", arch,
+		"threads", stats["total_threads"],
+		"frames_total", stats["total_frames"],
+		"frames_symbolicated", stats["symbolicated_frames"],
+		"swift_symbols", stats["swift_symbols"],
+		"objc_symbols", stats["objc_symbols"],
+		"app_code_frames", stats["app_code_frames"],
+		"success_rate", stats["success_rate"],
+		"extraction", extractionTime,
+		"atos", atosTime,
+		"duration", time.Since(startTime))
 
 	return result, nil
 }
 
+// symbolicationWarning 符号表 UUID 与报告不一致时返回提示信息
+func symbolicationWarning(result map[string]interface{}) string {
+	info, ok := result["symbolication_info"].(map[string]interface{})
+	if !ok || info["uuid_mismatch"] != true {
+		return ""
+	}
+	return fmt.Sprintf("符号表 UUID %v 与报告应用镜像 UUID %v 不一致，符号化结果可能不正确", info["dsym_uuids"], info["report_uuid"])
+}
+
+// symbolicationTiming 返回符号化结果中的耗时分解
+func symbolicationTiming(result map[string]interface{}) map[string]interface{} {
+	info, ok := result["symbolication_info"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	timing, _ := info["timing"].(map[string]interface{})
+	return timing
+}
+
+// recordMatchingTime 记录符号表匹配耗时（发生在 symbolicateReport 之前），并计入总耗时
+func recordMatchingTime(result map[string]interface{}, matchingTime time.Duration) {
+	timing := symbolicationTiming(result)
+	if timing == nil {
+		return
+	}
+	ms := matchingTime.Milliseconds()
+	timing["matching_ms"] = ms
+	if total, ok := timing["total_ms"].(int64); ok {
+		timing["total_ms"] = total + ms
+	}
+}
+
+// countThreadFrames 统计线程帧的符号化结果，用于判断上传的 dSYM 是否正确：
+// total_frames 总帧数；symbolicated_frames 本次符号化成功的帧；
+// app_frames 属于应用镜像的帧（无论是否符号化成功）；
+// unresolved_frames 既没有符号化结果也没有报告自带符号、只剩地址的帧
+func countThreadFrames(threads []interface{}, appName string) map[string]int {
+	counts := map[string]int{
+		"total_frames":        0,
+		"symbolicated_frames": 0,
+		"app_frames":          0,
+		"unresolved_frames":   0,
+	}
+	for _, threadData := range threads {
+		thread, _ := threadData.(map[string]interface{})
+		backtrace, _ := thread["backtrace"].(map[string]interface{})
+		contents, _ := backtrace["contents"].([]interface{})
+		for _, frameData := range contents {
+			frame, ok := frameData.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			counts["total_frames"]++
+			if appName != "" && filepath.Base(getString(frame, "object_name")) == appName {
+				counts["app_frames"]++
+			}
+			if getString(frame, "symbolicated_name") != "" {
+				counts["symbolicated_frames"]++
+				continue
+			}
+			if symbolName := getString(frame, "symbol_name"); symbolName == "" || symbolName == "<redacted>" {
+				counts["unresolved_frames"]++
+			}
+		}
+	}
+	return counts
+}
+
 // calculateSymbolicationStats 计算符号化统计信息
 func calculateSymbolicationStats(data []interface{}, dumpType int) map[string]interface{} {
 	stats := map[string]interface{}{
@@ -400,7 +871,7 @@ func calculateSymbolicationStats(data []interface{}, dumpType int) map[string]in
 			} else if _, hasBacktrace := firstItem["backtrace"]; hasBacktrace {
 				// 如果有 "backtrace" 字段，说明是线性结构（crash.threads）
 				isCustomStack = false
-			} else if dumpType == 2011 {
+			} else if dumpType == int(DumpTypePowerConsume) {
 				// 兜底：如果 dump_type 是 2011 (EDumpType_PowerConsume)，也认为是耗电数据
 				isCustomStack = true
 			}
@@ -518,43 +989,380 @@ func countStackFrameRecursive(frame interface{}, totalFrames, symbolicatedFrames
 }
 
 // getBinaryInfo 获取二进制文件信息
-func getBinaryInfo(dsymPath string) (binaryPath string, loadAddr uint64, err error) {
-	binaryPath = dsymPath
+// .dSYM.zip / .dSYM.tar.gz 会被解压到临时目录，符号化结束后必须调用 cleanup 释放
+func getBinaryInfo(dsymPath string) (binaryPath string, loadAddr uint64, cleanup func(), err error) {
+	binaryPath, cleanup, err = dsymBinary(dsymPath, extractDsymArchive)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	return binaryPath, 0, cleanup, nil
+}
 
+// dsymBinary 返回符号表中可交给 atos 的二进制，压缩包通过 extract 解压
+func dsymBinary(dsymPath string, extract func(string) (string, func(), error)) (binaryPath string, cleanup func(), err error) {
 	// 如果是 .app 文件
 	if strings.HasSuffix(dsymPath, ".app") {
 		appName := strings.TrimSuffix(filepath.Base(dsymPath), ".app")
-		binaryPath = filepath.Join(dsymPath, appName)
-		return binaryPath, 0, nil
+		return filepath.Join(dsymPath, appName), func() {}, nil
 	}
 
-	// 如果是 .dSYM.zip，需要解压
-	if strings.HasSuffix(dsymPath, ".dSYM.zip") {
-		tmpDir := filepath.Join(os.TempDir(), "dsym_symbolicate")
-		os.MkdirAll(tmpDir, 0755)
+	// 如果是 .dSYM.zip / .dSYM.tar.gz，需要解压
+	if isDsymArchive(dsymPath) {
+		return extract(dsymPath)
+	}
 
-		cmd := exec.Command("unzip", "-o", dsymPath, "-d", tmpDir)
-		if err := cmd.Run(); err != nil {
-			return "", 0, fmt.Errorf("解压 dSYM 失败: %v", err)
+	return dsymPath, func() {}, nil
+}
+
+// findImageDsymsIn 为应用之外的镜像（系统库等）按 UUID 查找已上传的符号表
+// 返回 image_addr → 符号表路径，已上传的符号表通过 UUID 索引查找
+func findImageDsymsIn(dir string, binaryImages []interface{}, appUUID string) map[uint64]string {
+	found := make(map[uint64]string)
+
+	wanted := make(map[string]uint64)
+	for _, img := range binaryImages {
+		imgMap, ok := img.(map[string]interface{})
+		if !ok {
+			continue
 		}
+		uuid := normalizeUUID(getString(imgMap, "uuid"))
+		imgAddr, ok := imgMap["image_addr"].(float64)
+		if !ok || uuid == "" || compareUUID(uuid, appUUID) {
+			continue
+		}
+		wanted[uuid] = uint64(imgAddr)
+	}
+	if len(wanted) == 0 {
+		return found
+	}
+
+	for uuid, imgAddr := range wanted {
+		if dsymPath := findDsymByUUID(dir, uuid); dsymPath != "" {
+			found[imgAddr] = dsymPath
+			delete(wanted, uuid)
+		}
+	}
 
-		matches, err := filepath.Glob(filepath.Join(tmpDir, "*.dSYM/Contents/Resources/DWARF/*"))
-		if err != nil || len(matches) == 0 {
-			return "", 0, fmt.Errorf("未找到 DWARF 文件")
+	// 仍未匹配的镜像：在 DSYM_SEARCH_PATHS 中查找
+	for uuid, imgAddr := range wanted {
+		if dsymPath := findDsymInSearchPaths(dsymSearchPaths, uuid); dsymPath != "" {
+			found[imgAddr] = dsymPath
 		}
-		binaryPath = matches[0]
 	}
 
-	return binaryPath, 0, nil
+	return found
 }
 
-// symbolicateThread 符号化单个线程
-func symbolicateThread(thread map[string]interface{}, binaryPath string, loadAddr uint64, arch string) map[string]interface{} {
-	result := make(map[string]interface{})
-	for k, v := range thread {
-		result[k] = v
+// openImageBinaries 准备各镜像符号表中的二进制，返回 image_addr → 二进制路径
+// 调用方已持有应用符号表的解压名额，这里不等待名额，名额不足时跳过该镜像
+func openImageBinaries(imageDsyms map[uint64]string) (map[uint64]string, func()) {
+	binaries := make(map[uint64]string)
+	var cleanups []func()
+
+	for imgAddr, dsymPath := range imageDsyms {
+		binaryPath, cleanup, err := dsymBinary(dsymPath, tryExtractDsymArchive)
+		if err != nil {
+			log.Printf("⚠️ 跳过镜像符号表 %s: %v", filepath.Base(dsymPath), err)
+			continue
+		}
+		binaries[imgAddr] = binaryPath
+		cleanups = append(cleanups, cleanup)
+	}
+
+	return binaries, func() {
+		for _, cleanup := range cleanups {
+			cleanup()
+		}
+	}
+}
+
+// imageBinaries 符号化线程时每一帧使用的二进制
+// 所属镜像有独立符号表时使用该镜像的二进制和基址，否则使用应用的二进制
+type imageBinaries struct {
+	appPath          string
+	appName          string // 应用镜像文件名，报告中找不到应用镜像时用于判断镜像是否属于应用
+	appLoadAddr      uint64
+	appImageAddr     uint64 // 报告中应用镜像的 image_addr，hasAppImage 为 false 时无效
+	hasAppImage      bool
+	overrideLoadAddr bool // 应用加载地址由请求指定或经过校验修正，应用帧不再使用帧自带的 object_addr
+	retryOtherArches bool // 没有 slice 的 UUID 与报告一致，应用帧解析失败时改用其它架构重试
+	binaryImages     []interface{}
+	byImageAddr      map[uint64]string // image_addr → 镜像符号表中的二进制
+}
+
+// forFrame 返回符号化该帧使用的二进制和加载地址，own 表示帧所属镜像有独立符号表
+// 应用镜像中的帧使用应用的加载地址（可能由请求指定或经过校验修正）
+// 帧属于其它镜像（系统库等）且该镜像没有符号表时 ok 为 false：不能用应用符号表和应用的加载地址解析
+func (b *imageBinaries) forFrame(frame map[string]interface{}, addr uint64) (binaryPath string, loadAddr uint64, own, ok bool) {
+	img := findBinaryImageForAddress(addr, b.binaryImages)
+	if img != nil {
+		imgAddr := uint64(img["image_addr"].(float64))
+		if path, found := b.byImageAddr[imgAddr]; found {
+			return path, imgAddr, true, true
+		}
+		if !b.isAppImage(img) {
+			return "", 0, false, false
+		}
+		return b.appPath, b.appLoadAddr, false, true
+	}
+	if b.overrideLoadAddr && b.isAppFrame(frame) {
+		return b.appPath, b.appLoadAddr, false, true
+	}
+	return b.appPath, frameLoadAddress(frame, addr, b.appLoadAddr, b.binaryImages), false, true
+}
+
+// isAppFrame 判断不在 binary_images 中的帧是否属于应用：镜像名是应用或未知
+func (b *imageBinaries) isAppFrame(frame map[string]interface{}) bool {
+	objName := getString(frame, "object_name")
+	return objName == "" || objName == "???" || (b.appName != "" && filepath.Base(objName) == b.appName)
+}
+
+// isAppImage 判断 binary_images 中的镜像是否为应用主二进制
+func (b *imageBinaries) isAppImage(img map[string]interface{}) bool {
+	if b.hasAppImage {
+		addr, _ := img["image_addr"].(float64)
+		return uint64(addr) == b.appImageAddr
+	}
+	return b.appName != "" && filepath.Base(getString(img, "name")) == b.appName
+}
+
+// frameLoadAddress 返回符号化某一帧时使用的加载地址
+// 帧地址在 binary_images 中时使用所在镜像的 image_addr；镜像缺失时使用 frame 自带的 object_addr，都没有时使用 loadAddr
+func frameLoadAddress(frame map[string]interface{}, addr uint64, loadAddr uint64, binaryImages []interface{}) uint64 {
+	if img := findBinaryImageForAddress(addr, binaryImages); img != nil {
+		return uint64(img["image_addr"].(float64))
+	}
+
+	if objAddr, ok := frame["object_addr"].(float64); ok && objAddr > 0 && addr >= uint64(objAddr) {
+		return uint64(objAddr)
+	}
+
+	return loadAddr
+}
+
+// loadAddrSampleSize 校验加载地址时抽样的应用帧数量
+const loadAddrSampleSize = 3
+
+// sampleAppFrames 从 crash 线程中抽样应用帧的指令地址，并收集这些帧自带的 object_addr
+func sampleAppFrames(reportMap map[string]interface{}, appName string, n int) (addrs []uint64, objectAddrs []uint64) {
+	crash, _ := reportMap["crash"].(map[string]interface{})
+	threads, _ := crash["threads"].([]interface{})
+	if appName == "" {
+		return nil, nil
+	}
+
+	seen := make(map[uint64]bool)
+	for _, threadData := range threads {
+		thread, _ := threadData.(map[string]interface{})
+		backtrace, _ := thread["backtrace"].(map[string]interface{})
+		contents, _ := backtrace["contents"].([]interface{})
+		for _, frameData := range contents {
+			frame, ok := frameData.(map[string]interface{})
+			if !ok || filepath.Base(getString(frame, "object_name")) != appName {
+				continue
+			}
+			addr, ok := frame["instruction_addr"].(float64)
+			if !ok || seen[uint64(addr)] {
+				continue
+			}
+			seen[uint64(addr)] = true
+			addrs = append(addrs, uint64(addr))
+			if objAddr, ok := frame["object_addr"].(float64); ok && objAddr > 0 {
+				objectAddrs = append(objectAddrs, uint64(objAddr))
+			}
+			if len(addrs) >= n {
+				return addrs, objectAddrs
+			}
+		}
+	}
+	return addrs, objectAddrs
+}
+
+// atosModule 取出 atos 结果中 "(in 模块名)" 的模块名
+func atosModule(symbol string) string {
+	idx := strings.Index(symbol, " (in ")
+	if idx == -1 {
+		return ""
+	}
+	rest := symbol[idx+len(" (in "):]
+	if end := strings.Index(rest, ")"); end != -1 {
+		return rest[:end]
+	}
+	return ""
+}
+
+// verifyLoadAddress 抽样符号化几个应用帧，确认解析出的模块就是应用本身
+// 报告的 image_addr 不可信时依次尝试帧自带的 object_addr 和 dSYM 的 __TEXT vmaddr（slide 为 0）；
+// 校验只是提示，全部候选都失败时仍使用 loadAddr，
+// 原因通过 warning 返回。抽样查询经过 atosSymbolCache，随后符号化同一批帧时直接命中缓存
+// 无法校验（符号化工具不可用、没有应用帧）时原样返回 loadAddr
+func verifyLoadAddress(binaryPath string, loadAddr uint64, arch string, appName string, reportMap map[string]interface{}) (verified uint64, corrected bool, warning string) {
+	if !activeSymbolizer.available() {
+		return loadAddr, false, ""
+	}
+	samples, objectAddrs := sampleAppFrames(reportMap, appName, loadAddrSampleSize)
+	if len(samples) == 0 {
+		return loadAddr, false, ""
+	}
+
+	candidates := append([]uint64{loadAddr}, objectAddrs...)
+	if textAddr, err := machoTextVMAddr(binaryPath, arch); err == nil {
+		candidates = append(candidates, textAddr)
+	}
+	modules := map[string]bool{appName: true, filepath.Base(binaryPath): true}
+	tried := make(map[uint64]bool)
+	resolvedAny := false
+	for _, candidate := range candidates {
+		if tried[candidate] {
+			continue
+		}
+		tried[candidate] = true
+
+		// 只校验当前架构，不用其它 slice 重试
+		symbols, _, _ := symbolicateAddressesArch(binaryPath, candidate, samples, arch, false)
+		for _, symbol := range symbols {
+			if symbol == "" {
+				continue
+			}
+			resolvedAny = true
+			if modules[atosModule(physicalFrameSymbol(symbol))] {
+				if candidate != loadAddr {
+					log.Printf("🔧 报告的应用加载地址 0x%x 校验失败，改用 0x%x", loadAddr, candidate)
+				}
+				return candidate, candidate != loadAddr, ""
+			}
+		}
+	}
+
+	if !resolvedAny {
+		warning = fmt.Sprintf("加载地址校验未通过：应用帧 %s 在候选加载地址下都无法解析，请确认报告的 image_addr 与符号表匹配", formatAddrs(samples))
+	} else {
+		warning = fmt.Sprintf("加载地址校验未通过：应用帧 %s 在候选加载地址下都没有解析到 %s，请确认报告的 image_addr 与符号表匹配", formatAddrs(samples), appName)
+	}
+	log.Printf("⚠️ %s", warning)
+	return loadAddr, false, warning
+}
+
+// formatAddrs 将地址列表格式化为 0x... 形式，用于诊断信息
+func formatAddrs(addrs []uint64) string {
+	parts := make([]string, len(addrs))
+	for i, addr := range addrs {
+		parts[i] = fmt.Sprintf("0x%x", addr)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// symbolicateWorkers 并发符号化线程的 worker 数量（SYMBOLICATE_WORKERS），默认 CPU 核数
+var symbolicateWorkers = envInt("SYMBOLICATE_WORKERS", runtime.NumCPU())
+
+// symbolKey 符号缓存键：同一加载地址下的同一指令地址
+type symbolKey struct {
+	loadAddr uint64
+	addr     uint64
+}
+
+// symbolCache 单次报告符号化内共享的符号缓存，卡顿报告中各线程大量重复的帧只需查询一次
+type symbolCache struct {
+	mu      sync.Mutex
+	symbols map[symbolKey]string
+	errors  map[symbolKey]string // 符号化失败原因（如 timeout），缓存命中时同样标记到帧上
+}
+
+func newSymbolCache() *symbolCache {
+	return &symbolCache{symbols: make(map[symbolKey]string), errors: make(map[symbolKey]string)}
+}
+
+func (c *symbolCache) get(loadAddr, addr uint64) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	symbol, ok := c.symbols[symbolKey{loadAddr, addr}]
+	return symbol, ok
+}
+
+func (c *symbolCache) put(loadAddr, addr uint64, symbol string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.symbols[symbolKey{loadAddr, addr}] = symbol
+	c.mu.Unlock()
+}
+
+// putError 记录地址的符号化失败原因，同时缓存空结果，避免其它线程再次等待同一个卡住的工具
+func (c *symbolCache) putError(loadAddr, addr uint64, reason string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.symbols[symbolKey{loadAddr, addr}] = ""
+	c.errors[symbolKey{loadAddr, addr}] = reason
+	c.mu.Unlock()
+}
+
+// errorFor 返回地址的符号化失败原因，没有记录时返回空字符串
+func (c *symbolCache) errorFor(loadAddr, addr uint64) string {
+	if c == nil {
+		return ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.errors[symbolKey{loadAddr, addr}]
+}
+
+// symbolicateThreads 使用有界 worker 池并发符号化线程，结果保持原始线程顺序
+// 单个线程符号化 panic 时保留该线程的原始数据，不影响其它线程
+func symbolicateThreads(threads []interface{}, workers int, binaries *imageBinaries, arch string, appName string, cache *symbolCache) []interface{} {
+	results := make([]interface{}, len(threads))
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = symbolicateThreadSafe(threads[i], binaries, arch, appName, cache)
+			}
+		}()
 	}
 
+	for i := range threads {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// symbolicateThreadSafe 符号化单个线程并捕获 panic，失败时返回原始线程
+func symbolicateThreadSafe(t interface{}, binaries *imageBinaries, arch string, appName string, cache *symbolCache) (result interface{}) {
+	thread, ok := t.(map[string]interface{})
+	if !ok {
+		return t
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("⚠️ 符号化线程 %d 时发生 panic: %v", getInt64(thread, "index"), r)
+			result = thread
+		}
+	}()
+
+	return symbolicateThread(thread, binaries, arch, appName, cache)
+}
+
+// symbolicateThread 符号化单个线程，每一帧使用所属镜像的二进制
+// cache 为 nil 时不使用缓存
+func symbolicateThread(thread map[string]interface{}, binaries *imageBinaries, arch string, appName string, cache *symbolCache) map[string]interface{} {
+	result := deepCopyMap(thread)
+
 	backtrace, ok := thread["backtrace"].(map[string]interface{})
 	if !ok {
 		return result
@@ -565,82 +1373,173 @@ func symbolicateThread(thread map[string]interface{}, binaryPath string, loadAdd
 		return result
 	}
 
-	// 符号化每一帧
+	// 第一遍：复制每一帧，收集需要符号化的地址（按二进制和加载地址分组）
 	symbolicatedFrames := []interface{}{}
-	for _, f := range contents {
-		frame := f.(map[string]interface{})
-		symbolicatedFrame := make(map[string]interface{})
-		for k, v := range frame {
-			symbolicatedFrame[k] = v
+	type pendingFrame struct {
+		index int
+		addr  uint64
+	}
+	type binaryKey struct {
+		binaryPath string
+		loadAddr   uint64
+	}
+	pendingByBinary := make(map[binaryKey][]pendingFrame)
+	var binaryOrder []binaryKey
+
+	// 每个二进制的 __TEXT vmaddr 只读取一次，用于记录 slide
+	textVMAddrs := make(map[string]textVMAddr)
+	binaryTextVMAddr := func(binaryPath string) textVMAddr {
+		text, ok := textVMAddrs[binaryPath]
+		if !ok {
+			addr, err := machoTextVMAddr(binaryPath, arch)
+			text = textVMAddr{addr: addr, ok: err == nil}
+			textVMAddrs[binaryPath] = text
 		}
+		return text
+	}
+
+	for i, f := range contents {
+		frame := f.(map[string]interface{})
+		symbolicatedFrames = append(symbolicatedFrames, deepCopyMap(frame))
 
 		// 检查是否需要符号化
 		addr, ok := frame["instruction_addr"].(float64)
 		if !ok {
-			symbolicatedFrames = append(symbolicatedFrames, symbolicatedFrame)
 			continue
 		}
 
 		objName, _ := frame["object_name"].(string)
 		symbolName, _ := frame["symbol_name"].(string)
 
-		// 如果是应用代码或未知代码，尝试符号化
-		if strings.Contains(objName, "MatrixTestApp") || objName == "???" ||
+		// 所属镜像有独立符号表，或是应用代码、未知代码，尝试符号化
+		frameBinary, frameLoadAddr, own, ok := binaries.forFrame(frame, uint64(addr))
+		if !ok {
+			continue
+		}
+		isAppFrame := appName != "" && filepath.Base(objName) == appName
+		if own || isAppFrame || objName == "???" ||
 			symbolName == "" || symbolName == "<redacted>" {
 
-			symbol := symbolicateAddress(binaryPath, loadAddr, uint64(addr), arch)
-			if symbol != "" {
-				symbolicatedFrame["symbolicated_name"] = symbol
-
-				// ✅ 新增：检测符号语言类型
-				language := detectSymbolLanguage(symbol)
-				symbolicatedFrame["symbol_language"] = language
-
-				// ✅ 新增：检查符号质量
-				symbolicatedFrame["symbol_quality"] = isSymbolWellFormatted(symbol)
-
-				// 解析文件名和行号
-				fileName, lineNum := parseSymbolOutput(symbol)
-				if fileName != "" {
-					symbolicatedFrame["file_name"] = fileName
-					symbolicatedFrame["line_number"] = lineNum
-
-					// ✅ 新增：记录文件类型
-					ext := filepath.Ext(fileName)
-					if ext == ".swift" {
-						symbolicatedFrame["file_type"] = "Swift"
-					} else if ext == ".mm" || ext == ".m" {
-						symbolicatedFrame["file_type"] = "Objective-C"
-					} else if ext == ".cpp" || ext == ".cc" || ext == ".cxx" {
-						symbolicatedFrame["file_type"] = "C++"
-					} else if ext == ".c" {
-						symbolicatedFrame["file_type"] = "C"
-					}
+			if symbol, ok := cache.get(frameLoadAddr, uint64(addr)); ok {
+				if symbol != "" {
+					applySymbolToFrame(symbolicatedFrames[i].(map[string]interface{}), symbol)
+					recordFrameAddressing(symbolicatedFrames[i].(map[string]interface{}), frameLoadAddr, uint64(addr), binaryTextVMAddr(frameBinary))
+				} else if reason := cache.errorFor(frameLoadAddr, uint64(addr)); reason != "" {
+					symbolicatedFrames[i].(map[string]interface{})["symbolication_error"] = reason
 				}
+				continue
+			}
+			key := binaryKey{frameBinary, frameLoadAddr}
+			if _, seen := pendingByBinary[key]; !seen {
+				binaryOrder = append(binaryOrder, key)
+			}
+			pendingByBinary[key] = append(pendingByBinary[key], pendingFrame{index: i, addr: uint64(addr)})
+		}
+	}
+
+	// 第二遍：每个二进制和加载地址只调用一次 atos，再按下标回填
+	for _, key := range binaryOrder {
+		pending := pendingByBinary[key]
+		addrs := make([]uint64, len(pending))
+		for i, p := range pending {
+			addrs[i] = p.addr
+		}
 
-				// 标记为应用代码
-				if !strings.Contains(fileName, "KSCrash") &&
-					!strings.Contains(fileName, "WC") &&
-					!strings.Contains(fileName, "Matrix") {
-					symbolicatedFrame["is_app_code"] = true
+		// 报告 UUID 已匹配到 slice 时，其它 slice 的代码布局不同，重试只会得到错误的符号
+		retry := key.binaryPath != binaries.appPath || binaries.retryOtherArches
+		symbols, symbolArchs, err := symbolicateAddressesArch(key.binaryPath, key.loadAddr, addrs, arch, retry)
+		timedOut := errors.Is(err, errSymbolizerTimeout)
+		for i, p := range pending {
+			// 超时的帧标记原因，而不是静默保留为未解析
+			if timedOut && symbols[i] == "" {
+				cache.putError(key.loadAddr, p.addr, "timeout")
+				symbolicatedFrames[p.index].(map[string]interface{})["symbolication_error"] = "timeout"
+				continue
+			}
+			cache.put(key.loadAddr, p.addr, symbols[i])
+			if symbols[i] != "" {
+				applySymbolToFrame(symbolicatedFrames[p.index].(map[string]interface{}), symbols[i])
+				recordFrameAddressing(symbolicatedFrames[p.index].(map[string]interface{}), key.loadAddr, p.addr, binaryTextVMAddr(key.binaryPath))
+				// 报告架构解析失败、改用其它 slice 成功时记录实际使用的架构
+				if symbolArchs[i] != arch {
+					symbolicatedFrames[p.index].(map[string]interface{})["symbol_arch"] = symbolArchs[i]
 				}
 			}
 		}
-
-		symbolicatedFrames = append(symbolicatedFrames, symbolicatedFrame)
 	}
 
-	// 更新 backtrace
-	newBacktrace := make(map[string]interface{})
-	for k, v := range backtrace {
-		newBacktrace[k] = v
-	}
-	newBacktrace["contents"] = symbolicatedFrames
+	// 更新 backtrace（保留 contents 之外的字段，如 skipped），内联帧展开为多个帧
+	newBacktrace := deepCopyMap(backtrace)
+	newBacktrace["contents"] = expandInlinedFrames(symbolicatedFrames)
 
 	result["backtrace"] = newBacktrace
 	return result
 }
 
+// textVMAddr 二进制的 __TEXT vmaddr，ok 为 false 表示无法读取
+type textVMAddr struct {
+	addr uint64
+	ok   bool
+}
+
+// recordFrameAddressing 记录符号化该帧时的地址计算，便于核对可疑的符号：
+// image_base 为使用的加载地址，slide 为加载地址与 __TEXT vmaddr 之差，file_offset 为帧地址相对加载地址的偏移
+func recordFrameAddressing(frame map[string]interface{}, loadAddr, addr uint64, text textVMAddr) {
+	frame["image_base"] = int64(loadAddr)
+	frame["file_offset"] = int64(addr) - int64(loadAddr)
+	if text.ok {
+		frame["slide"] = int64(loadAddr) - int64(text.addr)
+	}
+}
+
+// applySymbolToFrame 将 atos 结果写入帧：符号、语言、文件行号、是否应用代码
+func applySymbolToFrame(symbolicatedFrame map[string]interface{}, symbol string) {
+	symbolicatedFrame["symbolicated_name"] = symbol
+
+	// ✅ 新增：检测符号语言类型
+	language := detectSymbolLanguage(symbol)
+	symbolicatedFrame["symbol_language"] = language
+
+	// ✅ 新增：检查符号质量
+	symbolicatedFrame["symbol_quality"] = isSymbolWellFormatted(symbol)
+
+	// 解析函数名、模块名、文件名和行号
+	functionName, moduleName, fileName, lineNum := parseSymbolOutput(symbol)
+	if functionName != "" {
+		symbolicatedFrame["function_name"] = functionName
+		// demangle 过的 Swift 符号同时保留原始形式
+		if mangled := mangledSwiftName(functionName); mangled != "" {
+			symbolicatedFrame["mangled_name"] = mangled
+		}
+	}
+	if moduleName != "" {
+		symbolicatedFrame["module_name"] = moduleName
+	}
+	if fileName != "" {
+		symbolicatedFrame["file_name"] = fileName
+		symbolicatedFrame["line_number"] = lineNum
+
+		// ✅ 新增：记录文件类型
+		ext := filepath.Ext(fileName)
+		if ext == ".swift" {
+			symbolicatedFrame["file_type"] = "Swift"
+		} else if ext == ".mm" || ext == ".m" {
+			symbolicatedFrame["file_type"] = "Objective-C"
+		} else if ext == ".cpp" || ext == ".cc" || ext == ".cxx" {
+			symbolicatedFrame["file_type"] = "C++"
+		} else if ext == ".c" {
+			symbolicatedFrame["file_type"] = "C"
+		}
+	}
+
+	// 标记为应用代码
+	if !strings.Contains(fileName, "KSCrash") &&
+		!strings.Contains(fileName, "WC") &&
+		!strings.Contains(fileName, "Matrix") {
+		symbolicatedFrame["is_app_code"] = true
+	}
+}
+
 // symbolicateOOMReport 符号化 OOM 内存溢出报告
 // OOM 报告格式：items[].stacks[].frames[]
 // 每个 frame 格式: {uuid: "xxx", offset: 123456}
@@ -787,8 +1686,17 @@ func symbolicateStackFrame(frame interface{}, binaryPath string, loadAddr uint64
 			result["symbol_language"] = detectSymbolLanguage(symbol)
 			result["symbol_quality"] = isSymbolWellFormatted(symbol)
 
-			// 解析文件名和行号
-			fileName, lineNum := parseSymbolOutput(symbol)
+			// 解析函数名、模块名、文件名和行号
+			functionName, moduleName, fileName, lineNum := parseSymbolOutput(symbol)
+			if functionName != "" {
+				result["function_name"] = functionName
+				if mangled := mangledSwiftName(functionName); mangled != "" {
+					result["mangled_name"] = mangled
+				}
+			}
+			if moduleName != "" {
+				result["module_name"] = moduleName
+			}
 			if fileName != "" {
 				result["file_name"] = fileName
 				result["line_number"] = lineNum
@@ -851,33 +1759,229 @@ func findBinaryImageForAddress(addr uint64, binaryImages []interface{}) map[stri
 	return nil
 }
 
+// atosBatchSize 单次 atos 调用的最大地址数，避免命令行参数过长
+const atosBatchSize = 500
+
 // symbolicateAddress 使用 atos 符号化单个地址（增强 Swift 支持）
 func symbolicateAddress(binaryPath string, loadAddr uint64, targetAddr uint64, arch string) string {
+	return symbolicateAddresses(binaryPath, loadAddr, []uint64{targetAddr}, arch)[0]
+}
+
+// symbolicateAddresses 使用一次 atos 调用批量符号化多个地址
+// atos 对每个地址输出一行，结果与 addrs 按下标一一对应，失败的地址为空字符串
+// 先查询进程级缓存，只有未命中的地址才调用 atos
+func symbolicateAddresses(binaryPath string, loadAddr uint64, addrs []uint64, arch string) []string {
+	results, _ := symbolicateAddressesErr(binaryPath, loadAddr, addrs, arch)
+	return results
+}
+
+// symbolicateAddressesErr 同 symbolicateAddresses，同时返回符号化工具的错误
+// 超时（errSymbolizerTimeout）后不再处理剩余批次，同一个二进制大概率会再次卡住
+func symbolicateAddressesErr(binaryPath string, loadAddr uint64, addrs []uint64, arch string) ([]string, error) {
+	// 只有线程符号化会展开内联帧，其它调用方只需要外层实际所在的函数
+	results, _, err := symbolicateAddressesArch(binaryPath, loadAddr, addrs, arch, true)
+	for i, symbol := range results {
+		results[i] = physicalFrameSymbol(symbol)
+	}
+	return results, err
+}
+
+// symbolicateAddressesArch 同 symbolicateAddressesErr，另外返回每个地址解析成功时使用的架构（失败为空字符串）
+// retryArches 为 true 时，指定架构解析不出符号的地址依次使用二进制中其它架构的 slice 重试（报告的 cpu_arch 与 dSYM 不一致时常见）
+func symbolicateAddressesArch(binaryPath string, loadAddr uint64, addrs []uint64, arch string, retryArches bool) (results []string, archs []string, err error) {
+	results = make([]string, len(addrs))
+	archs = make([]string, len(addrs))
+
+	// 无法读取 UUID 的二进制不使用缓存（解压目录每次不同，不能以路径为键）
+	uuid, _, err := readMachOUUID(binaryPath)
+	if err != nil {
+		uuid = ""
+	}
+
+	var missIndexes []int
+	var missAddrs []uint64
+	for i, addr := range addrs {
+		if uuid != "" {
+			// 其它 slice 重试得到的结果同样按请求的架构缓存；不允许重试时忽略这类结果
+			symbol, symbolArch, ok := atosSymbolCache.get(atosCacheKey{uuid, loadAddr, addr, arch})
+			if ok && (retryArches || symbolArch == arch) {
+				results[i] = symbol
+				archs[i] = symbolArch
+				continue
+			}
+		}
+		missIndexes = append(missIndexes, i)
+		missAddrs = append(missAddrs, addr)
+	}
+
+	symbols, symbolizeErr := symbolizeInBatches(binaryPath, loadAddr, missAddrs, arch)
+	symbolArchs := make([]string, len(missAddrs))
+	for j, symbol := range symbols {
+		if symbol != "" {
+			symbolArchs[j] = arch
+		}
+	}
+	if retryArches && !errors.Is(symbolizeErr, errSymbolizerTimeout) {
+		retryWithOtherArches(binaryPath, loadAddr, missAddrs, arch, symbols, symbolArchs)
+	}
+
+	for j, symbol := range symbols {
+		results[missIndexes[j]] = symbol
+		archs[missIndexes[j]] = symbolArchs[j]
+		// 失败结果可能是 atos 临时出错，不缓存；重试成功的结果按请求的架构缓存，同时记录实际使用的架构
+		if uuid != "" && symbol != "" {
+			atosSymbolCache.put(atosCacheKey{uuid, loadAddr, missAddrs[j], arch}, symbol, symbolArchs[j])
+		}
+	}
+
+	return results, archs, symbolizeErr
+}
+
+// symbolizeInBatches 按 atosBatchSize 分批调用符号化后端，超时后不再处理剩余批次
+func symbolizeInBatches(binaryPath string, loadAddr uint64, addrs []uint64, arch string) ([]string, error) {
+	results := make([]string, len(addrs))
+	var symbolizeErr error
+
+	for start := 0; start < len(addrs); start += atosBatchSize {
+		end := start + atosBatchSize
+		if end > len(addrs) {
+			end = len(addrs)
+		}
+		symbols, err := activeSymbolizer.symbolize(binaryPath, loadAddr, addrs[start:end], arch)
+		copy(results[start:end], symbols)
+		if err != nil && symbolizeErr == nil {
+			symbolizeErr = err
+		}
+		if errors.Is(err, errSymbolizerTimeout) {
+			break
+		}
+	}
+
+	return results, symbolizeErr
+}
+
+// countArchFallbackFrames 统计线程中改用其它架构解析的帧（带 symbol_arch），按架构计数
+func countArchFallbackFrames(threads []interface{}) map[string]int {
+	counts := map[string]int{}
+	for _, t := range threads {
+		thread, _ := t.(map[string]interface{})
+		backtrace, _ := thread["backtrace"].(map[string]interface{})
+		contents, _ := backtrace["contents"].([]interface{})
+		for _, f := range contents {
+			frame, _ := f.(map[string]interface{})
+			if arch := getString(frame, "symbol_arch"); arch != "" {
+				counts[arch]++
+			}
+		}
+	}
+	return counts
+}
+
+// retryWithOtherArches 对仍未解析的地址依次使用二进制中其它架构的 slice 重试
+// 结果原地写回 symbols，成功时在 symbolArchs 中记录使用的架构
+func retryWithOtherArches(binaryPath string, loadAddr uint64, addrs []uint64, arch string, symbols, symbolArchs []string) {
+	slices, err := readMachOSlices(binaryPath)
+	if err != nil {
+		return
+	}
+
+	tried := map[string]bool{arch: true}
+	for _, slice := range slices {
+		if slice.Arch == "" || tried[slice.Arch] {
+			continue
+		}
+		tried[slice.Arch] = true
+
+		var indexes []int
+		var retryAddrs []uint64
+		for j, symbol := range symbols {
+			if symbol == "" {
+				indexes = append(indexes, j)
+				retryAddrs = append(retryAddrs, addrs[j])
+			}
+		}
+		if len(retryAddrs) == 0 {
+			return
+		}
+
+		retried, err := symbolizeInBatches(binaryPath, loadAddr, retryAddrs, slice.Arch)
+		recovered := 0
+		for k, symbol := range retried {
+			if symbol != "" {
+				symbols[indexes[k]] = symbol
+				symbolArchs[indexes[k]] = slice.Arch
+				recovered++
+			}
+		}
+		if recovered > 0 {
+			log.Printf("🔁 %s 未解析的 %d 个地址改用 %s 重试，成功 %d 个", arch, len(retryAddrs), slice.Arch, recovered)
+		}
+		if errors.Is(err, errSymbolizerTimeout) {
+			return
+		}
+	}
+}
+
+// runAtosBatch 执行一次 atos 并解析输出，atos 失败或超时时返回空结果和错误
+func runAtosBatch(binaryPath string, loadAddr uint64, addrs []uint64, arch string) ([]string, error) {
 	startTime := time.Now()
 
 	// ========================================================================
 	// 步骤1: 使用 atos 进行符号化
 	// ========================================================================
-	cmd := exec.Command(
-		"atos",
+	args := []string{
 		"-arch", arch,
 		"-o", binaryPath,
 		"-l", fmt.Sprintf("0x%x", loadAddr),
-		fmt.Sprintf("0x%x", targetAddr),
-	)
+	}
+	if atosInlineFrames {
+		args = append(args, "-i")
+	}
+	for _, addr := range addrs {
+		args = append(args, fmt.Sprintf("0x%x", addr))
+	}
 
-	var out bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &stderr
+	out, err := runToolWithTimeout("atos", args...)
+	if err != nil {
+		log.Printf("⚠️ atos 执行失败: %v", err)
+		return make([]string, len(addrs)), err
+	}
 
-	if err := cmd.Run(); err != nil {
-		log.Printf("⚠️ atos 执行失败: %v, stderr: %s", err, stderr.String())
-		return ""
+	var results []string
+	if atosInlineFrames {
+		results = parseAtosInlineOutput(string(out), addrs)
+	} else {
+		results = parseAtosBatchOutput(string(out), addrs)
 	}
+	log.Printf("✅ atos 批量符号化 %d 个地址 (耗时: %v)", len(addrs), time.Since(startTime))
+	return results, nil
+}
+
+// parseAtosBatchOutput 将 atos 的多行输出按顺序映射回地址
+// 输出行数少于地址数时，缺失的地址视为符号化失败
+func parseAtosBatchOutput(output string, addrs []uint64) []string {
+	results := make([]string, len(addrs))
 
-	symbol := strings.TrimSpace(out.String())
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if output == "" {
+		lines = nil
+	}
+	if len(lines) < len(addrs) {
+		log.Printf("⚠️ atos 输出 %d 行，少于请求的 %d 个地址", len(lines), len(addrs))
+	}
 
+	for i, addr := range addrs {
+		if i >= len(lines) {
+			break
+		}
+		results[i] = postProcessSymbol(strings.TrimSpace(lines[i]), addr)
+	}
+
+	return results
+}
+
+// postProcessSymbol 检查 atos 单行结果并处理 Swift 符号
+func postProcessSymbol(symbol string, targetAddr uint64) string {
 	// ========================================================================
 	// 步骤2: 检查符号化是否成功
 	// ========================================================================
@@ -903,8 +2007,7 @@ func symbolicateAddress(binaryPath string, loadAddr uint64, targetAddr uint64, a
 		// 检查 atos 是否已经 demangle
 		if isSymbolWellFormatted(symbol) {
 			// atos 已自动 demangle（推荐路径）
-			elapsed := time.Since(startTime)
-			log.Printf("✅ [Swift] atos 自动 demangle 成功 (耗时: %v)", elapsed)
+			log.Printf("✅ [Swift] atos 自动 demangle 成功")
 			log.Printf("   符号: %s", symbol)
 			return symbol
 		}
@@ -919,8 +2022,7 @@ func symbolicateAddress(binaryPath string, loadAddr uint64, targetAddr uint64, a
 			if demangled != mangledSymbol {
 				// 重新组合完整符号（保留文件名和行号等信息）
 				fullSymbol := replaceSymbolName(symbol, mangledSymbol, demangled)
-				elapsed := time.Since(startTime)
-				log.Printf("✅ [Swift] 手动 demangle 成功 (耗时: %v)", elapsed)
+				log.Printf("✅ [Swift] 手动 demangle 成功")
 				log.Printf("   最终符号: %s", fullSymbol)
 				return fullSymbol
 			}
@@ -934,8 +2036,7 @@ func symbolicateAddress(binaryPath string, loadAddr uint64, targetAddr uint64, a
 	// ========================================================================
 	// 步骤5: Objective-C/C/C++ 符号直接返回
 	// ========================================================================
-	elapsed := time.Since(startTime)
-	log.Printf("✅ [%s] 符号化成功 (耗时: %v, 地址: 0x%x)", language, elapsed, targetAddr)
+	log.Printf("✅ [%s] 符号化成功 (地址: 0x%x)", language, targetAddr)
 
 	return symbol
 }
@@ -966,8 +2067,15 @@ func replaceSymbolName(original, mangledName, demangledName string) string {
 	return strings.Replace(original, mangledName, demangledName, 1)
 }
 
+// symbolFileRegex 匹配 atos 结果末尾的 (文件:行号)
+var symbolFileRegex = regexp.MustCompile(`\(([^)]+\.(?:m|mm|c|cpp|cc|cxx|swift|h|hpp)):(\d+)\)`)
+
+// symbolOffsetRegex 匹配没有文件信息时 atos 结果末尾的 + 偏移
+var symbolOffsetRegex = regexp.MustCompile(`\s\+\s(?:0x[0-9a-fA-F]+|\d+)$`)
+
 // parseSymbolOutput 解析符号化输出（增强 Swift 支持）
-func parseSymbolOutput(symbol string) (fileName string, lineNum string) {
+// 返回函数名、模块名、文件名和行号，缺失的部分为空字符串
+func parseSymbolOutput(symbol string) (symbolName, moduleName, fileName, lineNum string) {
 	// 支持的文件扩展名：
 	// - Objective-C: .m, .mm
 	// - C/C++: .c, .cpp, .cc, .cxx
@@ -978,9 +2086,21 @@ func parseSymbolOutput(symbol string) (fileName string, lineNum string) {
 	// ObjC:  -[Class method] (in App) (File.mm:123)
 	// Swift: TestViewController.method() (in App) (File.swift:65)
 	// C++:   MyClass::method() (in App) (File.cpp:42)
+	// 无文件信息: objc_msgSend (in libobjc.A.dylib) + 32
+
+	symbol = strings.TrimSpace(symbol)
+	if symbol == "" || strings.HasPrefix(symbol, "0x") {
+		return "", "", "", ""
+	}
 
-	re := regexp.MustCompile(`\(([^)]+\.(?:m|mm|c|cpp|cc|cxx|swift|h|hpp)):(\d+)\)`)
-	matches := re.FindStringSubmatch(symbol)
+	if idx := strings.Index(symbol, " (in "); idx != -1 {
+		symbolName = symbol[:idx]
+		moduleName = atosModule(symbol)
+	} else {
+		symbolName = symbolOffsetRegex.ReplaceAllString(symbol, "")
+	}
+
+	matches := symbolFileRegex.FindStringSubmatch(symbol)
 
 	if len(matches) >= 3 {
 		fileName = matches[1]
@@ -993,18 +2113,19 @@ func parseSymbolOutput(symbol string) (fileName string, lineNum string) {
 		} else if ext == ".mm" || ext == ".m" {
 			log.Printf("📄 [ObjC] 文件: %s:%s", fileName, lineNum)
 		}
+
+		// 没有 (in 模块) 时，函数名是文件信息之前的部分
+		if moduleName == "" {
+			symbolName = strings.TrimSpace(symbol[:strings.Index(symbol, matches[0])])
+		}
 	}
 
-	return fileName, lineNum
+	return symbolName, moduleName, fileName, lineNum
 }
 
-// timeNow 返回当前时间的 ISO 格式字符串
+// timeNow 返回当前时间的 ISO 8601（RFC3339）格式字符串
 func timeNow() string {
-	return fmt.Sprintf("%d", timeNowUnix())
-}
-
-func timeNowUnix() int64 {
-	return 0 // 这里返回0，实际使用时可以返回 time.Now().Unix()
+	return time.Now().Format(time.RFC3339)
 }
 
 // FormatSymbolicatedReport 格式化符号化报告为人类可读格式
@@ -1018,7 +2139,7 @@ func FormatSymbolicatedReport(report map[string]interface{}) string {
 	}
 	
 	reportTitle := "🔍 Matrix 卡顿报告 - 符号化版本"
-	if dumpType == 2011 {
+	if dumpType == int(DumpTypePowerConsume) {
 		reportTitle = "🔋 Matrix 耗电监控报告 - 符号化版本"
 	}
 
@@ -1152,7 +2273,7 @@ func FormatSymbolicatedReport(report map[string]interface{}) string {
 			for i, f := range contents {
 				frame := f.(map[string]interface{})
 				objName, _ := frame["object_name"].(string)
-				addr := frame["instruction_addr"]
+				addr := getAddress(frame, "instruction_addr")
 
 				isApp, _ := frame["is_app_code"].(bool)
 
@@ -1180,14 +2301,14 @@ func FormatSymbolicatedReport(report map[string]interface{}) string {
 						languageTag = fmt.Sprintf(" [%s]", fileType)
 					}
 
-					buf.WriteString(fmt.Sprintf("%s%2d  %-25s %v%s\n", marker, i, objName, addr, languageTag))
+					buf.WriteString(fmt.Sprintf("%s%2d  %-25s 0x%016x%s\n", marker, i, objName, addr, languageTag))
 					buf.WriteString(fmt.Sprintf("      %s\n", symbolicatedName))
 				} else {
 					symbolName, _ := frame["symbol_name"].(string)
 					if symbolName != "" && symbolName != "<redacted>" {
-						buf.WriteString(fmt.Sprintf("%s%2d  %-25s %v %s\n", marker, i, objName, addr, symbolName))
+						buf.WriteString(fmt.Sprintf("%s%2d  %-25s 0x%016x %s\n", marker, i, objName, addr, symbolName))
 					} else {
-						buf.WriteString(fmt.Sprintf("%s%2d  %-25s %v\n", marker, i, objName, addr))
+						buf.WriteString(fmt.Sprintf("%s%2d  %-25s 0x%016x\n", marker, i, objName, addr))
 					}
 				}
 			}
@@ -1219,7 +2340,7 @@ func formatStackFrameRecursive(buf *bytes.Buffer, frame interface{}, index int,
 	indent := strings.Repeat("  ", depth)
 	
 	// 获取地址
-	addr, _ := frameMap["instruction_address"].(float64)
+	addr := getAddress(frameMap, "instruction_address")
 	isApp, _ := frameMap["is_app_code"].(bool)
 	language, _ := frameMap["symbol_language"].(string)
 	
@@ -1249,12 +2370,12 @@ func formatStackFrameRecursive(buf *bytes.Buffer, frame interface{}, index int,
 			languageTag = fmt.Sprintf(" [%s]", fileType)
 		}
 		
-		buf.WriteString(fmt.Sprintf("%s#%d  0x%x (采样:%d次)%s\n", marker, index, uint64(addr), int(sampleCount), languageTag))
+		buf.WriteString(fmt.Sprintf("%s#%d  0x%016x (采样:%d次)%s\n", marker, index, addr, int(sampleCount), languageTag))
 		buf.WriteString(fmt.Sprintf("%s     %s\n", indent, symbolicatedName))
 	} else if symbolName, ok := frameMap["symbol_name"].(string); ok && symbolName != "" {
-		buf.WriteString(fmt.Sprintf("%s#%d  0x%x (采样:%d次) %s\n", marker, index, uint64(addr), int(sampleCount), symbolName))
+		buf.WriteString(fmt.Sprintf("%s#%d  0x%016x (采样:%d次) %s\n", marker, index, addr, int(sampleCount), symbolName))
 	} else {
-		buf.WriteString(fmt.Sprintf("%s#%d  0x%x (采样:%d次)\n", marker, index, uint64(addr), int(sampleCount)))
+		buf.WriteString(fmt.Sprintf("%s#%d  0x%016x (采样:%d次)\n", marker, index, addr, int(sampleCount)))
 	}
 	
 	// 递归显示子帧
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ============================================================================
+// 符号化请求并发上限：每个请求会启动大量 atos 进程，同时处理的请求过多会耗尽进程和文件描述符
+// 与单份报告内部的线程 worker 池（SYMBOLICATE_WORKERS）相互独立
+// ============================================================================
+
+// maxConcurrentSymbolications 同时处理的符号化请求上限（MAX_CONCURRENT_SYMBOLICATIONS）
+var maxConcurrentSymbolications = envInt("MAX_CONCURRENT_SYMBOLICATIONS", 2)
+
+// symbolicationQueueTimeout 名额已满时请求排队等待的最长时间（SYMBOLICATE_QUEUE_TIMEOUT，秒），超时返回 429
+var symbolicationQueueTimeout = time.Duration(envInt("SYMBOLICATE_QUEUE_TIMEOUT", 10)) * time.Second
+
+var (
+	// symbolicationSlots 符号化请求信号量
+	symbolicationSlots = make(chan struct{}, maxConcurrentSymbolications)
+	// queuedSymbolications 正在排队等待名额的请求数量
+	queuedSymbolications int64
+)
+
+// errSymbolicationBusy 排队超时仍没有空闲的符号化名额
+var errSymbolicationBusy = errors.New("当前符号化请求过多，请稍后重试")
+
+// acquireSymbolicationSlot 获取一个符号化名额，名额已满时最多排队 symbolicationQueueTimeout
+// 成功时返回释放名额的函数；排队超时返回 errSymbolicationBusy，客户端断开时返回 ctx.Err()
+func acquireSymbolicationSlot(ctx context.Context) (release func(), err error) {
+	release = func() { <-symbolicationSlots }
+	select {
+	case symbolicationSlots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	atomic.AddInt64(&queuedSymbolications, 1)
+	defer atomic.AddInt64(&queuedSymbolications, -1)
+	timer := time.NewTimer(symbolicationQueueTimeout)
+	defer timer.Stop()
+	select {
+	case symbolicationSlots <- struct{}{}:
+		return release, nil
+	case <-timer.C:
+		return nil, errSymbolicationBusy
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// waitSymbolicationSlot 后台任务使用：一直排队直到获得名额，不受 symbolicationQueueTimeout 限制
+func waitSymbolicationSlot() (release func()) {
+	atomic.AddInt64(&queuedSymbolications, 1)
+	symbolicationSlots <- struct{}{}
+	atomic.AddInt64(&queuedSymbolications, -1)
+	return func() { <-symbolicationSlots }
+}
+
+// limitSymbolication 符号化接口的并发限制：名额已满时排队，超过 symbolicationQueueTimeout 返回 429 和 Retry-After
+func limitSymbolication() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		release, err := acquireSymbolicationSlot(c.Request.Context())
+		if errors.Is(err, errSymbolicationBusy) {
+			rejectSymbolication(c)
+			return
+		}
+		if err != nil {
+			// 客户端已断开，不再处理
+			c.Abort()
+			return
+		}
+		defer release()
+
+		c.Next()
+	}
+}
+
+// rejectSymbolication 返回 429，Retry-After 取排队超时时间（至少 1 秒）
+func rejectSymbolication(c *gin.Context) {
+	retryAfter := int(symbolicationQueueTimeout / time.Second)
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	requestLogger(c).Warn("符号化请求过多，拒绝请求", "path", c.Request.URL.Path, "limit", cap(symbolicationSlots))
+
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"error":       errSymbolicationBusy.Error(),
+		"limit":       cap(symbolicationSlots),
+		"retry_after": retryAfter,
+	})
+}
+
+// symbolicationStats 返回符号化请求的并发情况，用于健康检查
+func symbolicationStats() map[string]interface{} {
+	return map[string]interface{}{
+		"active": len(symbolicationSlots),
+		"queued": atomic.LoadInt64(&queuedSymbolications),
+		"limit":  cap(symbolicationSlots),
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLimitSymbolicationRejectsWhenSaturated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	oldSlots, oldTimeout := symbolicationSlots, symbolicationQueueTimeout
+	symbolicationSlots = make(chan struct{}, 2)
+	symbolicationQueueTimeout = 50 * time.Millisecond
+	defer func() { symbolicationSlots, symbolicationQueueTimeout = oldSlots, oldTimeout }()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	r := gin.New()
+	r.POST("/symbolicate", limitSymbolication(), func(c *gin.Context) {
+		started <- struct{}{}
+		<-release
+		c.Status(http.StatusOK)
+	})
+
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/symbolicate", nil))
+		return w
+	}
+
+	// 占满 2 个名额
+	done := make(chan int, 3)
+	for i := 0; i < 2; i++ {
+		go func() { done <- serve().Code }()
+		<-started
+	}
+
+	// 第 3 个请求排队超时后返回 429
+	w := serve()
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("第 3 个请求状态码 = %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+	if stats := symbolicationStats(); stats["active"] != 2 || stats["queued"] != int64(0) {
+		t.Errorf("symbolicationStats() = %v", stats)
+	}
+
+	// 排队中的请求在名额释放后继续处理
+	symbolicationQueueTimeout = 5 * time.Second
+	go func() { done <- serve().Code }()
+	for symbolicationStats()["queued"] != int64(1) {
+		time.Sleep(time.Millisecond)
+	}
+	release <- struct{}{}
+	<-started
+	close(release)
+	for i := 0; i < 3; i++ {
+		if code := <-done; code != http.StatusOK {
+			t.Errorf("请求状态码 = %d, want 200", code)
+		}
+	}
+	if got := len(symbolicationSlots); got != 0 {
+		t.Errorf("请求结束后占用名额 = %d, want 0", got)
+	}
+}
+
+func TestGetTopThreadHandlerSharesSymbolicationLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	oldSlots, oldTimeout, oldReportsDir := symbolicationSlots, symbolicationQueueTimeout, ReportsDir
+	symbolicationSlots = make(chan struct{}, 1)
+	symbolicationQueueTimeout = 10 * time.Millisecond
+	ReportsDir = t.TempDir()
+	defer func() {
+		symbolicationSlots, symbolicationQueueTimeout, ReportsDir = oldSlots, oldTimeout, oldReportsDir
+	}()
+
+	reportID, _, _, _, err := storeReport("report.json", []byte(`{"crash": {"threads": []}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 名额被占满时，尚未符号化的报告需要排队，超时返回 429
+	release := waitSymbolicationSlot()
+	defer release()
+
+	r := gin.New()
+	r.GET("/api/report/:id/top-thread", getTopThreadHandler)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/report/"+reportID+"/top-thread", nil))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("状态码 = %d, want 429, body = %s", w.Code, w.Body.String())
+	}
+}
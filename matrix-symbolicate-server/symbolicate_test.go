@@ -1,45 +1,104 @@
 package main
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestExtractDsymInfo(t *testing.T) {
-	// 这是一个示例测试
-	// 实际使用时需要有真实的 dSYM 文件
-	t.Log("dSYM 信息提取测试")
-	
-	// TODO: 添加实际的测试用例
-	// uuid, arch, err := extractDsymInfo("path/to/test.app")
-	// if err != nil {
-	//     t.Errorf("提取失败: %v", err)
-	// }
+	// 内置 Mach-O 解析失败（如旧格式的符号表）时回退到 dwarfdump
+	var gotArgs []string
+	installFakeRunner(t, func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		if name != "dwarfdump" {
+			return nil, fmt.Errorf("unexpected command %s", name)
+		}
+		gotArgs = args
+		return []byte("UUID: 11111111-2222-3333-4444-555555555555 (armv7) Demo\nUUID: AAAAAAAA-BBBB-CCCC-DDDD-EEEEEEEEEEEE (arm64) Demo\n"), nil
+	})
+	stubLookPath(t, nil)
+
+	dsymPath := filepath.Join(t.TempDir(), "Demo")
+	os.WriteFile(dsymPath, []byte("not a mach-o"), 0644)
+
+	slices, err := extractDsymInfo(dsymPath)
+	if err != nil {
+		t.Fatalf("extractDsymInfo() error = %v", err)
+	}
+	want := []DsymSlice{
+		{UUID: "11111111-2222-3333-4444-555555555555", Arch: "armv7"},
+		{UUID: "AAAAAAAA-BBBB-CCCC-DDDD-EEEEEEEEEEEE", Arch: "arm64"},
+	}
+	if !reflect.DeepEqual(slices, want) {
+		t.Errorf("extractDsymInfo() = %v, want %v", slices, want)
+	}
+	if !reflect.DeepEqual(gotArgs, []string{"--uuid", dsymPath}) {
+		t.Errorf("dwarfdump 参数 = %v", gotArgs)
+	}
+
+	// dwarfdump 失败时返回错误
+	installFakeRunner(t, func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return nil, fmt.Errorf("exit status 1")
+	})
+	if _, err := extractDsymInfo(dsymPath); err == nil {
+		t.Error("dwarfdump 失败时应返回错误")
+	}
 }
 
 func TestParseSymbolOutput(t *testing.T) {
 	tests := []struct {
 		name       string
 		input      string
+		wantSymbol string
+		wantModule string
 		wantFile   string
 		wantLine   string
 	}{
 		{
-			name:     "标准格式",
-			input:    "-[TestLagViewController simulateLag] (in MatrixTestApp) (TestLagViewController.mm:145)",
-			wantFile: "TestLagViewController.mm",
-			wantLine: "145",
+			name:       "标准格式",
+			input:      "-[TestLagViewController simulateLag] (in MatrixTestApp) (TestLagViewController.mm:145)",
+			wantSymbol: "-[TestLagViewController simulateLag]",
+			wantModule: "MatrixTestApp",
+			wantFile:   "TestLagViewController.mm",
+			wantLine:   "145",
+		},
+		{
+			name:       "Swift 文件",
+			input:      "MyClass.doSomething() (in MyApp) (MyFile.swift:42)",
+			wantSymbol: "MyClass.doSomething()",
+			wantModule: "MyApp",
+			wantFile:   "MyFile.swift",
+			wantLine:   "42",
 		},
 		{
-			name:     "Swift 文件",
-			input:    "MyClass.doSomething() (in MyApp) (MyFile.swift:42)",
-			wantFile: "MyFile.swift",
-			wantLine: "42",
+			name:       "C 文件",
+			input:      "my_function (in MyApp) (myfile.c:100)",
+			wantSymbol: "my_function",
+			wantModule: "MyApp",
+			wantFile:   "myfile.c",
+			wantLine:   "100",
 		},
 		{
-			name:     "C 文件",
-			input:    "my_function (in MyApp) (myfile.c:100)",
-			wantFile: "myfile.c",
-			wantLine: "100",
+			name:       "无文件信息",
+			input:      "objc_msgSend (in libobjc.A.dylib) + 0x40",
+			wantSymbol: "objc_msgSend",
+			wantModule: "libobjc.A.dylib",
+		},
+		{
+			name:       "无模块信息",
+			input:      "main + 64",
+			wantSymbol: "main",
 		},
 		{
 			name:     "无匹配",
@@ -51,12 +110,20 @@ func TestParseSymbolOutput(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotFile, gotLine := parseSymbolOutput(tt.input)
-			
+			gotSymbol, gotModule, gotFile, gotLine := parseSymbolOutput(tt.input)
+
+			if gotSymbol != tt.wantSymbol {
+				t.Errorf("parseSymbolOutput() 函数名 = %v, want %v", gotSymbol, tt.wantSymbol)
+			}
+
+			if gotModule != tt.wantModule {
+				t.Errorf("parseSymbolOutput() 模块名 = %v, want %v", gotModule, tt.wantModule)
+			}
+
 			if gotFile != tt.wantFile {
 				t.Errorf("parseSymbolOutput() 文件名 = %v, want %v", gotFile, tt.wantFile)
 			}
-			
+
 			if gotLine != tt.wantLine {
 				t.Errorf("parseSymbolOutput() 行号 = %v, want %v", gotLine, tt.wantLine)
 			}
@@ -65,27 +132,1448 @@ func TestParseSymbolOutput(t *testing.T) {
 }
 
 func TestFindMatchingDsym(t *testing.T) {
-	t.Log("符号表匹配测试")
-	
-	// 示例报告数据
+	oldDir := DsymDir
+	DsymDir = t.TempDir()
+	defer func() { DsymDir = oldDir }()
+
+	// 两个内置解析无法读取的符号表，UUID 由假的 dwarfdump 给出
+	uuids := map[string]string{
+		"Other.dSYM":         "00000000-0000-0000-0000-000000000001",
+		"MatrixTestApp.dSYM": "FD7CB3D0-06EF-3582-9C99-432ABD79F29C",
+	}
+	for name := range uuids {
+		path := filepath.Join(DsymDir, name)
+		os.WriteFile(path, []byte("legacy"), 0644)
+		defer evictDsymInfo(path)
+	}
+	installFakeRunner(t, func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		path := args[len(args)-1]
+		return []byte(fmt.Sprintf("UUID: %s (arm64) %s\n", uuids[filepath.Base(path)], path)), nil
+	})
+	stubLookPath(t, nil)
+
 	report := map[string]interface{}{
 		"binary_images": []interface{}{
 			map[string]interface{}{
 				"name":       "/var/containers/Bundle/Application/XXX/MatrixTestApp.app/MatrixTestApp",
-				"uuid":       "FD7CB3D0-06EF-3582-9C99-432ABD79F29C",
+				"uuid":       "fd7cb3d0-06ef-3582-9c99-432abd79f29c",
 				"image_addr": float64(0x100000000),
 			},
 		},
 	}
 
-	// TODO: 添加实际的匹配逻辑测试
-	result := findMatchingDsym(report)
-	t.Logf("匹配结果: %s", result)
+	if got, want := findMatchingDsym(report), filepath.Join(DsymDir, "MatrixTestApp.dSYM"); got != want {
+		t.Errorf("findMatchingDsym() = %q, want %q", got, want)
+	}
+
+	report["binary_images"].([]interface{})[0].(map[string]interface{})["uuid"] = "12345678-0000-0000-0000-000000000000"
+	if got := findMatchingDsym(report); got != "" {
+		t.Errorf("没有匹配的符号表时应返回空，得到 %q", got)
+	}
+}
+
+func TestWatchOSArm64_32Report(t *testing.T) {
+	// watchOS 报告：代码位于 WatchKit Extension 中，架构为 arm64_32
+	report := map[string]interface{}{
+		"system": map[string]interface{}{
+			"system_name": "watchOS",
+			"cpu_arch":    "arm64_32",
+		},
+		"binary_images": []interface{}{
+			map[string]interface{}{
+				"name":       "/usr/lib/system/libsystem_kernel.dylib",
+				"uuid":       "11111111-1111-1111-1111-111111111111",
+				"image_addr": float64(0x20000000),
+			},
+			map[string]interface{}{
+				"name":       "/private/var/containers/Bundle/Application/XXX/Demo.app/PlugIns/Demo WatchKit Extension.appex/Demo WatchKit Extension",
+				"uuid":       "a1b2c3d4-0000-1111-2222-333344445555",
+				"image_addr": float64(0x4000),
+			},
+		},
+	}
+
+	if got := normalizeArch("arm64_32"); got != "arm64_32" {
+		t.Errorf("normalizeArch(arm64_32) = %s, want arm64_32", got)
+	}
+	if got := normalizeArch("ARM64_32"); got != "arm64_32" {
+		t.Errorf("normalizeArch(ARM64_32) = %s, want arm64_32", got)
+	}
+
+	appImage := findAppImage(report)
+	if appImage == nil {
+		t.Fatal("findAppImage() 未找到 WatchKit Extension 镜像")
+	}
+	if uuid := getString(appImage, "uuid"); uuid != "a1b2c3d4-0000-1111-2222-333344445555" {
+		t.Errorf("findAppImage() uuid = %s", uuid)
+	}
+
+	regs := getRegisterOrder("arm64_32")
+	if len(regs) == 0 || regs[0] != "x0" || regs[len(regs)-1] != "cpsr" {
+		t.Errorf("getRegisterOrder(arm64_32) = %v", regs)
+	}
 }
 
 func BenchmarkSymbolicateAddress(b *testing.B) {
-	// 性能测试
-	// TODO: 添加实际的性能测试用例
-	b.Log("符号化性能测试")
+	// 假的 atos：每个地址输出一行，只衡量批量调用、输出解析和后处理的开销
+	old := commandRunner
+	defer func() { commandRunner = old }()
+	commandRunner = runnerFunc(func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		var out bytes.Buffer
+		for _, arg := range args[6:] {
+			fmt.Fprintf(&out, "-[Demo method_%s] (in Demo) (Demo.m:42)\n", arg)
+		}
+		return out.Bytes(), nil
+	})
+
+	// 不是 Mach-O 的路径没有 UUID，不会命中进程级缓存
+	addrs := make([]uint64, 100)
+	for i := range addrs {
+		addrs[i] = 0x100000000 + uint64(i)*4
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		symbolicateAddresses("/nonexistent/Demo", 0x100000000, addrs, "arm64")
+	}
+}
+
+func TestFrameLoadAddressImageMissing(t *testing.T) {
+	binaryImages := []interface{}{
+		map[string]interface{}{
+			"name":       "/var/containers/Bundle/Application/XXX/Demo.app/Demo",
+			"image_addr": float64(0x100000000),
+			"image_size": float64(0x10000),
+		},
+	}
+
+	// 地址在 binary_images 中：使用报告的加载地址
+	inImage := map[string]interface{}{"object_addr": float64(0x100000000)}
+	if got := frameLoadAddress(inImage, 0x100000100, 0x100000000, binaryImages); got != 0x100000000 {
+		t.Errorf("frameLoadAddress() = 0x%x, want 0x100000000", got)
+	}
+
+	// 镜像缺失：回退到 frame 自带的 object_addr
+	missing := map[string]interface{}{"object_name": "UIKitCore", "object_addr": float64(0x180000000)}
+	if got := frameLoadAddress(missing, 0x180000100, 0x100000000, binaryImages); got != 0x180000000 {
+		t.Errorf("frameLoadAddress() = 0x%x, want 0x180000000", got)
+	}
+
+	// 镜像缺失且没有 object_addr：保持报告的加载地址
+	bare := map[string]interface{}{"object_name": "UIKitCore"}
+	if got := frameLoadAddress(bare, 0x180000100, 0x100000000, binaryImages); got != 0x100000000 {
+		t.Errorf("frameLoadAddress() = 0x%x, want 0x100000000", got)
+	}
+
+	// 系统库镜像中的帧：返回所在镜像的 image_addr，且不使用应用符号表解析
+	withSystem := append(binaryImages, map[string]interface{}{
+		"name":       "/System/Library/PrivateFrameworks/UIKitCore.framework/UIKitCore",
+		"image_addr": float64(0x190000000),
+		"image_size": float64(0x10000),
+	})
+	system := map[string]interface{}{"object_name": "UIKitCore"}
+	if got := frameLoadAddress(system, 0x190000100, 0x100000000, withSystem); got != 0x190000000 {
+		t.Errorf("frameLoadAddress(系统库) = 0x%x, want 0x190000000", got)
+	}
+	binaries := &imageBinaries{appPath: "Demo", appLoadAddr: 0x100000000, appImageAddr: 0x100000000, hasAppImage: true, binaryImages: withSystem}
+	if _, _, _, ok := binaries.forFrame(system, 0x190000100); ok {
+		t.Error("forFrame(系统库) ok = true, want false")
+	}
+	if path, loadAddr, _, ok := binaries.forFrame(inImage, 0x100000100); !ok || path != "Demo" || loadAddr != 0x100000000 {
+		t.Errorf("forFrame(应用) = %s, 0x%x, %v", path, loadAddr, ok)
+	}
+
+	// 请求指定的加载地址优先于应用帧自带的 object_addr，其它镜像的帧仍使用 object_addr
+	partial := &imageBinaries{appPath: "Demo", appName: "Demo", appLoadAddr: 0x104000000, overrideLoadAddr: true}
+	appFrame := map[string]interface{}{"object_name": "Demo", "object_addr": float64(0x100000000)}
+	if _, loadAddr, _, _ := partial.forFrame(appFrame, 0x104000100); loadAddr != 0x104000000 {
+		t.Errorf("forFrame(指定加载地址) = 0x%x, want 0x104000000", loadAddr)
+	}
+	if _, loadAddr, _, _ := partial.forFrame(missing, 0x180000100); loadAddr != 0x180000000 {
+		t.Errorf("forFrame(其它镜像) = 0x%x, want 0x180000000", loadAddr)
+	}
+}
+
+func TestSymbolicateReportPreservesNestedFields(t *testing.T) {
+	report := map[string]interface{}{
+		"system": map[string]interface{}{"cpu_arch": "arm64"},
+		"crash": map[string]interface{}{
+			"diagnosis": "custom diagnosis",
+			"error":     map[string]interface{}{"type": "signal"},
+			"future_field": map[string]interface{}{
+				"nested": []interface{}{float64(1), float64(2)},
+			},
+			"threads": []interface{}{
+				map[string]interface{}{
+					"index":        float64(0),
+					"thread_extra": "keep me",
+					"backtrace": map[string]interface{}{
+						"skipped":  float64(0),
+						"contents": []interface{}{},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := symbolicateReport(report, "/nonexistent/Demo")
+	if err != nil {
+		t.Fatalf("symbolicateReport() error = %v", err)
+	}
+
+	crash := result["crash"].(map[string]interface{})
+	if crash["diagnosis"] != "custom diagnosis" {
+		t.Errorf("crash.diagnosis 丢失: %v", crash["diagnosis"])
+	}
+	future, ok := crash["future_field"].(map[string]interface{})
+	if !ok || len(future["nested"].([]interface{})) != 2 {
+		t.Errorf("crash.future_field 丢失: %v", crash["future_field"])
+	}
+
+	thread := crash["threads"].([]interface{})[0].(map[string]interface{})
+	if thread["thread_extra"] != "keep me" {
+		t.Errorf("thread_extra 丢失: %v", thread["thread_extra"])
+	}
+	if _, ok := thread["backtrace"].(map[string]interface{})["skipped"]; !ok {
+		t.Error("backtrace.skipped 丢失")
+	}
+
+	// 耗时分解
+	timing := symbolicationTiming(result)
+	for _, key := range []string{"extraction_ms", "matching_ms", "atos_ms", "total_ms"} {
+		if _, ok := timing[key].(int64); !ok {
+			t.Errorf("timing.%s 缺失: %v", key, timing)
+		}
+	}
+	total := timing["total_ms"].(int64)
+	recordMatchingTime(result, 25*time.Millisecond)
+	if timing["matching_ms"] != int64(25) || timing["total_ms"] != total+25 {
+		t.Errorf("recordMatchingTime 后 timing = %v", timing)
+	}
+
+	// 原始报告不应被修改
+	future["nested"] = nil
+	origFuture := report["crash"].(map[string]interface{})["future_field"].(map[string]interface{})
+	if origFuture["nested"] == nil {
+		t.Error("修改符号化结果影响了原始报告")
+	}
+}
+
+func TestParseHexAddress(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    uint64
+		wantErr bool
+	}{
+		{"0x100004000", 0x100004000, false},
+		{"0X1A", 0x1a, false},
+		{"  100004000 ", 0x100004000, false},
+		{"0x", 0, true},
+		{"0xZZZ", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseHexAddress(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseHexAddress(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseHexAddress(%q) = 0x%x, want 0x%x", tt.input, got, tt.want)
+		}
+	}
+}
+
+// writeFakeMachO 生成只包含 LC_UUID 的最小 64 位 Mach-O 文件
+func writeFakeMachO(t testing.TB, path string, cpu, subCpu uint32, uuid [16]byte) {
+	t.Helper()
+
+	buf := make([]byte, 32+24)
+	le := binary.LittleEndian
+	le.PutUint32(buf[0:], 0xfeedfacf) // MH_MAGIC_64
+	le.PutUint32(buf[4:], cpu)
+	le.PutUint32(buf[8:], subCpu)
+	le.PutUint32(buf[12:], 0xa) // MH_DSYM
+	le.PutUint32(buf[16:], 1)   // ncmds
+	le.PutUint32(buf[20:], 24)  // sizeofcmds
+	le.PutUint32(buf[32:], 0x1b)
+	le.PutUint32(buf[36:], 24)
+	copy(buf[40:], uuid[:])
+
+	if err := os.WriteFile(path, buf, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadMachOUUID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Demo")
+	uuid := [16]byte{0xfd, 0x7c, 0xb3, 0xd0, 0x06, 0xef, 0x35, 0x82, 0x9c, 0x99, 0x43, 0x2a, 0xbd, 0x79, 0xf2, 0x9c}
+	writeFakeMachO(t, path, 0x0100000c, 0, uuid)
+
+	gotUUID, gotArch, err := readMachOUUID(path)
+	if err != nil {
+		t.Fatalf("readMachOUUID() error = %v", err)
+	}
+	if gotUUID != "FD7CB3D0-06EF-3582-9C99-432ABD79F29C" {
+		t.Errorf("uuid = %s", gotUUID)
+	}
+	if gotArch != "arm64" {
+		t.Errorf("arch = %s, want arm64", gotArch)
+	}
+}
+
+func TestListDsymsWarnsWhenDwarfdumpMissing(t *testing.T) {
+	origLookPath := lookPath
+	defer func() { lookPath = origLookPath }()
+	lookPath = func(name string) (string, error) {
+		return "", fmt.Errorf("exec: %q: executable file not found in $PATH", name)
+	}
+
+	dir := t.TempDir()
+	// 一个有效的 Mach-O：内置解析可以读取
+	writeFakeMachO(t, filepath.Join(dir, "Good"), 0x0100000c, 2, [16]byte{1, 2, 3})
+	// 一个无效文件：dwarfdump 缺失时无法读取
+	os.WriteFile(filepath.Join(dir, "Broken"), []byte("not a mach-o"), 0644)
+
+	dsyms, warning, err := listDsyms(dir)
+	if err != nil {
+		t.Fatalf("listDsyms() error = %v", err)
+	}
+	if len(dsyms) != 2 {
+		t.Fatalf("期望 2 个符号表，实际 %d", len(dsyms))
+	}
+
+	for _, d := range dsyms {
+		if d["filename"] == "Good" && (d["uuid"] == "" || d["arch"] != "arm64e") {
+			t.Errorf("内置解析结果错误: %v", d)
+		}
+	}
+
+	if !strings.Contains(warning, "dwarfdump") {
+		t.Errorf("warning 未说明 dwarfdump 缺失: %q", warning)
+	}
+}
+
+func TestParseAtosBatchOutput(t *testing.T) {
+	addrs := []uint64{0x100004000, 0x100004100, 0x100004200}
+
+	t.Run("保持顺序", func(t *testing.T) {
+		output := "-[A a] (in Demo) (A.m:1)\n0x100004100\n-[C c] (in Demo) (C.m:3)\n"
+		got := parseAtosBatchOutput(output, addrs)
+		want := []string{"-[A a] (in Demo) (A.m:1)", "", "-[C c] (in Demo) (C.m:3)"}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("结果[%d] = %q, want %q", i, got[i], want[i])
+			}
+		}
+	})
+
+	t.Run("输出行数不足", func(t *testing.T) {
+		got := parseAtosBatchOutput("-[A a] (in Demo) (A.m:1)\n", addrs)
+		if len(got) != len(addrs) {
+			t.Fatalf("结果长度 = %d, want %d", len(got), len(addrs))
+		}
+		if got[0] == "" || got[1] != "" || got[2] != "" {
+			t.Errorf("结果 = %q", got)
+		}
+	})
+
+	t.Run("空输出", func(t *testing.T) {
+		got := parseAtosBatchOutput("", addrs)
+		for i, sym := range got {
+			if sym != "" {
+				t.Errorf("结果[%d] = %q, want 空", i, sym)
+			}
+		}
+	})
+}
+
+// writeFakeDsymZip 生成包含最小 Mach-O 的 Demo.dSYM.zip
+func writeFakeDsymZip(t testing.TB, path string, uuid [16]byte) {
+	t.Helper()
+
+	machoPath := filepath.Join(t.TempDir(), "Demo")
+	writeFakeMachO(t, machoPath, 0x0100000c, 0, uuid)
+	machoData, err := os.ReadFile(machoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("Demo.dSYM/Contents/Resources/DWARF/Demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(machoData)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractDsymZipReleasesSlots(t *testing.T) {
+	if !toolAvailable("unzip") {
+		t.Skip("unzip 不可用")
+	}
+
+	zipPath := filepath.Join(t.TempDir(), "Demo.dSYM.zip")
+	writeFakeDsymZip(t, zipPath, [16]byte{0xaa, 0xbb})
+
+	// 同时进行的解压数量不超过上限
+	binaries := make([]string, 0, cap(extractionSlots))
+	cleanups := make([]func(), 0, cap(extractionSlots))
+	for i := 0; i < cap(extractionSlots); i++ {
+		binaryPath, cleanup, err := extractDsymArchive(zipPath)
+		if err != nil {
+			t.Fatalf("extractDsymArchive() error = %v", err)
+		}
+		binaries = append(binaries, binaryPath)
+		cleanups = append(cleanups, cleanup)
+	}
+
+	if got := extractionStats()["active"].(int64); got != int64(cap(extractionSlots)) {
+		t.Errorf("active = %d, want %d", got, cap(extractionSlots))
+	}
+	if binaries[0] == binaries[1] {
+		t.Error("每次解压应使用独立的临时目录")
+	}
+
+	for _, cleanup := range cleanups {
+		cleanup()
+		cleanup() // 重复调用是安全的
+	}
+
+	if got := extractionStats()["active"].(int64); got != 0 {
+		t.Errorf("清理后 active = %d, want 0", got)
+	}
+	for _, binaryPath := range binaries {
+		if _, err := os.Stat(binaryPath); !os.IsNotExist(err) {
+			t.Errorf("临时文件未清理: %s", binaryPath)
+		}
+	}
+
+	// 解压失败也要释放名额
+	bogus := filepath.Join(t.TempDir(), "Bogus.dSYM.zip")
+	os.WriteFile(bogus, []byte("not a zip"), 0644)
+	if _, _, err := extractDsymArchive(bogus); err == nil {
+		t.Error("无效 zip 应返回错误")
+	}
+	if got := extractionStats()["active"].(int64); got != 0 {
+		t.Errorf("解压失败后 active = %d, want 0", got)
+	}
+}
+
+// writeFakeFatMachO 生成包含 armv7 + arm64 两个 slice 的通用 Mach-O 文件
+func writeFakeFatMachO(t testing.TB, path string, uuids [2][16]byte) {
+	t.Helper()
+
+	dir := t.TempDir()
+	cpus := [2]uint32{0xc, 0x0100000c} // armv7, arm64
+	var slices [2][]byte
+	for i := range cpus {
+		slicePath := filepath.Join(dir, fmt.Sprintf("slice%d", i))
+		writeFakeMachO(t, slicePath, cpus[i], 0, uuids[i])
+		data, err := os.ReadFile(slicePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		slices[i] = data
+	}
+
+	// fat 头使用大端序，slice 按 4K 对齐
+	be := binary.BigEndian
+	buf := make([]byte, 0x3000)
+	be.PutUint32(buf[0:], 0xcafebabe)
+	be.PutUint32(buf[4:], 2)
+	for i := range cpus {
+		offset := uint32(0x1000 * (i + 1))
+		arch := buf[8+20*i:]
+		be.PutUint32(arch[0:], cpus[i])
+		be.PutUint32(arch[4:], 0)
+		be.PutUint32(arch[8:], offset)
+		be.PutUint32(arch[12:], uint32(len(slices[i])))
+		be.PutUint32(arch[16:], 12)
+		copy(buf[offset:], slices[i])
+	}
+
+	if err := os.WriteFile(path, buf, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSelectDsymArch(t *testing.T) {
+	dir := t.TempDir()
+
+	// arm64e-only dSYM（CPU_SUBTYPE_ARM64E = 2）
+	arm64ePath := filepath.Join(dir, "arm64e")
+	writeFakeMachO(t, arm64ePath, 0x0100000c, 2, [16]byte{0xe1})
+	arm64eSlices, err := readMachOSlices(arm64ePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// armv7 + arm64 的通用 dSYM
+	fatPath := filepath.Join(dir, "fat")
+	writeFakeFatMachO(t, fatPath, [2][16]byte{{0x07}, {0x64}})
+	fatSlices, err := readMachOSlices(fatPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		cpuArch    string
+		slices     []DsymSlice
+		reportUUID string
+		want       string
+	}{
+		{"arm64e 报告 + arm64e dSYM", "arm64e", arm64eSlices, "", "arm64e"},
+		{"arm64 报告 + 只有 arm64e 的 dSYM", "arm64", arm64eSlices, "", "arm64e"},
+		{"arm64e 设备上的 arm64 应用", "arm64e", fatSlices, "", "arm64"},
+		{"armv7 报告选 armv7 slice", "armv7", fatSlices, "", "armv7"},
+		{"armv7s 报告回退到 armv7", "armv7s", fatSlices, "", "armv7"},
+		{"UUID 优先于架构名", "arm64", fatSlices, strings.ToLower(fatSlices[0].UUID), "armv7"},
+		{"没有 slice 信息时保持报告架构", "arm64e", nil, "", "arm64e"},
+	}
+
+	for _, tt := range tests {
+		if got := selectDsymArch(normalizeArch(tt.cpuArch), tt.slices, tt.reportUUID); got != tt.want {
+			t.Errorf("%s: selectDsymArch() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	for cpuArch, want := range map[string]string{
+		"arm64e": "arm64e", "ARM64": "arm64", "armv7": "armv7", "armv7s": "armv7s",
+		"arm": "armv7", "x86_64": "x86_64", "i386": "i386", "": "arm64",
+	} {
+		if got := normalizeArch(cpuArch); got != want {
+			t.Errorf("normalizeArch(%q) = %s, want %s", cpuArch, got, want)
+		}
+	}
+}
+
+func TestExtractDsymArchiveConcurrent(t *testing.T) {
+	// 两个同名（Demo.dSYM/…/DWARF/Demo）但 UUID 不同的符号表同时解压，不能互相覆盖
+	dir := t.TempDir()
+	archives := []string{filepath.Join(dir, "A.dSYM.tar.gz"), filepath.Join(dir, "B.dSYM.tar.gz")}
+	uuids := [][16]byte{{0x0a}, {0x0b}}
+	for i, path := range archives {
+		writeFakeDsymTarGz(t, path, uuids[i], nil)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			archive := archives[i%2]
+			binaryPath, cleanup, err := extractDsymArchive(archive)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer cleanup()
+
+			// 持有期间另一个符号表的解压不能影响这个二进制
+			time.Sleep(time.Millisecond)
+			uuid, _, err := readMachOUUID(binaryPath)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if want := fmt.Sprintf("%02X000000-", uuids[i%2][0]); !strings.HasPrefix(uuid, want) {
+				errs <- fmt.Errorf("%s 解压出的 UUID = %s", filepath.Base(archive), uuid)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+	if got := extractionStats()["active"].(int64); got != 0 {
+		t.Errorf("全部清理后 active = %d, want 0", got)
+	}
+}
+
+func TestReadMachOSlicesFat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Demo")
+	writeFakeFatMachO(t, path, [2][16]byte{{0x11}, {0x22}})
+
+	slices, err := readMachOSlices(path)
+	if err != nil {
+		t.Fatalf("readMachOSlices() error = %v", err)
+	}
+	if len(slices) != 2 {
+		t.Fatalf("期望 2 个 slice，实际 %d: %v", len(slices), slices)
+	}
+	if slices[0].Arch != "armv7" || slices[1].Arch != "arm64" {
+		t.Errorf("架构错误: %v", slices)
+	}
+	if !strings.HasPrefix(slices[1].UUID, "22000000-") {
+		t.Errorf("第二个 slice UUID 错误: %s", slices[1].UUID)
+	}
+
+	// 报告的 UUID 是第二个 slice 时也能匹配
+	if !dsymHasUUID(slices, strings.ToLower(slices[1].UUID)) {
+		t.Errorf("dsymHasUUID 未匹配第二个 slice")
+	}
+}
+
+func TestParseDwarfdumpUUIDs(t *testing.T) {
+	output := "UUID: 11111111-2222-3333-4444-555555555555 (armv7) /tmp/Demo\n" +
+		"UUID: aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee (arm64) /tmp/Demo\n"
+
+	slices := parseDwarfdumpUUIDs(output)
+	if len(slices) != 2 {
+		t.Fatalf("期望 2 个 slice，实际 %d", len(slices))
+	}
+	if slices[1].UUID != "AAAAAAAA-BBBB-CCCC-DDDD-EEEEEEEEEEEE" || slices[1].Arch != "arm64" {
+		t.Errorf("第二个 slice 错误: %v", slices[1])
+	}
+}
+
+func TestFindAppImageResolution(t *testing.T) {
+	images := []interface{}{
+		map[string]interface{}{"name": "/private/var/containers/Bundle/Application/ABC/Shop.app/Frameworks/Kit.framework/Kit", "uuid": "KIT"},
+		map[string]interface{}{"name": "/usr/lib/system/libsystem_kernel.dylib", "uuid": "SYS"},
+		map[string]interface{}{"name": "/private/var/containers/Bundle/Application/ABC/Shop.app/Shop", "uuid": "SHOP"},
+	}
+
+	tests := []struct {
+		name   string
+		system map[string]interface{}
+		want   string
+	}{
+		{
+			name:   "CFBundleExecutablePath 精确匹配",
+			system: map[string]interface{}{"CFBundleExecutablePath": "/private/var/containers/Bundle/Application/ABC/Shop.app/Shop"},
+			want:   "SHOP",
+		},
+		{
+			name:   "CFBundleExecutablePath 缺少 /private 前缀",
+			system: map[string]interface{}{"CFBundleExecutablePath": "/var/containers/Bundle/Application/ABC/Shop.app/Shop"},
+			want:   "SHOP",
+		},
+		{
+			name:   "进程名",
+			system: map[string]interface{}{"process_name": "Shop"},
+			want:   "SHOP",
+		},
+		{
+			name:   "启发式跳过内嵌 framework",
+			system: map[string]interface{}{},
+			want:   "SHOP",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := map[string]interface{}{"system": tt.system, "binary_images": images}
+			got := findAppImage(report)
+			if got == nil || getString(got, "uuid") != tt.want {
+				t.Errorf("findAppImage() = %v, want uuid %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeNow(t *testing.T) {
+	got, err := time.Parse(time.RFC3339, timeNow())
+	if err != nil {
+		t.Fatalf("timeNow() 不是 RFC3339 格式: %v", err)
+	}
+	if diff := time.Since(got); diff < -5*time.Second || diff > 5*time.Second {
+		t.Errorf("timeNow() = %v，与当前时间相差 %v", got, diff)
+	}
 }
 
+func TestSymbolicateThreadsPreservesOrder(t *testing.T) {
+	// 30 个线程，atos 不可用时帧保持原样，重点验证顺序与串行结果一致
+	var threads []interface{}
+	for i := 0; i < 30; i++ {
+		threads = append(threads, map[string]interface{}{
+			"index": float64(i),
+			"name":  fmt.Sprintf("thread-%d", i),
+			"backtrace": map[string]interface{}{
+				"contents": []interface{}{
+					map[string]interface{}{
+						"object_name":      "Demo",
+						"instruction_addr": float64(0x100004000 + i*0x10),
+						"symbol_name":      "main",
+					},
+				},
+			},
+		})
+	}
+	// 帧不是对象，symbolicateThread 会 panic，应保留原始线程
+	threads[7] = map[string]interface{}{
+		"index":     float64(7),
+		"backtrace": map[string]interface{}{"contents": []interface{}{"broken"}},
+	}
+
+	binaries := &imageBinaries{appPath: "/nonexistent", appLoadAddr: 0x100000000, binaryImages: []interface{}{}}
+	sequential := symbolicateThreads(threads, 1, binaries, "arm64", "", nil)
+	parallel := symbolicateThreads(threads, 8, binaries, "arm64", "", newSymbolCache())
+
+	if len(parallel) != len(threads) {
+		t.Fatalf("期望 %d 个线程，实际 %d", len(threads), len(parallel))
+	}
+	if !reflect.DeepEqual(sequential, parallel) {
+		t.Errorf("并发结果与串行结果不一致")
+	}
+	for i, thread := range parallel {
+		if got := getInt64(thread.(map[string]interface{}), "index"); got != int64(i) {
+			t.Errorf("第 %d 个线程的 index = %d", i, got)
+		}
+	}
+}
+
+// writeFakeMachOWithSymbols 生成带 __TEXT,__text section 和 LC_SYMTAB 的最小 arm64 Mach-O
+// __text 范围为 [0x100000400, 0x100000500)
+func writeFakeMachOWithSymbols(t testing.TB, path string, syms map[string]uint64) {
+	t.Helper()
+
+	const headerSize, segSize, symtabSize = 32, 72 + 80, 24
+	le := binary.LittleEndian
+
+	names := make([]string, 0, len(syms))
+	for name := range syms {
+		names = append(names, name)
+	}
+
+	symOff := headerSize + segSize + symtabSize
+	strOff := symOff + 16*len(names)
+	strtab := []byte{0}
+	nlists := make([]byte, 16*len(names))
+	for i, name := range names {
+		le.PutUint32(nlists[16*i:], uint32(len(strtab)))
+		nlists[16*i+4] = 0x0f // N_SECT | N_EXT
+		nlists[16*i+5] = 1
+		le.PutUint64(nlists[16*i+8:], syms[name])
+		strtab = append(append(strtab, name...), 0)
+	}
+
+	buf := make([]byte, strOff)
+	le.PutUint32(buf[0:], 0xfeedfacf)
+	le.PutUint32(buf[4:], 0x0100000c)
+	le.PutUint32(buf[12:], 0xa) // MH_DSYM
+	le.PutUint32(buf[16:], 2)
+	le.PutUint32(buf[20:], segSize+symtabSize)
+
+	seg := buf[headerSize:]
+	le.PutUint32(seg[0:], 0x19) // LC_SEGMENT_64
+	le.PutUint32(seg[4:], segSize)
+	copy(seg[8:], "__TEXT")
+	le.PutUint64(seg[24:], 0x100000000)
+	le.PutUint64(seg[32:], 0x1000)
+	le.PutUint32(seg[64:], 1) // nsects
+	sect := seg[72:]
+	copy(sect[0:], "__text")
+	copy(sect[16:], "__TEXT")
+	le.PutUint64(sect[32:], 0x100000400)
+	le.PutUint64(sect[40:], 0x100)
+
+	symtab := buf[headerSize+segSize:]
+	le.PutUint32(symtab[0:], 0x2) // LC_SYMTAB
+	le.PutUint32(symtab[4:], symtabSize)
+	le.PutUint32(symtab[8:], uint32(symOff))
+	le.PutUint32(symtab[12:], uint32(len(names)))
+	le.PutUint32(symtab[16:], uint32(strOff))
+	le.PutUint32(symtab[20:], uint32(len(strtab)))
+
+	copy(buf[symOff:], nlists)
+	buf = append(buf, strtab...)
+
+	if err := os.WriteFile(path, buf, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLookupMachOSymbol(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Demo")
+	writeFakeMachOWithSymbols(t, path, map[string]uint64{
+		"_compute":   0x100000400,
+		"-[Foo bar]": 0x100000440,
+		"_last":      0x1000004c0,
+	})
+
+	tests := []struct {
+		name        string
+		start, size uint64
+	}{
+		{"-[Foo bar]", 0x100000440, 0x80},
+		{"compute", 0x100000400, 0x40}, // C 函数不需要写前导下划线
+		{"_last", 0x1000004c0, 0x40},   // 最后一个符号延伸到 section 末尾
+	}
+
+	for _, tt := range tests {
+		sym, err := lookupMachOSymbol(path, tt.name, "")
+		if err != nil || sym == nil {
+			t.Fatalf("lookupMachOSymbol(%q) = %v, %v", tt.name, sym, err)
+		}
+		if sym.Start != tt.start || sym.Size != tt.size || sym.Arch != "arm64" {
+			t.Errorf("lookupMachOSymbol(%q) = %+v, want start 0x%x size 0x%x", tt.name, sym, tt.start, tt.size)
+		}
+	}
+
+	if sym, err := lookupMachOSymbol(path, "-[Foo missing]", ""); err != nil || sym != nil {
+		t.Errorf("不存在的符号应返回 nil: %v, %v", sym, err)
+	}
+	if _, err := lookupMachOSymbol(path, "compute", "x86_64"); err == nil {
+		t.Error("不存在的架构应返回错误")
+	}
+}
+
+// writeFakeDsymTarGz 生成包含最小 Mach-O 的 Demo.dSYM.tar.gz，extra 为额外的条目
+func writeFakeDsymTarGz(t testing.TB, path string, uuid [16]byte, extra map[string][]byte) {
+	t.Helper()
+
+	machoPath := filepath.Join(t.TempDir(), "Demo")
+	writeFakeMachO(t, machoPath, 0x0100000c, 0, uuid)
+	machoData, err := os.ReadFile(machoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	entries := map[string][]byte{
+		"Demo.dSYM/Contents/Resources/DWARF/Demo":      machoData,
+		"__MACOSX/Demo.dSYM/Contents/Resources/._Demo": []byte("AppleDouble"),
+	}
+	for name, data := range extra {
+		entries[name] = data
+	}
+	for name, data := range entries {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg})
+		tw.Write(data)
+	}
+	tw.Close()
+	gz.Close()
+}
+
+func TestExtractDsymTarGz(t *testing.T) {
+	dir := t.TempDir()
+	tarPath := filepath.Join(dir, "Demo.dSYM.tar.gz")
+	writeFakeDsymTarGz(t, tarPath, [16]byte{0x42}, nil)
+
+	slices, err := extractDsymInfo(tarPath)
+	if err != nil {
+		t.Fatalf("extractDsymInfo() error = %v", err)
+	}
+	if len(slices) != 1 || !strings.HasPrefix(slices[0].UUID, "42000000-") {
+		t.Errorf("slices = %v", slices)
+	}
+
+	data, _ := os.ReadFile(tarPath)
+	if err := validateDsymTarball(bytes.NewReader(data)); err != nil {
+		t.Errorf("validateDsymTarball() error = %v", err)
+	}
+
+	// 路径穿越的条目必须被拒绝，且不能写到目标目录之外
+	evil := filepath.Join(dir, "Evil.dSYM.tar.gz")
+	writeFakeDsymTarGz(t, evil, [16]byte{0x42}, map[string][]byte{"../../escaped": []byte("x")})
+	if _, _, err := extractDsymArchive(evil); err == nil {
+		t.Error("包含 ../ 的 tar 应解压失败")
+	}
+	if _, err := os.Stat(filepath.Join(os.TempDir(), "escaped")); err == nil {
+		t.Error("tar 条目被写到了临时目录之外")
+	}
+	data, _ = os.ReadFile(evil)
+	if err := validateDsymTarball(bytes.NewReader(data)); err == nil {
+		t.Error("validateDsymTarball 应拒绝路径穿越")
+	}
+}
+
+func TestSymbolicateThreadPerImageDsym(t *testing.T) {
+	// 假的 atos：输出 "<-o 的文件名>_<地址>"，用于验证每一帧交给了哪个二进制
+	installFakeAtos(t, "while [ $# -gt 0 ]; do\n  case \"$1\" in\n    -arch|-l) shift 2 ;;\n    -o) bin=$(basename \"$2\"); shift 2 ;;\n    *) echo \"${bin}_$1\"; shift ;;\n  esac\ndone\n")
+
+	// 两个系统库镜像，各自有一个已上传的符号表
+	dsymDir := t.TempDir()
+	uikitPath := filepath.Join(dsymDir, "UIKitCore")
+	foundationPath := filepath.Join(dsymDir, "Foundation")
+	writeFakeMachO(t, uikitPath, 0x0100000c, 0, [16]byte{0xc1, 0x01})
+	writeFakeMachO(t, foundationPath, 0x0100000c, 0, [16]byte{0xc1, 0x02})
+	defer evictDsymInfo(uikitPath)
+	defer evictDsymInfo(foundationPath)
+	uikitUUID, _, _ := readMachOUUID(uikitPath)
+	foundationUUID, _, _ := readMachOUUID(foundationPath)
+
+	images := []interface{}{
+		map[string]interface{}{"name": "/System/Library/PrivateFrameworks/UIKitCore.framework/UIKitCore", "uuid": uikitUUID, "image_addr": float64(0x180000000), "image_size": float64(0x1000000)},
+		map[string]interface{}{"name": "/System/Library/Frameworks/Foundation.framework/Foundation", "uuid": strings.ToLower(foundationUUID), "image_addr": float64(0x190000000), "image_size": float64(0x1000000)},
+		map[string]interface{}{"name": "/usr/lib/system/libsystem_kernel.dylib", "uuid": "00000000-0000-0000-0000-0000000000AA", "image_addr": float64(0x1a0000000), "image_size": float64(0x100000)},
+	}
+
+	imageDsyms := findImageDsymsIn(dsymDir, images, "")
+	if imageDsyms[0x180000000] != uikitPath || imageDsyms[0x190000000] != foundationPath || len(imageDsyms) != 2 {
+		t.Fatalf("findImageDsymsIn() = %v", imageDsyms)
+	}
+	paths, cleanup := openImageBinaries(imageDsyms)
+	defer cleanup()
+
+	thread := map[string]interface{}{
+		"backtrace": map[string]interface{}{
+			"contents": []interface{}{
+				map[string]interface{}{"object_name": "UIKitCore", "instruction_addr": float64(0x180000100), "symbol_name": "-[UIView layoutSubviews]"},
+				map[string]interface{}{"object_name": "Foundation", "instruction_addr": float64(0x190000200), "symbol_name": "_NSRaiseError"},
+				map[string]interface{}{"object_name": "libsystem_kernel.dylib", "instruction_addr": float64(0x1a0000300), "symbol_name": "__pthread_kill"},
+			},
+		},
+	}
+	binaries := &imageBinaries{appPath: "/nonexistent", appLoadAddr: 0x100000000, binaryImages: images, byImageAddr: paths}
+	result := symbolicateThread(thread, binaries, "arm64", "Demo", newSymbolCache())
+
+	frames := result["backtrace"].(map[string]interface{})["contents"].([]interface{})
+	want := []string{"UIKitCore_0x180000100", "Foundation_0x190000200", ""}
+	for i, w := range want {
+		got, _ := frames[i].(map[string]interface{})["symbolicated_name"].(string)
+		if got != w {
+			t.Errorf("第 %d 帧 symbolicated_name = %q, want %q", i, got, w)
+		}
+	}
+}
+
+func TestFindDsymInSearchPaths(t *testing.T) {
+	root := t.TempDir()
+
+	// 按 UUID 组织的共享目录：<root>/<UUID>/Demo.dSYM/Contents/Resources/DWARF/Demo
+	dwarfDir := filepath.Join(root, "AA01", "Demo.dSYM", "Contents", "Resources", "DWARF")
+	if err := os.MkdirAll(dwarfDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	binaryPath := filepath.Join(dwarfDir, "Demo")
+	writeFakeMachO(t, binaryPath, 0x0100000c, 0, [16]byte{0xaa, 0x01})
+	defer evictDsymInfo(binaryPath)
+	// macOS 打包残留的 AppleDouble 文件不是 Mach-O
+	os.WriteFile(filepath.Join(dwarfDir, "._Demo"), []byte("junk"), 0644)
+
+	uuid, _, err := readMachOUUID(binaryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paths := []string{filepath.Join(root, "missing"), root}
+	if got := findDsymInSearchPaths(paths, strings.ToLower(uuid)); got != binaryPath {
+		t.Errorf("findDsymInSearchPaths() = %q, want %q", got, binaryPath)
+	}
+	if got := findDsymInSearchPaths(paths, "00000000-0000-0000-0000-000000000000"); got != "" {
+		t.Errorf("不存在的 UUID 应返回空，得到 %q", got)
+	}
+
+	// 索引有效期内新增的符号表不会触发重新遍历，过期后才能找到
+	newDir := filepath.Join(root, "AA02", "Other.dSYM", "Contents", "Resources", "DWARF")
+	os.MkdirAll(newDir, 0755)
+	newBinary := filepath.Join(newDir, "Other")
+	writeFakeMachO(t, newBinary, 0x0100000c, 0, [16]byte{0xaa, 0x02})
+	defer evictDsymInfo(newBinary)
+	newUUID, _, _ := readMachOUUID(newBinary)
+	if got := findDsymInSearchPaths(paths, newUUID); got != "" {
+		t.Errorf("有效期内不应重新遍历，得到 %q", got)
+	}
+	oldTTL := dsymSearchIndexTTL
+	dsymSearchIndexTTL = 0
+	defer func() { dsymSearchIndexTTL = oldTTL }()
+	if got := findDsymInSearchPaths(paths, newUUID); got != newBinary {
+		t.Errorf("索引过期后 findDsymInSearchPaths() = %q, want %q", got, newBinary)
+	}
+}
+
+func TestSymbolicateReportUUIDMismatch(t *testing.T) {
+	dsymPath := filepath.Join(t.TempDir(), "Demo")
+	writeFakeMachO(t, dsymPath, 0x0100000c, 0, [16]byte{0xee, 0x01})
+
+	report := map[string]interface{}{
+		"system": map[string]interface{}{"cpu_arch": "arm64", "CFBundleExecutable": "Demo"},
+		"binary_images": []interface{}{
+			map[string]interface{}{
+				"name":       "/private/var/containers/Bundle/Application/X/Demo.app/Demo",
+				"uuid":       "11111111-2222-3333-4444-555555555555",
+				"image_addr": float64(0x100000000),
+				"image_size": float64(0x10000),
+			},
+		},
+		"crash": map[string]interface{}{
+			"threads": []interface{}{},
+		},
+	}
+
+	result, err := symbolicateReport(report, dsymPath)
+	if err != nil {
+		t.Fatalf("symbolicateReport() error = %v", err)
+	}
+	info := result["symbolication_info"].(map[string]interface{})
+	if info["uuid_mismatch"] != true || info["report_uuid"] != "11111111-2222-3333-4444-555555555555" {
+		t.Errorf("symbolication_info = %v", info)
+	}
+	if symbolicationWarning(result) == "" {
+		t.Error("UUID 不一致时应返回警告")
+	}
+
+	// UUID 一致时不标记
+	uuid, _, _ := readMachOUUID(dsymPath)
+	report["binary_images"].([]interface{})[0].(map[string]interface{})["uuid"] = strings.ToLower(uuid)
+	result, err = symbolicateReport(report, dsymPath)
+	if err != nil {
+		t.Fatalf("symbolicateReport() error = %v", err)
+	}
+	if _, ok := result["symbolication_info"].(map[string]interface{})["uuid_mismatch"]; ok || symbolicationWarning(result) != "" {
+		t.Errorf("UUID 一致时不应标记 uuid_mismatch")
+	}
+}
+
+// runnerFunc 用函数实现 CommandRunner
+type runnerFunc func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+func (f runnerFunc) Run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return f(ctx, name, args...)
+}
+
+// installFakeRunner 替换外部命令执行器，返回预置输出，测试结束后恢复
+func installFakeRunner(t *testing.T, f runnerFunc) {
+	t.Helper()
+
+	old := commandRunner
+	commandRunner = f
+	t.Cleanup(func() { commandRunner = old })
+}
+
+// stubLookPath 让 lookPath 认为 missing 之外的工具都已安装，测试结束后恢复
+func stubLookPath(t *testing.T, missing map[string]bool) {
+	t.Helper()
+
+	old := lookPath
+	lookPath = func(name string) (string, error) {
+		if missing[name] {
+			return "", os.ErrNotExist
+		}
+		return "/usr/bin/" + name, nil
+	}
+	t.Cleanup(func() { lookPath = old })
+}
+
+// installFakeAtos 在 PATH 最前面放一个 shell 脚本实现的 atos，用于在没有 Xcode 的环境中测试
+func installFakeAtos(t *testing.T, body string) {
+	t.Helper()
+
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "atos"), []byte("#!/bin/sh\n"+body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestSymbolicateReportVerifiesLoadAddress(t *testing.T) {
+	// 假的 atos：只有 -l 为帧自带的 object_addr 时才能解析到 Demo，否则原样返回地址
+	installFakeAtos(t, `while [ $# -gt 0 ]; do
+  case "$1" in
+    -arch|-o) shift 2 ;;
+    -l) load=$2; shift 2 ;;
+    *) if [ "$load" = "0x100000000" ]; then echo "main (in Demo) (main.m:10)"; else echo "$1"; fi; shift ;;
+  esac
+done
+`)
+
+	dsymPath := filepath.Join(t.TempDir(), "Demo")
+	writeFakeMachOWithSymbols(t, dsymPath, map[string]uint64{"_main": 0x100000400})
+
+	newReport := func() map[string]interface{} {
+		return map[string]interface{}{
+			"system": map[string]interface{}{"cpu_arch": "arm64", "CFBundleExecutable": "Demo"},
+			"binary_images": []interface{}{
+				// 故意错误的 image_addr，帧地址并不在这个范围内
+				map[string]interface{}{
+					"name":       "/private/var/containers/Bundle/Application/X/Demo.app/Demo",
+					"image_addr": float64(0x4000),
+					"image_size": float64(0x1000),
+				},
+			},
+			"crash": map[string]interface{}{
+				"threads": []interface{}{
+					map[string]interface{}{
+						"index":   float64(0),
+						"crashed": true,
+						"backtrace": map[string]interface{}{
+							"contents": []interface{}{
+								map[string]interface{}{"object_name": "Demo", "object_addr": float64(0x100000000), "instruction_addr": float64(0x100000410)},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	result, err := symbolicateReport(newReport(), dsymPath)
+	if err != nil {
+		t.Fatalf("symbolicateReport() error = %v", err)
+	}
+	info := result["symbolication_info"].(map[string]interface{})
+	if info["load_address"] != "0x100000000" || info["slide"] != "0x0" || info["load_address_corrected"] != true {
+		t.Errorf("symbolication_info = load_address %v, slide %v, corrected %v", info["load_address"], info["slide"], info["load_address_corrected"])
+	}
+	frame := result["crash"].(map[string]interface{})["threads"].([]interface{})[0].(map[string]interface{})["backtrace"].(map[string]interface{})["contents"].([]interface{})[0].(map[string]interface{})
+	if frame["symbolicated_name"] != "main (in Demo) (main.m:10)" {
+		t.Errorf("修正加载地址后帧未符号化: %v", frame["symbolicated_name"])
+	}
+
+	// 帧的 object_addr 也不对时，改用符号表的 __TEXT vmaddr（slide 为 0）
+	report := newReport()
+	appFrame := report["crash"].(map[string]interface{})["threads"].([]interface{})[0].(map[string]interface{})["backtrace"].(map[string]interface{})["contents"].([]interface{})[0].(map[string]interface{})
+	appFrame["object_addr"] = float64(0x4000)
+	result, err = symbolicateReport(report, dsymPath)
+	if err != nil {
+		t.Fatalf("symbolicateReport() error = %v", err)
+	}
+	info = result["symbolication_info"].(map[string]interface{})
+	if info["load_address"] != "0x100000000" || info["load_address_source"] != loadAddrSourceVerified {
+		t.Errorf("symbolication_info = load_address %v, source %v, want __TEXT vmaddr", info["load_address"], info["load_address_source"])
+	}
+
+	// 报告中没有应用加载地址时默认使用 __TEXT vmaddr
+	report = newReport()
+	delete(report["binary_images"].([]interface{})[0].(map[string]interface{}), "image_addr")
+	appFrame = report["crash"].(map[string]interface{})["threads"].([]interface{})[0].(map[string]interface{})["backtrace"].(map[string]interface{})["contents"].([]interface{})[0].(map[string]interface{})
+	delete(appFrame, "object_addr")
+	result, err = symbolicateReport(report, dsymPath)
+	if err != nil {
+		t.Fatalf("symbolicateReport() error = %v", err)
+	}
+	info = result["symbolication_info"].(map[string]interface{})
+	if info["load_address"] != "0x100000000" || info["load_address_source"] != loadAddrSourceDsym || info["load_address_corrected"] != nil {
+		t.Errorf("symbolication_info = load_address %v, source %v, corrected %v", info["load_address"], info["load_address_source"], info["load_address_corrected"])
+	}
+
+	// 所有候选地址都解析不到应用时仍按报告的地址符号化，在 symbolication_info 中给出提示
+	installFakeAtos(t, "while [ $# -gt 0 ]; do case \"$1\" in -arch|-o|-l) shift 2 ;; *) echo \"objc_msgSend (in libobjc.A.dylib)\"; shift ;; esac; done\n")
+	result, err = symbolicateReport(newReport(), dsymPath)
+	if err != nil {
+		t.Fatalf("symbolicateReport() error = %v, want 校验失败时不报错", err)
+	}
+	info = result["symbolication_info"].(map[string]interface{})
+	if warning, _ := info["load_address_warning"].(string); info["load_address"] != "0x4000" || !strings.Contains(warning, "加载地址校验未通过") {
+		t.Errorf("symbolication_info = load_address %v, load_address_warning %v", info["load_address"], info["load_address_warning"])
+	}
+}
+
+func TestSymbolicateReportFrameCounts(t *testing.T) {
+	// 假的 atos：只能解析 0x100000410
+	installFakeAtos(t, `while [ $# -gt 0 ]; do
+  case "$1" in
+    -arch|-o|-l) shift 2 ;;
+    0x100000410) echo "main (in Demo) (main.m:10)"; shift ;;
+    *) echo "$1"; shift ;;
+  esac
+done
+`)
+
+	dsymPath := filepath.Join(t.TempDir(), "Demo")
+	writeFakeMachOWithSymbols(t, dsymPath, map[string]uint64{"_main": 0x100000400})
+
+	report := map[string]interface{}{
+		"system": map[string]interface{}{"cpu_arch": "arm64", "CFBundleExecutable": "Demo"},
+		"binary_images": []interface{}{
+			map[string]interface{}{"name": "/private/var/containers/Bundle/Application/X/Demo.app/Demo", "image_addr": float64(0x100000000), "image_size": float64(0x1000)},
+		},
+		"crash": map[string]interface{}{
+			"threads": []interface{}{
+				map[string]interface{}{
+					"index":   float64(0),
+					"crashed": true,
+					"backtrace": map[string]interface{}{
+						"contents": []interface{}{
+							map[string]interface{}{"object_name": "Demo", "instruction_addr": float64(0x100000410)},
+							map[string]interface{}{"object_name": "libsystem_kernel.dylib", "instruction_addr": float64(0x1a0000000), "symbol_name": "__pthread_kill"},
+							map[string]interface{}{"object_name": "Demo", "instruction_addr": float64(0x100000500)},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := symbolicateReport(report, dsymPath)
+	if err != nil {
+		t.Fatalf("symbolicateReport() error = %v", err)
+	}
+	info := result["symbolication_info"].(map[string]interface{})
+	want := map[string]int{"total_frames": 3, "symbolicated_frames": 1, "app_frames": 2, "unresolved_frames": 1}
+	for key, count := range want {
+		if info[key] != count {
+			t.Errorf("symbolication_info.%s = %v, want %d", key, info[key], count)
+		}
+	}
+}
+
+func TestSymbolicateReportWithoutBinaryImages(t *testing.T) {
+	// 假的 atos：只有加载地址为帧自带的 object_addr 时才能解析
+	installFakeAtos(t, `while [ $# -gt 0 ]; do
+  case "$1" in
+    -arch|-o) shift 2 ;;
+    -l) load=$2; shift 2 ;;
+    *) if [ "$load" = "0x104000000" ]; then echo "main (in Demo) (main.m:10)"; else echo "$1"; fi; shift ;;
+  esac
+done
+`)
+
+	dsymPath := filepath.Join(t.TempDir(), "Demo")
+	writeFakeMachOWithSymbols(t, dsymPath, map[string]uint64{"_main": 0x100000400})
+
+	report := map[string]interface{}{
+		"system": map[string]interface{}{"cpu_arch": "arm64", "CFBundleExecutable": "Demo"},
+		"crash": map[string]interface{}{
+			"threads": []interface{}{
+				map[string]interface{}{
+					"index":   float64(0),
+					"crashed": true,
+					"backtrace": map[string]interface{}{
+						"contents": []interface{}{
+							map[string]interface{}{"object_name": "Demo", "object_addr": float64(0x104000000), "instruction_addr": float64(0x104000410)},
+							map[string]interface{}{"object_addr": float64(0x1a0000000), "instruction_addr": float64(0x1a0000010), "symbol_name": "<redacted>"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := symbolicateReport(report, dsymPath)
+	if err != nil {
+		t.Fatalf("symbolicateReport() error = %v", err)
+	}
+	info := result["symbolication_info"].(map[string]interface{})
+	if info["load_address"] != "0x104000000" || info["slide"] != "0x4000000" {
+		t.Errorf("load_address = %v, slide = %v, want 0x104000000 / 0x4000000", info["load_address"], info["slide"])
+	}
+	contents := result["crash"].(map[string]interface{})["threads"].([]interface{})[0].(map[string]interface{})["backtrace"].(map[string]interface{})["contents"].([]interface{})
+	frame := contents[0].(map[string]interface{})
+	if frame["symbolicated_name"] != "main (in Demo) (main.m:10)" || frame["is_app_code"] != true {
+		t.Errorf("应用帧 = %v", frame)
+	}
+
+	// 格式化时没有 binary_images 的帧用 object_addr 计算偏移
+	formatted := formatBacktrace(map[string]interface{}{"contents": contents[1:]}, result)
+	if !strings.Contains(formatted, "0x1a0000000 + 16") {
+		t.Errorf("formatBacktrace() = %q, want 0x1a0000000 + 16", formatted)
+	}
+}
+
+func TestSymbolicateThreadRecordsAddressing(t *testing.T) {
+	installFakeAtos(t, "while [ $# -gt 0 ]; do case \"$1\" in -arch|-o|-l) shift 2 ;; *) echo \"main (in Demo) (main.m:10)\"; shift ;; esac; done\n")
+
+	// __TEXT vmaddr 为 0x100000000，实际加载在 0x100004000，slide 为 0x4000
+	binaryPath := filepath.Join(t.TempDir(), "Demo")
+	writeFakeMachOWithSymbols(t, binaryPath, map[string]uint64{"_main": 0x100000400})
+
+	images := []interface{}{
+		map[string]interface{}{"name": "/private/var/containers/Bundle/Application/X/Demo.app/Demo", "image_addr": float64(0x100004000), "image_size": float64(0x10000)},
+	}
+	thread := map[string]interface{}{
+		"backtrace": map[string]interface{}{
+			"contents": []interface{}{
+				map[string]interface{}{"object_name": "Demo", "instruction_addr": float64(0x100004400)},
+				map[string]interface{}{"object_name": "libsystem_kernel.dylib", "instruction_addr": float64(0x1a0000300), "symbol_name": "__pthread_kill"},
+			},
+		},
+	}
+	binaries := &imageBinaries{appPath: binaryPath, appName: "Demo", appLoadAddr: 0x100004000, binaryImages: images}
+	result := symbolicateThread(thread, binaries, "arm64", "Demo", newSymbolCache())
+
+	frames := result["backtrace"].(map[string]interface{})["contents"].([]interface{})
+	frame := frames[0].(map[string]interface{})
+	if frame["image_base"] != int64(0x100004000) || frame["slide"] != int64(0x4000) || frame["file_offset"] != int64(0x400) {
+		t.Errorf("image_base = %v, slide = %v, file_offset = %v", frame["image_base"], frame["slide"], frame["file_offset"])
+	}
+	if _, ok := frames[1].(map[string]interface{})["image_base"]; ok {
+		t.Error("未符号化的帧不应记录地址计算")
+	}
+
+	report := map[string]interface{}{"binary_images": images}
+	if got := formatBacktrace(result["backtrace"].(map[string]interface{}), report); strings.Contains(got, "image_base") {
+		t.Errorf("默认模式不应输出地址计算:\n%s", got)
+	}
+	got := formatBacktrace(result["backtrace"].(map[string]interface{}), verboseReport(report))
+	if !strings.Contains(got, "image_base: 0x100004000  file_offset: 0x400  slide: 0x4000") {
+		t.Errorf("详细模式缺少地址计算:\n%s", got)
+	}
+}
+
+func TestNormalizeReportFormatEnvelope(t *testing.T) {
+	inner := map[string]interface{}{"crash": map[string]interface{}{"threads": []interface{}{}}, "dump_type": float64(2001)}
+
+	for _, key := range []string{"report", "crash_report"} {
+		got := normalizeReportFormat(map[string]interface{}{key: inner})
+		if got["dump_type"] != float64(2001) {
+			t.Errorf("%s 信封未解开: %v", key, got)
+		}
+	}
+	if got := normalizeReportFormat([]interface{}{map[string]interface{}{"report": inner}}); got["dump_type"] != float64(2001) {
+		t.Errorf("数组中的信封未解开: %v", got)
+	}
+
+	// 内层没有 crash 时不是信封
+	plain := map[string]interface{}{"head": map[string]interface{}{"app_uuid": "x"}}
+	if got := normalizeReportFormat(plain); got["head"] == nil {
+		t.Errorf("不应解开没有 crash 的单字段报告: %v", got)
+	}
+
+	// 普通报告顶层恰好有 reports 数组时不是合并结果
+	withReports := map[string]interface{}{"dump_type": float64(2001), "reports": []interface{}{map[string]interface{}{"dump_type": float64(1)}}}
+	if got := normalizeReportFormat(withReports); got["dump_type"] != float64(2001) {
+		t.Errorf("顶层 reports 数组被误认为合并结果: %v", got)
+	}
+}
+
+func TestSymbolicateReportArray(t *testing.T) {
+	installFakeAtos(t, "while [ $# -gt 0 ]; do case \"$1\" in -arch|-o|-l) shift 2 ;; *) echo \"main (in Demo) (main.m:10)\"; shift ;; esac; done\n")
+
+	dsymPath := filepath.Join(t.TempDir(), "Demo")
+	writeFakeMachOWithSymbols(t, dsymPath, map[string]uint64{"_main": 0x100000400})
+
+	newReport := func(dumpType float64, frames int) map[string]interface{} {
+		contents := []interface{}{}
+		for i := 0; i < frames; i++ {
+			contents = append(contents, map[string]interface{}{"object_name": "Demo", "instruction_addr": float64(0x100000400 + i*4)})
+		}
+		return map[string]interface{}{
+			"dump_type": dumpType,
+			"system":    map[string]interface{}{"cpu_arch": "arm64", "CFBundleExecutable": "Demo"},
+			"binary_images": []interface{}{
+				map[string]interface{}{"name": "/private/var/containers/Bundle/Application/X/Demo.app/Demo", "image_addr": float64(0x100000000), "image_size": float64(0x10000)},
+			},
+			"crash": map[string]interface{}{
+				"threads": []interface{}{
+					map[string]interface{}{"crashed": true, "backtrace": map[string]interface{}{"contents": contents}},
+				},
+			},
+		}
+	}
+
+	report := []interface{}{
+		newReport(2001, 1),
+		map[string]interface{}{"crash_report": newReport(2007, 2)},
+		map[string]interface{}{"unsupported": true},
+	}
+	result, err := symbolicateReport(report, dsymPath)
+	if err != nil {
+		t.Fatalf("symbolicateReport() error = %v", err)
+	}
+
+	reports := result[combinedReportsKey].([]interface{})
+	if len(reports) != 3 {
+		t.Fatalf("reports 数量 = %d, want 3", len(reports))
+	}
+	second := reports[1].(map[string]interface{})
+	if second["dump_type"] != float64(2007) || second["symbolication_info"] == nil {
+		t.Errorf("第二份报告应解开信封并符号化: %v", second["symbolication_info"])
+	}
+
+	info := result["symbolication_info"].(map[string]interface{})
+	if info["report_count"] != 3 || info["total_frames"] != 3 || info["symbolicated_frames"] != 3 {
+		t.Errorf("symbolication_info = %v", info)
+	}
+	if errs, _ := info["errors"].([]string); len(errs) != 1 {
+		t.Errorf("errors = %v, want 1 个", info["errors"])
+	}
+	formatted := info["formatted_report"].(string)
+	if !strings.Contains(formatted, "Report 1/3") || !strings.Contains(formatted, "Report 2/3") {
+		t.Errorf("formatted_report 缺少分隔:\n%s", formatted)
+	}
+
+	// 合并结果的 dump_type 取第一份
+	if code, _ := detectDumpType(result); code != 2001 {
+		t.Errorf("detectDumpType() = %d, want 2001", code)
+	}
+}
+
+func TestSymbolicateAddressesRetriesOtherArch(t *testing.T) {
+	oldCache := atosSymbolCache
+	atosSymbolCache = newLRUSymbolCache(atosCacheSize)
+	defer func() { atosSymbolCache = oldCache }()
+
+	binaryPath := filepath.Join(t.TempDir(), "Demo")
+	writeFakeFatMachO(t, binaryPath, [2][16]byte{{0xa7}, {0xa8}})
+
+	// 假的 atos：只有 arm64 slice 返回符号，其它架构只返回地址
+	var calls []string
+	installFakeRunner(t, func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		arch := args[1]
+		calls = append(calls, arch)
+		var out bytes.Buffer
+		for _, addr := range args[6:] {
+			if arch == "arm64" {
+				fmt.Fprintf(&out, "-[Demo run] (in Demo) (Demo.m:7)\n")
+			} else {
+				fmt.Fprintf(&out, "%s (in Demo)\n", addr)
+			}
+		}
+		return out.Bytes(), nil
+	})
+
+	symbols, archs, err := symbolicateAddressesArch(binaryPath, 0x100000000, []uint64{0x100000100}, "arm64e", true)
+	if err != nil {
+		t.Fatalf("symbolicateAddressesArch() error = %v", err)
+	}
+	if symbols[0] != "-[Demo run] (in Demo) (Demo.m:7)" || archs[0] != "arm64" {
+		t.Errorf("symbol = %q, arch = %q, want arm64 的结果", symbols[0], archs[0])
+	}
+	if strings.Join(calls, ",") != "arm64e,armv7,arm64" {
+		t.Errorf("atos 调用的架构 = %v, want [arm64e armv7 arm64]", calls)
+	}
+
+	// 重试得到的结果按请求的架构缓存，再次符号化时直接命中并保留实际使用的架构
+	calls = nil
+	symbols, archs, _ = symbolicateAddressesArch(binaryPath, 0x100000000, []uint64{0x100000100}, "arm64e", true)
+	if symbols[0] != "-[Demo run] (in Demo) (Demo.m:7)" || archs[0] != "arm64" || len(calls) != 0 {
+		t.Errorf("symbol = %q, arch = %q, atos 调用 = %v, want 命中缓存", symbols[0], archs[0], calls)
+	}
+
+	// 不允许重试时不使用其它架构的缓存结果
+	symbols, _, _ = symbolicateAddressesArch(binaryPath, 0x100000000, []uint64{0x100000100}, "arm64e", false)
+	if symbols[0] == "-[Demo run] (in Demo) (Demo.m:7)" || strings.Join(calls, ",") != "arm64e" {
+		t.Errorf("symbol = %q, atos 调用 = %v, want 只调用 arm64e", symbols[0], calls)
+	}
+
+	// 线程符号化时在帧上记录实际使用的架构
+	thread := map[string]interface{}{
+		"backtrace": map[string]interface{}{
+			"contents": []interface{}{
+				map[string]interface{}{"object_name": "Demo", "instruction_addr": float64(0x100000200)},
+			},
+		},
+	}
+	binaries := &imageBinaries{appPath: binaryPath, appLoadAddr: 0x100000000, retryOtherArches: true, binaryImages: []interface{}{}}
+	result := symbolicateThread(thread, binaries, "arm64e", "Demo", newSymbolCache())
+	frame := result["backtrace"].(map[string]interface{})["contents"].([]interface{})[0].(map[string]interface{})
+	if frame["symbolicated_name"] != "-[Demo run] (in Demo) (Demo.m:7)" || frame["symbol_arch"] != "arm64" {
+		t.Errorf("帧 = %v", frame)
+	}
+	if got := countArchFallbackFrames([]interface{}{result}); got["arm64"] != 1 {
+		t.Errorf("countArchFallbackFrames() = %v, want arm64: 1", got)
+	}
+
+	// 报告 UUID 已匹配到 slice 时不再用其它架构重试
+	calls = nil
+	binaries.retryOtherArches = false
+	thread["backtrace"].(map[string]interface{})["contents"].([]interface{})[0].(map[string]interface{})["instruction_addr"] = float64(0x100000300)
+	result = symbolicateThread(thread, binaries, "arm64e", "Demo", newSymbolCache())
+	frame = result["backtrace"].(map[string]interface{})["contents"].([]interface{})[0].(map[string]interface{})
+	if frame["symbol_arch"] != nil || strings.Join(calls, ",") != "arm64e" {
+		t.Errorf("帧 = %v, atos 调用的架构 = %v, want 只调用 arm64e", frame, calls)
+	}
+}
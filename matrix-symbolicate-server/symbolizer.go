@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// 符号化后端：macOS 使用 atos，Linux（Docker、CI）使用 llvm-symbolizer 或纯 Go 的 native
+// ============================================================================
+
+// symbolizer 符号化后端
+// symbolize 对同一二进制的一批运行时地址返回与 addrs 一一对应的 atos 风格结果：
+// "函数名 (in 镜像) (文件:行号)"，失败的地址为空字符串
+type symbolizer interface {
+	name() string
+	available() bool
+	symbolize(binaryPath string, loadAddr uint64, addrs []uint64, arch string) ([]string, error)
+}
+
+// atosTimeout 单次符号化命令的超时（ATOS_TIMEOUT，秒），超时后终止进程
+var atosTimeout = time.Duration(envInt("ATOS_TIMEOUT", 30)) * time.Second
+
+// errSymbolizerTimeout 符号化命令超时，对应的帧标记为 symbolication_error: timeout
+var errSymbolizerTimeout = errors.New("timeout")
+
+// runToolWithTimeout 在 atosTimeout 内执行外部工具，超时返回包装了 errSymbolizerTimeout 的错误
+func runToolWithTimeout(name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), atosTimeout)
+	defer cancel()
+
+	out, err := commandRunner.Run(ctx, name, args...)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%s 超过 %v 未返回，已终止: %w", name, atosTimeout, errSymbolizerTimeout)
+	}
+	return out, err
+}
+
+// activeSymbolizer 当前使用的后端，启动时由 selectSymbolizer 决定
+var activeSymbolizer symbolizer = atosSymbolizer{}
+
+// selectSymbolizer 按 SYMBOLIZER（atos / llvm-symbolizer / native）选择后端
+// 未配置时自动检测：优先 atos，其次 llvm-symbolizer，两者都没有时使用 native
+func selectSymbolizer(name string) symbolizer {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "atos":
+		return atosSymbolizer{}
+	case "llvm", "llvm-symbolizer":
+		return llvmSymbolizer{}
+	case "native":
+		return nativeSymbolizer{}
+	case "":
+	default:
+		log.Printf("警告: 未知的 SYMBOLIZER=%s，自动检测", name)
+	}
+
+	switch {
+	case toolAvailable("atos"):
+		return atosSymbolizer{}
+	case toolAvailable("llvm-symbolizer"):
+		return llvmSymbolizer{}
+	default:
+		return nativeSymbolizer{}
+	}
+}
+
+// atosSymbolizer 使用 Xcode 的 atos，直接接受运行时地址和加载地址
+type atosSymbolizer struct{}
+
+func (atosSymbolizer) name() string    { return "atos" }
+func (atosSymbolizer) available() bool { return toolAvailable("atos") }
+
+func (atosSymbolizer) symbolize(binaryPath string, loadAddr uint64, addrs []uint64, arch string) ([]string, error) {
+	return runAtosBatch(binaryPath, loadAddr, addrs, arch)
+}
+
+// llvmSymbolizer 使用 llvm-symbolizer 读取 DWARF
+// llvm-symbolizer 只接受文件内地址：运行时地址 - slide，slide = 加载地址 - __TEXT vmaddr
+type llvmSymbolizer struct{}
+
+func (llvmSymbolizer) name() string    { return "llvm-symbolizer" }
+func (llvmSymbolizer) available() bool { return toolAvailable("llvm-symbolizer") }
+
+func (llvmSymbolizer) symbolize(binaryPath string, loadAddr uint64, addrs []uint64, arch string) ([]string, error) {
+	startTime := time.Now()
+
+	textAddr, err := machoTextVMAddr(binaryPath, arch)
+	if err != nil {
+		log.Printf("⚠️ 读取 __TEXT 段失败，无法计算 slide: %v", err)
+		return make([]string, len(addrs)), err
+	}
+	slide := loadAddr - textAddr
+
+	args := []string{"--output-style=JSON", "--obj=" + binaryPath}
+	if arch != "" {
+		args = append(args, "--default-arch="+arch)
+	}
+	for _, addr := range addrs {
+		args = append(args, fmt.Sprintf("0x%x", addr-slide))
+	}
+
+	out, err := runToolWithTimeout("llvm-symbolizer", args...)
+	if err != nil {
+		log.Printf("⚠️ llvm-symbolizer 执行失败: %v", err)
+		return make([]string, len(addrs)), err
+	}
+
+	results := parseLLVMSymbolizerOutput(string(out), addrs, filepath.Base(binaryPath))
+	log.Printf("✅ llvm-symbolizer 批量符号化 %d 个地址 (耗时: %v)", len(addrs), time.Since(startTime))
+	return results, nil
+}
+
+// llvmSymbolizerResult llvm-symbolizer --output-style=JSON 每个地址输出一行
+type llvmSymbolizerResult struct {
+	Symbol []struct {
+		FunctionName string `json:"FunctionName"`
+		FileName     string `json:"FileName"`
+		Line         int    `json:"Line"`
+	} `json:"Symbol"`
+}
+
+// parseLLVMSymbolizerOutput 将 JSON 输出转换为 atos 风格的结果行
+// 有内联时取第一项（最内层函数），与 atos 的输出一致
+func parseLLVMSymbolizerOutput(output string, addrs []uint64, module string) []string {
+	results := make([]string, len(addrs))
+
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) < len(addrs) {
+		log.Printf("⚠️ llvm-symbolizer 输出 %d 行，少于请求的 %d 个地址", len(lines), len(addrs))
+	}
+
+	for i, addr := range addrs {
+		if i >= len(lines) {
+			break
+		}
+		var result llvmSymbolizerResult
+		if err := json.Unmarshal([]byte(lines[i]), &result); err != nil || len(result.Symbol) == 0 {
+			continue
+		}
+		frame := result.Symbol[0]
+		if frame.FunctionName == "" || frame.FunctionName == "??" {
+			continue
+		}
+
+		symbol := fmt.Sprintf("%s (in %s)", frame.FunctionName, module)
+		if frame.FileName != "" && frame.Line > 0 {
+			symbol += fmt.Sprintf(" (%s:%d)", filepath.Base(frame.FileName), frame.Line)
+		}
+		results[i] = postProcessSymbol(symbol, addr)
+	}
+
+	return results
+}
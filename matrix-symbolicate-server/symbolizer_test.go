@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLLVMSymbolizer(t *testing.T) {
+	// 假的 llvm-symbolizer：按收到的文件内地址输出 JSON，0x100000999 视为无法解析
+	binDir := t.TempDir()
+	script := `#!/bin/sh
+for arg in "$@"; do
+  case "$arg" in
+    --*) ;;
+    0x100000999) echo '{"Address":"0x100000999","Symbol":[{"FunctionName":"??","FileName":"","Line":0}]}' ;;
+    *) echo "{\"Address\":\"$arg\",\"Symbol\":[{\"FunctionName\":\"func_$arg\",\"FileName\":\"/src/Foo.m\",\"Line\":42},{\"FunctionName\":\"outer\",\"FileName\":\"/src/Foo.m\",\"Line\":7}]}" ;;
+  esac
+done
+`
+	if err := os.WriteFile(filepath.Join(binDir, "llvm-symbolizer"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	binaryPath := filepath.Join(t.TempDir(), "Demo")
+	writeFakeMachOWithSymbols(t, binaryPath, map[string]uint64{"_main": 0x100000400})
+
+	// 加载地址 0x104000000，__TEXT vmaddr 0x100000000，slide 0x4000000
+	got, err := llvmSymbolizer{}.symbolize(binaryPath, 0x104000000, []uint64{0x104000410, 0x104000999}, "arm64")
+	if err != nil {
+		t.Fatalf("symbolize() error = %v", err)
+	}
+	if want := "func_0x100000410 (in Demo) (Foo.m:42)"; got[0] != want {
+		t.Errorf("symbolize()[0] = %q, want %q", got[0], want)
+	}
+	if got[1] != "" {
+		t.Errorf("symbolize()[1] = %q, want 空", got[1])
+	}
+	if _, module, fileName, line := parseSymbolOutput(got[0]); module != "Demo" || fileName != "Foo.m" || line != "42" {
+		t.Errorf("parseSymbolOutput() = %q, %q, %q", module, fileName, line)
+	}
+
+	// 自动检测：没有 atos 时使用 llvm-symbolizer
+	oldLookPath := lookPath
+	defer func() { lookPath = oldLookPath }()
+	lookPath = func(name string) (string, error) {
+		if name == "atos" {
+			return "", os.ErrNotExist
+		}
+		return oldLookPath(name)
+	}
+	if s := selectSymbolizer(""); s.name() != "llvm-symbolizer" {
+		t.Errorf("selectSymbolizer(\"\") = %s, want llvm-symbolizer", s.name())
+	}
+	if s := selectSymbolizer("atos"); s.name() != "atos" {
+		t.Errorf("selectSymbolizer(atos) = %s", s.name())
+	}
+}
+
+func TestSymbolizerTimeoutMarksFrames(t *testing.T) {
+	oldTimeout, oldSymbolizer := atosTimeout, activeSymbolizer
+	defer func() { atosTimeout, activeSymbolizer = oldTimeout, oldSymbolizer }()
+
+	// 假的慢命令：一直阻塞到超时
+	calls := 0
+	installFakeRunner(t, func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		calls++
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	atosTimeout = 20 * time.Millisecond
+	activeSymbolizer = atosSymbolizer{}
+
+	if _, err := runAtosBatch("/nonexistent", 0x100000000, []uint64{0x100000400}, "arm64"); !errors.Is(err, errSymbolizerTimeout) {
+		t.Fatalf("runAtosBatch() error = %v, want errSymbolizerTimeout", err)
+	}
+
+	frame := func(addr float64) map[string]interface{} {
+		return map[string]interface{}{"object_name": "Demo", "instruction_addr": addr}
+	}
+	newThread := func() map[string]interface{} {
+		return map[string]interface{}{
+			"backtrace": map[string]interface{}{"contents": []interface{}{frame(0x100000400), frame(0x100000500)}},
+		}
+	}
+	binaries := &imageBinaries{appPath: "/nonexistent", appLoadAddr: 0x100000000}
+	cache := newSymbolCache()
+
+	calls = 0
+	for i := 0; i < 2; i++ {
+		result := symbolicateThread(newThread(), binaries, "arm64", "Demo", cache)
+		for j, f := range result["backtrace"].(map[string]interface{})["contents"].([]interface{}) {
+			if got := f.(map[string]interface{})["symbolication_error"]; got != "timeout" {
+				t.Errorf("第 %d 次第 %d 帧 symbolication_error = %v, want timeout", i, j, got)
+			}
+		}
+	}
+	// 第二个线程命中缓存，不再等待卡住的工具
+	if calls != 1 {
+		t.Errorf("runTool 调用了 %d 次，want 1", calls)
+	}
+}
@@ -0,0 +1,38 @@
+; 测试用的最小 dSYM 源码：两个函数，带行号信息
+; 生成方式见 testdata/tiny_dsym/gen.go
+target datalayout = "e-m:o-i64:64-i128:128-n32:64-S128"
+target triple = "arm64-apple-macosx11.0.0"
+
+define i32 @helper(i32 %x) !dbg !10 {
+entry:
+  %a = add i32 %x, 1, !dbg !13
+  %b = mul i32 %a, %x, !dbg !14
+  ret i32 %b, !dbg !15
+}
+
+define void @"\01-[Foo bar]"() !dbg !20 {
+entry:
+  %r = call i32 @helper(i32 5), !dbg !21
+  call void @sink(i32 %r), !dbg !22
+  ret void, !dbg !23
+}
+
+declare void @sink(i32)
+
+!llvm.dbg.cu = !{!0}
+!llvm.module.flags = !{!3, !4}
+
+!0 = distinct !DICompileUnit(language: DW_LANG_ObjC, file: !1, producer: "tiny", isOptimized: false, runtimeVersion: 2, emissionKind: FullDebug)
+!1 = !DIFile(filename: "Foo.m", directory: "/tmp/Tiny")
+!3 = !{i32 7, !"Dwarf Version", i32 4}
+!4 = !{i32 2, !"Debug Info Version", i32 3}
+!5 = !DISubroutineType(types: !6)
+!6 = !{null}
+!10 = distinct !DISubprogram(name: "helper", scope: !1, file: !1, line: 3, type: !5, scopeLine: 3, spFlags: DISPFlagDefinition, unit: !0)
+!13 = !DILocation(line: 4, column: 9, scope: !10)
+!14 = !DILocation(line: 5, column: 9, scope: !10)
+!15 = !DILocation(line: 6, column: 5, scope: !10)
+!20 = distinct !DISubprogram(name: "-[Foo bar]", scope: !1, file: !1, line: 10, type: !5, scopeLine: 10, spFlags: DISPFlagDefinition, unit: !0)
+!21 = !DILocation(line: 11, column: 13, scope: !20)
+!22 = !DILocation(line: 12, column: 5, scope: !20)
+!23 = !DILocation(line: 13, column: 1, scope: !20)
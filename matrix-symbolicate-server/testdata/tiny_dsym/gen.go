@@ -0,0 +1,141 @@
+//go:build ignore
+
+// gen 生成 native 符号化测试使用的最小 dSYM（testdata/Tiny.dSYM）
+//
+//	llc -O0 -filetype=obj Tiny.ll -o /tmp/Tiny.o
+//	go run gen.go /tmp/Tiny.o ../Tiny.dSYM/Contents/Resources/DWARF/Tiny
+//
+// 读取 llc 生成的 arm64 目标文件，把 __text 放到 0x100004000（__TEXT vmaddr 0x100000000），
+// 按重定位修正 DWARF 中的地址，写出只含 __TEXT 段描述、__DWARF 段和符号表的 MH_DSYM 文件
+package main
+
+import (
+	"bytes"
+	"debug/macho"
+	"encoding/binary"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	textVMAddr = 0x100000000
+	textBase   = 0x100004000
+	dwarfAddr  = 0x100008000
+)
+
+var uuid = [16]byte{0x7a, 0x1e, 0xd5, 0x0e, 0x3b, 0x4c, 0x4d, 0x8a, 0x9e, 0x01, 0x5a, 0x6b, 0x7c, 0x8d, 0x9e, 0xaf}
+
+type section struct {
+	name string
+	data []byte
+}
+
+func main() {
+	obj, err := macho.Open(os.Args[1])
+	if err != nil {
+		log.Fatal(err)
+	}
+	text := obj.Section("__text")
+
+	var dwarfSects []section
+	for _, s := range obj.Sections {
+		if s.Seg != "__DWARF" || !strings.HasPrefix(s.Name, "__debug_") {
+			continue
+		}
+		data, err := s.Data()
+		if err != nil {
+			log.Fatal(err)
+		}
+		// 目标文件中 __text 从 0 开始，DWARF 中的地址都需要加上 textBase
+		for _, r := range s.Relocs {
+			if r.Len != 3 || r.Pcrel {
+				continue
+			}
+			v := binary.LittleEndian.Uint64(data[r.Addr:])
+			if r.Extern {
+				v += obj.Symtab.Syms[r.Value].Value
+			}
+			binary.LittleEndian.PutUint64(data[r.Addr:], v+textBase)
+		}
+		dwarfSects = append(dwarfSects, section{s.Name, data})
+	}
+
+	var syms []macho.Symbol
+	for _, s := range obj.Symtab.Syms {
+		if s.Sect == 1 && s.Type&0x0e == 0x0e && !strings.HasPrefix(s.Name, "ltmp") {
+			syms = append(syms, s)
+		}
+	}
+
+	le := binary.LittleEndian
+	const headerSize, segSize, sectSize = 32, 72, 80
+	cmdsSize := 24 + (segSize + sectSize) + (segSize + sectSize*len(dwarfSects)) + 24
+	offset := uint64(headerSize + cmdsSize)
+
+	var cmds, body bytes.Buffer
+	w := func(b *bytes.Buffer, vals ...interface{}) {
+		for _, v := range vals {
+			binary.Write(b, le, v)
+		}
+	}
+	name16 := func(s string) [16]byte {
+		var n [16]byte
+		copy(n[:], s)
+		return n
+	}
+
+	// LC_UUID
+	w(&cmds, uint32(0x1b), uint32(24), uuid)
+
+	// __TEXT：dSYM 中只有段和 section 描述，没有内容
+	w(&cmds, uint32(0x19), uint32(segSize+sectSize), name16("__TEXT"),
+		uint64(textVMAddr), uint64(0x8000), uint64(0), uint64(0), uint32(5), uint32(5), uint32(1), uint32(0))
+	w(&cmds, name16("__text"), name16("__TEXT"), uint64(textBase), text.Size,
+		uint32(0), uint32(2), uint32(0), uint32(0), uint32(0x80000400), uint32(0), uint32(0), uint32(0))
+
+	// __DWARF
+	dwarfSize := uint64(0)
+	for _, s := range dwarfSects {
+		dwarfSize += uint64(len(s.data))
+	}
+	w(&cmds, uint32(0x19), uint32(segSize+sectSize*len(dwarfSects)), name16("__DWARF"),
+		uint64(dwarfAddr), dwarfSize, offset, dwarfSize, uint32(7), uint32(3), uint32(len(dwarfSects)), uint32(0))
+	addr := uint64(dwarfAddr)
+	for _, s := range dwarfSects {
+		w(&cmds, name16(s.name), name16("__DWARF"), addr, uint64(len(s.data)),
+			uint32(offset), uint32(0), uint32(0), uint32(0), uint32(0), uint32(0), uint32(0), uint32(0))
+		body.Write(s.data)
+		addr += uint64(len(s.data))
+		offset += uint64(len(s.data))
+	}
+
+	// LC_SYMTAB
+	var strtab bytes.Buffer
+	strtab.WriteByte(0)
+	var symtab bytes.Buffer
+	for _, s := range syms {
+		w(&symtab, uint32(strtab.Len()), uint8(0x0f), uint8(1), uint16(0), s.Value+textBase)
+		strtab.WriteString(s.Name)
+		strtab.WriteByte(0)
+	}
+	symoff := offset
+	stroff := symoff + uint64(symtab.Len())
+	w(&cmds, uint32(0x2), uint32(24), uint32(symoff), uint32(len(syms)), uint32(stroff), uint32(strtab.Len()))
+	body.Write(symtab.Bytes())
+	body.Write(strtab.Bytes())
+
+	var out bytes.Buffer
+	// MH_MAGIC_64, CPU_TYPE_ARM64, CPU_SUBTYPE_ARM64_ALL, MH_DSYM
+	w(&out, uint32(0xfeedfacf), uint32(0x0100000c), uint32(0), uint32(0xa), uint32(4), uint32(cmds.Len()), uint32(0), uint32(0))
+	out.Write(cmds.Bytes())
+	out.Write(body.Bytes())
+
+	if err := os.MkdirAll(filepath.Dir(os.Args[2]), 0755); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(os.Args[2], out.Bytes(), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
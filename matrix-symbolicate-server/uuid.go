@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ============================================================================
+// UUID 格式：dSYM、报告和索引中的 UUID 统一为大写带横线的形式，比较时忽略大小写和横线
+// ============================================================================
+
+// normalizeUUID 统一 UUID 为大写带横线格式（XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX），便于与 dSYM 比较
+// 不是 32 位十六进制的值只转为大写、去掉横线
+func normalizeUUID(uuid string) string {
+	uuid = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(uuid), "-", ""))
+	if !validUUID(uuid) {
+		return uuid
+	}
+	return fmt.Sprintf("%s-%s-%s-%s-%s", uuid[0:8], uuid[8:12], uuid[12:16], uuid[16:20], uuid[20:32])
+}
+
+// validUUID 判断是否为 32 位十六进制的 UUID（横线和大小写不限）
+func validUUID(uuid string) bool {
+	uuid = strings.ReplaceAll(strings.TrimSpace(uuid), "-", "")
+	if len(uuid) != 32 {
+		return false
+	}
+	for _, c := range uuid {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// compareUUID 比较两个 UUID，忽略大小写和横线；任一为空时不相等
+func compareUUID(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	return normalizeUUID(a) == normalizeUUID(b)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizeAndCompareUUID(t *testing.T) {
+	const canonical = "7A1ED50E-3B4C-4D8A-9E01-5A6B7C8D9EAF"
+	forms := []string{
+		canonical,
+		"7a1ed50e-3b4c-4d8a-9e01-5a6b7c8d9eaf",
+		"7A1ED50E3B4C4D8A9E015A6B7C8D9EAF",
+		"7a1ed50e3b4c4d8a9e015a6b7c8d9eaf",
+		" 7a1Ed50e-3B4C4d8a-9E01-5a6b7C8D9eaf ",
+	}
+	for _, form := range forms {
+		if got := normalizeUUID(form); got != canonical {
+			t.Errorf("normalizeUUID(%q) = %q, want %q", form, got, canonical)
+		}
+		if !validUUID(form) {
+			t.Errorf("validUUID(%q) = false", form)
+		}
+		for _, other := range forms {
+			if !compareUUID(form, other) {
+				t.Errorf("compareUUID(%q, %q) = false", form, other)
+			}
+		}
+	}
+
+	if compareUUID(canonical, "7A1ED50E-3B4C-4D8A-9E01-5A6B7C8D9EA0") {
+		t.Error("不同的 UUID 比较结果相等")
+	}
+	if compareUUID("", "") {
+		t.Error("空 UUID 比较结果相等")
+	}
+	for _, invalid := range []string{"", "7A1ED50E", "Demo.dSYM.zip", "../../etc/passwd", "ZZ1ED50E-3B4C-4D8A-9E01-5A6B7C8D9EAF"} {
+		if validUUID(invalid) {
+			t.Errorf("validUUID(%q) = true", invalid)
+		}
+	}
+}
+
+func TestFindDsymByUUIDAcceptsAnyForm(t *testing.T) {
+	dir := t.TempDir()
+	dsymPath := filepath.Join(dir, "Demo")
+	writeFakeMachO(t, dsymPath, 0x0100000c, 0, [16]byte{0xab, 0xcd, 0x01})
+	defer evictDsymInfo(dsymPath)
+
+	uuid, _, err := readMachOUUID(dsymPath)
+	if err != nil || uuid != "ABCD0100-0000-0000-0000-000000000000" {
+		t.Fatalf("readMachOUUID() = %q, %v", uuid, err)
+	}
+	for _, form := range []string{uuid, "abcd0100000000000000000000000000", "abcd0100-0000-0000-0000-000000000000"} {
+		if got := findDsymByUUID(dir, form); got != dsymPath {
+			t.Errorf("findDsymByUUID(%q) = %q, want %q", form, got, dsymPath)
+		}
+	}
+	if got := findDsymByUUID(dir, "../Demo"); got != "" {
+		t.Errorf("findDsymByUUID(非 UUID) = %q, want 空", got)
+	}
+}